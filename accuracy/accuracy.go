@@ -0,0 +1,300 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package accuracy
+
+import (
+	"fmt"
+	"gopher2600/cartridgeloader"
+	"gopher2600/digest"
+	"gopher2600/errors"
+	"gopher2600/hardware"
+	"gopher2600/screenshot"
+	"gopher2600/setup"
+	"gopher2600/television"
+	"image/png"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// the file extensions that indicate a ROM file
+var romExtensions = []string{".bin", ".a26", ".rom"}
+
+// the suffix added to the reference image filename when writing diff images
+const diffSuffix = "_diff.png"
+
+// Options for the accuracy test run
+type Options struct {
+	// television specification to use for every test
+	Spec string
+
+	// number of frames to run each ROM before comparing the frame
+	NumFrames int
+
+	// the first scanline of the screen to compare with the top of the
+	// reference image. a negative value means the top scanline will be
+	// whatever the television has decided the top of the screen is
+	Top int
+
+	// maximum difference in any color channel before a pixel is considered
+	// to be mismatched
+	Tolerance int
+}
+
+// the outcome of a single test in the suite
+type outcome int
+
+const (
+	outcomePass outcome = iota
+	outcomeFail
+	outcomeError
+)
+
+func (o outcome) String() string {
+	switch o {
+	case outcomePass:
+		return "pass"
+	case outcomeFail:
+		return "FAIL"
+	}
+	return "ERROR"
+}
+
+type result struct {
+	rom        string
+	outcome    outcome
+	mismatches int
+	pixels     int
+	digest     string
+	err        error
+}
+
+// Run every ROM in the test directory that has a matching reference image.
+// Output is a pass/fail matrix written to the output io.Writer. An error is
+// returned if any of the tests did not pass.
+func Run(output io.Writer, dir string, opts Options) error {
+	if output == nil {
+		return errors.New(errors.PanicError, "accuracy.Run()", "io.Writer should not be nil (use nopWriter)")
+	}
+
+	roms, err := findROMs(dir)
+	if err != nil {
+		return errors.New(errors.AccuracyError, err)
+	}
+
+	if len(roms) == 0 {
+		return errors.New(errors.AccuracyError, fmt.Sprintf("no ROMs with reference images found in %s", dir))
+	}
+
+	results := make([]result, 0, len(roms))
+	numPass := 0
+	numFail := 0
+	numError := 0
+
+	for _, rom := range roms {
+		output.Write([]byte(fmt.Sprintf("\rrunning: %s", rom)))
+
+		res := runTest(rom, opts)
+		switch res.outcome {
+		case outcomePass:
+			numPass++
+		case outcomeFail:
+			numFail++
+		case outcomeError:
+			numError++
+		}
+		results = append(results, res)
+
+		output.Write([]byte(fmt.Sprintf("\r%s\r", strings.Repeat(" ", len(rom)+9))))
+	}
+
+	// pass/fail matrix
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "result\trom\tmismatches\tdigest\n")
+	for _, res := range results {
+		rom, _ := filepath.Rel(dir, res.rom)
+		switch res.outcome {
+		case outcomeError:
+			fmt.Fprintf(w, "%s\t%s\t%v\t\n", res.outcome, rom, res.err)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", res.outcome, rom, res.mismatches, res.pixels, res.digest)
+		}
+	}
+	w.Flush()
+
+	output.Write([]byte(fmt.Sprintf("accuracy tests: %d pass, %d fail", numPass, numFail)))
+	if numError > 0 {
+		output.Write([]byte(fmt.Sprintf(", %d errors", numError)))
+	}
+	output.Write([]byte("\n"))
+
+	// a failed or errored test is an error for the suite as a whole
+	if numFail > 0 || numError > 0 {
+		return errors.New(errors.AccuracyError, fmt.Sprintf("%d of %d tests did not pass", numFail+numError, len(results)))
+	}
+
+	return nil
+}
+
+// findROMs walks the test directory looking for ROM files that have a
+// reference image
+func findROMs(dir string) ([]string, error) {
+	roms := make([]string, 0)
+
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(pth))
+		for _, e := range romExtensions {
+			if ext == e {
+				if _, err := os.Stat(referenceImage(pth)); err == nil {
+					roms = append(roms, pth)
+				}
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return roms, err
+}
+
+// referenceImage returns the filename of the reference image for the ROM
+func referenceImage(rom string) string {
+	return strings.TrimSuffix(rom, filepath.Ext(rom)) + ".png"
+}
+
+// diffImage returns the filename of the diff image for the ROM
+func diffImage(rom string) string {
+	return strings.TrimSuffix(rom, filepath.Ext(rom)) + diffSuffix
+}
+
+// runTest runs a single ROM and compares the result with the reference image
+func runTest(rom string, opts Options) result {
+	res := result{rom: rom, outcome: outcomeError}
+
+	// create headless television
+	tv, err := television.NewTelevision(opts.Spec)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer tv.End()
+
+	// we don't want to wait for the frame limiter
+	tv.SetFPSCap(false)
+
+	// frame capture and video digest. a real television shows video black
+	// during VBLANK and so the reference images will too
+	scr := screenshot.NewScreenshot(tv)
+	scr.SetVBlankBlack(true)
+	dig, err := digest.NewVideo(tv)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	// comparisons must be determinate so the random number generator is
+	// seeded with a value we know
+	vcs, err := hardware.NewVCS(tv, rand.New(rand.NewSource(1)))
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	err = setup.AttachCartridge(vcs, cartridgeloader.Loader{Filename: rom}, nil)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	err = vcs.RunForFrameCount(opts.NumFrames, nil)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	res.digest = dig.Hash()
+
+	// load reference image
+	f, err := os.Open(referenceImage(rom))
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer f.Close()
+
+	ref, err := png.Decode(f)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	top := opts.Top
+	if top < 0 {
+		top = scr.Top()
+	}
+
+	cmp, err := compare(ref, scr.Crop(top, ref.Bounds().Dy()), opts.Tolerance)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	res.mismatches = cmp.mismatches
+	res.pixels = cmp.pixels
+
+	if cmp.mismatches == 0 {
+		res.outcome = outcomePass
+
+		// remove any diff image from a previous failure
+		_ = os.Remove(diffImage(rom))
+
+		return res
+	}
+
+	res.outcome = outcomeFail
+
+	df, err := os.Create(diffImage(rom))
+	if err != nil {
+		res.outcome = outcomeError
+		res.err = err
+		return res
+	}
+	defer df.Close()
+
+	err = png.Encode(df, cmp.diff)
+	if err != nil {
+		res.outcome = outcomeError
+		res.err = err
+		return res
+	}
+
+	return res
+}
@@ -0,0 +1,178 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package accuracy_test
+
+import (
+	"bytes"
+	"gopher2600/accuracy"
+	"gopher2600/test"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// add a ROM to the test directory along with a reference image of the
+// specified size and color. the kernel ROM produces an entirely black screen
+func addTest(t *testing.T, dir string, name string, w, h int, col color.RGBA) *image.RGBA {
+	t.Helper()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	test.WriteROM(t, dir, name+".bin", data)
+
+	ref := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ref.SetRGBA(x, y, col)
+		}
+	}
+
+	writeImage(t, filepath.Join(dir, name+".png"), ref)
+
+	return ref
+}
+
+func writeImage(t *testing.T, filename string, img image.Image) {
+	t.Helper()
+
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("cannot create reference image: %v", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("cannot encode reference image: %v", err)
+	}
+}
+
+// run the tests in the directory and return the results matrix, one line per
+// ROM, indexed by the name of the ROM. the error from accuracy.Run() is
+// returned so that the outcome of the suite as a whole can be checked
+func run(t *testing.T, dir string, tolerance int) (map[string]string, error) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	err := accuracy.Run(out, dir, accuracy.Options{Spec: "NTSC", NumFrames: 5, Top: -1, Tolerance: tolerance})
+
+	// progress is written to the same output, separated by carriage returns
+	results := make(map[string]string)
+	lines := strings.FieldsFunc(out.String(), func(r rune) bool { return r == '\n' || r == '\r' })
+	for _, l := range lines {
+		f := strings.Fields(l)
+		if len(f) >= 3 && strings.HasSuffix(f[1], ".bin") {
+			results[strings.TrimSuffix(f[1], ".bin")] = strings.Join(f[:3], " ")
+		}
+	}
+
+	return results, err
+}
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_accuracy")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	black := color.RGBA{A: 255}
+
+	addTest(t, dir, "pass", 160, 100, black)
+
+	// reference images can be a multiple of the VCS's width
+	addTest(t, dir, "scaled", 320, 100, black)
+
+	// mismatched pixels are counted and shown in a diff image
+	ref := addTest(t, dir, "fail", 160, 100, black)
+	ref.SetRGBA(10, 10, color.RGBA{R: 255, A: 255})
+	ref.SetRGBA(11, 10, color.RGBA{G: 255, A: 255})
+	ref.SetRGBA(12, 10, color.RGBA{B: 255, A: 255})
+	writeImage(t, filepath.Join(dir, "fail.png"), ref)
+
+	// reference image is not a multiple of the VCS's width
+	addTest(t, dir, "width", 100, 100, black)
+
+	// a ROM without a reference image is not a test
+	test.WriteROM(t, dir, "noref.bin", test.NewROM(4096))
+
+	expected := map[string]string{
+		"pass":   "pass pass.bin 0/16000",
+		"scaled": "pass scaled.bin 0/16000",
+		"fail":   "FAIL fail.bin 3/16000",
+		"width":  "ERROR width.bin reference",
+	}
+
+	results, err := run(t, dir, 0)
+	if err == nil {
+		t.Errorf("expected an error for failed tests")
+	}
+	if len(results) != len(expected) {
+		t.Errorf("unexpected number of results: %v", results)
+	}
+	for k, v := range expected {
+		if results[k] != v {
+			t.Errorf("unexpected result for %s: %q (expected %q)", k, results[k], v)
+		}
+	}
+
+	// a diff image is written for failed tests only
+	if _, err := os.Stat(filepath.Join(dir, "fail_diff.png")); err != nil {
+		t.Errorf("no diff image for failed test")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pass_diff.png")); err == nil {
+		t.Errorf("diff image for passed test")
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_accuracy")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	addTest(t, dir, "grey", 160, 10, color.RGBA{R: 8, G: 8, B: 8, A: 255})
+
+	for _, tst := range []struct {
+		tolerance int
+		result    string
+		pass      bool
+	}{
+		{0, "FAIL grey.bin 1600/1600", false},
+		{7, "FAIL grey.bin 1600/1600", false},
+		{8, "pass grey.bin 0/1600", true},
+	} {
+		results, err := run(t, dir, tst.tolerance)
+		if r := results["grey"]; r != tst.result {
+			t.Errorf("tolerance %d: unexpected result %q (expected %q)", tst.tolerance, r, tst.result)
+		}
+		if tst.pass && err != nil {
+			t.Errorf("tolerance %d: unexpected error: %v", tst.tolerance, err)
+		}
+		if !tst.pass && err == nil {
+			t.Errorf("tolerance %d: expected an error for failed test", tst.tolerance)
+		}
+	}
+}
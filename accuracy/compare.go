@@ -0,0 +1,104 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package accuracy
+
+import (
+	"fmt"
+	"gopher2600/television"
+	"image"
+	"image/color"
+)
+
+// comparison is the result of comparing an emulated frame with a reference
+// image
+type comparison struct {
+	// the number of pixels that differ and the total number of pixels compared
+	mismatches int
+	pixels     int
+
+	// image showing the reference image (muted) with mismatched pixels
+	// highlighted
+	diff *image.RGBA
+}
+
+// diff image colors
+var diffMismatch = color.RGBA{R: 255, G: 0, B: 0, A: 255}
+
+// horizontalScale returns the number of reference image pixels used for every
+// VCS pixel. the reference image must be a whole multiple of the VCS's visible
+// width.
+func horizontalScale(ref image.Image) (int, error) {
+	w := ref.Bounds().Dx()
+	if w == 0 || w%television.HorizClksVisible != 0 {
+		return 0, fmt.Errorf("reference image width (%d) is not a multiple of %d", w, television.HorizClksVisible)
+	}
+	return w / television.HorizClksVisible, nil
+}
+
+// compare the reference image with the emulated image. the emulated image
+// should be HorizClksVisible pixels wide and as tall as the reference image.
+//
+// the tolerance value is the maximum difference allowed in any one color
+// channel before a pixel is considered to be different. a tolerance of zero
+// means that pixels must match exactly.
+func compare(ref image.Image, emu *image.RGBA, tolerance int) (comparison, error) {
+	scale, err := horizontalScale(ref)
+	if err != nil {
+		return comparison{}, err
+	}
+
+	rb := ref.Bounds()
+	if emu.Bounds().Dx() != television.HorizClksVisible || emu.Bounds().Dy() != rb.Dy() {
+		return comparison{}, fmt.Errorf("emulated image is not the same size as the reference image")
+	}
+
+	cmp := comparison{
+		diff: image.NewRGBA(image.Rect(0, 0, television.HorizClksVisible, rb.Dy())),
+	}
+
+	for y := 0; y < rb.Dy(); y++ {
+		for x := 0; x < television.HorizClksVisible; x++ {
+			r := color.RGBAModel.Convert(ref.At(rb.Min.X+x*scale, rb.Min.Y+y)).(color.RGBA)
+			e := emu.RGBAAt(x, y)
+
+			cmp.pixels++
+
+			if !channelMatch(r.R, e.R, tolerance) || !channelMatch(r.G, e.G, tolerance) || !channelMatch(r.B, e.B, tolerance) {
+				cmp.mismatches++
+				cmp.diff.SetRGBA(x, y, diffMismatch)
+				continue
+			}
+
+			// matching pixels are shown in a muted greyscale version
+			l := uint8((uint16(r.R) + uint16(r.G) + uint16(r.B)) / 9)
+			cmp.diff.SetRGBA(x, y, color.RGBA{R: l, G: l, B: l, A: 255})
+		}
+	}
+
+	return cmp, nil
+}
+
+func channelMatch(a, b uint8, tolerance int) bool {
+	d := int(a) - int(b)
+	if d < 0 {
+		d = -d
+	}
+	return d <= tolerance
+}
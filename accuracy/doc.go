@@ -0,0 +1,41 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package accuracy runs a suite of test ROMs and compares the emulated output
+// against golden reference images. The reference images would typically be
+// screenshots taken from a real console or from a trusted emulator, like
+// Stella.
+//
+// The test directory is searched (recursively) for ROM files. A ROM file is
+// considered to be part of the test suite if there is a PNG file of the same
+// name in the same directory. For example:
+//
+//	resetDuringHmove/missile/10.bin
+//	resetDuringHmove/missile/10.png
+//
+// Each ROM is run for the specified number of frames and the last complete
+// frame is compared pixel-for-pixel with the reference image. The reference
+// image should show the visible part of the screen only (ie. without the HBLANK
+// or VBLANK areas) and be 160 pixels wide, or a whole multiple of 160 pixels
+// wide. Stella screenshots for example, are typically 320 pixels wide.
+//
+// If a comparison fails, a diff image is written next to the reference image.
+// The diff image has the suffix "_diff.png" and shows the reference image in a
+// muted form with mismatched pixels shown in red.
+package accuracy
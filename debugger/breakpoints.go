@@ -201,12 +201,44 @@ func (bp *breakpoints) check(previousResult string) string {
 	for i := range bp.breaks {
 		// check current value of target with the requested value
 		if bp.breaks[i].check() {
-			checkString.WriteString(fmt.Sprintf("break on %s\n", bp.breaks[i]))
+			checkString.WriteString(fmt.Sprintf("break on %s%s\n", bp.breaks[i], bp.annotate(bp.breaks[i])))
 		}
 	}
 	return checkString.String()
 }
 
+// annotate returns the location symbol and user comment for any PC condition
+// in the breaker. the bank currently mapped to the address is used for the
+// lookup
+func (bp breakpoints) annotate(bk breaker) string {
+	for n := &bk; n != nil; n = n.next {
+		if n.target.Label() != "PC" {
+			continue // for loop
+		}
+
+		v, ok := n.value.(int)
+		if !ok {
+			continue // for loop
+		}
+
+		addr := uint16(v)
+		bank := bp.dbg.vcs.Mem.Cart.GetBank(addr)
+
+		ann := make([]string, 0, 2)
+		if sym, ok := bp.dbg.disasm.Symtable.LocationSymbol(bank, addr); ok {
+			ann = append(ann, sym)
+		}
+		if c, ok := bp.dbg.disasm.Symtable.Comment(bank, addr); ok {
+			ann = append(ann, fmt.Sprintf("; %s", c))
+		}
+		if len(ann) > 0 {
+			return fmt.Sprintf(" (%s)", strings.Join(ann, " "))
+		}
+	}
+
+	return ""
+}
+
 // list currently defined breakpoints
 func (bp breakpoints) list() {
 	if len(bp.breaks) == 0 {
@@ -214,7 +246,7 @@ func (bp breakpoints) list() {
 	} else {
 		bp.dbg.printLine(terminal.StyleFeedback, "breakpoints:")
 		for i := range bp.breaks {
-			bp.dbg.printLine(terminal.StyleFeedback, "% 2d: %s", i, bp.breaks[i])
+			bp.dbg.printLine(terminal.StyleFeedback, "% 2d: %s%s", i, bp.breaks[i], bp.annotate(bp.breaks[i]))
 		}
 	}
 }
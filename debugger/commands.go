@@ -34,6 +34,8 @@ import (
 	"gopher2600/hardware/memory/memorymap"
 	"gopher2600/hardware/riot/input"
 	"gopher2600/patch"
+	"gopher2600/ramsearch"
+	"gopher2600/screenshot"
 	"gopher2600/symbols"
 	"sort"
 	"strconv"
@@ -307,6 +309,17 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 
 	case cmdPatch:
 		f, _ := tokens.Get()
+		if strings.ToUpper(f) == "SAVE" {
+			f, _ = tokens.Get()
+			err := patch.Save(dbg.vcs.Mem.Cart, f)
+			if err != nil {
+				dbg.printLine(terminal.StyleError, "%v", err)
+				return false, nil
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d changes saved to %s", len(dbg.vcs.Mem.Cart.Changes), f)
+			return false, nil
+		}
+
 		patched, err := patch.CartridgeMemory(dbg.vcs.Mem.Cart, f)
 		if err != nil {
 			dbg.printLine(terminal.StyleError, "%v", err)
@@ -375,6 +388,50 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			dbg.printLine(terminal.StyleFeedback, output.String())
 		}
 
+	case cmdCoverage:
+		option, ok := tokens.Get()
+		if !ok {
+			dbg.printLine(terminal.StyleInstrument, "%s", dbg.disasm.Coverage)
+			return false, nil
+		}
+
+		switch strings.ToUpper(option) {
+		case "SAVE":
+			if err := dbg.saveCoverage(); err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "coverage saved")
+
+		case "CLEAR":
+			// entry types have been changed by the coverage so we need a new
+			// disassembly
+			dsm, err := disassembly.FromMemory(dbg.vcs.Mem.Cart, dbg.disasm.Symtable)
+			if err != nil {
+				return false, err
+			}
+			dbg.disasm = dsm
+			dbg.scr.SetFeature(gui.ReqAddDisasm, dbg.disasm)
+
+			if err := dbg.saveCoverage(); err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "coverage cleared")
+
+		default:
+			ai := dbg.dbgmem.mapAddress(option, true)
+			if ai == nil || ai.area != memorymap.Cartridge {
+				dbg.printLine(terminal.StyleError, "%s is not a cartridge address", option)
+				return false, nil
+			}
+
+			bank := dbg.vcs.Mem.Cart.GetBank(ai.mappedAddress)
+			ce := dbg.disasm.Coverage.Get(bank, ai.mappedAddress)
+			dbg.printLine(terminal.StyleInstrument, "%s [bank %d]: %s", ai, bank, ce.Flags)
+			if r := ce.ReadBy(); r != "" {
+				dbg.printLine(terminal.StyleInstrument, "read by %s", r)
+			}
+		}
+
 	case cmdSymbol:
 		tok, _ := tokens.Get()
 		switch strings.ToUpper(tok) {
@@ -398,6 +455,41 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 				dbg.disasm.Symtable.ListSymbols(dbg.printStyle(terminal.StyleFeedback))
 			}
 
+		case "LOAD":
+			filename, _ := tokens.Get()
+			if err := dbg.disasm.Symtable.LoadSymbolsFile(filename); err != nil {
+				return false, err
+			}
+
+			if err := dbg.disasm.UpdateSymbols(); err != nil {
+				return false, err
+			}
+
+			dbg.printLine(terminal.StyleFeedback, "symbols loaded from %s", filename)
+
+		case "ADD":
+			address, _ := tokens.Get()
+			symbol, _ := tokens.Get()
+
+			ai := dbg.dbgmem.mapAddress(address, true)
+			if ai == nil {
+				dbg.printLine(terminal.StyleError, "%s is not a valid address", address)
+				return false, nil
+			}
+
+			a := annotation{bank: dbg.annotationBank(ai), address: ai.address, text: symbol}
+			dbg.project.symbols = setAnnotation(dbg.project.symbols, a)
+			dbg.disasm.Symtable.AddSymbol(a.bank, a.address, a.text)
+
+			if err := dbg.saveProject(); err != nil {
+				return false, err
+			}
+			if err := dbg.disasm.UpdateSymbols(); err != nil {
+				return false, err
+			}
+
+			dbg.printLine(terminal.StyleFeedback, "%s -> %#04x", symbol, ai.address)
+
 		default:
 			symbol := tok
 			table, symbol, address, err := dbg.disasm.Symtable.SearchSymbol(symbol, symbols.UnspecifiedSymTable)
@@ -433,6 +525,59 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			}
 		}
 
+	case cmdComment:
+		address, _ := tokens.Get()
+
+		ai := dbg.dbgmem.mapAddress(address, true)
+		if ai == nil || ai.area != memorymap.Cartridge {
+			dbg.printLine(terminal.StyleError, "%s is not a cartridge address", address)
+			return false, nil
+		}
+
+		comment := strings.TrimSpace(tokens.Remainder())
+		comment = strings.TrimSuffix(strings.TrimPrefix(comment, "\""), "\"")
+		tokens.End()
+
+		a := annotation{bank: dbg.annotationBank(ai), address: ai.address, text: comment}
+		dbg.project.comments = setAnnotation(dbg.project.comments, a)
+		dbg.disasm.Symtable.AddComment(a.bank, a.address, a.text)
+
+		if err := dbg.saveProject(); err != nil {
+			return false, err
+		}
+		if err := dbg.disasm.UpdateSymbols(); err != nil {
+			return false, err
+		}
+
+		if comment == "" {
+			dbg.printLine(terminal.StyleFeedback, "comment removed from %#04x", ai.address)
+		} else {
+			dbg.printLine(terminal.StyleFeedback, "%#04x ; %s", ai.address, comment)
+		}
+
+	case cmdXref:
+		address, _ := tokens.Get()
+
+		// the address may be a read or a write symbol
+		ai := dbg.dbgmem.mapAddress(address, true)
+		if ai == nil {
+			ai = dbg.dbgmem.mapAddress(address, false)
+		}
+		if ai == nil {
+			dbg.printLine(terminal.StyleError, "%s is not a valid address", address)
+			return false, nil
+		}
+
+		dbg.printLine(terminal.StyleFeedback, "%s", ai)
+
+		xrefs := dbg.disasm.Xrefs(ai.address)
+		if len(xrefs) == 0 {
+			dbg.printLine(terminal.StyleFeedback, "no references")
+		}
+		for _, x := range xrefs {
+			dbg.printLine(terminal.StyleFeedback, "%s", x)
+		}
+
 	case cmdOnHalt:
 		if tokens.Remaining() == 0 {
 			if len(dbg.commandOnHalt) == 0 {
@@ -591,6 +736,14 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		s.WriteString(" ")
 		s.WriteString(dbg.disasm.GetField(disassembly.FldActualNotes, e))
 
+		if e.Comment != "" {
+			s.WriteString(fmt.Sprintf(" ; %s", e.Comment))
+		}
+
+		if e.Location != "" {
+			dbg.printLine(terminal.StyleFeedback, "%s", e.Location)
+		}
+
 		if dbg.vcs.CPU.LastResult.Final {
 			dbg.printLine(terminal.StyleCPUStep, s.String())
 		} else {
@@ -766,7 +919,22 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			option = strings.ToUpper(option)
 			switch option {
 			case "SPEC":
+				spec, ok := tokens.Get()
+				if ok {
+					err := dbg.tv.SetSpec(spec)
+					if err != nil {
+						return false, errors.New(errors.CommandError, err)
+					}
+				}
 				dbg.printLine(terminal.StyleInstrument, dbg.tv.GetSpec().ID)
+			case "SYNC":
+				switch arg, _ := tokens.Get(); strings.ToUpper(arg) {
+				case "ON":
+					dbg.tv.SetCRTSync(true)
+				case "OFF":
+					dbg.tv.SetCRTSync(false)
+				}
+				dbg.printInstrument(dbg.tv)
 			default:
 				// already caught by command line ValidateTokens()
 			}
@@ -900,6 +1068,122 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			return false, err
 		}
 
+	case cmdScreenshot:
+		mode := screenshot.ModeVisible
+
+		// the mode argument is optional. if it doesn't parse then it is taken
+		// to be the filename
+		arg, ok := tokens.Get()
+		if ok {
+			if m, err := screenshot.ParseMode(arg); err == nil {
+				mode = m
+			} else {
+				tokens.Unget()
+			}
+		}
+
+		filename, ok := tokens.Get()
+		if !ok {
+			filename = screenshot.UniqueFilename(dbg.vcs.Mem.Cart.ShortName())
+		}
+
+		err := dbg.screenshot.Save(filename, mode)
+		if err != nil {
+			return false, err
+		}
+		dbg.printLine(terminal.StyleFeedback, "screenshot (%s) saved to %s", mode, filename)
+
+	case cmdSearch:
+		option, ok := tokens.Get()
+		if !ok {
+			dbg.listRAMSearch()
+			return false, nil
+		}
+
+		option = strings.ToUpper(option)
+		switch option {
+		case "START":
+			if err := dbg.ramSearch.Start(); err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d candidates", len(dbg.ramSearch.Candidates()))
+
+		case "FREEZE":
+			a, _ := tokens.Get()
+			ai := dbg.dbgmem.mapAddress(a, true)
+			if ai == nil {
+				dbg.printLine(terminal.StyleError, "%s is not a RAM address", a)
+				return false, nil
+			}
+
+			var val uint8
+
+			v, ok := tokens.Get()
+			if ok {
+				n, err := strconv.ParseUint(v, 0, 8)
+				if err != nil {
+					dbg.printLine(terminal.StyleError, "value must be an 8 bit number (%s)", v)
+					return false, nil
+				}
+				val = uint8(n)
+			} else {
+				var err error
+				val, err = dbg.vcs.Mem.Peek(ai.mappedAddress)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			if err := dbg.ramSearch.Freeze(ai.mappedAddress, val); err != nil {
+				dbg.printLine(terminal.StyleError, "%v", err)
+				return false, nil
+			}
+			dbg.printLine(terminal.StyleFeedback, "%#04x frozen at %#02x", ai.mappedAddress, val)
+
+		case "UNFREEZE":
+			a, _ := tokens.Get()
+			if strings.ToUpper(a) == "ALL" {
+				dbg.ramSearch.UnfreezeAll()
+				dbg.printLine(terminal.StyleFeedback, "all addresses unfrozen")
+				return false, nil
+			}
+
+			ai := dbg.dbgmem.mapAddress(a, true)
+			if ai == nil || !dbg.ramSearch.Unfreeze(ai.mappedAddress) {
+				dbg.printLine(terminal.StyleError, "%s is not frozen", a)
+				return false, nil
+			}
+			dbg.printLine(terminal.StyleFeedback, "%#04x unfrozen", ai.mappedAddress)
+
+		case "CLEAR":
+			dbg.ramSearch.Clear()
+			dbg.printLine(terminal.StyleFeedback, "search cleared")
+
+		default:
+			cmp, err := ramsearch.ParseComparison(option)
+			if err != nil {
+				return false, err
+			}
+
+			var val uint8
+			if cmp == ramsearch.Equal {
+				v, _ := tokens.Get()
+				n, err := strconv.ParseUint(v, 0, 8)
+				if err != nil {
+					dbg.printLine(terminal.StyleError, "value must be an 8 bit number (%s)", v)
+					return false, nil
+				}
+				val = uint8(n)
+			}
+
+			n, err := dbg.ramSearch.Narrow(cmp, val)
+			if err != nil {
+				dbg.printLine(terminal.StyleError, "%v", err)
+				return false, nil
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d candidates", n)
+		}
+
 	case cmdPanel:
 		mode, _ := tokens.Get()
 		switch strings.ToUpper(mode) {
@@ -973,6 +1257,26 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		case "NORIGHT":
 			event = input.Right
 			value = false
+
+		// extra buttons of the Genesis pad and Booster Grip
+		case "BUTTONC":
+			event = input.GenesisButtonC
+			value = true
+		case "NOBUTTONC":
+			event = input.GenesisButtonC
+			value = false
+		case "TRIGGER":
+			event = input.BoosterGripTrigger
+			value = true
+		case "NOTRIGGER":
+			event = input.BoosterGripTrigger
+			value = false
+		case "BOOSTER":
+			event = input.BoosterGripBooster
+			value = true
+		case "NOBOOSTER":
+			event = input.BoosterGripBooster
+			value = false
 		}
 
 		n, _ := strconv.Atoi(stick)
@@ -1013,6 +1317,125 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			return false, err
 		}
 
+	case cmdSaveKey:
+		sk := dbg.vcs.RIOT.Input.HandController1.SaveKey()
+		if sk == nil {
+			sk = dbg.vcs.RIOT.Input.HandController0.SaveKey()
+		}
+		if sk == nil {
+			dbg.printLine(terminal.StyleFeedback, "no SaveKey or AtariVox attached")
+			return false, nil
+		}
+
+		option, _ := tokens.Get()
+		switch strings.ToUpper(option) {
+		case "CLEAR":
+			err := sk.Clear()
+			if err != nil {
+				return false, errors.New(errors.CommandError, err)
+			}
+			dbg.printLine(terminal.StyleFeedback, "%s EEPROM cleared", sk)
+
+		case "SPEECH":
+			speech := sk.Speech()
+			if speech == "" {
+				dbg.printLine(terminal.StyleFeedback, "no speech data")
+			} else {
+				dbg.printLine(terminal.StyleInstrument, "%s", speech)
+			}
+
+		default:
+			data := sk.Data()
+			s := &strings.Builder{}
+
+			// only rows that have been written to are shown. an empty
+			// row is one with every byte set to 0xff
+			for i := 0; i < len(data); i += 16 {
+				empty := true
+				for _, d := range data[i : i+16] {
+					if d != 0xff {
+						empty = false
+						break
+					}
+				}
+				if empty {
+					continue
+				}
+
+				if s.Len() > 0 {
+					s.WriteString("\n")
+				}
+				s.WriteString(fmt.Sprintf("%03x- | ", i/16))
+				for _, d := range data[i : i+16] {
+					s.WriteString(fmt.Sprintf("%02x ", d))
+				}
+			}
+
+			if s.Len() == 0 {
+				dbg.printLine(terminal.StyleFeedback, "%s EEPROM is empty", sk)
+			} else {
+				dbg.printInstrument(s)
+			}
+		}
+
+	case cmdKidVid:
+		hc := dbg.vcs.RIOT.Input.HandController1
+		if hc.KidVid() == nil {
+			hc = dbg.vcs.RIOT.Input.HandController0
+		}
+		if hc.KidVid() == nil {
+			dbg.printLine(terminal.StyleFeedback, "no Kid Vid attached")
+			return false, nil
+		}
+
+		var err error
+
+		option, _ := tokens.Get()
+		switch strings.ToUpper(option) {
+		case "TAPE":
+			filename, _ := tokens.Get()
+			err = hc.Handle(input.KidVidTape, filename)
+		case "PLAY":
+			err = hc.Handle(input.KidVidPlay, true)
+		case "STOP":
+			err = hc.Handle(input.KidVidPlay, false)
+		}
+		if err != nil {
+			return false, errors.New(errors.CommandError, err)
+		}
+
+		dbg.printLine(terminal.StyleInstrument, "%s", hc.KidVid())
+
+	case cmdController:
+		player, _ := tokens.Get()
+
+		hc := dbg.vcs.RIOT.Input.HandController0
+		if player == "1" {
+			hc = dbg.vcs.RIOT.Input.HandController1
+		}
+
+		which, ok := tokens.Get()
+		if ok {
+			err := hc.Handle(input.SetControllerType, which)
+			if err != nil {
+				return false, errors.New(errors.CommandError, err)
+			}
+
+			res, ok := tokens.Get()
+			if ok {
+				f, err := strconv.ParseFloat(res, 32)
+				if err != nil {
+					return false, errors.New(errors.CommandError, err)
+				}
+				err = hc.Handle(input.PointerResolution, float32(f))
+				if err != nil {
+					return false, errors.New(errors.CommandError, err)
+				}
+			}
+		}
+
+		dbg.printLine(terminal.StyleInstrument, "%s", hc.ControllerType())
+
 	case cmdBreak:
 		err := dbg.breakpoints.parseBreakpoint(tokens)
 		if err != nil {
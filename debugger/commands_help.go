@@ -85,7 +85,13 @@ will show where the game was loaded from, the cartridge type and bank number. Th
 argument shows a brief summary of what was discovered during disassembly. The BANK
 argument meanwhile can be used to switch banks (if possible).`,
 
-	cmdPatch: "Apply a patch file to the loaded cartridge",
+	cmdPatch: `Apply a patch file to the loaded cartridge. IPS and BPS patch files are supported, along with
+the simple text format described in the patch package. The patch file can be anywhere; if it does
+not exist at the given path then it is looked for in the patches directory.
+
+PATCH SAVE writes every patch and poke made to the cartridge since it was loaded to a new patch
+file. Files ending in .bps are saved in BPS format, all other files in IPS format. The saved file
+can be applied with the PATCH command or by adding it to the setup database.`,
 
 	cmdDisassembly: `Display cartridge disassembly. By default, all banks will be displayed. Single
 banks can be displayed by specifying the bank number. Use BYTECODE to display raw bytes alongside
@@ -97,7 +103,20 @@ in the disassembly to the termain.
 The scope of the GREP can be restricted to the MNEMONIC and OPERAND columns. By
 default GREP will consider the entire line.`,
 
-	cmdSymbol: `The SYMBOL command has two modes of operation. The first mode returns the address of
+	cmdCoverage: `Show a summary of the runtime coverage of the cartridge. Coverage records
+which bytes of the cartridge have been executed as opcodes or operands, which
+have been read as data and which have never been touched. Coverage information
+is used to improve the disassembly.
+
+Specifying an address shows the coverage of that address in the current bank,
+including the instruction that most recently read it as data.
+
+Coverage is saved when the debugger ends or when a new cartridge is inserted,
+and is restored the next time the cartridge is loaded. The SAVE argument saves
+the coverage immediately. The CLEAR argument forgets all coverage information
+for the cartridge.`,
+
+	cmdSymbol: `The SYMBOL command has three modes of operation. The first mode returns the address of
 the specified symbol. For example:
 
 	SYMBOL CXM1P
@@ -115,10 +134,57 @@ mirrored. You can quickly see this with the MIRRORS or ALL argument.
 The above example will return every address that mirrors the primary address.
 
 The second mode of operation allows you to view all the symbols in each symbol
-table. There are three symbol tables: READ, WRITE and LOCATION.
+table. There are three symbol tables: READ, WRITE and LOCATION. Locations that
+are specific to a cartridge bank are listed separately for each bank.
+
+The third mode of operation loads symbols from a file:
 
+	SYMBOL LOAD roms/game.lst
+
+Symbols files for DASM (.sym and .lst) and ca65 (.dbg) are understood. Any
+other file is read as a simple label file, with one address and symbol per
+line. Addresses in a label file can be qualified with a bank number:
+
+	$0080    score
+	$f000    reset
+	1:$f000  reset_bank1
+
+Symbols files with the same name as the cartridge are loaded automatically.
 Note that a cartridge without an accompanying symbols file will only have the
-canonical Atari VCS symbols defined.`,
+canonical Atari VCS symbols defined.
+
+New symbols can be added with the ADD argument:
+
+	SYMBOL ADD 0xf012 kernelStart
+
+Symbols for cartridge addresses are locations. In cartridges with more than
+one bank, the symbol only applies to the bank currently mapped to the address.
+Symbols for any other address are both READ and WRITE symbols. Added symbols
+are saved and will be restored the next time the cartridge is inserted.`,
+
+	cmdXref: `Lists every instruction that refers to an address. References are found by
+analysis of the disassembly and by observation while the emulation is running.
+For example:
+
+	XREF WSYNC
+
+Reads, writes, jumps and branches are all listed, along with the bank of the
+referring instruction and the number of times the reference has been seen
+during emulation. The address can be any address: RAM, TIA and RIOT registers,
+and cartridge addresses, including bank switching hotspots. All mirrors of the
+address are considered.
+
+Runtime references are forgotten with COVERAGE CLEAR.`,
+
+	cmdComment: `Adds a comment to a cartridge address. The comment is shown in the
+disassembly, when stepping with the LAST command, and with any PC breakpoint for
+the address. For example:
+
+	COMMENT 0xf012 "draws the score"
+
+Omitting the comment removes any existing comment for the address. As with
+SYMBOL ADD, comments apply to the bank currently mapped to the address and are
+saved for the next time the cartridge is inserted.`,
 
 	cmdOnHalt: `Define commands to run whenever emulation is halted. A halt is
 caused by a BREAK, a TRAP, a WATCH or a manual interrupt. Specify multiple
@@ -189,7 +255,22 @@ current delay information for all TIA video components.`,
                               |
            volume ------------+`,
 
-	cmdTV: "Display the current TV state.",
+	cmdTV: `Display the current TV state.
+
+The SPEC argument shows the current TV specification. The specification can
+be changed by adding one of NTSC, PAL, PAL60, SECAM or AUTO. AUTO keeps the
+current specification but allows it to change from a 60Hz specification (NTSC
+or PAL60) to PAL if the number of scanlines in a frame is too many.
+
+AUTO-PAL and AUTO-SECAM are hints for the color system of the console. With
+AUTO-PAL the specification is PAL60 and changes to PAL if the number of
+scanlines is too many. With AUTO-SECAM the specification is NTSC and changes to
+SECAM.
+
+The SYNC argument turns the CRT sync model on or off. With the model on, the
+picture rolls if the VSYNC is too short or if the number of scanlines in a
+frame is too far from the specification. Scanlines of the wrong length cause
+the picture to be skewed.`,
 
 	cmdPlayer: `Display the current state of the player sprites. The player information to
 display can be selected with 0 or 1 arguments. Omitting this argument will show
@@ -357,6 +438,35 @@ The OVERLAY ON and OVERLAY OFF arguments toggle a debugging overlay. This
 overlay decorates the display with markers showing when during the drawing
 process key video events were triggered.`,
 
+	cmdScreenshot: `Save the most recently completed frame as a PNG file. The FULL
+argument saves the entire television signal, including the hblank and vblank
+areas. The VISIBLE argument (the default) saves only the visible area of the
+screen. The ASPECT argument saves the visible area, stretched horizontally in
+the same way as the display.
+
+If no filename is given then a unique filename is created, based on the
+cartridge name.`,
+
+	cmdSearch: `Search for the location of game variables in VCS RAM and in any active cartridge RAM.
+START begins a new search with every RAM address as a candidate. The search is narrowed by
+comparing the current value of each candidate with the value when the search was last started
+or narrowed:
+
+	EQUAL      the value is equal to the number given
+	CHANGED    the value has changed
+	UNCHANGED  the value has not changed
+	INCREASED  the value has increased
+	DECREASED  the value has decreased
+
+For example, to find the number of lives: START the search, lose a life, narrow with DECREASED,
+play on without losing a life, narrow with UNCHANGED and so on.
+
+FREEZE forces an address to a value at the start of every frame. If no value is given then the
+current value of the address is used. UNFREEZE releases an address, or every address with ALL.
+CLEAR ends the search but does not release frozen addresses.
+
+Without an argument the remaining candidates and the frozen addresses are listed.`,
+
 	// user input
 	cmdPanel: "Inspect and set front panel settings. Switches can be set or toggled..",
 
@@ -366,13 +476,49 @@ subsequent video cycles.
 Specify the player with the 0 or 1 arguments.
 
 Note that it is possible to set the stick combinations that would normally not
-be possible with a joystick. For example, LEFT and RIGHT set at the same time.`,
+be possible with a joystick. For example, LEFT and RIGHT set at the same time.
+
+The BUTTONC argument presses the extra button of the Genesis pad. The TRIGGER
+and BOOSTER arguments press the extra buttons of the Booster Grip. The
+controller type must first be set with the CONTROLLER command (or the setup
+database).`,
 
 	cmdKeypad: `Set keyboard input for Player 0 or Player 1 for the next and subsequent
 video cycles.
 
 Specify the player with the 0 or 1 arguments.`,
 
+	cmdSaveKey: `Inspect the EEPROM of the SaveKey or AtariVox attached to a controller
+port. The DUMP argument (the default) shows the parts of the EEPROM that are
+not empty. The CLEAR argument erases the EEPROM, including the copy on disk.
+
+The SPEECH argument shows the most recent data sent to the AtariVox, decoded
+into SpeakJet allophones and codes.
+
+The SaveKey or AtariVox must first be attached with a "controller" entry in
+the setup database.`,
+
+	cmdKidVid: `Show the state of the Kid Vid attached to a controller port or
+control its tape. The TAPE argument loads the tape with the base filename. Each
+track of the tape is a WAV file named after the base filename, for example
+"smurfs_1.wav", "smurfs_2.wav" and "smurfs_3.wav" for a base filename of
+"smurfs". The left channel of a file is the voice track and the right channel,
+if present, is the data track. The data track of a mono file is generated. The
+PLAY and STOP arguments press and release the play button of the cassette
+player. The tape only moves when the cartridge has selected a track and
+started the motor.
+
+The Kid Vid must first be attached with a "controller" entry in the setup
+database or with the CONTROLLER command.`,
+
+	cmdController: `Show or change the type of controller attached to the Player 0
+or Player 1 port. Specify the player with the 0 or 1 arguments.
+
+Changing the controller type with this command works in the same way as the
+"controller" entry in the setup database. The Trak-Ball and mouse types accept
+an optional resolution, which is the number of movement signals generated as
+the host's pointer moves over the width of the display.`,
+
 	// halt conditions
 	cmdBreak: `Halt execution of the emulation when a specific value is "loaded" into a named
 target. A target is a part of the emulation hardware that can be interegated
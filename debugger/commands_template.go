@@ -35,7 +35,10 @@ const (
 	cmdPatch       = "PATCH"
 	cmdDisassembly = "DISASSEMBLY"
 	cmdGrep        = "GREP"
+	cmdCoverage    = "COVERAGE"
 	cmdSymbol      = "SYMBOL"
+	cmdComment     = "COMMENT"
+	cmdXref        = "XREF"
 	cmdOnHalt      = "ONHALT"
 	cmdOnStep      = "ONSTEP"
 	cmdLast        = "LAST"
@@ -53,11 +56,16 @@ const (
 	cmdBall        = "BALL"
 	cmdPlayfield   = "PLAYFIELD"
 	cmdDisplay     = "DISPLAY"
+	cmdScreenshot  = "SCREENSHOT"
+	cmdSearch      = "SEARCH"
 
 	// user input
-	cmdPanel  = "PANEL"
-	cmdStick  = "STICK"
-	cmdKeypad = "KEYPAD"
+	cmdPanel      = "PANEL"
+	cmdStick      = "STICK"
+	cmdKeypad     = "KEYPAD"
+	cmdSaveKey    = "SAVEKEY"
+	cmdKidVid     = "KIDVID"
+	cmdController = "CONTROLLER"
 
 	// halt conditions
 	cmdBreak = "BREAK"
@@ -82,10 +90,13 @@ var commandTemplate = []string{
 
 	cmdInsert + " %<cartridge>F",
 	cmdCartridge + " (ANALYSIS|BANK %<number>N)",
-	cmdPatch + " %<patch file>S",
+	cmdPatch + " [SAVE %<new file>F|%<patch file>F]",
 	cmdDisassembly + " (BYTECODE) (%<bank num>N)",
 	cmdGrep + " (MNEMONIC|OPERAND) %<search>S",
-	cmdSymbol + " [%<symbol>S (ALL|MIRRORS)|LIST (LOCATIONS|READ|WRITE)]",
+	cmdCoverage + " (SAVE|CLEAR|%<address>S)",
+	cmdSymbol + " [LIST (LOCATIONS|READ|WRITE)|LOAD %<file>F|ADD %<address>S %<symbol>S|%<symbol>S (ALL|MIRRORS)]",
+	cmdComment + " %<address>S {%<comment>S}",
+	cmdXref + " %<address>S",
 	cmdOnHalt + " (OFF|ON|%<command>S {%<commands>S})",
 	cmdOnStep + " (OFF|ON|%<command>S {%<commands>S})",
 	cmdLast + " (DEFN|BYTECODE)",
@@ -97,17 +108,22 @@ var commandTemplate = []string{
 	cmdTimer,
 	cmdTIA + " (DELAYS)",
 	cmdAudio,
-	cmdTV + " (SPEC)",
+	cmdTV + " (SPEC (NTSC|PAL|PAL60|SECAM|AUTO|AUTO-PAL|AUTO-SECAM)|SYNC (ON|OFF))",
 	cmdPlayer + " (0|1)",
 	cmdMissile + " (0|1)",
 	cmdBall,
 	cmdPlayfield,
 	cmdDisplay + " (ON|OFF|SCALE [%<scale value>P]|MASKING (ON|OFF)|ALT (ON|OFF)|OVERLAY (ON|OFF))", // see notes
+	cmdScreenshot + " (FULL|VISIBLE|ASPECT) (%<file>F)",
+	cmdSearch + " (START|EQUAL %<value>N|CHANGED|UNCHANGED|INCREASED|DECREASED|FREEZE %<address>S (%<value>N)|UNFREEZE [ALL|%<address>S]|CLEAR)",
 
 	// user input
 	cmdPanel + " (SET [P0PRO|P1PRO|P0AM|P1AM|COL|BW]|TOGGLE [P0|P1|COL])",
-	cmdStick + " [0|1] [LEFT|RIGHT|UP|DOWN|FIRE|NOLEFT|NORIGHT|NOUP|NODOWN|NOFIRE]",
+	cmdStick + " [0|1] [LEFT|RIGHT|UP|DOWN|FIRE|NOLEFT|NORIGHT|NOUP|NODOWN|NOFIRE|BUTTONC|NOBUTTONC|TRIGGER|NOTRIGGER|BOOSTER|NOBOOSTER]",
 	cmdKeypad + " [0|1] [none|0|1|2|3|4|5|6|7|8|9|*|#]",
+	cmdSaveKey + " (DUMP|CLEAR|SPEECH)",
+	cmdKidVid + " (TAPE %<file>F|PLAY|STOP)",
+	cmdController + " [0|1] (JOYSTICK|PADDLE|KEYPAD|DRIVING|SAVEKEY|ATARIVOX|TRAKBALL|AMIGAMOUSE|STMOUSE|GENESIS|BOOSTERGRIP|KIDVID) (%<resolution>N)",
 
 	// halt conditions
 	cmdBreak + " [%<target>S %<value>N|%<pc value>S] {& %<target>S %<value>S|& %<value>S}",
@@ -0,0 +1,164 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"gopher2600/disassembly"
+	"gopher2600/errors"
+	"gopher2600/hardware/memory/memorymap"
+	"os"
+)
+
+// coverageAccess is an access of memory noted during the execution of an
+// instruction
+type coverageAccess struct {
+	// bank is only meaningful for cartridge addresses
+	bank    int
+	address uint16
+	write   bool
+}
+
+// coverage watches memory access during emulation and records the runtime
+// coverage of the cartridge, and the runtime cross-references of every
+// address, in the disassembly
+type coverage struct {
+	dbg *Debugger
+
+	// the bank of the instruction currently being executed. noted at the
+	// start of the instruction because the instruction may cause the bank to
+	// change
+	bank int
+
+	// memory accesses made by the instruction currently being executed
+	accesses []coverageAccess
+
+	// memory access ID of the most recently noted access
+	lastAccessID int
+}
+
+func newCoverage(dbg *Debugger) *coverage {
+	return &coverage{
+		dbg:      dbg,
+		accesses: make([]coverageAccess, 0, 8),
+	}
+}
+
+// startInstruction should be called before every CPU instruction
+func (cov *coverage) startInstruction() {
+	cov.bank = cov.dbg.vcs.Mem.Cart.GetBank(cov.dbg.vcs.CPU.PC.Address())
+	cov.accesses = cov.accesses[:0]
+}
+
+// check should be called every video cycle. it notes any memory access that
+// has happened since the previous call
+func (cov *coverage) check() {
+	mem := cov.dbg.vcs.Mem
+
+	if mem.LastAccessID == cov.lastAccessID {
+		return
+	}
+	cov.lastAccessID = mem.LastAccessID
+
+	acc := coverageAccess{
+		address: mem.LastAccessAddress,
+		write:   mem.LastAccessWrite,
+	}
+
+	if _, area := memorymap.MapAddress(mem.LastAccessAddress, true); area == memorymap.Cartridge {
+		acc.bank = mem.Cart.GetBank(mem.LastAccessAddress)
+	}
+
+	cov.accesses = append(cov.accesses, acc)
+}
+
+// endInstruction should be called after every CPU instruction. the executed
+// instruction and any memory accesses that were not part of the instruction
+// itself are recorded in the disassembly
+func (cov *coverage) endInstruction() {
+	result := cov.dbg.vcs.CPU.LastResult
+	if !result.Final || result.Defn == nil {
+		return
+	}
+
+	cov.dbg.disasm.Executed(cov.bank, result)
+
+	start := result.Address & memorymap.AddressMaskCart
+	end := start + uint16(result.Defn.Bytes)
+
+	for _, acc := range cov.accesses {
+		_, area := memorymap.MapAddress(acc.address, !acc.write)
+
+		if area == memorymap.Cartridge && !acc.write {
+			address := acc.address & memorymap.AddressMaskCart
+
+			// reading of the instruction itself
+			if acc.bank == cov.bank && address >= start && address < end {
+				continue // for loop
+			}
+
+			cov.dbg.disasm.ReadData(acc.bank, address, cov.bank, result)
+		}
+
+		cov.dbg.disasm.Referenced(acc.address, acc.write, cov.bank, result)
+	}
+
+	cov.dbg.disasm.Flowed(cov.dbg.vcs.CPU.PC.Address(), cov.bank, result)
+}
+
+// coverageFile returns the name of the coverage file for the current
+// cartridge. returns the empty string if there is no cartridge
+func (dbg *Debugger) coverageFile() (string, error) {
+	if dbg.vcs.Mem.Cart.Hash == "" || dbg.vcs.Mem.Cart.IsEjected() {
+		return "", nil
+	}
+	return disassembly.CoverageFile(dbg.vcs.Mem.Cart.Hash)
+}
+
+// loadCoverage reads the coverage file for the current cartridge, if it
+// exists, and applies it to the disassembly
+func (dbg *Debugger) loadCoverage() error {
+	return dbg.disasm.LoadCoverageFile()
+}
+
+// saveCoverage writes the coverage of the current cartridge to the coverage
+// file. the coverage file is removed if there is no coverage to save
+func (dbg *Debugger) saveCoverage() error {
+	if dbg.disasm == nil || dbg.disasm.Coverage == nil {
+		return nil
+	}
+
+	pth, err := dbg.coverageFile()
+	if err != nil {
+		return errors.New(errors.CoverageError, err)
+	}
+	if pth == "" {
+		return nil
+	}
+
+	// there's no need to keep a file for a cartridge with no coverage
+	if dbg.disasm.Coverage.IsEmpty() {
+		if err := os.Remove(pth); err != nil && !os.IsNotExist(err) {
+			return errors.New(errors.CoverageError, err)
+		}
+		return nil
+	}
+
+	return dbg.disasm.Coverage.Save(pth)
+}
@@ -28,7 +28,10 @@ import (
 	"gopher2600/errors"
 	"gopher2600/gui"
 	"gopher2600/hardware"
+	"gopher2600/playmode"
+	"gopher2600/ramsearch"
 	"gopher2600/reflection"
+	"gopher2600/screenshot"
 	"gopher2600/setup"
 	"gopher2600/symbols"
 	"gopher2600/television"
@@ -64,6 +67,24 @@ type Debugger struct {
 	// frame limiter
 	lmtr *limiter
 
+	// records the runtime coverage of the cartridge in the disassembly
+	coverage *coverage
+
+	// symbols and comments added by the user for the current cartridge
+	project *project
+
+	// keeps a copy of the most recent frame for the SCREENSHOT command
+	screenshot *screenshot.Screenshot
+
+	// search for game variables in RAM and the list of frozen addresses. used
+	// by the SEARCH command and the GUI
+	ramSearch *ramsearch.Search
+
+	// maps keyboard and gamepad input to VCS input. the same bindings are
+	// used in playmode. loaded on demand by the first keyboard or gamepad
+	// event, see getBindings()
+	bindings *playmode.Bindings
+
 	// halt conditions
 	breakpoints *breakpoints
 	traps       *traps
@@ -138,11 +159,14 @@ func NewDebugger(tv television.Television, scr gui.GUI, term terminal.Terminal)
 	}
 
 	// create a new VCS instance
-	dbg.vcs, err = hardware.NewVCS(dbg.tv)
+	dbg.vcs, err = hardware.NewVCS(dbg.tv, nil)
 	if err != nil {
 		return nil, errors.New(errors.DebuggerError, err)
 	}
 
+	// the contents of the SaveKey and AtariVox are kept between sessions
+	dbg.vcs.RIOT.Input.PersistPeripherals(true)
+
 	// create instance of disassembly -- the same base structure is used
 	// for disassemblies subseuquent to the first one.
 	dbg.disasm, err = disassembly.FromMemory(dbg.vcs.Mem.Cart, nil)
@@ -157,6 +181,18 @@ func NewDebugger(tv television.Television, scr gui.GUI, term terminal.Terminal)
 	// function.
 	dbg.dbgmem = &memoryDebug{mem: dbg.vcs.Mem, symtable: dbg.disasm.Symtable}
 
+	// coverage is recorded in whatever the current disassembly is
+	dbg.coverage = newCoverage(dbg)
+
+	// user symbols and comments are replaced when a cartridge is loaded
+	dbg.project = &project{}
+
+	// screenshots are taken from the most recently completed frame
+	dbg.screenshot = screenshot.NewScreenshot(dbg.tv)
+
+	// frozen addresses are poked at the start of every frame
+	dbg.ramSearch = ramsearch.NewSearch(dbg.tv, dbg.vcs.Mem)
+
 	// set up frame limiter
 	dbg.lmtr = newLimiter(tv, func() error {
 		_, err := dbg.checkEvents(nil)
@@ -244,6 +280,13 @@ func (dbg *Debugger) Start(initScript string, cartload cartridgeloader.Loader) e
 		}
 	}
 
+	// save coverage of whatever cartridge is loaded when the debugger ends
+	defer func() {
+		if err := dbg.saveCoverage(); err != nil {
+			dbg.printLine(terminal.StyleError, "%s", err)
+		}
+	}()
+
 	// end script recording gracefully
 	defer func() {
 		if dbg.scriptScribe.IsActive() {
@@ -270,20 +313,51 @@ func (dbg *Debugger) Start(initScript string, cartload cartridgeloader.Loader) e
 // this is the glue that hold the cartridge and disassembly packages together.
 // especially important is the repointing of symtable in the instance of dbgmem
 func (dbg *Debugger) loadCartridge(cartload cartridgeloader.Loader) error {
-	err := setup.AttachCartridge(dbg.vcs, cartload)
-	if err != nil && !errors.Has(err, errors.CartridgeEjected) {
-		return err
+	// save coverage of the outgoing cartridge. failure is not fatal
+	if err := dbg.saveCoverage(); err != nil {
+		dbg.printLine(terminal.StyleError, "%s", err)
 	}
 
-	symtable, err := symbols.ReadSymbolsFile(cartload.Filename)
-	if err != nil {
-		dbg.printLine(terminal.StyleError, "%s", err)
-		// continuing because symtable is always valid even if err non-nil
+	// the RAM search and frozen addresses are meaningless for the new
+	// cartridge
+	dbg.ramSearch.Clear()
+	dbg.ramSearch.UnfreezeAll()
+
+	// the disassembly is created during setup so that the analysis can be
+	// used to select the controllers
+	disassemble := func() (*disassembly.Disassembly, error) {
+		symtable, err := symbols.ReadSymbolsFile(cartload.Filename)
+		if err != nil {
+			dbg.printLine(terminal.StyleError, "%s", err)
+			// continuing because symtable is always valid even if err non-nil
+		}
+
+		// user symbols and comments from previous sessions. failure is not fatal
+		if err := dbg.loadProject(symtable); err != nil {
+			dbg.printLine(terminal.StyleError, "%s", err)
+		}
+
+		dbg.disasm, err = disassembly.FromMemory(dbg.vcs.Mem.Cart, symtable)
+		return dbg.disasm, err
 	}
 
-	dbg.disasm, err = disassembly.FromMemory(dbg.vcs.Mem.Cart, symtable)
+	err := setup.AttachCartridge(dbg.vcs, cartload, disassemble)
 	if err != nil {
-		return err
+		if !errors.Has(err, errors.CartridgeEjected) {
+			return err
+		}
+
+		// setup stops early if there is no cartridge but we still need a
+		// disassembly
+		_, err = disassemble()
+		if err != nil {
+			return err
+		}
+	}
+
+	// coverage from previous sessions. failure is not fatal
+	if err := dbg.loadCoverage(); err != nil {
+		dbg.printLine(terminal.StyleError, "%s", err)
 	}
 
 	dbg.scr.SetFeature(gui.ReqAddDisasm, dbg.disasm)
@@ -68,6 +68,16 @@ func (t *mockTV) IsStable() bool {
 	return true
 }
 
+func (t *mockTV) SetCRTSync(_ bool) {
+}
+
+func (t *mockTV) SaveState() interface{} {
+	return nil
+}
+
+func (t *mockTV) RestoreState(_ interface{}) {
+}
+
 func (t *mockTV) End() error {
 	return nil
 }
@@ -76,7 +86,8 @@ func (t *mockTV) SpecIDOnCreation() string {
 	return ""
 }
 
-func (t *mockTV) SetFPSCap(set bool) {
+func (t *mockTV) SetFPSCap(set bool) bool {
+	return false
 }
 
 func (t *mockTV) SetFPS(fps float32) {
@@ -206,11 +217,34 @@ func (trm *mockTerm) cmpOutput(s string) bool {
 	return false
 }
 
+// waitOutput waits for the debugger to output a line matching the string
+// argument. unlike cmpOutput() it does not expect the output to arrive within a
+// few milliseconds, which makes it suitable for commands that take a variable
+// amount of time. the test fails if the line has not arrived after several
+// seconds.
+func (trm *mockTerm) waitOutput(s string) bool {
+	for {
+		select {
+		case o := <-trm.out:
+			trm.output = append(trm.output, o)
+			if o == s {
+				return true
+			}
+
+		case <-time.After(5 * time.Second):
+			trm.t.Errorf(fmt.Sprintf("debugger did not output (%s)", s))
+			return false
+		}
+	}
+}
+
 func (trm *mockTerm) testSequence() {
 	defer func() { trm.sndInput("QUIT") }()
 	trm.testBreakpoints()
 	trm.testTraps()
 	trm.testWatches()
+	trm.testProject()
+	trm.testRAMSearch()
 }
 
 func TestDebugger_withNonExistantInitScript(t *testing.T) {
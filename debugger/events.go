@@ -27,6 +27,20 @@ import (
 	"gopher2600/playmode"
 )
 
+// getBindings returns the input bindings, loading them if necessary. the
+// bindings are not loaded by NewDebugger() because a debugger without a GUI
+// has no need for them
+func (dbg *Debugger) getBindings() (*playmode.Bindings, error) {
+	if dbg.bindings == nil {
+		var err error
+		dbg.bindings, err = playmode.NewBindings()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dbg.bindings, nil
+}
+
 func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 	var err error
 
@@ -39,7 +53,12 @@ func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 		var handled bool
 
 		// check playmode key presses first
-		handled, err = playmode.KeyboardEventHandler(ev, dbg.vcs)
+		var bindings *playmode.Bindings
+		bindings, err = dbg.getBindings()
+		if err != nil {
+			break // switch ev.(type)
+		}
+		handled, err = bindings.KeyboardEventHandler(ev, dbg.vcs)
 		if err != nil {
 			break // switch ev.(type)
 		}
@@ -87,6 +106,20 @@ func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 	case gui.EventMouseMotion:
 		_, err := playmode.MouseMotionEventHandler(ev, dbg.vcs)
 		return err
+
+	case gui.EventGamepadButton:
+		var bindings *playmode.Bindings
+		bindings, err = dbg.getBindings()
+		if err == nil {
+			_, err = bindings.GamepadButtonEventHandler(ev, dbg.vcs)
+		}
+
+	case gui.EventGamepadAxis:
+		var bindings *playmode.Bindings
+		bindings, err = dbg.getBindings()
+		if err == nil {
+			_, err = bindings.GamepadAxisEventHandler(ev, dbg.vcs)
+		}
 	}
 
 	// wrap error in GUIEventError
@@ -34,6 +34,7 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 	// vcsStep is to be called every video cycle when the quantum mode
 	// is set to CPU
 	vcsStep := func() error {
+		dbg.coverage.check()
 		return dbg.reflect.Check()
 	}
 
@@ -238,6 +239,8 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 				return nil
 			}
 
+			dbg.coverage.startInstruction()
+
 			switch dbg.quantum {
 			case QuantumCPU:
 				err = dbg.vcs.Step(vcsStep)
@@ -266,6 +269,8 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 						return errors.New(errors.DebuggerError, err)
 					}
 				}
+
+				dbg.coverage.endInstruction()
 			}
 
 			if dbg.commandOnStep != nil {
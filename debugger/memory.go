@@ -123,7 +123,11 @@ func (dbgmem memoryDebug) mapAddress(address interface{}, read bool) *addressInf
 		}
 
 		if !found {
-			// finally, this may be a string representation of a numerical address
+			// finally, this may be a string representation of a numerical
+			// address. addresses prefixed with $ are hexadecimal
+			if strings.HasPrefix(address, "$") {
+				address = fmt.Sprintf("0x%s", address[1:])
+			}
 			addr, err = strconv.ParseUint(address, 0, 16)
 			if err != nil {
 				return nil
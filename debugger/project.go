@@ -0,0 +1,233 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/paths"
+	"gopher2600/symbols"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// project files are stored in this sub-directory of the resource path. the
+// filename is the hash of the cartridge
+const projectPath = "projects"
+
+// the first line of every project file
+const projectHeader = "gopher2600 project"
+
+// annotation is a symbol or comment added by the user for an address
+type annotation struct {
+	bank    int
+	address uint16
+	text    string
+}
+
+// project records the symbols and comments added by the user with the SYMBOL
+// ADD and COMMENT commands. the project is saved whenever it changes and is
+// reloaded when the cartridge is next inserted
+type project struct {
+	symbols  []annotation
+	comments []annotation
+}
+
+// setAnnotation adds the annotation to the list, replacing any existing annotation for
+// the same bank and address. an empty annotation is removed from the list
+func setAnnotation(list []annotation, a annotation) []annotation {
+	for i := range list {
+		if list[i].bank == a.bank && list[i].address == a.address {
+			if a.text == "" {
+				return append(list[:i], list[i+1:]...)
+			}
+			list[i] = a
+			return list
+		}
+	}
+
+	if a.text == "" {
+		return list
+	}
+
+	return append(list, a)
+}
+
+// apply the annotations in the project to the symbols table
+func (prj *project) apply(symtable *symbols.Table) {
+	for _, a := range prj.symbols {
+		symtable.AddSymbol(a.bank, a.address, a.text)
+	}
+	for _, a := range prj.comments {
+		symtable.AddComment(a.bank, a.address, a.text)
+	}
+}
+
+func (prj *project) isEmpty() bool {
+	return len(prj.symbols) == 0 && len(prj.comments) == 0
+}
+
+func (prj *project) write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, projectHeader)
+	for _, a := range prj.symbols {
+		fmt.Fprintf(b, "symbol,%d,%04x,%s\n", a.bank, a.address, a.text)
+	}
+	for _, a := range prj.comments {
+		fmt.Fprintf(b, "comment,%d,%04x,%s\n", a.bank, a.address, a.text)
+	}
+	return b.Flush()
+}
+
+func readProject(r io.Reader) (*project, error) {
+	prj := &project{}
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != projectHeader {
+		return nil, errors.New(errors.ProjectError, "not a project file")
+	}
+
+	lineNum := 1
+	for scanner.Scan() {
+		lineNum++
+
+		// the annotation text can contain commas so we limit the number of
+		// fields
+		fields := strings.SplitN(scanner.Text(), ",", 4)
+		if len(fields) != 4 {
+			return nil, errors.New(errors.ProjectError, fmt.Sprintf("line %d: wrong number of fields", lineNum))
+		}
+
+		bank, err := strconv.Atoi(fields[1])
+		if err != nil || bank < symbols.AnyBank {
+			return nil, errors.New(errors.ProjectError, fmt.Sprintf("line %d: invalid bank", lineNum))
+		}
+
+		address, err := strconv.ParseUint(fields[2], 16, 16)
+		if err != nil {
+			return nil, errors.New(errors.ProjectError, fmt.Sprintf("line %d: invalid address", lineNum))
+		}
+
+		a := annotation{bank: bank, address: uint16(address), text: fields[3]}
+
+		switch fields[0] {
+		case "symbol":
+			prj.symbols = setAnnotation(prj.symbols, a)
+		case "comment":
+			prj.comments = setAnnotation(prj.comments, a)
+		default:
+			return nil, errors.New(errors.ProjectError, fmt.Sprintf("line %d: unrecognised annotation (%s)", lineNum, fields[0]))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(errors.ProjectError, err)
+	}
+
+	return prj, nil
+}
+
+// projectFile returns the name of the project file for the current
+// cartridge. returns the empty string if there is no cartridge
+func (dbg *Debugger) projectFile() (string, error) {
+	if dbg.vcs.Mem.Cart.Hash == "" || dbg.vcs.Mem.Cart.IsEjected() {
+		return "", nil
+	}
+	return paths.ResourcePath(projectPath, dbg.vcs.Mem.Cart.Hash)
+}
+
+// loadProject reads the project file for the current cartridge, if it
+// exists, and applies it to the symbols table. the project is reset even if
+// there is an error
+func (dbg *Debugger) loadProject(symtable *symbols.Table) error {
+	dbg.project = &project{}
+
+	pth, err := dbg.projectFile()
+	if err != nil {
+		return errors.New(errors.ProjectError, err)
+	}
+	if pth == "" {
+		return nil
+	}
+
+	f, err := os.Open(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.New(errors.ProjectError, err)
+	}
+	defer f.Close()
+
+	prj, err := readProject(f)
+	if err != nil {
+		return err
+	}
+
+	dbg.project = prj
+	dbg.project.apply(symtable)
+
+	return nil
+}
+
+// saveProject writes the project for the current cartridge to the project
+// file. the project file is removed if the project is empty
+func (dbg *Debugger) saveProject() error {
+	pth, err := dbg.projectFile()
+	if err != nil {
+		return errors.New(errors.ProjectError, err)
+	}
+	if pth == "" {
+		return nil
+	}
+
+	if dbg.project.isEmpty() {
+		if err := os.Remove(pth); err != nil && !os.IsNotExist(err) {
+			return errors.New(errors.ProjectError, err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(pth)
+	if err != nil {
+		return errors.New(errors.ProjectError, err)
+	}
+	defer f.Close()
+
+	if err := dbg.project.write(f); err != nil {
+		return errors.New(errors.ProjectError, err)
+	}
+
+	return nil
+}
+
+// annotationBank returns the bank that an annotation for the address should
+// be qualified with. annotations are only qualified for cartridge addresses
+// in cartridges with more than one bank
+func (dbg *Debugger) annotationBank(ai *addressInfo) int {
+	if ai.area != memorymap.Cartridge || dbg.vcs.Mem.Cart.NumBanks() == 1 {
+		return symbols.AnyBank
+	}
+	return dbg.vcs.Mem.Cart.GetBank(ai.address)
+}
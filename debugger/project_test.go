@@ -0,0 +1,152 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/debugger"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func (trm *mockTerm) testProject() {
+	trm.sndInput("SYMBOL ADD 0xf012 kernelStart")
+	trm.waitOutput("kernelStart -> 0xf012")
+
+	trm.sndInput("SYMBOL kernelStart")
+	trm.waitOutput("kernelStart (location) -> 0xf012")
+
+	trm.sndInput("COMMENT $f012 \"draws the score\"")
+	trm.waitOutput("0xf012 ; draws the score")
+
+	// PC breakpoints are annotated with the symbol and comment. the mirror of
+	// the address is found
+	trm.sndInput("BREAK PC 0xf012")
+	trm.cmpOutput("")
+	trm.sndInput("LIST BREAKS")
+	trm.waitOutput(" 3: PC->0x1012 (kernelStart ; draws the score)")
+
+	// comment can be removed
+	trm.sndInput("COMMENT 0xf012")
+	trm.waitOutput("comment removed from 0xf012")
+	trm.sndInput("LIST BREAKS")
+	trm.waitOutput(" 3: PC->0x1012 (kernelStart)")
+
+	// comments can only be added to cartridge addresses
+	trm.sndInput("COMMENT 0x80 ram")
+	trm.waitOutput("0x80 is not a cartridge address")
+}
+
+// change to a temporary directory so that project files are written to a
+// resource path that is removed at the end of the test. returns the temporary
+// directory and a function that restores the working directory
+func chdir(t *testing.T) (string, func()) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "project_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+
+	return dir, func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func (trm *mockTerm) testProjectPersistence(rom string, other string) {
+	defer func() { trm.sndInput("QUIT") }()
+
+	trm.sndInput("SYMBOL ADD 0xf012 kernelStart")
+	trm.waitOutput("kernelStart -> 0xf012")
+	trm.sndInput("COMMENT $f012 \"draws the score\"")
+	trm.waitOutput("0xf012 ; draws the score")
+
+	// the project is saved to a file named after the cartridge hash
+	files, err := ioutil.ReadDir(filepath.Join(".gopher2600", "projects"))
+	if err != nil || len(files) != 1 {
+		trm.t.Errorf("expected one project file")
+	}
+
+	// the project belongs to the cartridge and is not seen by a different
+	// cartridge
+	trm.sndInput("INSERT " + other)
+	trm.rcvOutput()
+	trm.sndInput("SYMBOL kernelStart")
+	trm.waitOutput("kernelStart -> not found")
+
+	// inserting the original cartridge restores the symbols and comments
+	trm.sndInput("INSERT " + rom)
+	trm.rcvOutput()
+	trm.sndInput("SYMBOL kernelStart")
+	trm.waitOutput("kernelStart (location) -> 0xf012")
+	trm.sndInput("BREAK PC 0xf012")
+	trm.cmpOutput("")
+	trm.sndInput("LIST BREAKS")
+	trm.waitOutput(" 0: PC->0x1012 (kernelStart ; draws the score)")
+
+	// removal of a comment is also saved
+	trm.sndInput("COMMENT 0xf012")
+	trm.waitOutput("comment removed from 0xf012")
+	trm.sndInput("INSERT " + rom)
+	trm.rcvOutput()
+	trm.sndInput("LIST BREAKS")
+	trm.waitOutput(" 0: PC->0x1012 (kernelStart)")
+}
+
+func TestDebugger_projectPersistence(t *testing.T) {
+	dir, restore := chdir(t)
+	defer restore()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	rom := test.WriteROM(t, dir, "project.bin", data)
+
+	// the other cartridge differs only by one byte
+	data[len(test.Kernel)] = 0xea
+	other := test.WriteROM(t, dir, "other.bin", data)
+
+	trm := newMockTerm(t)
+
+	dbg, err := debugger.NewDebugger(&mockTV{}, &mockGUI{}, trm)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go trm.testProjectPersistence(rom, other)
+
+	err = dbg.Start("", cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"gopher2600/debugger/terminal"
+)
+
+// listRAMSearch prints the remaining candidates of the RAM search, along with
+// any frozen addresses
+func (dbg *Debugger) listRAMSearch() {
+	candidates := dbg.ramSearch.Candidates()
+	if candidates == nil {
+		dbg.printLine(terminal.StyleFeedback, "no search in progress")
+	} else {
+		dbg.printLine(terminal.StyleFeedback, "%d candidates", len(candidates))
+		for _, c := range candidates {
+			dbg.printLine(terminal.StyleInstrument, "%s", c)
+		}
+	}
+
+	frozen := dbg.ramSearch.Frozen()
+	if len(frozen) > 0 {
+		dbg.printLine(terminal.StyleFeedback, "%d frozen", len(frozen))
+		for _, f := range frozen {
+			dbg.printLine(terminal.StyleInstrument, "%s", f)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/debugger"
+	"gopher2600/test"
+	"testing"
+)
+
+func (trm *mockTerm) testRAMSearch() {
+	trm.sndInput("SEARCH")
+	trm.cmpOutput("no search in progress")
+
+	trm.sndInput("SEARCH START")
+	trm.cmpOutput("128 candidates")
+
+	// emulation is halted so only the poked address will have changed
+	trm.sndInput("POKE 0x80 5")
+	trm.rcvOutput()
+	trm.sndInput("SEARCH CHANGED")
+	trm.cmpOutput("1 candidates")
+	trm.sndInput("SEARCH EQUAL 6")
+	trm.cmpOutput("0 candidates")
+
+	// freezing without a value uses the current value
+	trm.sndInput("SEARCH FREEZE 0x80")
+	trm.cmpOutput("0x0080 frozen at 0x05")
+	trm.sndInput("SEARCH")
+	trm.cmpOutput("0x0080 = 0x05")
+
+	// only RAM can be frozen
+	trm.sndInput("SEARCH FREEZE 0xf000 1")
+	trm.cmpOutput("ram search: 0x1000 is not a RAM address")
+
+	trm.sndInput("SEARCH UNFREEZE 0x80")
+	trm.cmpOutput("0x0080 unfrozen")
+	trm.sndInput("SEARCH UNFREEZE 0x80")
+	trm.cmpOutput("0x80 is not frozen")
+
+	trm.sndInput("SEARCH CLEAR")
+	trm.cmpOutput("search cleared")
+}
+
+func (trm *mockTerm) testRAMSearchInsert(other string) {
+	defer func() { trm.sndInput("QUIT") }()
+
+	trm.sndInput("SEARCH START")
+	trm.waitOutput("128 candidates")
+	trm.sndInput("SEARCH FREEZE 0x80 0x42")
+	trm.waitOutput("0x0080 frozen at 0x42")
+
+	// the search and the frozen addresses do not survive the insertion of a
+	// different cartridge
+	trm.sndInput("INSERT " + other)
+	trm.rcvOutput()
+	trm.sndInput("SEARCH")
+	trm.waitOutput("no search in progress")
+	trm.sndInput("SEARCH UNFREEZE 0x80")
+	trm.waitOutput("0x80 is not frozen")
+}
+
+func TestDebugger_ramSearchInsert(t *testing.T) {
+	dir, restore := chdir(t)
+	defer restore()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	rom := test.WriteROM(t, dir, "ramsearch.bin", data)
+	data[len(test.Kernel)] = 0xea
+	other := test.WriteROM(t, dir, "other.bin", data)
+
+	trm := newMockTerm(t)
+
+	dbg, err := debugger.NewDebugger(&mockTV{}, &mockGUI{}, trm)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go trm.testRAMSearchInsert(other)
+
+	err = dbg.Start("", cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
@@ -21,6 +21,7 @@ package debugger
 
 import (
 	"gopher2600/disassembly"
+	"gopher2600/ramsearch"
 )
 
 // GetQuantum returns the current quantum value
@@ -45,3 +46,9 @@ func (dbg *Debugger) TogglePCBreak(e *disassembly.Entry) {
 func (dbg *Debugger) IsRunning() bool {
 	return dbg.running
 }
+
+// GetRAMSearch returns the RAM search instance. It should only be used in the
+// emulation goroutine (see PushRawEvent())
+func (dbg *Debugger) GetRAMSearch() *ramsearch.Search {
+	return dbg.ramSearch
+}
@@ -132,3 +132,29 @@ func (dig *Video) SetAltPixel(x, y int, red, green, blue byte, vblank bool) erro
 func (dig *Video) EndRendering() error {
 	return nil
 }
+
+// the state of the video digest as returned by SaveState()
+type videoState struct {
+	digest   [sha1.Size]byte
+	pixels   []byte
+	frameNum int
+}
+
+// SaveState notes and returns the current state of the digest. Pixels that
+// are not drawn in a frame are carried over from the previous frame so the
+// pixels are part of the state, as well as the digest value.
+func (dig *Video) SaveState() interface{} {
+	return videoState{
+		digest:   dig.digest,
+		pixels:   append([]byte{}, dig.pixels...),
+		frameNum: dig.frameNum,
+	}
+}
+
+// RestoreState returns the digest to a previously known state
+func (dig *Video) RestoreState(state interface{}) {
+	s := state.(videoState)
+	dig.digest = s.digest
+	copy(dig.pixels, s.pixels)
+	dig.frameNum = s.frameNum
+}
@@ -23,8 +23,12 @@ import (
 	"fmt"
 	"gopher2600/errors"
 	"gopher2600/hardware/cpu"
+	"gopher2600/hardware/cpu/execution"
 	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/cartridge"
 	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/hardware/riot/input"
+	"sort"
 	"strings"
 )
 
@@ -34,6 +38,21 @@ type Analysis struct {
 	ExecuteFromRAM bool
 	Interrupts     bool
 	ForcedRTS      bool
+
+	// the controller types the cartridge seems to expect, indexed by port
+	Controllers [2]input.ControllerType
+
+	// the number of instructions found by the flow pass that access a
+	// cartridge hotspot, and the number of those that cause execution to
+	// continue in a different bank
+	BankSwitchSites      int
+	CrossBankSwitchSites int
+
+	// the number of instructions reached by switching banks
+	CrossBankEntries int
+
+	// the number of bank switch sites for each hotspot address
+	Hotspots map[uint16]int
 }
 
 // Analysis returns a summary of anything interesting found during disassembly.
@@ -42,17 +61,71 @@ func (ana Analysis) String() string {
 	s.WriteString(fmt.Sprintf("Execute from RAM: %v\n", ana.ExecuteFromRAM))
 	s.WriteString(fmt.Sprintf("Interrupts: %v\n", ana.Interrupts))
 	s.WriteString(fmt.Sprintf("Forced RTS: %v\n", ana.ForcedRTS))
+	s.WriteString(fmt.Sprintf("Controllers: %v, %v\n", ana.Controllers[0], ana.Controllers[1]))
+	s.WriteString(fmt.Sprintf("Bank switch sites: %d (%d cross-bank)\n", ana.BankSwitchSites, ana.CrossBankSwitchSites))
+	s.WriteString(fmt.Sprintf("Cross-bank entries: %d\n", ana.CrossBankEntries))
+
+	if len(ana.Hotspots) > 0 {
+		hs := make([]uint16, 0, len(ana.Hotspots))
+		for a := range ana.Hotspots {
+			hs = append(hs, a)
+		}
+		sort.Slice(hs, func(i, j int) bool { return hs[i] < hs[j] })
+
+		s.WriteString("Hotspots:")
+		for _, a := range hs {
+			s.WriteString(fmt.Sprintf(" %#04x (%d)", a, ana.Hotspots[a]))
+		}
+		s.WriteString("\n")
+	}
+
 	return s.String()
 }
 
+// summarise the bank switching found by the flow pass
+func (dsm *Disassembly) bankSwitchAnalysis() {
+	dsm.Analysis.BankSwitchSites = 0
+	dsm.Analysis.CrossBankSwitchSites = 0
+	dsm.Analysis.CrossBankEntries = 0
+	dsm.Analysis.Hotspots = make(map[uint16]int)
+
+	for b := range dsm.Entries {
+		for _, e := range dsm.Entries[b] {
+			if e == nil || e.Type < EntryTypeAnalysis {
+				continue // for loop
+			}
+
+			if e.CrossBank {
+				dsm.Analysis.CrossBankEntries++
+			}
+
+			if len(e.Hotspots) == 0 {
+				continue // for loop
+			}
+
+			dsm.Analysis.BankSwitchSites++
+			if len(e.NextBank) > 0 {
+				dsm.Analysis.CrossBankSwitchSites++
+			}
+			for _, a := range e.Hotspots {
+				dsm.Analysis.Hotspots[a]++
+			}
+		}
+	}
+}
+
 func (dsm *Disassembly) flowAnalysis(mc *cpu.CPU, flowedFrom uint16, subroutineDepth int) error {
+	// the next instruction was reached by switching banks
+	crossBank := false
+
 	for {
 		// get bank now before executing instruction. the bank may change
 		// as a result of the instruction execution. we cannot stop this withe
 		// the CPU's NoFlow mechanism
 		bank := dsm.cart.GetBank(mc.PC.Address())
 
-		// exeute the instruction
+		// exeute the instruction, noting any hotspots that are accessed
+		dsm.mem.clearHotspots()
 		err := mc.ExecuteInstruction(nil)
 
 		// filter out the predictable errors
@@ -96,12 +169,16 @@ func (dsm *Disassembly) flowAnalysis(mc *cpu.CPU, flowedFrom uint16, subroutineD
 		// if we've seen this before but it was not from then flow pass then
 		// finish the disassembly and flowedFrom is zero
 		d := dsm.Entries[bank][mc.LastResult.Address&memorymap.AddressMaskCart]
-		if d != nil && d.Type == EntryTypeAnalysis {
+		if d != nil && d.Type >= EntryTypeAnalysis {
+			if crossBank {
+				d.CrossBank = true
+			}
 			return nil
 		}
 
-		// create new disassembly entry
-		d, err = dsm.FormatResult(mc.LastResult)
+		// create new disassembly entry. we can't use FormatResult() because
+		// the instruction may have changed the bank
+		d, err = newEntry(mc.LastResult, bank, dsm.Symtable)
 		if err != nil {
 			return err
 		}
@@ -119,6 +196,9 @@ func (dsm *Disassembly) flowAnalysis(mc *cpu.CPU, flowedFrom uint16, subroutineD
 			flowedFrom = 0
 		}
 
+		d.CrossBank = crossBank
+		crossBank = false
+
 		// updated. we need to do it before any jumping/branching because the
 		// information needs to be there for the iterated function (loop
 		// detection)
@@ -130,6 +210,29 @@ func (dsm *Disassembly) flowAnalysis(mc *cpu.CPU, flowedFrom uint16, subroutineD
 		dsm.Entries[bank][mc.LastResult.Address&memorymap.AddressMaskCart] = d
 		e := &d
 
+		// note the hotspots accessed by the instruction. any bank switching is
+		// followed once the instruction has otherwise been dealt with.
+		//
+		// hotspots accessed by the reading of the instruction itself are
+		// ignored. the flow pass continues through data after a JMP and we
+		// don't want to follow bank switches caused by data that happens to
+		// sit at a hotspot address. note that single byte instructions still
+		// read the byte after the opcode
+		instructionBytes := uint16(mc.LastResult.ByteCount)
+		if instructionBytes < 2 {
+			instructionBytes = 2
+		}
+		hotspots := []cartridge.Hotspot{}
+		for _, h := range dsm.mem.hotspots {
+			if h.Address-(memorymap.OriginCart|(mc.LastResult.Address&memorymap.AddressMaskCart)) < instructionBytes {
+				continue // for loop
+			}
+			hotspots = append(hotspots, h)
+			if !containsAddress(d.Hotspots, h.Address) {
+				d.Hotspots = append(d.Hotspots, h.Address)
+			}
+		}
+
 		// we've disabled flow-control in the cpu but we still need to pay
 		// attention to what's going on or we won't get to see all the areas of
 		// the ROM.
@@ -251,5 +354,134 @@ func (dsm *Disassembly) flowAnalysis(mc *cpu.CPU, flowedFrom uint16, subroutineD
 			// do nothing with interrupts
 			dsm.Analysis.Interrupts = true
 		}
+
+		// follow bank switching caused by the instruction
+		if len(hotspots) > 0 {
+			crossBank, err = dsm.followHotspots(mc, d, bank, hotspots, subroutineDepth)
+			if err != nil {
+				return err
+			}
+			if crossBank {
+				flowedFrom = d.Result.Address
+			}
+		}
 	}
 }
+
+func containsAddress(l []uint16, a uint16) bool {
+	for _, v := range l {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBank(l []int, b int) bool {
+	for _, v := range l {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
+
+// followHotspots follows execution into the banks that may have been selected
+// by the hotspots accessed by the instruction. execution continues in the
+// bank selected by the hotspot that was actually accessed but the flow pass
+// CPU cannot know the value of the index registers, so an indexed access
+// might have selected any hotspot in range of the index. the other
+// possibilities are followed in the same way as branches.
+//
+// the bank selected by tigervision style hotspots depends on the value
+// written. the value written by the flow pass CPU is assumed to be correct.
+//
+// returns true if the next instruction is in a different bank to the
+// instruction that accessed the hotspot.
+func (dsm *Disassembly) followHotspots(mc *cpu.CPU, d *Entry, bank int, hotspots []cartridge.Hotspot, subroutineDepth int) (bool, error) {
+	retPC := mc.PC.Address()
+
+	// banks in which execution continues
+	next := []int{dsm.cart.GetBank(retPC)}
+
+	for _, h := range dsm.alternativeHotspots(d.Result, hotspots) {
+		state := dsm.cart.SaveState()
+
+		if err := dsm.cart.SetBank(h.Segment, h.Bank); err != nil {
+			dsm.cart.RestoreState(state)
+			continue // for loop
+		}
+
+		nb := dsm.cart.GetBank(retPC)
+		if containsBank(next, nb) {
+			dsm.cart.RestoreState(state)
+			continue // for loop
+		}
+		next = append(next, nb)
+
+		err := dsm.flowAnalysis(mc, d.Result.Address, subroutineDepth)
+		if err != nil {
+			return false, err
+		}
+
+		// mark the first instruction in the alternative bank
+		if nb != bank {
+			if e := dsm.Entries[nb][retPC&memorymap.AddressMaskCart]; e != nil && e.Type >= EntryTypeAnalysis {
+				e.CrossBank = true
+			}
+		}
+
+		// resume from where we left off
+		dsm.cart.RestoreState(state)
+		mc.PC.Load(retPC)
+	}
+
+	crossBank := next[0] != bank
+
+	for _, b := range next {
+		if b != bank {
+			d.NextBank = next
+			sort.Ints(d.NextBank)
+			break // for loop
+		}
+	}
+
+	return crossBank, nil
+}
+
+// alternativeHotspots returns the ROM hotspots that might have been accessed
+// by an indexed instruction, other than the ones that were actually accessed
+func (dsm *Disassembly) alternativeHotspots(result execution.Result, accessed []cartridge.Hotspot) []cartridge.Hotspot {
+	if result.Defn == nil {
+		return nil
+	}
+
+	switch result.Defn.AddressingMode {
+	case instructions.AbsoluteIndexedX, instructions.AbsoluteIndexedY:
+	default:
+		return nil
+	}
+
+	write := result.Defn.Effect == instructions.Write
+
+	alts := []cartridge.Hotspot{}
+	for i := uint16(0); i <= 0xff; i++ {
+		h, ok := dsm.cart.Hotspot(result.InstructionData+i, write)
+		if !ok || h.RAM || h.Bank == cartridge.HotspotData {
+			continue // for loop
+		}
+
+		actual := false
+		for _, a := range accessed {
+			if a.Address == h.Address {
+				actual = true
+				break // for loop
+			}
+		}
+		if !actual {
+			alts = append(alts, h)
+		}
+	}
+
+	return alts
+}
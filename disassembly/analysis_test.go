@@ -0,0 +1,86 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"gopher2600/disassembly"
+	"gopher2600/test"
+	"testing"
+)
+
+func TestBankSwitchAnalysis(t *testing.T) {
+	// 16k cartridge that selects a bank with an indexed read of the
+	// hotspots. the code after the bank switch is at the same address in
+	// every bank
+	data := test.NewROM(16384)
+	copy(data, []byte{
+		0xa2, 0x01, // LDX #$01
+		0xbd, 0xf6, 0xff, // LDA $FFF6,X
+	})
+	for b := 0; b < 4; b++ {
+		copy(data[b*4096+5:], []byte{
+			0x4c, 0x05, 0xf0, // JMP $F005
+		})
+	}
+
+	dsm := disassemble(t, data, "F6")
+
+	e, _ := dsm.Get(0, 0xf002)
+	if len(e.Hotspots) != 1 || e.Hotspots[0] != 0x1ff7 {
+		t.Errorf("unexpected hotspots for bank switching instruction: %v", e.Hotspots)
+	}
+	if len(e.NextBank) != 4 {
+		t.Errorf("unexpected banks after bank switching instruction: %v", e.NextBank)
+	}
+
+	// the code after the bank switch has been found in every bank
+	for b := 0; b < 4; b++ {
+		e, ok := dsm.Get(b, 0xf005)
+		if !ok || e.Type < disassembly.EntryTypeAnalysis {
+			t.Fatalf("expected flow pass to reach 0xf005 in bank %d", b)
+		}
+		if e.CrossBank != (b != 0) {
+			t.Errorf("unexpected cross-bank entry at 0xf005 in bank %d", b)
+		}
+	}
+
+	ana := dsm.Analysis
+	if ana.BankSwitchSites != 1 || ana.CrossBankSwitchSites != 1 || ana.CrossBankEntries != 3 {
+		t.Errorf("unexpected bank switching analysis: %s", ana)
+	}
+	if len(ana.Hotspots) != 1 || ana.Hotspots[0x1ff7] != 1 {
+		t.Errorf("unexpected hotspots: %v", ana.Hotspots)
+	}
+
+	// the call graph follows the bank switch to every bank
+	cg := dsm.CallGraph()
+	sub, ok := cg.Subroutine(disassembly.CodeLocation{Bank: 0, Address: 0xf000})
+	if !ok {
+		t.Fatalf("expected subroutine at reset address")
+	}
+	if len(sub.Calls) != 3 {
+		t.Errorf("unexpected calls from reset subroutine: %v", sub.Calls)
+	}
+	for _, c := range sub.Calls {
+		if c.Type != disassembly.EdgeTrampoline {
+			t.Errorf("unexpected call type from reset subroutine: %v", c.Type)
+		}
+	}
+}
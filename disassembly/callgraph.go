@@ -0,0 +1,568 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CodeLocation identifies an instruction by cartridge bank and address
+type CodeLocation struct {
+	Bank    int    `json:"bank"`
+	Address uint16 `json:"address"`
+}
+
+func (l CodeLocation) String() string {
+	return fmt.Sprintf("%#04x [bank %d]", l.Address, l.Bank)
+}
+
+// node name of the location for DOT output
+func (l CodeLocation) node() string {
+	return fmt.Sprintf("b%d_%04x", l.Bank, l.Address&memorymap.AddressMaskCart)
+}
+
+// the key used to index the disassembly
+func (l CodeLocation) key() CodeLocation {
+	return CodeLocation{Bank: l.Bank, Address: l.Address & memorymap.AddressMaskCart}
+}
+
+func (l CodeLocation) less(m CodeLocation) bool {
+	if l.Bank != m.Bank {
+		return l.Bank < m.Bank
+	}
+	return l.Address&memorymap.AddressMaskCart < m.Address&memorymap.AddressMaskCart
+}
+
+// EdgeType describes how control passes from one location to another
+type EdgeType int
+
+// List of valid EdgeTypes. Trampoline edges are where execution continues in
+// a different bank after a bank switch.
+const (
+	EdgeFallthrough EdgeType = iota
+	EdgeBranch
+	EdgeJump
+	EdgeCall
+	EdgeTrampoline
+)
+
+func (t EdgeType) String() string {
+	switch t {
+	case EdgeFallthrough:
+		return "fallthrough"
+	case EdgeBranch:
+		return "branch"
+	case EdgeJump:
+		return "jump"
+	case EdgeCall:
+		return "call"
+	case EdgeTrampoline:
+		return "trampoline"
+	}
+	return ""
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (t EdgeType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Edge is a directed edge in the call graph or in a control-flow graph
+type Edge struct {
+	From CodeLocation `json:"from"`
+	To   CodeLocation `json:"to"`
+	Type EdgeType     `json:"type"`
+}
+
+// BasicBlock is a sequence of instructions with a single entry and a single
+// exit
+type BasicBlock struct {
+	Start CodeLocation `json:"start"`
+
+	// address of the last instruction in the block
+	End uint16 `json:"end"`
+
+	// the block ends with RTS or RTI (or BRK)
+	Return bool `json:"return"`
+
+	// the disassembled instructions in the block
+	Code []string `json:"code"`
+}
+
+// Subroutine is the control-flow graph of a JSR target (or the reset
+// address).
+type Subroutine struct {
+	Entry CodeLocation `json:"entry"`
+	Label string       `json:"label,omitempty"`
+
+	Blocks []*BasicBlock `json:"blocks"`
+
+	// edges between the blocks in the subroutine
+	Edges []Edge `json:"edges"`
+
+	// edges from instructions in the subroutine to other subroutines. either
+	// a JSR or a trampoline to another bank
+	Calls []Edge `json:"calls"`
+}
+
+// CallGraph is the graph of subroutines found by the flow pass. Subroutines
+// are identified by bank and address, so a JSR to an address that exists in
+// more than one bank is a call to each subroutine that the flow pass found.
+//
+// Execution continuing in a different bank, in the manner of a bank-switching
+// trampoline, is treated as a call to a new subroutine at the destination.
+type CallGraph struct {
+	// subroutines not called from anywhere else. the reset address
+	Roots []CodeLocation `json:"roots"`
+
+	// sorted by bank and address
+	Subroutines []*Subroutine `json:"subroutines"`
+}
+
+// Subroutine returns the subroutine with the entry at the location
+func (cg *CallGraph) Subroutine(loc CodeLocation) (*Subroutine, bool) {
+	loc = loc.key()
+	for _, sub := range cg.Subroutines {
+		if sub.Entry.key() == loc {
+			return sub, true
+		}
+	}
+	return nil, false
+}
+
+// successor of an instruction in a control-flow graph
+type successor struct {
+	loc CodeLocation
+	typ EdgeType
+}
+
+// flowBanks returns the banks in which execution continues at the address,
+// having arrived from an instruction in the specified bank. Execution
+// continues in the same bank if the flow pass found an instruction there,
+// otherwise every bank in which the flow pass found an instruction at the
+// address is returned.
+//
+// Bank switches noted by the flow pass (Entry.NextBank) are handled by
+// successors().
+func (dsm *Disassembly) flowBanks(bank int, address uint16) []int {
+	if _, area := memorymap.MapAddress(address, true); area != memorymap.Cartridge {
+		return nil
+	}
+
+	address &= memorymap.AddressMaskCart
+
+	if e := dsm.Entries[bank][address]; e != nil && e.Type >= EntryTypeAnalysis {
+		return []int{bank}
+	}
+
+	banks := []int{}
+	for b := range dsm.Entries {
+		if e := dsm.Entries[b][address]; e != nil && e.Type >= EntryTypeAnalysis {
+			banks = append(banks, b)
+		}
+	}
+
+	return banks
+}
+
+// successors returns the locations that execution can flow to from the
+// instruction at the location. JSR instructions are followed by the
+// instruction after the JSR and the subroutine is returned separately.
+func (dsm *Disassembly) successors(loc CodeLocation, e *Entry) ([]successor, []successor) {
+	defn := e.Result.Defn
+	if defn == nil {
+		return nil, nil
+	}
+
+	var succ []successor
+	var calls []successor
+
+	add := func(l *[]successor, address uint16, typ EdgeType) {
+		for _, b := range dsm.flowBanks(loc.Bank, address) {
+			t := typ
+			if b != loc.Bank {
+				t = EdgeTrampoline
+			}
+			*l = append(*l, successor{loc: CodeLocation{Bank: b, Address: address}, typ: t})
+		}
+	}
+
+	next := e.Result.Address + uint16(defn.Bytes)
+
+	// the flow pass noted the banks in which execution continues after a
+	// bank switch
+	if len(e.NextBank) > 0 && defn.Effect != instructions.Flow && defn.Effect != instructions.Subroutine {
+		for _, b := range e.NextBank {
+			n := dsm.Entries[b][next&memorymap.AddressMaskCart]
+			if n == nil || n.Type < EntryTypeAnalysis {
+				continue // for loop
+			}
+			t := EdgeFallthrough
+			if b != loc.Bank {
+				t = EdgeTrampoline
+			}
+			succ = append(succ, successor{loc: CodeLocation{Bank: b, Address: next}, typ: t})
+		}
+		return succ, nil
+	}
+
+	switch defn.Effect {
+	case instructions.Flow:
+		if defn.IsBranch() {
+			_, target := staticXrefs(e)
+			add(&succ, target, EdgeBranch)
+			add(&succ, next, EdgeFallthrough)
+		} else if defn.AddressingMode == instructions.Indirect {
+			// the destination of an indirect jump was recorded by the flow
+			// pass
+			for _, n := range e.Next {
+				add(&succ, n, EdgeJump)
+			}
+		} else {
+			add(&succ, e.Result.InstructionData, EdgeJump)
+		}
+
+	case instructions.Subroutine:
+		if defn.Mnemonic == "JSR" {
+			add(&calls, e.Result.InstructionData, EdgeCall)
+			add(&succ, next, EdgeFallthrough)
+		}
+
+	case instructions.Interrupt:
+		// BRK and RTI end the flow
+
+	default:
+		add(&succ, next, EdgeFallthrough)
+	}
+
+	return succ, calls
+}
+
+// isReturn returns true if the instruction returns from a subroutine or
+// interrupt
+func isReturn(e *Entry) bool {
+	if e.Result.Defn == nil {
+		return false
+	}
+	switch e.Result.Defn.Effect {
+	case instructions.Subroutine:
+		return e.Result.Defn.Mnemonic == "RTS"
+	case instructions.Interrupt:
+		return true
+	}
+	return false
+}
+
+// CallGraph builds the call graph and the control-flow graph of every
+// subroutine, from the entries found by the flow pass.
+func (dsm *Disassembly) CallGraph() *CallGraph {
+	cg := &CallGraph{}
+
+	if dsm.cart == nil || dsm.cart.IsEjected() {
+		return cg
+	}
+
+	// subroutines waiting to be graphed
+	queue := []CodeLocation{dsm.entryPoint}
+	seen := map[CodeLocation]bool{dsm.entryPoint.key(): true}
+	called := map[CodeLocation]bool{}
+
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+
+		sub := dsm.subroutine(loc)
+		cg.Subroutines = append(cg.Subroutines, sub)
+
+		for _, c := range sub.Calls {
+			k := c.To.key()
+			called[k] = true
+			if !seen[k] {
+				seen[k] = true
+				queue = append(queue, c.To)
+			}
+		}
+	}
+
+	sort.Slice(cg.Subroutines, func(i, j int) bool {
+		return cg.Subroutines[i].Entry.less(cg.Subroutines[j].Entry)
+	})
+
+	for _, sub := range cg.Subroutines {
+		if !called[sub.Entry.key()] {
+			cg.Roots = append(cg.Roots, sub.Entry)
+		}
+	}
+
+	return cg
+}
+
+// subroutine builds the control-flow graph of the subroutine at the location
+func (dsm *Disassembly) subroutine(entry CodeLocation) *Subroutine {
+	sub := &Subroutine{Entry: entry}
+	if v, ok := dsm.Symtable.LocationSymbol(entry.Bank, entry.Address); ok {
+		sub.Label = v
+	}
+
+	// find every instruction reachable from the entry without leaving the
+	// bank or following a JSR
+	entries := map[CodeLocation]*Entry{}
+	succs := map[CodeLocation][]successor{}
+	preds := map[CodeLocation][]successor{}
+	calls := map[Edge]bool{}
+
+	queue := []CodeLocation{entry}
+	entries[entry.key()] = nil
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+
+		e := dsm.Entries[loc.Bank][loc.Address&memorymap.AddressMaskCart]
+		if e == nil {
+			delete(entries, loc.key())
+			continue // for loop
+		}
+		entries[loc.key()] = e
+
+		// use the address of the instruction as it was executed by the flow
+		// pass. the address may have been a mirror of loc.Address
+		loc.Address = e.Result.Address
+
+		s, c := dsm.successors(loc, e)
+		for _, n := range c {
+			calls[Edge{From: loc, To: n.loc, Type: n.typ}] = true
+		}
+
+		for _, n := range s {
+			if n.typ == EdgeTrampoline {
+				calls[Edge{From: loc, To: n.loc, Type: n.typ}] = true
+				continue // for loop
+			}
+
+			succs[loc.key()] = append(succs[loc.key()], n)
+			preds[n.loc.key()] = append(preds[n.loc.key()], successor{loc: loc, typ: n.typ})
+
+			if _, ok := entries[n.loc.key()]; !ok {
+				entries[n.loc.key()] = nil
+				queue = append(queue, n.loc)
+			}
+		}
+	}
+
+	for c := range calls {
+		sub.Calls = append(sub.Calls, c)
+	}
+	sortEdges(sub.Calls)
+
+	// an instruction starts a new block if it is the entry, if it is the
+	// destination of a branch or jump, or if it isn't the only instruction
+	// that follows its predecessor
+	leader := func(k CodeLocation) bool {
+		if k == entry.key() {
+			return true
+		}
+		p := preds[k]
+		if len(p) != 1 || p[0].typ != EdgeFallthrough {
+			return true
+		}
+		return len(succs[p[0].loc.key()]) != 1
+	}
+
+	blocks := map[CodeLocation]*BasicBlock{}
+	for k, e := range entries {
+		if !leader(k) {
+			continue // for loop
+		}
+
+		blk := &BasicBlock{Start: CodeLocation{Bank: k.Bank, Address: e.Result.Address}}
+		sub.Blocks = append(sub.Blocks, blk)
+		blocks[k] = blk
+
+		for {
+			blk.End = e.Result.Address
+			blk.Code = append(blk.Code, strings.TrimSpace(fmt.Sprintf("%s %s", e.Mnemonic, e.Operand)))
+
+			s := succs[k]
+			if len(s) != 1 || s[0].typ != EdgeFallthrough || leader(s[0].loc.key()) {
+				blk.Return = isReturn(e)
+				for _, n := range s {
+					sub.Edges = append(sub.Edges, Edge{From: blk.Start, To: n.loc, Type: n.typ})
+				}
+				break // for loop
+			}
+
+			k = s[0].loc.key()
+			e = entries[k]
+		}
+	}
+
+	// the destination of edges should be the start of the block rather than
+	// the (possibly mirrored) address in the instruction
+	for i := range sub.Edges {
+		if blk, ok := blocks[sub.Edges[i].To.key()]; ok {
+			sub.Edges[i].To = blk.Start
+		}
+	}
+
+	sort.Slice(sub.Blocks, func(i, j int) bool {
+		return sub.Blocks[i].Start.less(sub.Blocks[j].Start)
+	})
+	sortEdges(sub.Edges)
+
+	return sub
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From.less(edges[j].From)
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To.less(edges[j].To)
+		}
+		return edges[i].Type < edges[j].Type
+	})
+}
+
+// the DOT style of each edge type
+var dotEdgeStyle = map[EdgeType]string{
+	EdgeFallthrough: "",
+	EdgeBranch:      " [label=\"branch\"]",
+	EdgeJump:        " [label=\"jump\"]",
+	EdgeCall:        "",
+	EdgeTrampoline:  " [label=\"bank\" style=dashed color=red]",
+}
+
+// label of a subroutine for DOT output
+func (sub *Subroutine) dotLabel() string {
+	if sub.Label != "" {
+		return fmt.Sprintf("%s\\n%s", sub.Label, sub.Entry)
+	}
+	return sub.Entry.String()
+}
+
+// WriteDOT writes the call graph in the Graphviz DOT language
+func (cg *CallGraph) WriteDOT(w io.Writer) error {
+	s := &strings.Builder{}
+	s.WriteString("digraph callgraph {\n")
+	s.WriteString("\tnode [shape=box fontname=monospace]\n")
+
+	banks := map[int][]*Subroutine{}
+	order := []int{}
+	for _, sub := range cg.Subroutines {
+		if _, ok := banks[sub.Entry.Bank]; !ok {
+			order = append(order, sub.Entry.Bank)
+		}
+		banks[sub.Entry.Bank] = append(banks[sub.Entry.Bank], sub)
+	}
+
+	// group subroutines by bank
+	for _, b := range order {
+		s.WriteString(fmt.Sprintf("\tsubgraph cluster_bank%d {\n", b))
+		s.WriteString(fmt.Sprintf("\t\tlabel=\"bank %d\"\n", b))
+		for _, sub := range banks[b] {
+			s.WriteString(fmt.Sprintf("\t\t%s [label=\"%s\"]\n", sub.Entry.node(), sub.dotLabel()))
+		}
+		s.WriteString("\t}\n")
+	}
+
+	for _, sub := range cg.Subroutines {
+		done := map[CodeLocation]bool{}
+		for _, c := range sub.Calls {
+			if done[c.To.key()] {
+				continue // for loop
+			}
+			done[c.To.key()] = true
+			s.WriteString(fmt.Sprintf("\t%s -> %s%s\n", sub.Entry.node(), c.To.node(), dotEdgeStyle[c.Type]))
+		}
+	}
+
+	s.WriteString("}\n")
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// write subroutine nodes and edges for DOT output. node names are prefixed
+// so that the same block in different subroutines is a different node
+func (sub *Subroutine) writeDOT(s *strings.Builder, indent string) {
+	prefix := sub.Entry.node()
+	for _, blk := range sub.Blocks {
+		label := strings.Builder{}
+		label.WriteString(fmt.Sprintf("%s\\l", blk.Start))
+		for _, c := range blk.Code {
+			label.WriteString(fmt.Sprintf("  %s\\l", c))
+		}
+		shape := ""
+		if blk.Return {
+			shape = " peripheries=2"
+		}
+		s.WriteString(fmt.Sprintf("%s%s_%s [label=\"%s\"%s]\n", indent, prefix, blk.Start.node(), label.String(), shape))
+	}
+	for _, e := range sub.Edges {
+		s.WriteString(fmt.Sprintf("%s%s_%s -> %s_%s%s\n", indent, prefix, e.From.node(), prefix, e.To.node(), dotEdgeStyle[e.Type]))
+	}
+}
+
+// WriteDOT writes the control-flow graph of the subroutine in the Graphviz
+// DOT language
+func (sub *Subroutine) WriteDOT(w io.Writer) error {
+	s := &strings.Builder{}
+	s.WriteString(fmt.Sprintf("digraph %s {\n", sub.Entry.node()))
+	s.WriteString(fmt.Sprintf("\tlabel=\"%s\"\n", sub.dotLabel()))
+	s.WriteString("\tnode [shape=box fontname=monospace]\n")
+	sub.writeDOT(s, "\t")
+	s.WriteString("}\n")
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// WriteCFG writes the control-flow graph of every subroutine in the Graphviz
+// DOT language. Each subroutine is drawn as a separate cluster.
+func (cg *CallGraph) WriteCFG(w io.Writer) error {
+	s := &strings.Builder{}
+	s.WriteString("digraph cfg {\n")
+	s.WriteString("\tnode [shape=box fontname=monospace]\n")
+	for _, sub := range cg.Subroutines {
+		s.WriteString(fmt.Sprintf("\tsubgraph cluster_%s {\n", sub.Entry.node()))
+		s.WriteString(fmt.Sprintf("\t\tlabel=\"%s\"\n", sub.dotLabel()))
+		sub.writeDOT(s, "\t\t")
+		s.WriteString("\t}\n")
+	}
+	s.WriteString("}\n")
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// WriteJSON writes the call graph, including the control-flow graph of every
+// subroutine, as JSON
+func (cg *CallGraph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cg)
+}
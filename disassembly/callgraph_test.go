@@ -0,0 +1,177 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"encoding/json"
+	"gopher2600/disassembly"
+	"gopher2600/test"
+	"strings"
+	"testing"
+)
+
+func TestCallGraph(t *testing.T) {
+	// 8k cartridge with a trampoline from bank 0 into bank 1 and back again
+	data := test.NewROM(8192)
+	copy(data, []byte{
+		0x20, 0x10, 0xf0, // JSR $F010
+		0x4c, 0x00, 0xf0, // JMP $F000
+	})
+	copy(data[0x10:], []byte{
+		0xad, 0xf9, 0xff, // LDA $FFF9 (switch to bank 1)
+	})
+	data[0x19] = 0x60 // RTS
+	copy(data[0x1013:], []byte{
+		0x20, 0x20, 0xf0, // JSR $F020
+		0xad, 0xf8, 0xff, // LDA $FFF8 (switch to bank 0)
+	})
+	data[0x1020] = 0x60 // RTS
+
+	dsm := disassemble(t, data, "")
+
+	cg := dsm.CallGraph()
+
+	if len(cg.Roots) != 1 || cg.Roots[0] != (disassembly.CodeLocation{Bank: 0, Address: 0xf000}) {
+		t.Errorf("unexpected roots: %v", cg.Roots)
+	}
+
+	s := &strings.Builder{}
+	if err := cg.WriteDOT(s); err != nil {
+		t.Fatalf("cannot write call graph: %v", err)
+	}
+
+	expected := `digraph callgraph {
+	node [shape=box fontname=monospace]
+	subgraph cluster_bank0 {
+		label="bank 0"
+		b0_0000 [label="0xf000 [bank 0]"]
+		b0_0010 [label="0xf010 [bank 0]"]
+		b0_0019 [label="0xf019 [bank 0]"]
+	}
+	subgraph cluster_bank1 {
+		label="bank 1"
+		b1_0013 [label="0xf013 [bank 1]"]
+		b1_0020 [label="0xf020 [bank 1]"]
+	}
+	b0_0000 -> b0_0010
+	b0_0010 -> b1_0013 [label="bank" style=dashed color=red]
+	b1_0013 -> b1_0020
+	b1_0013 -> b0_0019 [label="bank" style=dashed color=red]
+}
+`
+	if s.String() != expected {
+		t.Errorf("unexpected call graph:\n%s", s.String())
+	}
+
+	// the subroutine in bank 1 is made up of a single block, the JSR does
+	// not end the block
+	sub, ok := cg.Subroutine(disassembly.CodeLocation{Bank: 1, Address: 0xf013})
+	if !ok {
+		t.Fatalf("expected subroutine at 0xf013 in bank 1")
+	}
+	if len(sub.Blocks) != 1 || len(sub.Blocks[0].Code) != 2 {
+		t.Errorf("unexpected blocks in subroutine: %v", sub.Blocks)
+	}
+
+	// the root subroutine loops back on itself
+	sub, _ = cg.Subroutine(disassembly.CodeLocation{Bank: 0, Address: 0xf000})
+	if len(sub.Edges) != 1 || sub.Edges[0].Type != disassembly.EdgeJump || sub.Edges[0].To != sub.Entry {
+		t.Errorf("unexpected edges in subroutine: %v", sub.Edges)
+	}
+
+	// JSON output
+	s.Reset()
+	if err := cg.WriteJSON(s); err != nil {
+		t.Fatalf("cannot write call graph: %v", err)
+	}
+
+	var v struct {
+		Subroutines []struct {
+			Calls []struct {
+				Type string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(s.String()), &v); err != nil {
+		t.Fatalf("cannot read JSON call graph: %v", err)
+	}
+	if len(v.Subroutines) != 5 || v.Subroutines[1].Calls[0].Type != "trampoline" {
+		t.Errorf("unexpected JSON call graph: %s", s.String())
+	}
+}
+
+func TestCallGraph_F8Trampoline(t *testing.T) {
+	// 8k cartridge laid out in the usual way for the F8 format. the same
+	// trampoline is at the same address in both banks: a write to a hotspot
+	// followed by a jump to the entry point in the other bank
+	trampoline := []byte{
+		0x8d, 0xf9, 0xff, // STA $FFF9 (switch to bank 1)
+		0x4c, 0x00, 0xf1, // JMP $F100
+		0x8d, 0xf8, 0xff, // STA $FFF8 (switch to bank 0)
+		0x4c, 0x00, 0xf2, // JMP $F200
+	}
+
+	data := test.NewROM(8192)
+	copy(data[0x0fe0:], trampoline)
+	copy(data[0x1fe0:], trampoline)
+	copy(data[0x0000:], []byte{0x4c, 0xe0, 0xff}) // JMP $FFE0
+	copy(data[0x0200:], []byte{0x4c, 0xe0, 0xff}) // JMP $FFE0
+	copy(data[0x1100:], []byte{0x4c, 0xe6, 0xff}) // JMP $FFE6
+
+	dsm := disassemble(t, data, "")
+
+	cg := dsm.CallGraph()
+
+	s := &strings.Builder{}
+	if err := cg.WriteDOT(s); err != nil {
+		t.Fatalf("cannot write call graph: %v", err)
+	}
+
+	// execution continues after each hotspot in the other bank
+	expected := `digraph callgraph {
+	node [shape=box fontname=monospace]
+	subgraph cluster_bank0 {
+		label="bank 0"
+		b0_0000 [label="0xf000 [bank 0]"]
+		b0_0fe9 [label="0xffe9 [bank 0]"]
+	}
+	subgraph cluster_bank1 {
+		label="bank 1"
+		b1_0fe3 [label="0xffe3 [bank 1]"]
+	}
+	b0_0000 -> b1_0fe3 [label="bank" style=dashed color=red]
+	b0_0fe9 -> b1_0fe3 [label="bank" style=dashed color=red]
+	b1_0fe3 -> b0_0fe9 [label="bank" style=dashed color=red]
+}
+`
+	if s.String() != expected {
+		t.Errorf("unexpected call graph:\n%s", s.String())
+	}
+
+	// the trampoline in bank 0 is part of the root subroutine. the jump to
+	// the trampoline does not leave the bank
+	sub, ok := cg.Subroutine(disassembly.CodeLocation{Bank: 0, Address: 0xf000})
+	if !ok {
+		t.Fatalf("expected subroutine at 0xf000 in bank 0")
+	}
+	if len(sub.Edges) != 1 || sub.Edges[0].Type != disassembly.EdgeJump || sub.Edges[0].To != (disassembly.CodeLocation{Bank: 0, Address: 0xffe0}) {
+		t.Errorf("unexpected edges in subroutine: %v", sub.Edges)
+	}
+}
@@ -0,0 +1,288 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/addresses"
+	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/hardware/riot/input"
+)
+
+// the gray code sequence output by the driving controller as it is rotated
+// clockwise. ROMs that use the driving controller will often contain a table
+// of these values, either in the high nibble (port 0) or the low nibble (port
+// 1), in one direction or the other.
+var grayCode = []uint8{0x00, 0x01, 0x03, 0x02}
+
+// the evidence for each controller type found in the disassembly. indexed by
+// port.
+type controllerEvidence struct {
+	paddle    [2]bool
+	trigger   [2]bool
+	keypadDDR [2]bool
+	grayMask  [2]bool
+
+	// keypad rows are selected by writing a value to SWCHA with one bit of
+	// the port's nibble cleared. if the value can't be determined then the
+	// write is counted as evidence for both ports
+	keypadRow [2]bool
+}
+
+// operandAddress returns the memory address referenced by the instruction in
+// the entry. returns false if the address can not be determined statically.
+func operandAddress(e *Entry) (uint16, bool) {
+	switch e.Result.Defn.AddressingMode {
+	case instructions.Absolute, instructions.ZeroPage,
+		instructions.AbsoluteIndexedX, instructions.AbsoluteIndexedY,
+		instructions.IndexedZeroPageX, instructions.IndexedZeroPageY:
+		return e.Result.InstructionData, true
+	}
+	return 0, false
+}
+
+// readSymbol returns the canonical name of the chip register read by the
+// instruction in the entry. returns the empty string if the entry does not
+// read a chip register.
+func readSymbol(e *Entry) string {
+	if e.Result.Defn.Effect != instructions.Read && e.Result.Defn.Effect != instructions.RMW {
+		return ""
+	}
+
+	addr, ok := operandAddress(e)
+	if !ok {
+		return ""
+	}
+
+	addr, area := memorymap.MapAddress(addr, true)
+	if area != memorymap.TIA && area != memorymap.RIOT {
+		return ""
+	}
+
+	if int(addr) >= len(addresses.Read) {
+		return ""
+	}
+
+	return addresses.Read[addr]
+}
+
+// writeSymbol returns the canonical name of the chip register written to by
+// the instruction in the entry. returns the empty string if the entry does
+// not write to a chip register.
+func writeSymbol(e *Entry) string {
+	if e.Result.Defn.Effect != instructions.Write {
+		return ""
+	}
+
+	addr, ok := operandAddress(e)
+	if !ok {
+		return ""
+	}
+
+	addr, area := memorymap.MapAddress(addr, false)
+	if area != memorymap.TIA && area != memorymap.RIOT {
+		return ""
+	}
+
+	if int(addr) >= len(addresses.Write) {
+		return ""
+	}
+
+	return addresses.Write[addr]
+}
+
+// controllerAnalysis looks for evidence of the type of controller expected by
+// the cartridge. only entries found during the flow pass are considered.
+//
+// the result is stored in the Controllers field of the Analysis type.
+// joystick is assumed unless there is evidence for something else.
+func (dsm *Disassembly) controllerAnalysis() {
+	var ev controllerEvidence
+
+	for b := range dsm.Entries {
+		// the previous entry if it immediately precedes the current entry
+		var prev *Entry
+
+		for _, e := range dsm.Entries[b] {
+			if e == nil || e.Type < EntryTypeAnalysis || e.Result.Defn == nil {
+				continue
+			}
+
+			if prev != nil {
+				end := (prev.Result.Address + uint16(prev.Result.ByteCount)) & memorymap.AddressMaskCart
+				if end != e.Result.Address&memorymap.AddressMaskCart {
+					prev = nil
+				}
+			}
+
+			dsm.controllerEvidence(&ev, prev, e)
+
+			prev = e
+		}
+	}
+
+	grayTable := dsm.findGrayTable()
+
+	for p := 0; p < len(dsm.Analysis.Controllers); p++ {
+		switch {
+		case ev.keypadDDR[p] && ev.keypadRow[p] && ev.paddle[p] && ev.trigger[p]:
+			dsm.Analysis.Controllers[p] = input.KeypadType
+		case ev.grayMask[p] && grayTable[p]:
+			dsm.Analysis.Controllers[p] = input.DrivingType
+		case ev.paddle[p]:
+			dsm.Analysis.Controllers[p] = input.PaddleType
+		default:
+			dsm.Analysis.Controllers[p] = input.JoystickType
+		}
+	}
+}
+
+// controllerEvidence adds to the evidence with the current entry. prev is the
+// entry immediately preceding the current entry (or nil).
+func (dsm *Disassembly) controllerEvidence(ev *controllerEvidence, prev *Entry, e *Entry) {
+	// paddle potentiometers are read through the INPTx registers. keypad
+	// columns are read through the same registers and the trigger register of
+	// the port
+	switch readSymbol(e) {
+	case "INPT0", "INPT1":
+		ev.paddle[0] = true
+	case "INPT2", "INPT3":
+		ev.paddle[1] = true
+	case "INPT4":
+		ev.trigger[0] = true
+	case "INPT5":
+		ev.trigger[1] = true
+	}
+
+	switch writeSymbol(e) {
+	case "SWCHA":
+		v, ok := storedValue(prev, e)
+		if !ok {
+			ev.keypadRow[0] = true
+			ev.keypadRow[1] = true
+			break
+		}
+		if singleBitClear(v >> 4) {
+			ev.keypadRow[0] = true
+		}
+		if singleBitClear(v & 0x0f) {
+			ev.keypadRow[1] = true
+		}
+
+	case "SWACNT":
+		// keypad rows are driven by setting the data direction of the
+		// upper/lower nibble to output
+		v, ok := storedValue(prev, e)
+		if !ok {
+			break
+		}
+		if v&0xf0 == 0xf0 {
+			ev.keypadDDR[0] = true
+		}
+		if v&0x0f == 0x0f {
+			ev.keypadDDR[1] = true
+		}
+	}
+
+	// driving controllers are read by masking the two gray code bits of SWCHA
+	if prev != nil && readSymbol(prev) == "SWCHA" {
+		if e.Result.Defn.Mnemonic == "AND" && e.Result.Defn.AddressingMode == instructions.Immediate {
+			switch uint8(e.Result.InstructionData) {
+			case 0x30:
+				ev.grayMask[0] = true
+			case 0x03:
+				ev.grayMask[1] = true
+			}
+		}
+	}
+}
+
+// storedValue returns the value written by the store instruction in the
+// entry. the value is taken from the immediately preceding load instruction of
+// the same register. returns false if the value can not be determined.
+func storedValue(prev *Entry, e *Entry) (uint8, bool) {
+	if prev == nil || prev.Result.Defn.AddressingMode != instructions.Immediate {
+		return 0, false
+	}
+
+	if len(prev.Result.Defn.Mnemonic) != 3 || len(e.Result.Defn.Mnemonic) != 3 {
+		return 0, false
+	}
+
+	if prev.Result.Defn.Mnemonic[:2] != "LD" || prev.Result.Defn.Mnemonic[2] != e.Result.Defn.Mnemonic[2] {
+		return 0, false
+	}
+
+	return uint8(prev.Result.InstructionData), true
+}
+
+// singleBitClear returns true if exactly one bit of the nibble is clear
+func singleBitClear(nibble uint8) bool {
+	v := ^nibble & 0x0f
+	return v != 0 && v&(v-1) == 0
+}
+
+// findGrayTable searches every bank of the cartridge for a table of gray code
+// values. the result is indexed by port.
+func (dsm *Disassembly) findGrayTable() [2]bool {
+	var found [2]bool
+
+	for b := 0; b < dsm.cart.NumBanks(); b++ {
+		data, err := dsm.bankData(b)
+		if err != nil {
+			return found
+		}
+
+		for i := 0; i+len(grayCode) <= len(data); i++ {
+			if matchGrayCode(data[i:i+len(grayCode)], 0) {
+				found[1] = true
+			}
+			if matchGrayCode(data[i:i+len(grayCode)], 4) {
+				found[0] = true
+			}
+		}
+	}
+
+	return found
+}
+
+// matchGrayCode returns true if the data is a rotation of the gray code
+// sequence, in either direction, shifted by the specified number of bits.
+func matchGrayCode(data []uint8, shift uint) bool {
+	n := len(grayCode)
+
+	for r := 0; r < n; r++ {
+		fwd := true
+		rev := true
+		for i := 0; i < n; i++ {
+			if data[i] != grayCode[(r+i)%n]<<shift {
+				fwd = false
+			}
+			if data[i] != grayCode[(r+n-i)%n]<<shift {
+				rev = false
+			}
+		}
+		if fwd || rev {
+			return true
+		}
+	}
+
+	return false
+}
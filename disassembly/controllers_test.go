@@ -0,0 +1,127 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"gopher2600/hardware/riot/input"
+	"gopher2600/test"
+	"testing"
+)
+
+// every test program ends with a jump back to the start of the cartridge
+var loop = []byte{0x4c, 0x00, 0xf0} // JMP $F000
+
+func analyse(t *testing.T, program []byte, table []byte) [2]input.ControllerType {
+	t.Helper()
+
+	data := test.NewROM(4096)
+	copy(data, append(program, loop...))
+
+	// data tables are placed well away from the program
+	copy(data[0x800:], table)
+
+	dsm := disassemble(t, data, "")
+
+	return dsm.Analysis.Controllers
+}
+
+func expect(t *testing.T, got [2]input.ControllerType, p0 input.ControllerType, p1 input.ControllerType) {
+	t.Helper()
+	if got[0] != p0 || got[1] != p1 {
+		t.Errorf("expected %v, %v but got %v, %v", p0, p1, got[0], got[1])
+	}
+}
+
+func TestControllers_joystick(t *testing.T) {
+	got := analyse(t, []byte{
+		0xad, 0x80, 0x02, // LDA SWCHA
+		0xa5, 0x0c, // LDA INPT4
+	}, nil)
+	expect(t, got, input.JoystickType, input.JoystickType)
+}
+
+func TestControllers_paddle(t *testing.T) {
+	got := analyse(t, []byte{
+		0xa5, 0x08, // LDA INPT0
+		0xb5, 0x0a, // LDA INPT2,X
+	}, nil)
+	expect(t, got, input.PaddleType, input.PaddleType)
+}
+
+func TestControllers_keypad(t *testing.T) {
+	got := analyse(t, []byte{
+		0xa9, 0x0f, // LDA #$0F
+		0x8d, 0x81, 0x02, // STA SWACNT
+		0xa9, 0xfe, // LDA #$FE
+		0x8d, 0x80, 0x02, // STA SWCHA
+		0xa5, 0x0a, // LDA INPT2
+		0xa5, 0x0d, // LDA INPT5
+		0xa5, 0x08, // LDA INPT0
+	}, nil)
+	expect(t, got, input.PaddleType, input.KeypadType)
+
+	// row selected from a table. the value written to SWCHA can't be known
+	got = analyse(t, []byte{
+		0xa9, 0xf0, // LDA #$F0
+		0x8d, 0x81, 0x02, // STA SWACNT
+		0xbd, 0x00, 0xf8, // LDA $F800,X
+		0x8d, 0x80, 0x02, // STA SWCHA
+		0xa5, 0x09, // LDA INPT1
+		0xa5, 0x0c, // LDA INPT4
+	}, nil)
+	expect(t, got, input.KeypadType, input.JoystickType)
+
+	// all bits of SWCHA set to output and written to but only the joystick
+	// inputs are read
+	got = analyse(t, []byte{
+		0xa9, 0xff, // LDA #$FF
+		0x8d, 0x81, 0x02, // STA SWACNT
+		0x8d, 0x80, 0x02, // STA SWCHA
+		0xa5, 0x0c, // LDA INPT4
+		0xa5, 0x0d, // LDA INPT5
+	}, nil)
+	expect(t, got, input.JoystickType, input.JoystickType)
+
+	// no row is selected by the value written to SWCHA
+	got = analyse(t, []byte{
+		0xa9, 0xff, // LDA #$FF
+		0x8d, 0x81, 0x02, // STA SWACNT
+		0xa9, 0x00, // LDA #$00
+		0x8d, 0x80, 0x02, // STA SWCHA
+		0xa5, 0x08, // LDA INPT0
+		0xa5, 0x0c, // LDA INPT4
+	}, nil)
+	expect(t, got, input.PaddleType, input.JoystickType)
+}
+
+func TestControllers_driving(t *testing.T) {
+	got := analyse(t, []byte{
+		0xad, 0x80, 0x02, // LDA SWCHA
+		0x29, 0x30, // AND #$30
+	}, []byte{0x00, 0x10, 0x30, 0x20})
+	expect(t, got, input.DrivingType, input.JoystickType)
+
+	// no gray code table
+	got = analyse(t, []byte{
+		0xad, 0x80, 0x02, // LDA SWCHA
+		0x29, 0x30, // AND #$30
+	}, nil)
+	expect(t, got, input.JoystickType, input.JoystickType)
+}
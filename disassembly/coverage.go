@@ -0,0 +1,431 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"bufio"
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/cpu/execution"
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/paths"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// coverage files are stored in this sub-directory of the resource path. the
+// filename is the hash of the cartridge
+const coveragePath = "coverage"
+
+// CoverageFile returns the name of the coverage file for the cartridge with
+// the specified hash.
+func CoverageFile(hash string) (string, error) {
+	return paths.ResourcePath(coveragePath, hash)
+}
+
+// CoverageFlags records how a byte of cartridge memory has been accessed
+// during execution. A byte may have been accessed in more than one way.
+type CoverageFlags uint8
+
+// List of valid CoverageFlags. A byte with no flags set has never been
+// touched.
+const (
+	CoverageOpcode CoverageFlags = 0x01 << iota
+	CoverageOperand
+	CoverageData
+)
+
+func (f CoverageFlags) String() string {
+	s := strings.Builder{}
+	if f&CoverageOpcode == CoverageOpcode {
+		s.WriteString("opcode ")
+	}
+	if f&CoverageOperand == CoverageOperand {
+		s.WriteString("operand ")
+	}
+	if f&CoverageData == CoverageData {
+		s.WriteString("data ")
+	}
+	if s.Len() == 0 {
+		return "untouched"
+	}
+	return strings.TrimSpace(s.String())
+}
+
+// CoverageEntry is the runtime coverage of a single byte of cartridge memory.
+type CoverageEntry struct {
+	Flags CoverageFlags
+
+	// the most recent instruction to have read the byte as data. only valid
+	// if the CoverageData flag is set
+	ReadBank     int
+	ReadAddress  uint16
+	ReadMnemonic string
+	ReadMode     instructions.AddressingMode
+}
+
+// ReadBy returns a description of the instruction that most recently read
+// the byte as data. For example, "LDA (ind),Y @ $f013 [bank 0]". Returns the
+// empty string if the byte has not been read as data.
+func (ce CoverageEntry) ReadBy() string {
+	if ce.Flags&CoverageData != CoverageData {
+		return ""
+	}
+	return fmt.Sprintf("%s %s @ $%04x [bank %d]", ce.ReadMnemonic, modeSyntax[ce.ReadMode], ce.ReadAddress, ce.ReadBank)
+}
+
+// modeSyntax is a short description of each addressing mode, using the
+// syntax of the assembly language
+var modeSyntax = map[instructions.AddressingMode]string{
+	instructions.Implied:             "",
+	instructions.Immediate:           "#imm",
+	instructions.Relative:            "rel",
+	instructions.Absolute:            "abs",
+	instructions.ZeroPage:            "zp",
+	instructions.Indirect:            "(ind)",
+	instructions.PreIndexedIndirect:  "(ind,X)",
+	instructions.PostIndexedIndirect: "(ind),Y",
+	instructions.AbsoluteIndexedX:    "abs,X",
+	instructions.AbsoluteIndexedY:    "abs,Y",
+	instructions.IndexedZeroPageX:    "zp,X",
+	instructions.IndexedZeroPageY:    "zp,Y",
+}
+
+// Coverage records which bytes of cartridge memory were executed as opcodes
+// or operands and which were read as data during live execution.
+//
+// Coverage is indexed in the same way as Disassembly.Entries, by bank and by
+// address masked with memorymap.AddressMaskCart.
+type Coverage struct {
+	Bytes [][memorymap.AddressMaskCart + 1]CoverageEntry
+}
+
+// NewCoverage is the preferred method of initialisation for the Coverage
+// type.
+func NewCoverage(numBanks int) *Coverage {
+	return &Coverage{
+		Bytes: make([][memorymap.AddressMaskCart + 1]CoverageEntry, numBanks),
+	}
+}
+
+// Get returns the coverage of the byte at the bank/address.
+func (cov *Coverage) Get(bank int, address uint16) CoverageEntry {
+	if bank < 0 || bank >= len(cov.Bytes) {
+		return CoverageEntry{}
+	}
+	return cov.Bytes[bank][address&memorymap.AddressMaskCart]
+}
+
+// Clear all coverage information.
+func (cov *Coverage) Clear() {
+	cov.Bytes = make([][memorymap.AddressMaskCart + 1]CoverageEntry, len(cov.Bytes))
+}
+
+// IsEmpty returns true if no bytes have been touched.
+func (cov *Coverage) IsEmpty() bool {
+	for b := range cov.Bytes {
+		for _, ce := range cov.Bytes[b] {
+			if ce.Flags != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns a summary of the coverage of each bank.
+func (cov *Coverage) String() string {
+	s := strings.Builder{}
+	for b := range cov.Bytes {
+		var executed, data, untouched int
+		for _, ce := range cov.Bytes[b] {
+			if ce.Flags&(CoverageOpcode|CoverageOperand) != 0 {
+				executed++
+			}
+			if ce.Flags&CoverageData == CoverageData {
+				data++
+			}
+			if ce.Flags == 0 {
+				untouched++
+			}
+		}
+		s.WriteString(fmt.Sprintf("bank %d: executed %d, data %d, untouched %d\n", b, executed, data, untouched))
+	}
+	return s.String()
+}
+
+// the first line of every coverage file
+const coverageHeader = "gopher2600 coverage"
+
+// Save coverage information to the named file. Only bytes that have been
+// touched are saved.
+func (cov *Coverage) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New(errors.CoverageError, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, coverageHeader)
+
+	for b := range cov.Bytes {
+		for a, ce := range cov.Bytes[b] {
+			if ce.Flags == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%d,%04x,%d", b, a, ce.Flags)
+			if ce.Flags&CoverageData == CoverageData {
+				fmt.Fprintf(w, ",%d,%04x,%s,%d", ce.ReadBank, ce.ReadAddress, ce.ReadMnemonic, ce.ReadMode)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.New(errors.CoverageError, err)
+	}
+
+	return nil
+}
+
+// LoadCoverage reads coverage information previously saved with Save(). The
+// number of banks in the coverage file must agree with the numBanks argument.
+func LoadCoverage(filename string, numBanks int) (*Coverage, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.New(errors.CoverageError, err)
+	}
+	defer f.Close()
+
+	return readCoverage(f, numBanks)
+}
+
+// LoadCoverageFile applies the coverage file for the disassembled cartridge,
+// as saved by the debugger. It is not an error for the file not to exist.
+func (dsm *Disassembly) LoadCoverageFile() error {
+	if dsm.cart.Hash == "" || dsm.cart.IsEjected() {
+		return nil
+	}
+
+	pth, err := CoverageFile(dsm.cart.Hash)
+	if err != nil {
+		return errors.New(errors.CoverageError, err)
+	}
+
+	if _, err := os.Stat(pth); os.IsNotExist(err) {
+		return nil
+	}
+
+	cov, err := LoadCoverage(pth, dsm.cart.NumBanks())
+	if err != nil {
+		return err
+	}
+
+	return dsm.ApplyCoverage(cov)
+}
+
+func readCoverage(r io.Reader, numBanks int) (*Coverage, error) {
+	cov := NewCoverage(numBanks)
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != coverageHeader {
+		return nil, errors.New(errors.CoverageError, "not a coverage file")
+	}
+
+	lineNum := 1
+	for scanner.Scan() {
+		lineNum++
+
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) != 3 && len(fields) != 7 {
+			return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: wrong number of fields", lineNum))
+		}
+
+		bank, err := strconv.Atoi(fields[0])
+		if err != nil || bank < 0 || bank >= numBanks {
+			return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid bank", lineNum))
+		}
+
+		address, err := strconv.ParseUint(fields[1], 16, 16)
+		if err != nil || address > uint64(memorymap.AddressMaskCart) {
+			return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid address", lineNum))
+		}
+
+		flags, err := strconv.ParseUint(fields[2], 10, 8)
+		if err != nil {
+			return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid flags", lineNum))
+		}
+
+		ce := &cov.Bytes[bank][address]
+		ce.Flags = CoverageFlags(flags)
+
+		if len(fields) == 7 {
+			ce.ReadBank, err = strconv.Atoi(fields[3])
+			if err != nil {
+				return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid bank", lineNum))
+			}
+
+			a, err := strconv.ParseUint(fields[4], 16, 16)
+			if err != nil {
+				return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid address", lineNum))
+			}
+			ce.ReadAddress = uint16(a)
+
+			ce.ReadMnemonic = fields[5]
+
+			m, err := strconv.Atoi(fields[6])
+			if err != nil {
+				return nil, errors.New(errors.CoverageError, fmt.Sprintf("line %d: invalid addressing mode", lineNum))
+			}
+			ce.ReadMode = instructions.AddressingMode(m)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(errors.CoverageError, err)
+	}
+
+	return cov, nil
+}
+
+// Executed records the execution of an instruction from the specified bank.
+// The disassembly entry for the instruction is promoted to EntryTypeLive.
+// Results that are not final are ignored.
+func (dsm *Disassembly) Executed(bank int, result execution.Result) {
+	if !result.Final || result.Defn == nil || bank < 0 || bank >= len(dsm.Entries) {
+		return
+	}
+
+	if _, area := memorymap.MapAddress(result.Address, true); area != memorymap.Cartridge {
+		return
+	}
+
+	idx := result.Address & memorymap.AddressMaskCart
+	dsm.Coverage.Bytes[bank][idx].Flags |= CoverageOpcode
+	for i := uint16(1); i < uint16(result.Defn.Bytes); i++ {
+		if idx+i > memorymap.AddressMaskCart {
+			break // for loop
+		}
+		dsm.Coverage.Bytes[bank][idx+i].Flags |= CoverageOperand
+	}
+
+	e := dsm.Entries[bank][idx]
+	if e == nil {
+		var err error
+		e, err = dsm.FormatResult(result)
+		if err != nil {
+			return
+		}
+		e.Bank = bank
+		e.Type = EntryTypeLive
+		dsm.Entries[bank][idx] = e
+		dsm.adjustCounts(bank, -1, EntryTypeLive)
+		return
+	}
+	dsm.setType(e, EntryTypeLive)
+}
+
+// ReadData records the reading of cartridge memory as data, by the
+// instruction in result. The byteBank argument is the bank of the byte being
+// read and the resultBank is the bank of the instruction.
+//
+// The disassembly entry at the address of the byte being read is demoted to
+// EntryTypeNaive, unless the entry has also been executed or was found during
+// the flow pass.
+func (dsm *Disassembly) ReadData(byteBank int, address uint16, resultBank int, result execution.Result) {
+	if result.Defn == nil || byteBank < 0 || byteBank >= len(dsm.Entries) {
+		return
+	}
+
+	idx := address & memorymap.AddressMaskCart
+	ce := &dsm.Coverage.Bytes[byteBank][idx]
+	ce.Flags |= CoverageData
+	ce.ReadBank = resultBank
+	ce.ReadAddress = result.Address
+	ce.ReadMnemonic = result.Defn.Mnemonic
+	ce.ReadMode = result.Defn.AddressingMode
+
+	if e := dsm.Entries[byteBank][idx]; e != nil && e.Type == EntryTypeDecode {
+		dsm.setType(e, EntryTypeNaive)
+	}
+}
+
+// ApplyCoverage replaces the current coverage information and adjusts the
+// type of every entry in the disassembly accordingly. See Executed() and
+// ReadData() for details. The number of banks in the coverage information
+// must agree with the number of banks in the disassembly.
+func (dsm *Disassembly) ApplyCoverage(cov *Coverage) error {
+	if len(cov.Bytes) != len(dsm.Entries) {
+		return errors.New(errors.CoverageError, "coverage does not match cartridge")
+	}
+
+	dsm.Coverage = cov
+
+	for b := range dsm.Entries {
+		for a, e := range dsm.Entries[b] {
+			if e == nil {
+				continue
+			}
+			flags := cov.Bytes[b][a].Flags
+			if flags&CoverageOpcode == CoverageOpcode {
+				e.Type = EntryTypeLive
+			} else if flags&CoverageData == CoverageData && e.Type == EntryTypeDecode {
+				e.Type = EntryTypeNaive
+			}
+		}
+	}
+
+	dsm.countTypes()
+
+	// coverage may reveal graphics not found by static analysis. failure is
+	// not fatal
+	_ = dsm.UpdateGraphics()
+
+	return nil
+}
+
+// setType changes the type of an entry, keeping the Counts field up to date.
+func (dsm *Disassembly) setType(e *Entry, typ EntryType) {
+	if e.Type == typ {
+		return
+	}
+	dsm.adjustCounts(e.Bank, e.Type, typ)
+	e.Type = typ
+}
+
+// adjustCounts updates the Counts field for an entry in the bank changing
+// type. an entry is counted in its own type and every type below it. an
+// entry that has not been counted before should have a from value of -1.
+func (dsm *Disassembly) adjustCounts(bank int, from EntryType, to EntryType) {
+	if bank < 0 || bank >= len(dsm.Counts) {
+		return
+	}
+
+	for t := from + 1; t <= to; t++ {
+		dsm.Counts[bank][t]++
+	}
+	for t := to + 1; t <= from; t++ {
+		dsm.Counts[bank][t]--
+	}
+}
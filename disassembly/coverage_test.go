@@ -0,0 +1,191 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"gopher2600/disassembly"
+	"gopher2600/hardware/cpu/execution"
+	"gopher2600/hardware/cpu/instructions"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_disassembly")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := make([]byte, 4096)
+	fill(data)
+	copy(data, program)
+	data[0xffc] = 0x00
+	data[0xffd] = 0xf0
+
+	dsm := disassemble(t, data, "")
+
+	defns, err := instructions.GetDefinitions()
+	if err != nil {
+		t.Fatalf("cannot get instruction definitions: %v", err)
+	}
+
+	// LDA $F800,X from the test program
+	lda := execution.Result{
+		Defn:            defns[0xbd],
+		ByteCount:       3,
+		Address:         0xf013,
+		InstructionData: 0xf800,
+		Final:           true,
+	}
+
+	// an entry in the data table, as decoded during the linear pass
+	var e *disassembly.Entry
+	table := uint16(0xf800)
+	for ; table < 0xf900; table++ {
+		if e, _ = dsm.Get(0, table); e != nil && e.Type == disassembly.EntryTypeDecode {
+			break // for loop
+		}
+	}
+	if table == 0xf900 {
+		t.Fatalf("expected data table to have been decoded as an instruction")
+	}
+	decodeCount := dsm.Counts[0][disassembly.EntryTypeDecode]
+
+	dsm.Executed(0, lda)
+	dsm.ReadData(0, table, 0, lda)
+
+	if e, _ := dsm.Get(0, 0xf013); e.Type != disassembly.EntryTypeLive {
+		t.Errorf("expected executed instruction to be promoted to a live entry")
+	}
+	if e.Type != disassembly.EntryTypeNaive {
+		t.Errorf("expected data read to demote instruction to a naive entry")
+	}
+	if dsm.Counts[0][disassembly.EntryTypeLive] != 1 {
+		t.Errorf("expected one live entry (got %d)", dsm.Counts[0][disassembly.EntryTypeLive])
+	}
+	if dsm.Counts[0][disassembly.EntryTypeDecode] != decodeCount-1 {
+		t.Errorf("expected one fewer decode entries")
+	}
+
+	ce := dsm.Coverage.Get(0, 0xf014)
+	if ce.Flags != disassembly.CoverageOperand {
+		t.Errorf("expected operand coverage (got %s)", ce.Flags)
+	}
+	ce = dsm.Coverage.Get(0, table)
+	if ce.ReadBy() != "LDA abs,X @ $f013 [bank 0]" {
+		t.Errorf("unexpected data reader (%s)", ce.ReadBy())
+	}
+
+	// reassembly comments data with the instruction that read it
+	source := &strings.Builder{}
+	if err := dsm.Reassemble(source, data); err != nil {
+		t.Fatalf("cannot reassemble ROM: %v", err)
+	}
+	if !strings.Contains(source.String(), "; read by LDA abs,X @ $f013 [bank 0]") {
+		t.Errorf("expected data reader in reassembled source")
+	}
+
+	// save and load coverage into a fresh disassembly
+	cov := filepath.Join(dir, "coverage")
+	if err := dsm.Coverage.Save(cov); err != nil {
+		t.Fatalf("cannot save coverage: %v", err)
+	}
+
+	dsm = disassemble(t, data, "")
+
+	loaded, err := disassembly.LoadCoverage(cov, 1)
+	if err != nil {
+		t.Fatalf("cannot load coverage: %v", err)
+	}
+	if err := dsm.ApplyCoverage(loaded); err != nil {
+		t.Fatalf("cannot apply coverage: %v", err)
+	}
+
+	if e, _ := dsm.Get(0, 0xf013); e.Type != disassembly.EntryTypeLive {
+		t.Errorf("expected loaded coverage to promote instruction to a live entry")
+	}
+	if e, _ := dsm.Get(0, table); e.Type != disassembly.EntryTypeNaive {
+		t.Errorf("expected loaded coverage to demote data to a naive entry")
+	}
+	if dsm.Coverage.Get(0, table).ReadBy() != ce.ReadBy() {
+		t.Errorf("data reader not preserved by save/load")
+	}
+
+	if err := dsm.ApplyCoverage(disassembly.NewCoverage(2)); err == nil {
+		t.Errorf("expected coverage with wrong number of banks to fail")
+	}
+}
+
+func TestCoverageFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_disassembly")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// coverage files are found relative to the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	data := make([]byte, 4096)
+	fill(data)
+	copy(data, program)
+	data[0xffc] = 0x00
+	data[0xffd] = 0xf0
+
+	// it is not an error for there to be no coverage file
+	dsm := disassemble(t, data, "")
+	if err := dsm.LoadCoverageFile(); err != nil {
+		t.Fatalf("unexpected error for missing coverage file: %v", err)
+	}
+	if !dsm.Coverage.IsEmpty() {
+		t.Errorf("expected no coverage without a coverage file")
+	}
+
+	cov := disassembly.NewCoverage(1)
+	cov.Bytes[0][0x013].Flags = disassembly.CoverageOpcode
+	pth, err := disassembly.CoverageFile(fmt.Sprintf("%x", sha1.Sum(data)))
+	if err != nil {
+		t.Fatalf("cannot get coverage filename: %v", err)
+	}
+	if err := cov.Save(pth); err != nil {
+		t.Fatalf("cannot save coverage: %v", err)
+	}
+
+	dsm = disassemble(t, data, "")
+	if err := dsm.LoadCoverageFile(); err != nil {
+		t.Fatalf("cannot load coverage file: %v", err)
+	}
+	if e, _ := dsm.Get(0, 0xf013); e.Type != disassembly.EntryTypeLive {
+		t.Errorf("expected coverage file to promote instruction to a live entry")
+	}
+}
@@ -51,6 +51,25 @@ type Disassembly struct {
 
 	// static analysis (best effort) of cartridge
 	Analysis Analysis
+
+	// regions of the cartridge that look like graphics data. see
+	// UpdateGraphics()
+	Graphics []GraphicsRegion
+
+	// runtime coverage of the cartridge. the debugger records the coverage
+	// with the Executed() and ReadData() functions
+	Coverage *Coverage
+
+	// references to addresses observed during emulation. see Referenced()
+	// and Flowed(). static references are found on demand by Xrefs()
+	RuntimeXrefs *Xrefs
+
+	// the location at which the flow pass began. see CallGraph()
+	entryPoint CodeLocation
+
+	// the memory used by the flow pass. accesses to cartridge hotspots are
+	// noted by the memory
+	mem *disasmMemory
 }
 
 // Get returns the disassembly at the specified bank/address.
@@ -62,8 +81,15 @@ func (dsm Disassembly) Get(bank int, address uint16) (*Entry, bool) {
 // FormatResult returns the formatted representation of an execution result.
 // Build string representations with GetField(). Also see Write*() functions for
 // less flexible but convenient alternative.
+//
+// Location symbols are looked up in the bank currently mapped to the result's
+// address.
 func (dsm Disassembly) FormatResult(result execution.Result) (*Entry, error) {
-	return newEntry(result, dsm.Symtable)
+	bank := 0
+	if dsm.cart != nil {
+		bank = dsm.cart.GetBank(result.Address)
+	}
+	return newEntry(result, bank, dsm.Symtable)
 }
 
 // FromCartridge initialises a new partial emulation and returns a
@@ -97,6 +123,8 @@ func FromMemory(cart *cartridge.Cartridge, symtable *symbols.Table) (*Disassembl
 	dsm.cart = cart
 	dsm.Symtable = symtable
 	dsm.Entries = make([][memorymap.AddressMaskCart + 1]*Entry, dsm.cart.NumBanks())
+	dsm.Coverage = NewCoverage(dsm.cart.NumBanks())
+	dsm.RuntimeXrefs = NewXrefs()
 
 	// exit early if cartridge memory self reports as being ejected
 	if dsm.cart.IsEjected() {
@@ -113,10 +141,10 @@ func FromMemory(cart *cartridge.Cartridge, symtable *symbols.Table) (*Disassembl
 	dsm.cart.Initialise()
 
 	// create new memory
-	mem := &disasmMemory{cart: cart}
+	dsm.mem = &disasmMemory{cart: cart}
 
 	// create a new NoFlowControl CPU to help disassemble memory
-	mc, err := cpu.NewCPU(mem)
+	mc, err := cpu.NewCPU(dsm.mem)
 	if err != nil {
 		return nil, errors.New(errors.DisasmError, err)
 	}
@@ -135,6 +163,7 @@ func FromMemory(cart *cartridge.Cartridge, symtable *symbols.Table) (*Disassembl
 		return nil, err
 	}
 	dsm.cart.Initialise()
+	dsm.entryPoint = CodeLocation{Bank: dsm.cart.GetBank(mc.PC.Address()), Address: mc.PC.Address()}
 
 	// flow pass
 	err = dsm.flowAnalysis(mc, addresses.Reset, 0)
@@ -142,9 +171,19 @@ func FromMemory(cart *cartridge.Cartridge, symtable *symbols.Table) (*Disassembl
 		return nil, errors.New(errors.AnalysisError, err)
 	}
 
+	// look for evidence of controller types. the cartridge state will be
+	// restored by the deferred RestoreState() above
+	dsm.controllerAnalysis()
+
+	// summarise the bank switching found by the flow pass
+	dsm.bankSwitchAnalysis()
+
 	// count entry types
 	dsm.countTypes()
 
+	// look for graphics data. failure is not fatal
+	_ = dsm.UpdateGraphics()
+
 	return dsm, nil
 }
 
@@ -156,6 +195,10 @@ func (dsm *Disassembly) countTypes() {
 		for _, e := range dsm.Entries[b] {
 			if e != nil {
 				switch e.Type {
+				case EntryTypeLive:
+					dsm.Counts[b][EntryTypeLive]++
+					fallthrough
+
 				case EntryTypeAnalysis:
 					dsm.Counts[b][EntryTypeAnalysis]++
 					fallthrough
@@ -171,3 +214,34 @@ func (dsm *Disassembly) countTypes() {
 		}
 	}
 }
+
+// UpdateSymbols reformats the location, operand and comment of every entry
+// using the current symbols table. Unlike FromMemory() the flow analysis is
+// not repeated so this is cheap enough to call whenever a symbol or comment is
+// added to the symbols table.
+func (dsm *Disassembly) UpdateSymbols() error {
+	for b := range dsm.Entries {
+		for _, e := range dsm.Entries[b] {
+			if e == nil || e.Result.Defn == nil {
+				continue
+			}
+
+			f, err := newEntry(e.Result, e.Bank, dsm.Symtable)
+			if err != nil {
+				return err
+			}
+
+			e.Location = f.Location
+			e.Operand = f.Operand
+			e.Comment = f.Comment
+
+			// naive entries do not contribute to the field widths. see
+			// decode()
+			if e.Type != EntryTypeNaive {
+				dsm.fields.updateWidths(e)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/disassembly"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// disassemble writes the ROM data to a temporary file and disassembles it. an
+// empty format means the cartridge format is detected from the data. ROM data
+// is most easily created with test.NewROM()
+func disassemble(t *testing.T, data []byte, format string) *disassembly.Disassembly {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "gopher2600_disassembly")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := test.WriteROM(t, dir, "test.bin", data)
+
+	dsm, err := disassembly.FromCartridge(cartridgeloader.Loader{Filename: rom, Format: format})
+	if err != nil {
+		t.Fatalf("cannot disassemble ROM: %v", err)
+	}
+
+	return dsm
+}
+
+func TestUpdateSymbols(t *testing.T) {
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	dsm := disassemble(t, data, "")
+
+	dsm.Symtable.AddSymbol(0, 0xf000, "kernelStart")
+	dsm.Symtable.AddComment(0, 0xf000, "start of frame")
+	if err := dsm.UpdateSymbols(); err != nil {
+		t.Fatalf("cannot update symbols: %v", err)
+	}
+
+	e, _ := dsm.Get(0, 0xf000)
+	if e.Location != "kernelStart" || e.Comment != "start of frame" {
+		t.Errorf("unexpected location and comment for 0xf000: %q %q", e.Location, e.Comment)
+	}
+
+	// the operand of the JMP at the end of the kernel refers to the new symbol
+	e, _ = dsm.Get(0, 0xf000+uint16(len(test.Kernel))-3)
+	if e.Operand != "kernelStart" {
+		t.Errorf("unexpected operand for JMP: %q", e.Operand)
+	}
+
+	// removing the comment is also seen by the entry
+	dsm.Symtable.AddComment(0, 0xf000, "")
+	if err := dsm.UpdateSymbols(); err != nil {
+		t.Fatalf("cannot update symbols: %v", err)
+	}
+	e, _ = dsm.Get(0, 0xf000)
+	if e.Comment != "" {
+		t.Errorf("comment for 0xf000 was not removed: %q", e.Comment)
+	}
+}
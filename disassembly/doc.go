@@ -37,6 +37,13 @@
 // EntryTypeDecode only. Useful for printing static disassemblies of
 // a cartridge but probably not much else.
 //
+// The Coverage type records which bytes of the cartridge were executed and
+// which were read as data during live execution. A debugger should call the
+// Executed() and ReadData() functions as the emulation progresses. Entries
+// that have been executed are promoted to EntryTypeLive. Coverage can be
+// saved and reapplied to a later disassembly of the same cartridge with
+// ApplyCoverage().
+//
 // The Iteration type provides a convenient way of iterating of the disassembly
 // entries. It takes care of empty entries and entries not of the correct entry
 // type.
@@ -46,11 +46,12 @@ type EntryType int
 //
 // Live instructions are the most reliable because they contain information
 // from the last actual execution of the entire system (not just a mock CPU, as
-// in the case of the Flow type)
+// in the case of the Flow type). See the Coverage type.
 const (
 	EntryTypeNaive EntryType = iota
 	EntryTypeDecode
 	EntryTypeAnalysis
+	EntryTypeLive
 )
 
 // Entry is a disassambled instruction. The constituent parts of the
@@ -82,18 +83,33 @@ type Entry struct {
 	ActualCycles string
 	ActualNotes  string
 
+	// user comment for the instruction. see symbols.Table.AddComment()
+	Comment string
+
 	// addresses from which the instruction can be reached
 	Prev []uint16
 
 	// address to which the instruction flows to next
 	// subroutines
 	Next []uint16
+
+	// cartridge hotspots accessed by the instruction during the flow pass
+	Hotspots []uint16
+
+	// the banks in which execution continues after the instruction, when a
+	// hotspot access changes the bank of the next instruction. empty if the
+	// bank does not change
+	NextBank []int
+
+	// the instruction was reached from an instruction in a different bank,
+	// after a bank switch
+	CrossBank bool
 }
 
 // format execution.Result and create a new instance of Entry
-func newEntry(result execution.Result, symtable *symbols.Table) (*Entry, error) {
+func newEntry(result execution.Result, bank int, symtable *symbols.Table) (*Entry, error) {
 	if symtable == nil {
-		symtable = &symbols.Table{}
+		symtable = symbols.NewTable()
 	}
 
 	d := &Entry{
@@ -111,10 +127,15 @@ func newEntry(result execution.Result, symtable *symbols.Table) (*Entry, error)
 	d.Address = fmt.Sprintf("0x%04x", result.Address)
 
 	// look up address in symbol table
-	if v, ok := symtable.Locations.Symbols[result.Address]; ok {
+	if v, ok := symtable.LocationSymbol(bank, result.Address); ok {
 		d.Location = v
 	}
 
+	// user comment for the address
+	if v, ok := symtable.Comment(bank, result.Address); ok {
+		d.Comment = v
+	}
+
 	// mnemonic is just a string anyway
 	d.Mnemonic = result.Defn.Mnemonic
 
@@ -212,12 +233,12 @@ func newEntry(result execution.Result, symtable *symbols.Table) (*Entry, error)
 						pc.Add(operand)
 
 						// -- look up mock program counter value in symbol table
-						if v, ok := symtable.Locations.Symbols[pc.Address()]; ok {
+						if v, ok := symtable.LocationSymbol(bank, pc.Address()); ok {
 							d.Operand = v
 						}
 
 					} else {
-						if v, ok := symtable.Locations.Symbols[operand]; ok {
+						if v, ok := symtable.LocationSymbol(bank, operand); ok {
 							d.Operand = v
 						}
 					}
@@ -0,0 +1,470 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/television/colors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"sort"
+)
+
+// GraphicsRegion is a region of cartridge data that is written to one of the
+// TIA graphics registers.
+type GraphicsRegion struct {
+	Bank int
+
+	// the address of the first byte of the region, as seen by the program
+	Address uint16
+
+	// one byte for every row of the graphics
+	Data []uint8
+
+	// the TIA register the data is written to. one of GRP0, GRP1, PF0, PF1,
+	// PF2
+	Register string
+
+	// the colour of each row of the graphics, if a colour table for the
+	// graphics has been found. otherwise nil
+	Colors []uint8
+
+	// the address of the instruction that loads the data
+	LoadedBy uint16
+}
+
+func (gr GraphicsRegion) String() string {
+	s := fmt.Sprintf("%s $%04x (%d rows) loaded by $%04x", gr.Register, gr.Address, len(gr.Data), gr.LoadedBy)
+	if gr.Colors != nil {
+		s = fmt.Sprintf("%s with colour", s)
+	}
+	return s
+}
+
+// Pixel returns true if the pixel in the row is set. Pixels are numbered from
+// left to right as they would appear on the screen. The bits of PF0 and PF2
+// are drawn in reverse order.
+func (gr GraphicsRegion) Pixel(row int, x int) bool {
+	if gr.Register == "PF0" || gr.Register == "PF2" {
+		return gr.Data[row]&(0x01<<uint(x)) != 0
+	}
+	return gr.Data[row]&(0x80>>uint(x)) != 0
+}
+
+// the maximum number of bytes in a graphics region found by static analysis
+const maxGraphicsRegion = 256
+
+// the number of instructions after a load instruction in which to look for
+// the store to a graphics register
+const graphicsStoreDistance = 8
+
+// the number of bytes either side of a graphics load instruction in which to
+// look for a colour load instruction
+const graphicsColorDistance = 32
+
+// graphics registers and their corresponding colour register
+var graphicsRegisters = map[string]string{
+	"GRP0": "COLUP0",
+	"GRP1": "COLUP1",
+	"PF0":  "COLUPF",
+	"PF1":  "COLUPF",
+	"PF2":  "COLUPF",
+}
+
+// colour registers
+var colorRegisters = map[string]bool{
+	"COLUP0": true,
+	"COLUP1": true,
+	"COLUPF": true,
+}
+
+// the registers written to by each load instruction and the instructions that
+// would replace the loaded value
+var loadInstructions = map[string]struct {
+	store    string
+	replaced []string
+}{
+	"LDA": {store: "STA", replaced: []string{"LDA", "TXA", "TYA", "PLA"}},
+	"LDX": {store: "STX", replaced: []string{"LDX", "TAX", "TSX"}},
+	"LDY": {store: "STY", replaced: []string{"LDY", "TAY"}},
+}
+
+// UpdateGraphics searches the cartridge for graphics data and updates the
+// Graphics field. Graphics are found with static analysis of the load/store
+// instructions in the disassembly and with the runtime coverage of the data
+// read by those instructions.
+func (dsm *Disassembly) UpdateGraphics() error {
+	if dsm.cart == nil || dsm.cart.IsEjected() {
+		dsm.Graphics = nil
+		return nil
+	}
+
+	state := dsm.cart.SaveState()
+	defer dsm.cart.RestoreState(state)
+
+	graphics := make([]GraphicsRegion, 0)
+
+	for b := range dsm.Entries {
+		data, err := dsm.bankData(b)
+		if err != nil {
+			return errors.New(errors.DisasmError, err)
+		}
+
+		// bases of all the tables read by static load instructions. used to
+		// limit the extent of the graphics regions
+		bases := make([]uint16, 0)
+		for _, e := range dsm.Entries[b] {
+			if e != nil && e.Type >= EntryTypeAnalysis && loadsTable(e) {
+				bases = append(bases, e.Result.InstructionData&memorymap.AddressMaskCart)
+			}
+		}
+		sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+
+		seen := make(map[uint16]bool)
+
+		for _, e := range dsm.Entries[b] {
+			if e == nil || e.Type < EntryTypeAnalysis || e.Result.Defn == nil {
+				continue
+			}
+
+			reg := dsm.storedTo(b, e)
+			col, ok := graphicsRegisters[reg]
+			if !ok {
+				continue
+			}
+
+			for _, gr := range dsm.graphicsRegions(b, e, data, bases) {
+				if seen[gr.Address] {
+					continue
+				}
+				seen[gr.Address] = true
+
+				gr.Register = reg
+				gr.Colors = dsm.graphicsColors(b, e, col, gr, data)
+				graphics = append(graphics, gr)
+			}
+		}
+	}
+
+	sort.SliceStable(graphics, func(i, j int) bool {
+		if graphics[i].Bank == graphics[j].Bank {
+			return graphics[i].Address < graphics[j].Address
+		}
+		return graphics[i].Bank < graphics[j].Bank
+	})
+
+	dsm.Graphics = graphics
+
+	return nil
+}
+
+// loadsTable returns true if the entry is a load instruction that reads from
+// an indexed table in cartridge space.
+func loadsTable(e *Entry) bool {
+	if e.Result.Defn == nil {
+		return false
+	}
+	if _, ok := loadInstructions[e.Result.Defn.Mnemonic]; !ok {
+		return false
+	}
+	switch e.Result.Defn.AddressingMode {
+	case instructions.AbsoluteIndexedX, instructions.AbsoluteIndexedY:
+	default:
+		return false
+	}
+	_, area := memorymap.MapAddress(e.Result.InstructionData, true)
+	return area == memorymap.Cartridge
+}
+
+// storedTo returns the name of the TIA register that the value loaded by the
+// instruction in the entry is stored to. returns the empty string if the
+// entry is not a load instruction or if the loaded value is not stored to a
+// TIA register before it is replaced.
+func (dsm *Disassembly) storedTo(bank int, e *Entry) string {
+	ld, ok := loadInstructions[e.Result.Defn.Mnemonic]
+	if !ok {
+		return ""
+	}
+
+	switch e.Result.Defn.AddressingMode {
+	case instructions.Absolute, instructions.AbsoluteIndexedX,
+		instructions.AbsoluteIndexedY, instructions.PostIndexedIndirect:
+	default:
+		return ""
+	}
+
+	addr := e.Result.Address
+	for i := 0; i < graphicsStoreDistance; i++ {
+		addr += uint16(e.Result.Defn.Bytes)
+
+		e = dsm.Entries[bank][addr&memorymap.AddressMaskCart]
+		if e == nil || e.Type < EntryTypeDecode || e.Result.Defn == nil {
+			return ""
+		}
+
+		switch e.Result.Defn.Effect {
+		case instructions.Flow, instructions.Subroutine, instructions.Interrupt:
+			return ""
+		}
+
+		if e.Result.Defn.Mnemonic == ld.store {
+			sym := writeSymbol(e)
+			if _, ok := graphicsRegisters[sym]; ok || colorRegisters[sym] {
+				return sym
+			}
+		}
+
+		for _, r := range ld.replaced {
+			if e.Result.Defn.Mnemonic == r {
+				return ""
+			}
+		}
+	}
+
+	return ""
+}
+
+// graphicsRegions returns the regions of data read by the load instruction.
+// the regions are taken from the runtime coverage if it is available, or
+// from the operand of the instruction if not.
+func (dsm *Disassembly) graphicsRegions(bank int, e *Entry, data []uint8, bases []uint16) []GraphicsRegion {
+	regions := make([]GraphicsRegion, 0)
+	origin := e.Result.Address &^ memorymap.AddressMaskCart
+
+	// runtime coverage. contiguous runs of bytes read by the instruction
+	if dsm.Coverage != nil && bank < len(dsm.Coverage.Bytes) {
+		var gr *GraphicsRegion
+		for a := uint16(0); a <= memorymap.AddressMaskCart; a++ {
+			ce := dsm.Coverage.Bytes[bank][a]
+			if ce.Flags&CoverageData == CoverageData && ce.ReadBank == bank &&
+				ce.ReadAddress&memorymap.AddressMaskCart == e.Result.Address&memorymap.AddressMaskCart {
+				if gr == nil {
+					gr = &GraphicsRegion{Bank: bank, Address: origin | a, LoadedBy: e.Result.Address}
+				}
+				gr.Data = append(gr.Data, data[a])
+			} else if gr != nil {
+				regions = append(regions, *gr)
+				gr = nil
+			}
+		}
+		if gr != nil {
+			regions = append(regions, *gr)
+		}
+	}
+
+	if len(regions) > 0 || !loadsTable(e) {
+		return regions
+	}
+
+	// static analysis. the table starts at the operand address and continues
+	// until the start of the next table or until the next instruction that we
+	// know to be reachable. note that the flow pass will happily continue
+	// through data after an unconditional jump so we can't stop at any
+	// EntryTypeAnalysis entry
+	base := e.Result.InstructionData & memorymap.AddressMaskCart
+	end := base + maxGraphicsRegion
+	for _, b := range bases {
+		if b > base && b < end {
+			end = b
+		}
+	}
+	if end > memorymap.AddressMaskCart+1 {
+		end = memorymap.AddressMaskCart + 1
+	}
+	for a := base; a < end; a++ {
+		if c := dsm.Entries[bank][a]; c != nil && (c.Type == EntryTypeLive || len(c.Prev) > 0) {
+			end = a
+			break // for loop
+		}
+	}
+
+	if end-base > 1 {
+		regions = append(regions, GraphicsRegion{
+			Bank:     bank,
+			Address:  e.Result.InstructionData,
+			Data:     append([]uint8{}, data[base:end]...),
+			LoadedBy: e.Result.Address,
+		})
+	}
+
+	return regions
+}
+
+// graphicsColors returns the colour of each row in the graphics region. the
+// colour table is found by looking for a load instruction close to the
+// graphics load instruction, using the same addressing mode and storing to
+// the colour register. returns nil if no colour table can be found.
+func (dsm *Disassembly) graphicsColors(bank int, e *Entry, col string, gr GraphicsRegion, data []uint8) []uint8 {
+	if !loadsTable(e) {
+		return nil
+	}
+
+	idx := e.Result.Address & memorymap.AddressMaskCart
+
+	var colorBase uint16
+	found := false
+	for d := uint16(1); d <= graphicsColorDistance && !found; d++ {
+		for _, a := range []uint16{idx - d, idx + d} {
+			if a > memorymap.AddressMaskCart {
+				continue // for loop
+			}
+			c := dsm.Entries[bank][a]
+			if c == nil || c.Type < EntryTypeAnalysis || c.Result.Defn == nil {
+				continue // for loop
+			}
+			if c.Result.Defn.AddressingMode != e.Result.Defn.AddressingMode || !loadsTable(c) {
+				continue // for loop
+			}
+			if dsm.storedTo(bank, c) == col {
+				colorBase = c.Result.InstructionData & memorymap.AddressMaskCart
+				found = true
+				break // for loop
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	// the rows of the graphics region are offset from the base of the
+	// graphics table in the same way as the rows of the colour table
+	offset := (gr.Address - e.Result.InstructionData) & memorymap.AddressMaskCart
+	colors := make([]uint8, len(gr.Data))
+	for i := range colors {
+		a := colorBase + offset + uint16(i)
+		if a > memorymap.AddressMaskCart {
+			return nil
+		}
+		colors[i] = data[a]
+	}
+
+	return colors
+}
+
+// graphics sheet layout
+const (
+	graphicsScale   = 4
+	graphicsSpacing = 4
+	graphicsHeight  = 256
+)
+
+// GraphicsSheet draws every graphics region in the bank to an image. Regions
+// with a colour table are drawn with the palette, which should be the palette
+// of the television specification the cartridge is intended for. Returns nil
+// if there are no graphics regions in the bank.
+func (dsm *Disassembly) GraphicsSheet(bank int, palette colors.Palette) *image.RGBA {
+	regions := make([]GraphicsRegion, 0)
+	for _, gr := range dsm.Graphics {
+		if gr.Bank == bank {
+			regions = append(regions, gr)
+		}
+	}
+
+	if len(regions) == 0 {
+		return nil
+	}
+
+	// regions are drawn in columns, from left to right, wrapping to a new
+	// column when the column is full
+	type position struct{ x, y int }
+	positions := make([]position, len(regions))
+	x, y, w, h := graphicsSpacing, graphicsSpacing, 0, 0
+	for i, gr := range regions {
+		rh := len(gr.Data) * graphicsScale
+		if y > graphicsSpacing && y+rh > graphicsHeight*graphicsScale/2 {
+			x += 8*graphicsScale + graphicsSpacing
+			y = graphicsSpacing
+		}
+		positions[i] = position{x: x, y: y}
+		y += rh + graphicsSpacing
+		if y > h {
+			h = y
+		}
+	}
+	w = x + 8*graphicsScale + graphicsSpacing
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	bg := color.RGBA{R: 64, G: 64, B: 64, A: 255}
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			img.SetRGBA(px, py, bg)
+		}
+	}
+
+	for i, gr := range regions {
+		for row := range gr.Data {
+			fg := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			if gr.Colors != nil {
+				c := palette[gr.Colors[row]]
+				fg = color.RGBA{R: c.Red, G: c.Green, B: c.Blue, A: 255}
+			}
+			for bit := 0; bit < 8; bit++ {
+				col := color.RGBA{A: 255}
+				if gr.Pixel(row, bit) {
+					col = fg
+				}
+				for sy := 0; sy < graphicsScale; sy++ {
+					for sx := 0; sx < graphicsScale; sx++ {
+						img.SetRGBA(positions[i].x+bit*graphicsScale+sx, positions[i].y+row*graphicsScale+sy, col)
+					}
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// SaveGraphics writes a PNG graphics sheet for every bank that contains
+// graphics, drawn with the palette (see GraphicsSheet()). Filenames are formed
+// from the basename and the bank number. The names of the files written are
+// returned.
+func (dsm *Disassembly) SaveGraphics(basename string, palette colors.Palette) ([]string, error) {
+	files := make([]string, 0)
+
+	for b := range dsm.Entries {
+		img := dsm.GraphicsSheet(b, palette)
+		if img == nil {
+			continue
+		}
+
+		filename := fmt.Sprintf("%s_bank%d.png", basename, b)
+		f, err := os.Create(filename)
+		if err != nil {
+			return files, errors.New(errors.DisasmError, err)
+		}
+
+		err = png.Encode(f, img)
+		f.Close()
+		if err != nil {
+			return files, errors.New(errors.DisasmError, err)
+		}
+
+		files = append(files, filename)
+	}
+
+	return files, nil
+}
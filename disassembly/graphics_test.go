@@ -0,0 +1,110 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"gopher2600/television/colors"
+	"gopher2600/test"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGraphics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_disassembly")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := test.NewROM(4096)
+	copy(data, []byte{
+		0xa0, 0x07, // LDY #7
+		0xb9, 0x00, 0xf8, // LDA $F800,Y
+		0x85, 0x02, // STA WSYNC
+		0x85, 0x1b, // STA GRP0
+		0xb9, 0x08, 0xf8, // LDA $F808,Y
+		0x85, 0x06, // STA COLUP0
+		0x88,       // DEY
+		0x10, 0xf1, // BPL $F002
+		0x4c, 0x00, 0xf0, // JMP $F000
+	})
+
+	gfx := []byte{0x18, 0x3c, 0x7e, 0xff, 0xff, 0x7e, 0x3c, 0x18}
+	col := []byte{0x0e, 0x1e, 0x2e, 0x3e, 0x4e, 0x5e, 0x6e, 0x7e}
+	copy(data[0x800:], gfx)
+	copy(data[0x808:], col)
+
+	dsm := disassemble(t, data, "")
+
+	if len(dsm.Graphics) != 1 {
+		t.Fatalf("expected one graphics region (got %d)", len(dsm.Graphics))
+	}
+
+	gr := dsm.Graphics[0]
+	if gr.Register != "GRP0" || gr.Address != 0xf800 || gr.LoadedBy != 0xf002 {
+		t.Errorf("unexpected graphics region: %s", gr)
+	}
+	if string(gr.Data) != string(gfx) {
+		t.Errorf("unexpected graphics data: %v", gr.Data)
+	}
+	if string(gr.Colors) != string(col) {
+		t.Errorf("unexpected graphics colours: %v", gr.Colors)
+	}
+	if !gr.Pixel(0, 3) || gr.Pixel(0, 2) {
+		t.Errorf("unexpected pixels in first row of graphics")
+	}
+
+	// the colours of the graphics sheet depend on the palette
+	for _, pal := range []colors.Palette{colors.PaletteNTSC, colors.PalettePAL, colors.PaletteSECAM} {
+		img := dsm.GraphicsSheet(0, pal)
+		if img == nil {
+			t.Fatalf("no graphics sheet for bank 0")
+		}
+
+		found := make(map[color.RGBA]bool)
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				found[img.RGBAAt(x, y)] = true
+			}
+		}
+
+		for _, c := range col {
+			rgb := color.RGBA{R: pal[c].Red, G: pal[c].Green, B: pal[c].Blue, A: 255}
+			if !found[rgb] {
+				t.Errorf("colour %#02x not drawn as %v", c, rgb)
+			}
+		}
+	}
+
+	files, err := dsm.SaveGraphics(filepath.Join(dir, "graphics"), colors.PaletteNTSC)
+	if err != nil {
+		t.Fatalf("cannot save graphics: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected one graphics sheet (got %d)", len(files))
+	}
+	if _, err := os.Stat(files[0]); err != nil {
+		t.Errorf("graphics sheet not written: %v", err)
+	}
+}
@@ -28,12 +28,26 @@ import (
 // read cartridge memory.
 type disasmMemory struct {
 	cart *cartridge.Cartridge
+
+	// hotspots accessed since the last call to clearHotspots()
+	hotspots []cartridge.Hotspot
+}
+
+func (dismem *disasmMemory) clearHotspots() {
+	dismem.hotspots = dismem.hotspots[:0]
+}
+
+func (dismem *disasmMemory) noteHotspot(address uint16, write bool) {
+	if h, ok := dismem.cart.Hotspot(address, write); ok {
+		dismem.hotspots = append(dismem.hotspots, h)
+	}
 }
 
 func (dismem *disasmMemory) Read(address uint16) (uint8, error) {
 	// map address
 	if address&memorymap.OriginCart == memorymap.OriginCart {
 		address = address & memorymap.MemtopCart
+		dismem.noteHotspot(address, false)
 		return dismem.cart.Read(address)
 	}
 
@@ -50,13 +64,35 @@ func (dismem *disasmMemory) Write(address uint16, data uint8) error {
 	// map address
 	if address&memorymap.OriginCart == memorymap.OriginCart {
 		address = address & memorymap.MemtopCart
+		dismem.noteHotspot(address, true)
 		return dismem.cart.Write(address, data)
 	}
 
 	// address outside of cartidge range - call Listen() in case cartridge
 	// requires it to function correctly (tigervision cartridges bank switch on
 	// writes to certain addresses)
+	dismem.noteHotspot(address, true)
 	dismem.cart.Listen(address, data)
 
 	return nil
 }
+
+// bankData returns the contents of the cartridge address space with the
+// specified bank selected. the cartridge state should be saved before calling
+// this function and restored afterwards.
+func (dsm *Disassembly) bankData(bank int) ([]uint8, error) {
+	data := make([]uint8, 0, memorymap.AddressMaskCart+1)
+	for a := uint16(0); a <= memorymap.AddressMaskCart; a++ {
+		// setting the bank before every read because reading a hotspot
+		// address may have changed the bank
+		if err := dsm.cart.SetBank(memorymap.OriginCart|a, bank); err != nil {
+			return nil, err
+		}
+		v, err := dsm.cart.Read(memorymap.OriginCart | a)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, v)
+	}
+	return data, nil
+}
@@ -0,0 +1,496 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// the number of data bytes output on a single .byte line
+const reassembleDataWidth = 8
+
+// symbols from the symbols table must also be valid DASM identifiers
+var reassembleIdentifier = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// reassembleBank is the layout of a single cartridge bank in the reassembled
+// source.
+type reassembleBank struct {
+	bank int
+	data []uint8
+
+	// the index into the bank's entries where the bank's data begins. this
+	// is only ever non-zero for cartridge formats with banks smaller than 4k
+	idx uint16
+
+	// the address of the first byte of data, as seen by the program.
+	// written to the source as the RORG value
+	origin uint16
+
+	// instructions to be output, indexed by offset into data
+	code map[uint16]*Entry
+
+	// labels to be output, indexed by offset into data
+	labels map[uint16]string
+}
+
+// Reassemble writes DASM compatible source to output. The data argument
+// should be the cartridge data from which the disassembly was created; the
+// source will assemble to a binary identical to that data.
+//
+// Only instructions found during the flow pass, or seen during live
+// execution, are output as instructions. Everything else, including
+// instructions with undocumented opcodes, is output as .byte data. Data read
+// during live execution is commented with the instruction that read it.
+func (dsm *Disassembly) Reassemble(output io.Writer, data []byte) error {
+	numBanks := len(dsm.Entries)
+	if numBanks == 0 || len(data)%numBanks != 0 {
+		return errors.New(errors.DisasmError, "reassembly: cartridge data does not match disassembly")
+	}
+
+	bankSize := len(data) / numBanks
+	if bankSize > int(memorymap.AddressMaskCart)+1 {
+		return errors.New(errors.DisasmError, fmt.Sprintf("reassembly: unsupported bank size (%d bytes)", bankSize))
+	}
+
+	banks := make([]*reassembleBank, numBanks)
+	for b := range banks {
+		banks[b] = dsm.layoutBank(b, data[b*bankSize:(b+1)*bankSize])
+	}
+
+	// labels are chosen after the layout of every bank has been decided so
+	// that duplicate names can be avoided. the names of equates are added to
+	// the same set so that an equate never has the same name as a label
+	used := make(map[string]bool)
+	for _, bnk := range banks {
+		dsm.labelBank(bnk, used)
+	}
+
+	// equates for chip registers are collected on a first pass of the
+	// operands so that they can be written before any ORG directive
+	equates := make(map[string]uint16)
+	for _, bnk := range banks {
+		for _, o := range bnk.offsets() {
+			dsm.reassembleOperand(bnk, o, bnk.code[o], equates, used)
+		}
+	}
+
+	s := &strings.Builder{}
+	s.WriteString("; assemble with: dasm <file> -f3 -o<binary>\n")
+	s.WriteString("\tprocessor 6502\n\n")
+
+	names := make([]string, 0, len(equates))
+	for n := range equates {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if equates[names[i]] == equates[names[j]] {
+			return names[i] < names[j]
+		}
+		return equates[names[i]] < equates[names[j]]
+	})
+	for _, n := range names {
+		if equates[n] > 0xff {
+			s.WriteString(fmt.Sprintf("%s = $%04X\n", n, equates[n]))
+		} else {
+			s.WriteString(fmt.Sprintf("%s = $%02X\n", n, equates[n]))
+		}
+	}
+
+	for _, bnk := range banks {
+		s.WriteString(fmt.Sprintf("\n; bank %d\n", bnk.bank))
+		s.WriteString(fmt.Sprintf("\tORG $%04X\n", bnk.bank*bankSize))
+		s.WriteString(fmt.Sprintf("\tRORG $%04X\n", bnk.origin))
+		dsm.reassembleBank(s, bnk, equates, used)
+	}
+
+	if _, err := output.Write([]byte(s.String())); err != nil {
+		return errors.New(errors.DisasmError, err)
+	}
+
+	return nil
+}
+
+// layoutBank decides where the bank's data sits in the bank's entries and
+// which entries are to be output as instructions.
+func (dsm *Disassembly) layoutBank(bank int, data []uint8) *reassembleBank {
+	bnk := &reassembleBank{
+		bank:   bank,
+		data:   data,
+		code:   make(map[uint16]*Entry),
+		labels: make(map[uint16]string),
+	}
+
+	size := uint16(len(data))
+	slices := (memorymap.AddressMaskCart + 1) / size
+
+	// banks smaller than 4k can appear in more than one segment of the
+	// entries array. choose the segment in which the flow pass found the most
+	// instructions. the default for the last bank is the final segment
+	// because that's where the fixed bank of a segmented cartridge lives (and
+	// where the vectors of a 2k cartridge are).
+	if bank == len(dsm.Entries)-1 {
+		bnk.idx = (slices - 1) * size
+	}
+	best := dsm.countAnalysis(bank, bnk.idx, size)
+	for i := uint16(0); i < slices; i++ {
+		if c := dsm.countAnalysis(bank, i*size, size); c > best {
+			best = c
+			bnk.idx = i * size
+		}
+	}
+
+	// the origin is the most commonly seen upper address of the instructions
+	// in the flow pass
+	origins := make(map[uint16]int)
+	for o := uint16(0); o < size; o++ {
+		if e := dsm.Entries[bank][bnk.idx+o]; e != nil && e.Type >= EntryTypeAnalysis {
+			origins[e.Result.Address&^memorymap.AddressMaskCart]++
+		}
+	}
+	origin := uint16(0xf000)
+	best = 0
+	for k, v := range origins {
+		if v > best || (v == best && k > origin) {
+			best = v
+			origin = k
+		}
+	}
+	bnk.origin = origin | bnk.idx
+
+	// walk through data deciding which entries are to be output as
+	// instructions. overlapping instructions are not possible in the
+	// reassembled source so the first instruction wins.
+	//
+	// bytes that have been read as data but never executed during live
+	// execution are output as data even if the flow pass thought otherwise
+	for o := uint16(0); o < size; {
+		e := dsm.Entries[bank][bnk.idx+o]
+		if reassemblable(e, data[o:]) && !dsm.dataOnly(bank, bnk.idx+o) {
+			bnk.code[o] = e
+			o += uint16(e.Result.Defn.Bytes)
+		} else {
+			o++
+		}
+	}
+
+	return bnk
+}
+
+// offsets returns the offsets of the instructions in the bank, in order.
+func (bnk *reassembleBank) offsets() []uint16 {
+	offsets := make([]uint16, 0, len(bnk.code))
+	for o := range bnk.code {
+		offsets = append(offsets, o)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+// dataOnly returns true if the coverage information says that the byte has
+// been read as data but never executed.
+func (dsm *Disassembly) dataOnly(bank int, idx uint16) bool {
+	if dsm.Coverage == nil {
+		return false
+	}
+	flags := dsm.Coverage.Get(bank, idx).Flags
+	return flags&CoverageData == CoverageData && flags&(CoverageOpcode|CoverageOperand) == 0
+}
+
+// countAnalysis returns the number of entries found during the flow pass in
+// the specified range of the bank's entries.
+func (dsm *Disassembly) countAnalysis(bank int, idx uint16, size uint16) int {
+	c := 0
+	for o := idx; o < idx+size; o++ {
+		if e := dsm.Entries[bank][o]; e != nil && e.Type >= EntryTypeAnalysis {
+			c++
+		}
+	}
+	return c
+}
+
+// reassemblable returns true if the entry can be output as an instruction
+// that assembles to the bytes at the start of data.
+func reassemblable(e *Entry, data []uint8) bool {
+	if e == nil || e.Type < EntryTypeAnalysis || e.Result.Defn == nil {
+		return false
+	}
+
+	defn := e.Result.Defn
+
+	// undocumented instructions are given lower case mnemonics
+	if strings.ToUpper(defn.Mnemonic) != defn.Mnemonic {
+		return false
+	}
+
+	if e.Result.ByteCount != defn.Bytes || len(data) < defn.Bytes {
+		return false
+	}
+
+	if data[0] != defn.OpCode {
+		return false
+	}
+
+	switch defn.Bytes {
+	case 2:
+		return data[1] == uint8(e.Result.InstructionData)
+	case 3:
+		return data[1] == uint8(e.Result.InstructionData) && data[2] == uint8(e.Result.InstructionData>>8)
+	}
+
+	return true
+}
+
+// target returns the address referred to by a branch instruction or by an
+// instruction using absolute addressing. the address of a branch
+// instruction is calculated relative to the bank origin rather than the
+// address seen during the flow pass. this is because that is how the
+// assembler will calculate the branch offset.
+func (bnk *reassembleBank) target(o uint16, e *Entry) (uint16, bool) {
+	switch e.Result.Defn.AddressingMode {
+	case instructions.Relative:
+		t := int(bnk.origin) + int(o) + 2 + int(int8(e.Result.InstructionData))
+		if t < 0 || t > 0xffff {
+			return 0, false
+		}
+		return uint16(t), true
+
+	case instructions.Absolute, instructions.AbsoluteIndexedX,
+		instructions.AbsoluteIndexedY, instructions.Indirect:
+		return e.Result.InstructionData, true
+	}
+
+	return 0, false
+}
+
+// offset returns the offset into the bank data of an address. returns false
+// if the address is not in the bank or if it is not somewhere a label can be
+// placed.
+func (bnk *reassembleBank) offset(addr uint16) (uint16, bool) {
+	if addr < bnk.origin || int(addr) >= int(bnk.origin)+len(bnk.data) {
+		return 0, false
+	}
+
+	o := addr - bnk.origin
+
+	// a label can not be placed in the middle of an instruction
+	for i := uint16(1); i < 3 && i <= o; i++ {
+		if e, ok := bnk.code[o-i]; ok && uint16(e.Result.Defn.Bytes) > i {
+			return 0, false
+		}
+	}
+
+	return o, true
+}
+
+// labelBank adds a label for every address in the bank targeted by an
+// instruction in the same bank.
+func (dsm *Disassembly) labelBank(bnk *reassembleBank, used map[string]bool) {
+	for _, o := range bnk.offsets() {
+		t, ok := bnk.target(o, bnk.code[o])
+		if !ok {
+			continue
+		}
+
+		l, ok := bnk.offset(t)
+		if !ok {
+			continue
+		}
+
+		if _, ok := bnk.labels[l]; ok {
+			continue
+		}
+
+		name := ""
+		if dsm.Symtable != nil {
+			name, _ = dsm.Symtable.LocationSymbol(bnk.bank, t)
+		}
+		if name == "" || used[name] || !reassembleIdentifier.MatchString(name) {
+			name = fmt.Sprintf("L%d_%04X", bnk.bank, t)
+		}
+
+		used[name] = true
+		bnk.labels[l] = name
+	}
+}
+
+// reassembleOperand returns the operand of the instruction at offset o as it
+// should appear in the reassembled source. the second return value is true if
+// the absolute form of the instruction must be forced. any symbols used in
+// the operand are added to the equates map and to the set of used names.
+func (dsm *Disassembly) reassembleOperand(bnk *reassembleBank, o uint16, e *Entry, equates map[string]uint16, used map[string]bool) (string, bool) {
+	defn := e.Result.Defn
+	v := e.Result.InstructionData
+
+	var operand string
+
+	// addresses in the bank are given by label
+	if t, ok := bnk.target(o, e); ok {
+		if l, ok := bnk.offset(t); ok {
+			operand = bnk.labels[l]
+		}
+		if defn.AddressingMode == instructions.Relative {
+			if operand == "" {
+				operand = fmt.Sprintf("$%04X", t)
+			}
+			return operand, false
+		}
+	}
+
+	// other addresses may be given by a symbol. the symbol is only used if
+	// it does not conflict with a label or with an equate of a different
+	// value. the same name can appear in both the read and write tables
+	if operand == "" && defn.AddressingMode != instructions.Implied && defn.AddressingMode != instructions.Immediate && dsm.Symtable != nil {
+		var name string
+		switch defn.Effect {
+		case instructions.Read:
+			if dsm.Symtable.Read != nil {
+				name = dsm.Symtable.Read.Symbols[v]
+			}
+		case instructions.Write, instructions.RMW:
+			if dsm.Symtable.Write != nil {
+				name = dsm.Symtable.Write.Symbols[v]
+			}
+		}
+
+		if name != "" && reassembleIdentifier.MatchString(name) {
+			if ev, ok := equates[name]; ok && ev == v {
+				operand = name
+			} else if !ok && !used[name] {
+				used[name] = true
+				equates[name] = v
+				operand = name
+			}
+		}
+	}
+
+	switch defn.AddressingMode {
+	case instructions.Implied:
+		return "", false
+	case instructions.Immediate:
+		return fmt.Sprintf("#$%02X", v), false
+	}
+
+	if operand == "" {
+		switch defn.AddressingMode {
+		case instructions.ZeroPage, instructions.IndexedZeroPageX, instructions.IndexedZeroPageY,
+			instructions.PreIndexedIndirect, instructions.PostIndexedIndirect:
+			operand = fmt.Sprintf("$%02X", v)
+		default:
+			operand = fmt.Sprintf("$%04X", v)
+		}
+	}
+
+	// absolute addresses in the zero page must be forced to use the absolute
+	// form of the instruction or the assembler will choose the zero page form
+	force := v <= 0xff && defn.AddressingMode != instructions.Indirect
+
+	switch defn.AddressingMode {
+	case instructions.Absolute:
+		return operand, force
+	case instructions.AbsoluteIndexedX:
+		return operand + ",X", force
+	case instructions.AbsoluteIndexedY:
+		return operand + ",Y", force
+	case instructions.Indirect:
+		return "(" + operand + ")", false
+	case instructions.IndexedZeroPageX:
+		return operand + ",X", false
+	case instructions.IndexedZeroPageY:
+		return operand + ",Y", false
+	case instructions.PreIndexedIndirect:
+		return "(" + operand + ",X)", false
+	case instructions.PostIndexedIndirect:
+		return "(" + operand + "),Y", false
+	}
+
+	return operand, false
+}
+
+// reassembleBank writes the source for a single bank.
+func (dsm *Disassembly) reassembleBank(s *strings.Builder, bnk *reassembleBank, equates map[string]uint16, used map[string]bool) {
+	var data []string
+
+	// the instruction that read the current run of data bytes, according to
+	// the coverage information
+	var reader string
+
+	flush := func() {
+		if len(data) > 0 {
+			s.WriteString(fmt.Sprintf("\t.byte %s\n", strings.Join(data, ",")))
+			data = data[:0]
+		}
+	}
+
+	for o := 0; o < len(bnk.data); {
+		if l, ok := bnk.labels[uint16(o)]; ok {
+			flush()
+			s.WriteString(fmt.Sprintf("%s\n", l))
+			reader = ""
+		}
+
+		e, ok := bnk.code[uint16(o)]
+		if !ok {
+			if dsm.Coverage != nil {
+				if r := dsm.Coverage.Get(bnk.bank, bnk.idx+uint16(o)).ReadBy(); r != reader {
+					flush()
+					if r != "" {
+						s.WriteString(fmt.Sprintf("; read by %s\n", r))
+					}
+					reader = r
+				}
+			}
+
+			data = append(data, fmt.Sprintf("$%02X", bnk.data[o]))
+			if len(data) == reassembleDataWidth {
+				flush()
+			}
+			o++
+			continue
+		}
+
+		flush()
+		reader = ""
+
+		mnemonic := e.Result.Defn.Mnemonic
+		operand, force := dsm.reassembleOperand(bnk, uint16(o), e, equates, used)
+		if force {
+			mnemonic = fmt.Sprintf("%s.w", mnemonic)
+		}
+		if e.Comment != "" {
+			s.WriteString(fmt.Sprintf("; %s\n", e.Comment))
+		}
+		if operand == "" {
+			s.WriteString(fmt.Sprintf("\t%s\n", mnemonic))
+		} else {
+			s.WriteString(fmt.Sprintf("\t%s %s\n", mnemonic, operand))
+		}
+
+		o += e.Result.Defn.Bytes
+	}
+
+	flush()
+}
@@ -0,0 +1,481 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"bytes"
+	"fmt"
+	"gopher2600/hardware/cpu/instructions"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// assembler is a minimal assembler, understanding only the subset of DASM
+// syntax produced by the Reassemble() function. the choice between zero page
+// and absolute addressing follows the rules used by DASM.
+type assembler struct {
+	opcodes map[string]map[instructions.AddressingMode]uint8
+	symbols map[string]int
+	output  []byte
+}
+
+func newAssembler(t *testing.T) *assembler {
+	t.Helper()
+
+	defns, err := instructions.GetDefinitions()
+	if err != nil {
+		t.Fatalf("cannot get instruction definitions: %v", err)
+	}
+
+	asm := &assembler{
+		opcodes: make(map[string]map[instructions.AddressingMode]uint8),
+		symbols: make(map[string]int),
+	}
+
+	for _, d := range defns {
+		if d == nil || strings.ToUpper(d.Mnemonic) != d.Mnemonic {
+			continue
+		}
+		if _, ok := asm.opcodes[d.Mnemonic]; !ok {
+			asm.opcodes[d.Mnemonic] = make(map[instructions.AddressingMode]uint8)
+		}
+		asm.opcodes[d.Mnemonic][d.AddressingMode] = d.OpCode
+	}
+
+	return asm
+}
+
+func (asm *assembler) value(s string, final bool) (int, error) {
+	if strings.HasPrefix(s, "$") {
+		v, err := strconv.ParseUint(s[1:], 16, 16)
+		return int(v), err
+	}
+	if v, ok := asm.symbols[s]; ok {
+		return v, nil
+	}
+	if final {
+		return 0, fmt.Errorf("unknown symbol: %s", s)
+	}
+
+	// unresolved forward references are assumed to be absolute
+	return 0xffff, nil
+}
+
+func (asm *assembler) assemble(source string) error {
+	for pass := 0; pass < 2; pass++ {
+		final := pass == 1
+		asm.output = asm.output[:0]
+		offset := 0
+		pc := 0
+
+		emit := func(b ...byte) {
+			for len(asm.output) < offset+len(b) {
+				asm.output = append(asm.output, 0)
+			}
+			copy(asm.output[offset:], b)
+			offset += len(b)
+			pc += len(b)
+		}
+
+		for n, line := range strings.Split(source, "\n") {
+			if i := strings.Index(line, ";"); i >= 0 {
+				line = line[:i]
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			// equates and labels
+			if line[0] != '\t' && line[0] != ' ' {
+				f := strings.Fields(line)
+				if len(f) == 3 && f[1] == "=" {
+					v, err := asm.value(f[2], true)
+					if err != nil {
+						return fmt.Errorf("line %d: %v", n+1, err)
+					}
+					asm.symbols[f[0]] = v
+				} else {
+					asm.symbols[f[0]] = pc
+				}
+				continue
+			}
+
+			f := strings.Fields(line)
+			mnemonic := strings.ToUpper(f[0])
+			// symbols are case sensitive, as they are in DASM
+			operand := ""
+			if len(f) > 1 {
+				operand = f[1]
+			}
+
+			switch mnemonic {
+			case "PROCESSOR":
+				continue
+			case "ORG", "RORG":
+				v, err := asm.value(operand, true)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", n+1, err)
+				}
+				if mnemonic == "ORG" {
+					offset = v
+				}
+				pc = v
+				continue
+			case ".BYTE":
+				for _, s := range strings.Split(operand, ",") {
+					v, err := asm.value(s, true)
+					if err != nil {
+						return fmt.Errorf("line %d: %v", n+1, err)
+					}
+					emit(byte(v))
+				}
+				continue
+			}
+
+			force := strings.HasSuffix(mnemonic, ".W")
+			mnemonic = strings.TrimSuffix(mnemonic, ".W")
+
+			modes, ok := asm.opcodes[mnemonic]
+			if !ok {
+				return fmt.Errorf("line %d: unknown mnemonic: %s", n+1, mnemonic)
+			}
+
+			// operand syntax decides the addressing mode with the exception
+			// of the zero page/absolute choice
+			var zp, abs instructions.AddressingMode
+			expr := operand
+			switch {
+			case operand == "":
+				emit(modes[instructions.Implied])
+				continue
+			case strings.HasPrefix(operand, "#"):
+				v, err := asm.value(operand[1:], true)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", n+1, err)
+				}
+				emit(modes[instructions.Immediate], byte(v))
+				continue
+			case strings.HasSuffix(operand, ",X)"):
+				zp, abs = instructions.PreIndexedIndirect, instructions.PreIndexedIndirect
+				expr = operand[1 : len(operand)-3]
+			case strings.HasSuffix(operand, "),Y"):
+				zp, abs = instructions.PostIndexedIndirect, instructions.PostIndexedIndirect
+				expr = operand[1 : len(operand)-3]
+			case strings.HasPrefix(operand, "("):
+				zp, abs = instructions.Indirect, instructions.Indirect
+				expr = operand[1 : len(operand)-1]
+			case strings.HasSuffix(operand, ",X"):
+				zp, abs = instructions.IndexedZeroPageX, instructions.AbsoluteIndexedX
+				expr = operand[:len(operand)-2]
+			case strings.HasSuffix(operand, ",Y"):
+				zp, abs = instructions.IndexedZeroPageY, instructions.AbsoluteIndexedY
+				expr = operand[:len(operand)-2]
+			default:
+				zp, abs = instructions.ZeroPage, instructions.Absolute
+			}
+
+			v, err := asm.value(expr, final)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", n+1, err)
+			}
+
+			if op, ok := modes[instructions.Relative]; ok {
+				emit(op, byte(v-(pc+2)))
+				continue
+			}
+
+			if op, ok := modes[zp]; ok && (zp == abs || (!force && v <= 0xff)) {
+				if zp == instructions.Indirect {
+					emit(op, byte(v), byte(v>>8))
+				} else {
+					emit(op, byte(v))
+				}
+				continue
+			}
+
+			op, ok := modes[abs]
+			if !ok {
+				return fmt.Errorf("line %d: unsupported addressing mode: %s", n+1, line)
+			}
+			emit(op, byte(v), byte(v>>8))
+		}
+	}
+
+	return nil
+}
+
+// assemble the source with the minimal assembler
+func assemble(t *testing.T, source string) []byte {
+	t.Helper()
+
+	asm := newAssembler(t)
+	if err := asm.assemble(source); err != nil {
+		t.Fatalf("cannot assemble source: %v", err)
+	}
+
+	return asm.output
+}
+
+// assemble the source with DASM
+func assembleDASM(t *testing.T, source string) []byte {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "gopher2600_disassembly")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "reassemble.asm")
+	if err := ioutil.WriteFile(src, []byte(source), 0600); err != nil {
+		t.Fatalf("cannot create source file: %v", err)
+	}
+
+	bin := filepath.Join(dir, "reassemble.bin")
+	out, err := exec.Command("dasm", src, "-f3", "-o"+bin).CombinedOutput()
+	if err != nil {
+		t.Fatalf("dasm failed: %v\n%s", err, out)
+	}
+
+	data, err := ioutil.ReadFile(bin)
+	if err != nil {
+		t.Fatalf("cannot read assembled ROM: %v", err)
+	}
+
+	return data
+}
+
+// roundTrip disassembles then reassembles the data, comparing the assembled
+// result with the original data.
+func roundTrip(t *testing.T, data []byte, assemble func(*testing.T, string) []byte) string {
+	t.Helper()
+
+	dsm := disassemble(t, data, "")
+
+	source := &strings.Builder{}
+	if err := dsm.Reassemble(source, data); err != nil {
+		t.Fatalf("cannot reassemble ROM: %v", err)
+	}
+
+	output := assemble(t, source.String())
+
+	if !bytes.Equal(output, data) {
+		for i := range data {
+			if i >= len(output) || output[i] != data[i] {
+				t.Fatalf("reassembled ROM differs from original at offset %#04x", i)
+			}
+		}
+		t.Fatalf("reassembled ROM is longer than original (%d bytes)", len(output))
+	}
+
+	return source.String()
+}
+
+// program exercising branches, subroutines, zero page addresses accessed
+// with absolute addressing, and an undocumented opcode
+var program = []byte{
+	0xa2, 0x10, // LDX #$10
+	0x85, 0x02, // STA WSYNC
+	0x8d, 0x80, 0x00, // STA.w $0080
+	0xca,       // DEX
+	0xd0, 0xf8, // BNE $F002
+	0x20, 0x13, 0xf0, // JSR $F013
+	0x04, 0x80, // nop $80 (undocumented)
+	0x4c, 0x00, 0xf0, // JMP $F000
+	0xea,             // NOP
+	0xbd, 0x00, 0xf8, // LDA $F800,X
+	0x60, // RTS
+}
+
+func fill(data []byte) {
+	r := rand.New(rand.NewSource(2600))
+	for i := range data {
+		data[i] = byte(r.Intn(256))
+	}
+}
+
+// the ROMs used by the reassembly tests. each function also returns the
+// strings expected in the reassembled source
+var roms = map[string]func() ([]byte, []string){
+	"4k": func() ([]byte, []string) {
+		data := make([]byte, 4096)
+		fill(data)
+		copy(data, program)
+		data[0xffc] = 0x00
+		data[0xffd] = 0xf0
+		return data, []string{"WSYNC = $02", "STA WSYNC", "STA.w $0080", "BNE L0_F002", "JSR L0_F013", "LDA L0_F800,X"}
+	},
+
+	"2k": func() ([]byte, []string) {
+		data := make([]byte, 2048)
+		fill(data)
+
+		// the 2k program runs from $F800 so the addresses in the program
+		// need adjusting
+		p := append([]byte{}, program...)
+		p[12] = 0xf8
+		p[17] = 0xf8
+		copy(data, p)
+		data[0x7fc] = 0x00
+		data[0x7fd] = 0xf8
+		return data, []string{"RORG $F800"}
+	},
+
+	"8k": func() ([]byte, []string) {
+		data := make([]byte, 8192)
+		fill(data)
+
+		// the program is in the second bank and the first bank has nothing
+		// but a reset vector pointing to a switch to the second bank
+		copy(data[0x1000:], program)
+		data[0x1ffc] = 0x00
+		data[0x1ffd] = 0xf0
+		copy(data[0xff0:], []byte{0xad, 0xf9, 0xff}) // LDA $FFF9
+		data[0xffc] = 0xf0
+		data[0xffd] = 0xff
+		return data, []string{"ORG $1000"}
+	},
+}
+
+func testReassemble(t *testing.T, name string, assemble func(*testing.T, string) []byte) {
+	t.Helper()
+
+	data, expected := roms[name]()
+	source := roundTrip(t, data, assemble)
+
+	for _, s := range expected {
+		if !strings.Contains(source, s) {
+			t.Errorf("expected %q in reassembled source", s)
+		}
+	}
+}
+
+func TestReassemble_4k(t *testing.T) {
+	testReassemble(t, "4k", assemble)
+}
+
+func TestReassemble_2k(t *testing.T) {
+	testReassemble(t, "2k", assemble)
+}
+
+func TestReassemble_8k(t *testing.T) {
+	testReassemble(t, "8k", assemble)
+}
+
+// a name that appears in the locations table and in the write table must
+// only be used once in the reassembled source
+func TestReassemble_duplicateSymbol(t *testing.T) {
+	data, _ := roms["4k"]()
+	dsm := disassemble(t, data, "")
+
+	dsm.Symtable.AddSymbol(0, 0xf013, "update")
+	dsm.Symtable.Write.Symbols[0x0080] = "update"
+
+	source := &strings.Builder{}
+	if err := dsm.Reassemble(source, data); err != nil {
+		t.Fatalf("cannot reassemble ROM: %v", err)
+	}
+
+	if output := assemble(t, source.String()); !bytes.Equal(output, data) {
+		t.Errorf("reassembled ROM differs from original")
+	}
+
+	for _, s := range []string{"JSR update", "STA.w $0080"} {
+		if !strings.Contains(source.String(), s) {
+			t.Errorf("expected %q in reassembled source", s)
+		}
+	}
+	if strings.Contains(source.String(), "update = ") {
+		t.Errorf("unexpected equate for location label")
+	}
+}
+
+// readGolden returns the golden ROM and the DASM source that assembles to it
+func readGolden(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile("testdata/reassemble_2k.bin")
+	if err != nil {
+		t.Fatalf("cannot read golden ROM: %v", err)
+	}
+
+	source, err := ioutil.ReadFile("testdata/reassemble_2k.asm")
+	if err != nil {
+		t.Fatalf("cannot read golden source: %v", err)
+	}
+
+	return data, string(source)
+}
+
+// the reassembled source of the golden ROM must be identical to the golden
+// source. unlike the tests above, this test does not rely on the minimal
+// assembler
+func TestReassemble_golden(t *testing.T) {
+	data, golden := readGolden(t)
+	dsm := disassemble(t, data, "")
+
+	source := &strings.Builder{}
+	if err := dsm.Reassemble(source, data); err != nil {
+		t.Fatalf("cannot reassemble ROM: %v", err)
+	}
+
+	got := strings.Split(source.String(), "\n")
+	expected := strings.Split(golden, "\n")
+	for i := range expected {
+		if i >= len(got) {
+			t.Fatalf("reassembled source is shorter than golden source (%d lines)", len(got))
+		}
+		if got[i] != expected[i] {
+			t.Fatalf("line %d: expected %q but got %q", i+1, expected[i], got[i])
+		}
+	}
+	if len(got) > len(expected) {
+		t.Fatalf("reassembled source is longer than golden source (%d lines)", len(got))
+	}
+}
+
+// the minimal assembler above only understands what Reassemble() is expected
+// to produce. assembling with DASM itself makes sure that the output really is
+// valid DASM source. the test is skipped if DASM is not installed
+func TestReassemble_dasm(t *testing.T) {
+	if _, err := exec.LookPath("dasm"); err != nil {
+		t.Skip("dasm not found in PATH")
+	}
+
+	for _, name := range []string{"4k", "2k", "8k"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			testReassemble(t, name, assembleDASM)
+		})
+	}
+
+	t.Run("golden", func(t *testing.T) {
+		data, golden := readGolden(t)
+		if output := assembleDASM(t, golden); !bytes.Equal(output, data) {
+			t.Errorf("golden source does not assemble to golden ROM")
+		}
+	})
+}
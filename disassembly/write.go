@@ -99,6 +99,21 @@ func (dsm *Disassembly) WriteEntry(output io.Writer, attr WriteAttr, e *Entry) {
 				output.Write([]byte(fmt.Sprintf("%#04x ", e.Prev[i])))
 			}
 		}
+
+		if len(e.NextBank) > 0 {
+			output.Write([]byte(" => bank "))
+			for i := range e.NextBank {
+				output.Write([]byte(fmt.Sprintf("%d ", e.NextBank[i])))
+			}
+		}
+
+		if e.CrossBank {
+			output.Write([]byte(" <= bank switch"))
+		}
+	}
+
+	if e.Comment != "" {
+		output.Write([]byte(fmt.Sprintf(" ; %s", e.Comment)))
 	}
 
 	output.Write([]byte("\n"))
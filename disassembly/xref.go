@@ -0,0 +1,330 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	"fmt"
+	"gopher2600/hardware/cpu/execution"
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"sort"
+)
+
+// XrefType describes how an address is referenced by an instruction
+type XrefType int
+
+// List of valid XrefTypes
+const (
+	XrefRead XrefType = iota
+	XrefWrite
+	XrefJump
+	XrefBranch
+)
+
+func (t XrefType) String() string {
+	switch t {
+	case XrefRead:
+		return "read"
+	case XrefWrite:
+		return "write"
+	case XrefJump:
+		return "jump"
+	case XrefBranch:
+		return "branch"
+	}
+	return ""
+}
+
+// Xref is a reference to an address by a single instruction. The reference
+// may have been found by static analysis of the disassembly, or by
+// observation during emulation, or both.
+type Xref struct {
+	Type XrefType
+
+	// the bank and address of the referring instruction
+	Bank int
+	From uint16
+
+	// instruction details
+	Mnemonic string
+	Mode     instructions.AddressingMode
+
+	// reference found in the disassembly
+	Static bool
+
+	// number of times the reference has been seen during emulation
+	Count int
+}
+
+func (x Xref) String() string {
+	s := fmt.Sprintf("%-6s %#04x [bank %d] %s %s", x.Type, x.From, x.Bank, x.Mnemonic, modeSyntax[x.Mode])
+	switch {
+	case x.Static && x.Count > 0:
+		return fmt.Sprintf("%s (static, runtime x%d)", s, x.Count)
+	case x.Static:
+		return fmt.Sprintf("%s (static)", s)
+	}
+	return fmt.Sprintf("%s (runtime x%d)", s, x.Count)
+}
+
+// the key identifying an Xref for a referenced address
+type xrefKey struct {
+	typ  XrefType
+	bank int
+	from uint16
+}
+
+func (x Xref) key() xrefKey {
+	return xrefKey{typ: x.Type, bank: x.Bank, from: x.From & memorymap.AddressMaskCart}
+}
+
+// Xrefs records references to addresses observed during emulation. The
+// addresses are normalised with memorymap.MapAddress()
+type Xrefs struct {
+	refs map[uint16]map[xrefKey]*Xref
+}
+
+// NewXrefs is the preferred method of initialisation for the Xrefs type
+func NewXrefs() *Xrefs {
+	return &Xrefs{refs: make(map[uint16]map[xrefKey]*Xref)}
+}
+
+// Clear forgets all runtime references
+func (xr *Xrefs) Clear() {
+	xr.refs = make(map[uint16]map[xrefKey]*Xref)
+}
+
+func (xr *Xrefs) add(address uint16, x Xref) {
+	m, ok := xr.refs[address]
+	if !ok {
+		m = make(map[xrefKey]*Xref)
+		xr.refs[address] = m
+	}
+
+	k := x.key()
+	if e, ok := m[k]; ok {
+		e.Count++
+		return
+	}
+
+	x.Count = 1
+	m[k] = &x
+}
+
+// Referenced should be called for every memory access made by an instruction,
+// other than the reading of the instruction itself. The bank is the bank the
+// instruction was executed from.
+func (dsm *Disassembly) Referenced(address uint16, write bool, bank int, result execution.Result) {
+	if result.Defn == nil {
+		return
+	}
+
+	t := XrefRead
+	if write {
+		t = XrefWrite
+	}
+
+	mapped, _ := memorymap.MapAddress(address, !write)
+	dsm.RuntimeXrefs.add(mapped, Xref{
+		Type:     t,
+		Bank:     bank,
+		From:     result.Address,
+		Mnemonic: result.Defn.Mnemonic,
+		Mode:     result.Defn.AddressingMode,
+	})
+}
+
+// Flowed should be called after every instruction with the value of the
+// program counter. Taken branches, jumps and subroutine calls are recorded
+// as references to the new program counter.
+func (dsm *Disassembly) Flowed(pc uint16, bank int, result execution.Result) {
+	t, ok := flowType(result)
+	if !ok {
+		return
+	}
+
+	// branches that were not taken are not references
+	if t == XrefBranch && pc == result.Address+uint16(result.Defn.Bytes) {
+		return
+	}
+
+	mapped, _ := memorymap.MapAddress(pc, true)
+	dsm.RuntimeXrefs.add(mapped, Xref{
+		Type:     t,
+		Bank:     bank,
+		From:     result.Address,
+		Mnemonic: result.Defn.Mnemonic,
+		Mode:     result.Defn.AddressingMode,
+	})
+}
+
+// flowType returns the type of reference made by a flow instruction. returns
+// false if the instruction is not a flow instruction or if the destination
+// of the instruction is not known from the instruction alone (RTS, RTI, BRK)
+func flowType(result execution.Result) (XrefType, bool) {
+	if result.Defn == nil {
+		return 0, false
+	}
+
+	switch result.Defn.Effect {
+	case instructions.Flow:
+		if result.Defn.IsBranch() {
+			return XrefBranch, true
+		}
+		return XrefJump, true
+	case instructions.Subroutine:
+		if result.Defn.AddressingMode == instructions.Absolute {
+			return XrefJump, true
+		}
+	}
+
+	return 0, false
+}
+
+// staticXrefs returns the address referenced by the disassembly entry, along
+// with the type of reference. RMW instructions are both a read and a write of
+// the address. indirect addressing modes reference the pointer address.
+func staticXrefs(e *Entry) ([]XrefType, uint16) {
+	defn := e.Result.Defn
+	if defn == nil {
+		return nil, 0
+	}
+
+	if t, ok := flowType(e.Result); ok {
+		switch defn.AddressingMode {
+		case instructions.Relative:
+			operand := e.Result.InstructionData
+			if operand&0x0080 == 0x0080 {
+				operand |= 0xff00
+			}
+			return []XrefType{t}, e.Result.Address + uint16(defn.Bytes) + operand
+		case instructions.Indirect:
+			// the pointer of an indirect JMP is read
+			return []XrefType{XrefRead}, e.Result.InstructionData
+		}
+		return []XrefType{t}, e.Result.InstructionData
+	}
+
+	switch defn.AddressingMode {
+	case instructions.Implied, instructions.Immediate, instructions.Relative:
+		return nil, 0
+	case instructions.PreIndexedIndirect, instructions.PostIndexedIndirect:
+		return []XrefType{XrefRead}, e.Result.InstructionData
+	}
+
+	switch defn.Effect {
+	case instructions.Read:
+		return []XrefType{XrefRead}, e.Result.InstructionData
+	case instructions.Write:
+		return []XrefType{XrefWrite}, e.Result.InstructionData
+	case instructions.RMW:
+		return []XrefType{XrefRead, XrefWrite}, e.Result.InstructionData
+	}
+
+	return nil, 0
+}
+
+// Xrefs returns every reference to the address, from both the disassembly and
+// from runtime observation. Only disassembly entries found by the flow pass
+// (or during execution) are considered.
+//
+// The address is normalised with memorymap.MapAddress() before comparison, so
+// all mirrors of the address are considered. Note that the normalisation is
+// different for reads and writes of TIA and RIOT registers.
+func (dsm *Disassembly) Xrefs(address uint16) []Xref {
+	mappedRead, _ := memorymap.MapAddress(address, true)
+	mappedWrite, _ := memorymap.MapAddress(address, false)
+
+	// match returns true if the reference type and address refer to the
+	// requested address
+	match := func(t XrefType, a uint16) bool {
+		if t == XrefWrite {
+			m, _ := memorymap.MapAddress(a, false)
+			return m == mappedWrite
+		}
+		m, _ := memorymap.MapAddress(a, true)
+		return m == mappedRead
+	}
+
+	xrefs := make(map[xrefKey]*Xref)
+
+	for b := range dsm.Entries {
+		for _, e := range dsm.Entries[b] {
+			if e == nil || e.Type < EntryTypeAnalysis {
+				continue // for loop
+			}
+
+			types, a := staticXrefs(e)
+			for _, t := range types {
+				if !match(t, a) {
+					continue // for loop
+				}
+				x := &Xref{
+					Type:     t,
+					Bank:     b,
+					From:     e.Result.Address,
+					Mnemonic: e.Result.Defn.Mnemonic,
+					Mode:     e.Result.Defn.AddressingMode,
+					Static:   true,
+				}
+				xrefs[x.key()] = x
+			}
+		}
+	}
+
+	if dsm.RuntimeXrefs != nil {
+		for _, m := range []uint16{mappedRead, mappedWrite} {
+			for k, r := range dsm.RuntimeXrefs.refs[m] {
+				if !match(k.typ, m) {
+					continue // for loop
+				}
+				if x, ok := xrefs[k]; ok {
+					x.Count = r.Count
+				} else {
+					x := *r
+					xrefs[k] = &x
+				}
+			}
+
+			// no need to look again if the read and write addresses are the
+			// same
+			if mappedRead == mappedWrite {
+				break // for loop
+			}
+		}
+	}
+
+	l := make([]Xref, 0, len(xrefs))
+	for _, x := range xrefs {
+		l = append(l, *x)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Type != l[j].Type {
+			return l[i].Type < l[j].Type
+		}
+		if l[i].Bank != l[j].Bank {
+			return l[i].Bank < l[j].Bank
+		}
+		return l[i].From < l[j].From
+	})
+
+	return l
+}
@@ -0,0 +1,101 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly_test
+
+import (
+	"gopher2600/test"
+	"testing"
+)
+
+func TestXrefs(t *testing.T) {
+	data := test.NewROM(4096)
+	copy(data, []byte{
+		0xa5, 0x80, // LDA $80
+		0x85, 0x02, // STA WSYNC
+		0xe6, 0x80, // INC $80
+		0xd0, 0xf8, // BNE $F000
+		0x20, 0x10, 0xf0, // JSR $F010
+		0x4c, 0x00, 0xf0, // JMP $F000
+	})
+	data[0x10] = 0x60 // RTS
+
+	dsm := disassemble(t, data, "")
+
+	expect := func(address uint16, expected ...string) {
+		t.Helper()
+		xrefs := dsm.Xrefs(address)
+		if len(xrefs) != len(expected) {
+			t.Fatalf("expected %d references to %#04x (got %d)", len(expected), address, len(xrefs))
+		}
+		for i := range xrefs {
+			if xrefs[i].String() != expected[i] {
+				t.Errorf("unexpected reference to %#04x: %q (expected %q)", address, xrefs[i], expected[i])
+			}
+		}
+	}
+
+	expect(0x80,
+		"read   0xf000 [bank 0] LDA zp (static)",
+		"read   0xf004 [bank 0] INC zp (static)",
+		"write  0xf004 [bank 0] INC zp (static)",
+	)
+
+	// mirrors of the address are found
+	expect(0x0102,
+		"write  0xf002 [bank 0] STA zp (static)",
+	)
+
+	expect(0xf000,
+		"jump   0xf00b [bank 0] JMP abs (static)",
+		"branch 0xf006 [bank 0] BNE rel (static)",
+	)
+
+	expect(0x1010,
+		"jump   0xf008 [bank 0] JSR abs (static)",
+	)
+
+	// runtime references are merged with static references
+	e, _ := dsm.Get(0, 0xf000)
+	dsm.Referenced(0x0080, false, 0, e.Result)
+	dsm.Referenced(0x0080, false, 0, e.Result)
+
+	e, _ = dsm.Get(0, 0xf00b)
+	dsm.Referenced(0x0090, true, 0, e.Result)
+
+	expect(0x80,
+		"read   0xf000 [bank 0] LDA zp (static, runtime x2)",
+		"read   0xf004 [bank 0] INC zp (static)",
+		"write  0xf004 [bank 0] INC zp (static)",
+	)
+
+	expect(0x90,
+		"write  0xf00b [bank 0] JMP abs (runtime x1)",
+	)
+
+	// branches that are not taken are not references
+	e, _ = dsm.Get(0, 0xf006)
+	dsm.Flowed(0xf008, 0, e.Result)
+	expect(0xf008)
+	dsm.Flowed(0xf000, 0, e.Result)
+	expect(0xf000,
+		"jump   0xf00b [bank 0] JMP abs (static)",
+		"branch 0xf006 [bank 0] BNE rel (static, runtime x1)",
+	)
+}
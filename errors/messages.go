@@ -44,6 +44,7 @@ const (
 	TerminalError   = "%v"
 	GUIEventError   = "%v"
 	BreakpointError = "breakpoint error: %v"
+	ProjectError    = "project error: %v"
 
 	// commandline
 	ParserError     = "parser error: %v"
@@ -54,6 +55,7 @@ const (
 	DisasmError    = "disasm error: %v"
 	AnalysisError  = "disasm analysis error: %v"
 	IterationError = "disasm iteration error: %v"
+	CoverageError  = "disasm coverage error: %v"
 
 	// script
 	ScriptFileError       = "script error: %v"
@@ -78,15 +80,28 @@ const (
 	RegressionDigestError   = "digest entry: %v"
 	RegressionPlaybackError = "playback entry: %v"
 
+	// accuracy
+	AccuracyError = "accuracy error: %v"
+
+	// render
+	RenderError = "render error: %v"
+
+	// screenshot
+	ScreenshotError = "screenshot error: %v"
+
 	// setup
 	SetupError           = "setup error: %v"
 	SetupPanelError      = "panel setup: %v"
 	SetupPatchError      = "patch setup: %v"
 	SetupTelevisionError = "tv setup: %v"
+	SetupControllerError = "controller setup: %v"
 
 	// patch
 	PatchError = "patch error: %v"
 
+	// ramsearch
+	RAMSearchError = "ram search: %v"
+
 	// symbols
 	SymbolsFileError       = "symbols error: error processing symbols file: %v"
 	SymbolsFileUnavailable = "symbols error: no symbols file for %v"
@@ -117,8 +132,11 @@ const (
 	UnpatchableCartType = "cartridge error: cannot patch this cartridge type (%v)"
 
 	// input
-	UnknownInputEvent = "input error: %v: unsupported event (%v)"
-	BadInputEventType = "input error: bad value type for event %v (expecting %s)"
+	UnknownInputEvent     = "input error: %v: unsupported event (%v)"
+	BadInputEventType     = "input error: bad value type for event %v (expecting %s)"
+	UnknownControllerType = "input error: unknown controller type (%v)"
+	BindingsError         = "input error: bindings: %v"
+	KidVidError           = "input error: kid vid: %v"
 
 	// television
 	UnknownTVRequest = "television error: unsupported request (%v)"
@@ -131,6 +149,9 @@ const (
 	// audio2wav
 	WavWriter = "wav writer: %v"
 
+	// resampler
+	ResamplerError = "resampler: %v"
+
 	// gui
 	UnsupportedGUIRequest = "gui error: unsupported request (%v)"
 	SDLDebug              = "sdldebug: %v"
@@ -21,6 +21,7 @@ package main
 
 import (
 	"fmt"
+	"gopher2600/accuracy"
 	"gopher2600/cartridgeloader"
 	"gopher2600/debugger"
 	"gopher2600/debugger/terminal"
@@ -38,11 +39,15 @@ import (
 	"gopher2600/playmode"
 	"gopher2600/recorder"
 	"gopher2600/regression"
+	"gopher2600/render"
+	"gopher2600/resampler"
 	"gopher2600/television"
+	"gopher2600/television/colors"
 	"gopher2600/wavwriter"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 )
 
@@ -177,7 +182,7 @@ func launch(sync *mainSync) {
 	md := &modalflag.Modes{Output: os.Stdout}
 	md.NewArgs(os.Args[1:])
 	md.NewMode()
-	md.AddSubModes("RUN", "PLAY", "DEBUG", "DISASM", "PERFORMANCE", "REGRESS")
+	md.AddSubModes("RUN", "PLAY", "DEBUG", "DISASM", "PERFORMANCE", "REGRESS", "TEST", "RENDER")
 
 	p, err := md.Parse()
 	switch p {
@@ -206,6 +211,12 @@ func launch(sync *mainSync) {
 
 	case "REGRESS":
 		err = regress(md)
+
+	case "TEST":
+		err = accuracyTest(md)
+
+	case "RENDER":
+		err = renderPlayback(md)
 	}
 
 	if err != nil {
@@ -218,13 +229,17 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 	md.NewMode()
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL, PAL60, SECAM or AUTO (AUTO-PAL and AUTO-SECAM give a color hint)")
 	scaling := md.AddFloat64("scale", 3.0, "television scaling")
 	stable := md.AddBool("stable", true, "wait for stable frame before opening display")
 	fpsCap := md.AddBool("fpscap", true, "cap fps to specification")
+	crtSync := md.AddBool("crtsync", false, "emulate how a CRT television synchronises with the VCS")
 	record := md.AddBool("record", false, "record user input to a file")
 	wav := md.AddString("wav", "", "record audio to wav file")
+	stereo := md.AddBool("stereo", false, "pan TIA audio channels left and right")
+	lowPass := md.AddBool("lowpass", false, "apply low-pass filter to audio")
 	patchFile := md.AddString("patch", "", "patch file to apply (cartridge args only)")
+	skip := md.AddInt("skip", 0, "skip forward to frame before display (playback files only)")
 
 	p, err := md.Parse()
 	if p != modalflag.ParseContinue {
@@ -249,9 +264,16 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 		// set fps cap
 		tv.SetFPSCap(*fpsCap)
 
+		// set CRT sync model
+		tv.SetCRTSync(*crtSync)
+
 		// add wavwriter mixer if wav argument has been specified
 		if *wav != "" {
-			aw, err := wavwriter.New(*wav)
+			aw, err := wavwriter.NewResampled(*wav, resampler.Options{
+				Rate:    resampler.Rate48000,
+				Stereo:  *stereo,
+				LowPass: *lowPass,
+			})
 			if err != nil {
 				return errors.New(errors.PlayError, err)
 			}
@@ -282,7 +304,17 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 			return err
 		}
 
-		err = playmode.Play(tv, scr, *stable, *record, cartload, *patchFile)
+		// audio options
+		err = scr.SetFeature(gui.ReqSetAudioStereo, *stereo)
+		if err != nil {
+			return err
+		}
+		err = scr.SetFeature(gui.ReqSetAudioLowPass, *lowPass)
+		if err != nil {
+			return err
+		}
+
+		err = playmode.Play(tv, scr, *stable, *record, cartload, *patchFile, *skip)
 		if err != nil {
 			return err
 		}
@@ -306,8 +338,9 @@ func debug(md *modalflag.Modes, sync *mainSync) error {
 	}
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL, PAL60, SECAM or AUTO (AUTO-PAL and AUTO-SECAM give a color hint)")
 	termType := md.AddString("term", "IMGUI", "terminal type to use in debug mode: IMGUI, COLOR, PLAIN")
+	crtSync := md.AddBool("crtsync", false, "emulate how a CRT television synchronises with the VCS")
 	initScript := md.AddString("initscript", defInitScript, "script to run on debugger start")
 	profile := md.AddBool("profile", false, "run debugger through cpu profiler")
 
@@ -322,6 +355,8 @@ func debug(md *modalflag.Modes, sync *mainSync) error {
 	}
 	defer tv.End()
 
+	tv.SetCRTSync(*crtSync)
+
 	var term terminal.Terminal
 
 	// decide which gui to use
@@ -427,6 +462,11 @@ func disasm(md *modalflag.Modes) error {
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
 	bytecode := md.AddBool("bytecode", false, "include bytecode in disassembly")
+	reassemble := md.AddBool("reassemble", false, "output DASM compatible source")
+	graphics := md.AddBool("graphics", false, "write PNG sheets of graphics data for each bank")
+	spec := md.AddString("tv", "NTSC", "television specification for the colours of graphics sheets: NTSC, PAL, PAL60, SECAM")
+	graph := md.AddString("graph", "", "output call graph: CALLS (DOT), CFG (DOT) or JSON")
+	coverage := md.AddBool("coverage", true, "apply coverage saved by the debugger")
 
 	p, err := md.Parse()
 	if p != modalflag.ParseContinue {
@@ -452,6 +492,73 @@ func disasm(md *modalflag.Modes) error {
 			}
 			return errors.New(errors.DisassemblyError, err)
 		}
+
+		if *coverage {
+			err = dsm.LoadCoverageFile()
+			if err != nil {
+				return errors.New(errors.DisassemblyError, err)
+			}
+		}
+
+		if *graphics {
+			var palette colors.Palette
+			switch strings.ToUpper(*spec) {
+			case "NTSC":
+				palette = television.SpecNTSC.Colors
+			case "PAL":
+				palette = television.SpecPAL.Colors
+			case "PAL60":
+				palette = television.SpecPAL60.Colors
+			case "SECAM":
+				palette = television.SpecSECAM.Colors
+			default:
+				return fmt.Errorf("unsupported tv specification (%s) for %s mode", *spec, md)
+			}
+
+			basename := strings.TrimSuffix(filepath.Base(cartload.Filename), filepath.Ext(cartload.Filename))
+			files, err := dsm.SaveGraphics(basename, palette)
+			for _, f := range files {
+				md.Output.Write([]byte(fmt.Sprintf("%s\n", f)))
+			}
+			if err != nil {
+				return errors.New(errors.DisassemblyError, err)
+			}
+			if len(files) == 0 {
+				md.Output.Write([]byte("no graphics found\n"))
+			}
+			return nil
+		}
+
+		if *graph != "" {
+			cg := dsm.CallGraph()
+			switch strings.ToUpper(*graph) {
+			case "CALLS":
+				err = cg.WriteDOT(md.Output)
+			case "CFG":
+				err = cg.WriteCFG(md.Output)
+			case "JSON":
+				err = cg.WriteJSON(md.Output)
+			default:
+				return fmt.Errorf("unknown graph type (%s) for %s mode", *graph, md)
+			}
+			if err != nil {
+				return errors.New(errors.DisassemblyError, err)
+			}
+			return nil
+		}
+
+		if *reassemble {
+			data, err := cartload.Load()
+			if err != nil {
+				return errors.New(errors.DisassemblyError, err)
+			}
+			err = dsm.Reassemble(md.Output, data)
+			if err != nil {
+				return errors.New(errors.DisassemblyError, err)
+			}
+			return nil
+		}
+
 		err = dsm.Write(md.Output, attr)
 		if err != nil {
 			return errors.New(errors.DisassemblyError, err)
@@ -470,7 +577,7 @@ func perform(md *modalflag.Modes, sync *mainSync) error {
 	display := md.AddBool("display", false, "display TV output")
 	fpsCap := md.AddBool("fpscap", true, "cap FPS to specification (only valid if -display=true)")
 	scaling := md.AddFloat64("scale", 3.0, "display scaling (only valid if -display=true")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL, PAL60, SECAM or AUTO (AUTO-PAL and AUTO-SECAM give a color hint)")
 	duration := md.AddString("duration", "5s", "run duration (note: there is a 2s overhead)")
 	profile := md.AddBool("profile", false, "produce cpu and memory profiling reports")
 
@@ -536,6 +643,106 @@ func perform(md *modalflag.Modes, sync *mainSync) error {
 	return nil
 }
 
+func accuracyTest(md *modalflag.Modes) error {
+	md.NewMode()
+
+	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL, PAL60, SECAM or AUTO (AUTO-PAL and AUTO-SECAM give a color hint)")
+	numframes := md.AddInt("frames", 10, "number of frames to run each test ROM")
+	top := md.AddInt("top", -1, "scanline corresponding to the top of the reference images (-1 for automatic)")
+	tolerance := md.AddInt("tolerance", 0, "allowed difference in each color channel")
+
+	md.AdditionalHelp("The test directory is searched for ROM files with a reference PNG image of the same name. A diff image is written for every test that fails.")
+
+	p, err := md.Parse()
+	if p != modalflag.ParseContinue {
+		return err
+	}
+
+	switch len(md.RemainingArgs()) {
+	case 0:
+		return fmt.Errorf("test directory required for %s mode", md)
+	case 1:
+		opts := accuracy.Options{
+			Spec:      strings.ToUpper(*spec),
+			NumFrames: *numframes,
+			Top:       *top,
+			Tolerance: *tolerance,
+		}
+
+		err := accuracy.Run(md.Output, md.GetArg(0), opts)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("too many arguments for %s mode", md)
+	}
+
+	return nil
+}
+
+func renderPlayback(md *modalflag.Modes) error {
+	md.NewMode()
+
+	format := md.AddString("format", render.FormatPNG, "video format: PNG, Y4M, RAW")
+	video := md.AddString("video", "", "video output file (base filename for PNG format)")
+	audio := md.AddString("audio", "", "audio output file (WAV format)")
+	noAudio := md.AddBool("noaudio", false, "do not write audio")
+	audioRate := md.AddInt("audiorate", resampler.Rate48000, "audio sample rate (0 for the native TIA rate)")
+	stereo := md.AddBool("stereo", false, "pan TIA audio channels left and right")
+	lowPass := md.AddBool("lowpass", false, "apply low-pass filter to audio")
+	start := md.AddInt("start", 0, "first frame to write")
+	end := md.AddInt("end", -1, "last frame to write (-1 for end of playback)")
+	scale := md.AddFloat64("scale", 1.0, "scaling of the visible screen")
+	aspect := md.AddBool("aspect", true, "apply pixel width and aspect bias of the television specification")
+
+	md.AdditionalHelp("The playback file is replayed as quickly as possible. Output filenames are based on the playback filename unless specified.")
+
+	p, err := md.Parse()
+	if p != modalflag.ParseContinue {
+		return err
+	}
+
+	switch len(md.RemainingArgs()) {
+	case 0:
+		return fmt.Errorf("playback file required for %s mode", md)
+	case 1:
+		transcript := md.GetArg(0)
+		base := strings.TrimSuffix(transcript, filepath.Ext(transcript))
+
+		opts := render.Options{
+			Format:    strings.ToUpper(*format),
+			Video:     *video,
+			Audio:     *audio,
+			AudioRate: *audioRate,
+			Stereo:    *stereo,
+			LowPass:   *lowPass,
+			Start:     *start,
+			End:       *end,
+			Scale:     *scale,
+			Aspect:    *aspect,
+		}
+
+		if opts.Video == "" {
+			opts.Video = fmt.Sprintf("%s.%s", base, strings.ToLower(opts.Format))
+		}
+
+		if *noAudio {
+			opts.Audio = ""
+		} else if opts.Audio == "" {
+			opts.Audio = fmt.Sprintf("%s.wav", base)
+		}
+
+		err := render.Run(md.Output, transcript, opts)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("too many arguments for %s mode", md)
+	}
+
+	return nil
+}
+
 type yesReader struct{}
 
 func (*yesReader) Read(p []byte) (n int, err error) {
@@ -632,7 +839,7 @@ func regressAdd(md *modalflag.Modes) error {
 	md.NewMode()
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL [cartridge args only]")
+	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL, PAL60, SECAM or AUTO (AUTO-PAL and AUTO-SECAM give a color hint) [cartridge args only]")
 	numframes := md.AddInt("frames", 10, "number of frames to run [cartridge args only]")
 	state := md.AddBool("state", false, "record TV state at every CPU step [cartrdige args only]")
 	mode := md.AddString("mode", "video", "type of digest to create [cartridge args only]")
@@ -51,6 +51,11 @@ type EventMouseMotion struct {
 	// as a fraction of the window's dimensions
 	X float32
 	Y float32
+
+	// the movement since the previous event, as a fraction of the window's
+	// dimensions
+	DX float32
+	DY float32
 }
 
 // MouseButton identifies the mouse button
@@ -79,3 +84,22 @@ type EventDbgMouseButton struct {
 	HorizPos int
 	Scanline int
 }
+
+// EventGamepadButton is the data that accompanies gamepad button events. The
+// Gamepad field is the number of the gamepad, counting from zero in the order
+// the gamepads were attached. The Button field is the name of the button (eg.
+// "a", "start" or "dpleft")
+type EventGamepadButton struct {
+	Gamepad int
+	Button  string
+	Down    bool
+}
+
+// EventGamepadAxis is the data that accompanies gamepad axis events. The Axis
+// field is the name of the axis (eg. "leftx" or "righttrigger"). The Value
+// field is in the range -1.0 to 1.0
+type EventGamepadAxis struct {
+	Gamepad int
+	Axis    string
+	Value   float32
+}
@@ -48,6 +48,12 @@ const (
 	ReqAddDebugger        FeatureReq = "ReqAddDebugger"        // *debugger.Debugger
 	ReqAddVCS             FeatureReq = "ReqAddVCS"             // *hardware.VCS
 	ReqAddDisasm          FeatureReq = "ReqAddDisasm"          // *disassembly.Disassembly
+	ReqSetAudioStereo     FeatureReq = "ReqSetAudioStereo"     // bool
+	ReqSetAudioLowPass    FeatureReq = "ReqSetAudioLowPass"    // bool
+
+	// a short message to show to the user. how and where the message is shown
+	// is up to the GUI. the empty string removes any message.
+	ReqSetMessage FeatureReq = "ReqSetMessage" // string
 
 	// the event channel is used to by the GUI implementation to send
 	// information back to the main program. the GUI may or may not be in its
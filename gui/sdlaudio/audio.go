@@ -20,7 +20,8 @@
 package sdlaudio
 
 import (
-	"gopher2600/hardware/tia/audio"
+	"gopher2600/resampler"
+	"sync"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -33,42 +34,52 @@ import (
 // computationally expensive function.
 //
 // the following value has been discovered through trial and error. the precise
-// value is not critical.
-const bufferLength = 256
+// value is not critical. note that the value is measured in frames of two
+// (left and right) samples
+const bufferLength = 512
+
+// earlier versions of this package tried to detect the "silence value" of a
+// ROM, the volume that the ROM outputs when it is not making a sound, and
+// replaced it with the silence value of the audio device. this was to prevent
+// an audible click when the audio buffer underflowed and the device dropped to
+// its own silence value.
+//
+// that is no longer necessary. the resampler's DC blocking filter means that
+// any constant volume settles to zero, which is the silence value of the
+// signed 16bit format we ask for. an underflow therefore continues at the
+// level the output was already at and there is nothing to hear.
+
+// the sample rate we ask the audio device for. the device may choose a
+// different rate, in which case we resample to that rate instead
+const requestedFreq = resampler.Rate48000
 
 // Audio outputs sound using SDL
 type Audio struct {
-	id       sdl.AudioDeviceID
-	spec     sdl.AudioSpec
-	buffer   []uint8
-	bufferCt int
-
-	// some ROMs do not output 0 as the silence value. silence is technically
-	// caused by constant unchanging value so this shouldn't be a problem. the
-	// problem is caused when there is an audio buffer underflow and the sound
-	// device flips to the real silence value - this causes a audible click.
-	//
-	// to mitigate this we try to detect what the silence value is by counting
-	// the number of unchanging values
-	detectedSilenceValue uint8
-	lastAudioData        uint8
-	countAudioData       int
+	id   sdl.AudioDeviceID
+	spec sdl.AudioSpec
+
+	// the resampler converts the TIA output to the sample rate of the audio
+	// device. the buffer contains interleaved left/right samples. the crit
+	// mutex protects the resampler from changes made by the GUI thread
+	crit   sync.Mutex
+	rs     *resampler.Resampler
+	buffer []int16
+
+	// the buffer converted to bytes for QueueAudio()
+	data []byte
 }
 
-// the number of consecutive cycles for an audio signal to be considered the
-// new silence value
-const audioDataSilenceThreshold = 10000
-
 // NewAudio is the preferred method of initialisatoin for the Audio Type
 func NewAudio() (*Audio, error) {
 	aud := &Audio{}
 
-	aud.buffer = make([]uint8, bufferLength)
+	aud.buffer = make([]int16, 0, bufferLength*2)
+	aud.data = make([]byte, 0, bufferLength*4)
 
 	spec := &sdl.AudioSpec{
-		Freq:     audio.SampleFreq,
-		Format:   sdl.AUDIO_U8,
-		Channels: 1,
+		Freq:     requestedFreq,
+		Format:   sdl.AUDIO_S16LSB,
+		Channels: 2,
 		Samples:  uint16(bufferLength),
 	}
 
@@ -81,7 +92,12 @@ func NewAudio() (*Audio, error) {
 	}
 
 	aud.spec = actualSpec
-	aud.detectedSilenceValue = aud.spec.Silence
+
+	aud.rs, err = resampler.NewResampler(resampler.Options{Rate: int(aud.spec.Freq)})
+	if err != nil {
+		sdl.CloseAudioDevice(aud.id)
+		return nil, err
+	}
 
 	// make sure audio device is unpaused on startup
 	sdl.PauseAudioDevice(aud.id, false)
@@ -89,43 +105,59 @@ func NewAudio() (*Audio, error) {
 	return aud, nil
 }
 
-// SetAudio implements the television.AudioMixer interface
-func (aud *Audio) SetAudio(audioData uint8) error {
-	if aud.bufferCt >= len(aud.buffer) {
-		return aud.FlushAudio()
-	}
+// SetStereo pans the two TIA channels left and right
+func (aud *Audio) SetStereo(stereo bool) {
+	aud.crit.Lock()
+	defer aud.crit.Unlock()
+	aud.rs.SetStereo(stereo)
+}
 
-	// silence detector
-	if audioData == aud.lastAudioData && aud.countAudioData <= audioDataSilenceThreshold {
-		aud.countAudioData++
-		if aud.countAudioData > audioDataSilenceThreshold {
-			aud.detectedSilenceValue = audioData
-		}
-	} else {
-		aud.lastAudioData = audioData
-		aud.countAudioData = 0
-	}
+// SetLowPass turns the low-pass filter on or off
+func (aud *Audio) SetLowPass(lowPass bool) {
+	aud.crit.Lock()
+	defer aud.crit.Unlock()
+	aud.rs.SetLowPass(lowPass)
+}
+
+// SetStereoAudio implements the television.StereoMixer interface
+func (aud *Audio) SetStereoAudio(channel0 uint8, channel1 uint8, external float32) error {
+	aud.crit.Lock()
+	aud.buffer = aud.rs.Process(channel0, channel1, external, aud.buffer)
+	aud.crit.Unlock()
 
-	// never allow sound buffer to "output" silence - some sound devices take
-	// an appreciable amount of time to move from silence to non-silence
-	//
-	if audioData == aud.detectedSilenceValue {
-		aud.buffer[aud.bufferCt] = aud.spec.Silence
-	} else {
-		aud.buffer[aud.bufferCt] = audioData + aud.spec.Silence
+	if len(aud.buffer) >= bufferLength*2 {
+		return aud.FlushAudio()
 	}
-	aud.bufferCt++
 
 	return nil
 }
 
+// SetAudio implements the television.AudioMixer interface
+//
+// The television will call SetStereoAudio() in preference to this function.
+// It is provided to satisfy the AudioMixer interface and divides the mixed
+// value evenly between the two channels.
+func (aud *Audio) SetAudio(audioData uint8) error {
+	return aud.SetStereoAudio(audioData/2, audioData-audioData/2, 0)
+}
+
 // FlushAudio implements the television.AudioMixer interface
 func (aud *Audio) FlushAudio() error {
-	err := sdl.QueueAudio(aud.id, aud.buffer)
+	if len(aud.buffer) == 0 {
+		return nil
+	}
+
+	// QueueAudio() requires a slice of bytes
+	aud.data = aud.data[:0]
+	for _, v := range aud.buffer {
+		aud.data = append(aud.data, byte(v), byte(v>>8))
+	}
+
+	err := sdl.QueueAudio(aud.id, aud.data)
 	if err != nil {
 		return err
 	}
-	aud.bufferCt = 0
+	aud.buffer = aud.buffer[:0]
 
 	return nil
 }
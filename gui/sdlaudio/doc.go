@@ -20,4 +20,8 @@
 // Package sdlaudio provides the Audio type. The Audio type implements the
 // AudioMixer interface using SDL and is suitable for use with any SDL
 // presenation.
+//
+// Audio from the TIA is resampled to the sample rate of the audio device
+// using the resampler package. Stereo output and the low-pass filter can be
+// turned on and off while the emulation is running.
 package sdlaudio
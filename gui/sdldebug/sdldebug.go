@@ -22,6 +22,7 @@ package sdldebug
 import (
 	"gopher2600/errors"
 	"gopher2600/gui"
+	"gopher2600/gui/sdlgamepad"
 	"gopher2600/reflection"
 	"gopher2600/television"
 	"io"
@@ -45,6 +46,9 @@ type SdlDebug struct {
 	// connects SDL guiLoop with the parent process
 	events chan gui.Event
 
+	// game controllers attached to the host
+	gamepads *sdlgamepad.Gamepads
+
 	// sdl stuff
 	window   *sdl.Window
 	renderer *sdl.Renderer
@@ -123,6 +127,8 @@ func NewSdlDebug(tv television.Television, scale float32) (*SdlDebug, error) {
 
 	setupService()
 
+	scr.gamepads = sdlgamepad.NewGamepads()
+
 	// SDL window - window size is set in Resize() function
 	scr.window, err = sdl.CreateWindow(windowTitle,
 		int32(sdl.WINDOWPOS_UNDEFINED), int32(sdl.WINDOWPOS_UNDEFINED),
@@ -165,6 +171,7 @@ func NewSdlDebug(tv television.Television, scale float32) (*SdlDebug, error) {
 
 // Destroy implements GuiCreator interface
 func (scr *SdlDebug) Destroy(output io.Writer) {
+	scr.gamepads.Destroy()
 	scr.overlay.destroy(output)
 	scr.textures.destroy(output)
 
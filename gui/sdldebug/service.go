@@ -68,6 +68,11 @@ func (scr *SdlDebug) Service() {
 			case *sdl.QuitEvent:
 				scr.showWindow(false)
 
+			case *sdl.ControllerDeviceEvent, *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent:
+				if gev, ok := scr.gamepads.Convert(ev); ok {
+					scr.events <- gev
+				}
+
 			case *sdl.KeyboardEvent:
 				mod := gui.KeyModNone
 
@@ -171,7 +176,8 @@ func (scr *SdlDebug) Service() {
 		// mouse motion
 		if scr.isCaptured {
 			mx, my, _ := sdl.GetMouseState()
-			if mx != scr.mx || my != scr.my {
+			dx, dy, _ := sdl.GetRelativeMouseState()
+			if mx != scr.mx || my != scr.my || dx != 0 || dy != 0 {
 				w, h := scr.window.GetSize()
 
 				// reduce mouse x and y coordintes to the range 0.0 to 1.0
@@ -182,7 +188,14 @@ func (scr *SdlDebug) Service() {
 				x := float32(mx) / float32(w)
 				y := float32(my) / float32(h)
 
-				scr.events <- gui.EventMouseMotion{X: x, Y: y}
+				// relative motion is reported even when the pointer is at the
+				// edge of the window
+				scr.events <- gui.EventMouseMotion{
+					X:  x,
+					Y:  y,
+					DX: float32(dx) / float32(w),
+					DY: float32(dy) / float32(h),
+				}
 				scr.mx = mx
 				scr.my = my
 			}
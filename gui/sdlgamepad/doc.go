@@ -0,0 +1,28 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package sdlgamepad provides the Gamepads type. The Gamepads type opens the
+// game controllers attached to the host and converts SDL game controller
+// events into the gamepad events of the gui package. It is suitable for use
+// with any SDL presentation.
+//
+// Gamepads are numbered in the order in which they are attached, starting
+// from zero. The number of a detached gamepad is reused by the next gamepad
+// to be attached.
+package sdlgamepad
@@ -0,0 +1,148 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package sdlgamepad
+
+import (
+	"gopher2600/gui"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// the smallest change in an axis value that will result in a new event. SDL
+// sends a lot of axis events for small movements that we are not interested
+// in
+const axisThreshold = 256
+
+// Gamepads keeps track of the game controllers attached to the host
+type Gamepads struct {
+	// game controllers indexed by gamepad number. a detached gamepad leaves a
+	// nil entry in the slice
+	pads []*sdl.GameController
+
+	// the most recent value of each axis, indexed by SDL joystick instance
+	// and axis
+	axes map[sdl.JoystickID][sdl.CONTROLLER_AXIS_MAX]int16
+}
+
+// NewGamepads is the preferred method of initialisation for the Gamepads
+// type. SDL must have been initialised with the game controller subsystem.
+// Gamepads already attached to the host will be opened by the
+// CONTROLLERDEVICEADDED events that SDL sends on startup.
+func NewGamepads() *Gamepads {
+	return &Gamepads{
+		pads: make([]*sdl.GameController, 0),
+		axes: make(map[sdl.JoystickID][sdl.CONTROLLER_AXIS_MAX]int16),
+	}
+}
+
+// Destroy closes all open gamepads
+func (gp *Gamepads) Destroy() {
+	for i, p := range gp.pads {
+		if p != nil {
+			p.Close()
+			gp.pads[i] = nil
+		}
+	}
+}
+
+// number returns the gamepad number of the SDL joystick instance. returns -1
+// if the instance is not known
+func (gp *Gamepads) number(id sdl.JoystickID) int {
+	for i, p := range gp.pads {
+		if p != nil && p.Joystick().InstanceID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Convert an SDL event to a gui event. Returns false if the SDL event is not
+// a gamepad event or if the event does not need to be forwarded.
+//
+// MUST ONLY be called from the #mainthread
+func (gp *Gamepads) Convert(ev sdl.Event) (gui.Event, bool) {
+	switch ev := ev.(type) {
+	case *sdl.ControllerDeviceEvent:
+		switch ev.Type {
+		case sdl.CONTROLLERDEVICEADDED:
+			// the Which field is the device index for added events
+			p := sdl.GameControllerOpen(int(ev.Which))
+			if p == nil {
+				return nil, false
+			}
+
+			for i := range gp.pads {
+				if gp.pads[i] == nil {
+					gp.pads[i] = p
+					return nil, false
+				}
+			}
+			gp.pads = append(gp.pads, p)
+
+		case sdl.CONTROLLERDEVICEREMOVED:
+			n := gp.number(ev.Which)
+			if n >= 0 {
+				gp.pads[n].Close()
+				gp.pads[n] = nil
+				delete(gp.axes, ev.Which)
+			}
+		}
+
+	case *sdl.ControllerButtonEvent:
+		n := gp.number(ev.Which)
+		if n < 0 {
+			return nil, false
+		}
+
+		return gui.EventGamepadButton{
+			Gamepad: n,
+			Button:  sdl.GameControllerGetStringForButton(sdl.GameControllerButton(ev.Button)),
+			Down:    ev.Type == sdl.CONTROLLERBUTTONDOWN,
+		}, true
+
+	case *sdl.ControllerAxisEvent:
+		n := gp.number(ev.Which)
+		if n < 0 || int(ev.Axis) >= sdl.CONTROLLER_AXIS_MAX {
+			return nil, false
+		}
+
+		// ignore small changes in the axis value
+		axes := gp.axes[ev.Which]
+		d := int(ev.Value) - int(axes[ev.Axis])
+		if d > -axisThreshold && d < axisThreshold {
+			return nil, false
+		}
+		axes[ev.Axis] = ev.Value
+		gp.axes[ev.Which] = axes
+
+		v := float32(ev.Value) / 32767.0
+		if v < -1.0 {
+			v = -1.0
+		}
+
+		return gui.EventGamepadAxis{
+			Gamepad: n,
+			Axis:    sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(ev.Axis)),
+			Value:   v,
+		}, true
+	}
+
+	return nil, false
+}
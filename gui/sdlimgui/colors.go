@@ -87,12 +87,14 @@ type imguiColors struct {
 	TermStyleInstrument      imgui.Vec4
 	TermStyleError           imgui.Vec4
 
-	vec4PaletteNTSC   vec4Palette
-	vec4PalettePAL    vec4Palette
-	vec4PaletteAlt    vec4Palette
-	packedPaletteNTSC packedPalette
-	packedPalettePAL  packedPalette
-	packedPaletteAlt  packedPalette
+	vec4PaletteNTSC    vec4Palette
+	vec4PalettePAL     vec4Palette
+	vec4PaletteSECAM   vec4Palette
+	vec4PaletteAlt     vec4Palette
+	packedPaletteNTSC  packedPalette
+	packedPalettePAL   packedPalette
+	packedPaletteSECAM packedPalette
+	packedPaletteAlt   packedPalette
 }
 
 func newColors() *imguiColors {
@@ -189,6 +191,17 @@ func newColors() *imguiColors {
 		cols.vec4PalettePAL = append(cols.vec4PalettePAL, v)
 	}
 
+	cols.vec4PaletteSECAM = make(vec4Palette, 0, len(colors.PaletteSECAM))
+	for _, c := range colors.PaletteSECAM {
+		v := imgui.Vec4{
+			float32(c.Red) / 255,
+			float32(c.Green) / 255,
+			float32(c.Blue) / 255,
+			1.0,
+		}
+		cols.vec4PaletteSECAM = append(cols.vec4PaletteSECAM, v)
+	}
+
 	cols.vec4PaletteAlt = make(vec4Palette, 0, len(colors.PaletteAlt))
 	for _, c := range colors.PaletteAlt {
 		v := imgui.Vec4{
@@ -210,6 +223,11 @@ func newColors() *imguiColors {
 		cols.packedPalettePAL = append(cols.packedPalettePAL, imgui.PackedColorFromVec4(c))
 	}
 
+	cols.packedPaletteSECAM = make(packedPalette, 0, len(cols.vec4PaletteSECAM))
+	for _, c := range cols.vec4PaletteSECAM {
+		cols.packedPaletteSECAM = append(cols.packedPaletteSECAM, imgui.PackedColorFromVec4(c))
+	}
+
 	cols.packedPaletteAlt = make(packedPalette, 0, len(cols.vec4PaletteAlt))
 	for _, c := range cols.vec4PaletteAlt {
 		cols.packedPaletteAlt = append(cols.packedPaletteAlt, imgui.PackedColorFromVec4(c))
@@ -116,6 +116,10 @@ func (img *SdlImgui) imguiTVPalette() (string, packedPalette) {
 	switch img.lazy.TV.Spec.ID {
 	case "PAL":
 		return "PAL", img.cols.packedPalettePAL
+	case "PAL60":
+		return "PAL60", img.cols.packedPalettePAL
+	case "SECAM":
+		return "SECAM", img.cols.packedPaletteSECAM
 	case "NTSC":
 		return "NTSC", img.cols.packedPaletteNTSC
 	}
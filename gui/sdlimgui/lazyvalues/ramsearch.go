@@ -0,0 +1,67 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package lazyvalues
+
+import (
+	"gopher2600/ramsearch"
+	"sync/atomic"
+)
+
+// LazyRAMSearch lazily accesses the debugger's RAM search.
+type LazyRAMSearch struct {
+	val *Values
+
+	atomicCandidates atomic.Value // []ramsearch.Candidate
+	atomicCurrent    atomic.Value // []uint8
+	atomicFrozen     atomic.Value // []ramsearch.Frozen
+
+	// Candidates is nil if no search is in progress
+	Candidates []ramsearch.Candidate
+
+	// the value currently at the address of each candidate. the Value field
+	// in the candidate is the value when the search was last narrowed
+	Current []uint8
+
+	Frozen []ramsearch.Frozen
+}
+
+func newLazyRAMSearch(val *Values) *LazyRAMSearch {
+	return &LazyRAMSearch{val: val}
+}
+
+func (lz *LazyRAMSearch) update() {
+	lz.val.Dbg.PushRawEvent(func() {
+		srch := lz.val.Dbg.GetRAMSearch()
+
+		// Candidates() and Frozen() both return copies
+		c := srch.Candidates()
+		v := make([]uint8, len(c))
+		for i := range c {
+			v[i], _ = lz.val.VCS.Mem.Peek(c[i].Address)
+		}
+
+		lz.atomicCandidates.Store(c)
+		lz.atomicCurrent.Store(v)
+		lz.atomicFrozen.Store(srch.Frozen())
+	})
+	lz.Candidates, _ = lz.atomicCandidates.Load().([]ramsearch.Candidate)
+	lz.Current, _ = lz.atomicCurrent.Load().([]uint8)
+	lz.Frozen, _ = lz.atomicFrozen.Load().([]ramsearch.Frozen)
+}
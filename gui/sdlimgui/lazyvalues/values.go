@@ -48,6 +48,7 @@ type Values struct {
 	Ball      *LazyBall
 	TV        *LazyTV
 	Cart      *LazyCart
+	RAMSearch *LazyRAMSearch
 
 	// \/\/\/ the following are read on demand rather than thorugh the update
 	// function, because they require more context
@@ -64,6 +65,28 @@ type Values struct {
 
 	// breakpoints
 	atomicBrk []atomic.Value // debugger.BreakGroup
+
+	// runtime coverage of each address in cartridge space. the bank is
+	// stored with the coverage entry
+	atomicCoverage []atomic.Value // lazyCoverage
+
+	// references to the most recently requested address
+	atomicXrefs atomic.Value // lazyXrefs
+
+	// graphics found in the cartridge
+	atomicGraphics atomic.Value // []disassembly.GraphicsRegion
+}
+
+// the coverage entry for an address in a specific bank
+type lazyCoverage struct {
+	bank  int
+	entry disassembly.CoverageEntry
+}
+
+// the references to an address
+type lazyXrefs struct {
+	address uint16
+	xrefs   []disassembly.Xref
 }
 
 // NewValues is the preferred method of initialisation for the Values type
@@ -80,6 +103,7 @@ func NewValues() *Values {
 	val.Ball = newLazyBall(val)
 	val.TV = newLazyTV(val)
 	val.Cart = newLazyCart(val)
+	val.RAMSearch = newLazyRAMSearch(val)
 
 	// allocating enough ram for an entire cart bank because, theoretically, a
 	// cartridge format could have a RAM area as large as that
@@ -88,6 +112,7 @@ func NewValues() *Values {
 	// allocating enough space for every byte in cartridge space. not worrying
 	// about bank sizes or anything like that.
 	val.atomicBrk = make([]atomic.Value, memorymap.MemtopCart-memorymap.OriginCart+1)
+	val.atomicCoverage = make([]atomic.Value, memorymap.MemtopCart-memorymap.OriginCart+1)
 
 	return val
 }
@@ -108,7 +133,7 @@ func (val *Values) Update() {
 	val.Ball.update()
 	val.TV.update()
 	val.Cart.update()
-
+	val.RAMSearch.update()
 }
 
 // ReadRAM returns the data at read address
@@ -143,3 +168,61 @@ func (val *Values) HasBreak(e *disassembly.Entry) debugger.BreakGroup {
 
 	return debugger.BrkNone
 }
+
+// Coverage returns the runtime coverage of the disassembly entry
+func (val *Values) Coverage(dsm *disassembly.Disassembly, e *disassembly.Entry) disassembly.CoverageEntry {
+	if val.Dbg == nil || dsm == nil {
+		return disassembly.CoverageEntry{}
+	}
+
+	addr := e.Result.Address & memorymap.AddressMaskCart
+	bank := e.Bank
+
+	val.Dbg.PushRawEvent(func() {
+		if dsm.Coverage != nil {
+			val.atomicCoverage[addr].Store(lazyCoverage{bank: bank, entry: dsm.Coverage.Get(bank, addr)})
+		}
+	})
+
+	// the stored value may be for the same address in a different bank
+	if c, ok := val.atomicCoverage[addr].Load().(lazyCoverage); ok && c.bank == bank {
+		return c.entry
+	}
+
+	return disassembly.CoverageEntry{}
+}
+
+// Xrefs returns the references to the address. The references include
+// those found at runtime so should be requested every frame.
+func (val *Values) Xrefs(dsm *disassembly.Disassembly, address uint16) []disassembly.Xref {
+	if val.Dbg == nil || dsm == nil {
+		return nil
+	}
+
+	val.Dbg.PushRawEvent(func() {
+		val.atomicXrefs.Store(lazyXrefs{address: address, xrefs: dsm.Xrefs(address)})
+	})
+
+	// the stored value may be for the previously requested address
+	if x, ok := val.atomicXrefs.Load().(lazyXrefs); ok && x.address == address {
+		return x.xrefs
+	}
+
+	return nil
+}
+
+// Graphics returns a copy of the graphics found in the disassembled cartridge
+func (val *Values) Graphics(dsm *disassembly.Disassembly) []disassembly.GraphicsRegion {
+	if val.Dbg == nil || dsm == nil {
+		return nil
+	}
+
+	val.Dbg.PushRawEvent(func() {
+		g := make([]disassembly.GraphicsRegion, len(dsm.Graphics))
+		copy(g, dsm.Graphics)
+		val.atomicGraphics.Store(g)
+	})
+
+	g, _ := val.atomicGraphics.Load().([]disassembly.GraphicsRegion)
+	return g
+}
@@ -92,6 +92,12 @@ func (img *SdlImgui) serviceFeatureRequests(request featureRequest) {
 	case gui.ReqSetPause:
 		img.pause(request.args[0].(bool))
 
+	case gui.ReqSetAudioStereo:
+		img.audio.SetStereo(request.args[0].(bool))
+
+	case gui.ReqSetAudioLowPass:
+		img.audio.SetLowPass(request.args[0].(bool))
+
 	case gui.ReqAddDebugger:
 		img.lazy.Dbg = request.args[0].(*debugger.Debugger)
 
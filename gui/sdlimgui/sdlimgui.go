@@ -24,6 +24,7 @@ import (
 	"gopher2600/disassembly"
 	"gopher2600/gui"
 	"gopher2600/gui/sdlaudio"
+	"gopher2600/gui/sdlgamepad"
 	"gopher2600/gui/sdlimgui/lazyvalues"
 	"gopher2600/paths"
 	"gopher2600/television"
@@ -52,6 +53,9 @@ type SdlImgui struct {
 	screen *screen
 	audio  *sdlaudio.Audio
 
+	// game controllers attached to the host
+	gamepads *sdlgamepad.Gamepads
+
 	// imgui window management
 	wm *windowManager
 
@@ -108,6 +112,8 @@ func NewSdlImgui(tv television.Television) (*SdlImgui, error) {
 		return nil, err
 	}
 
+	img.gamepads = sdlgamepad.NewGamepads()
+
 	iniPath, err := paths.ResourcePath("", "debugger_imgui.ini")
 	if err != nil {
 		return nil, err
@@ -143,6 +149,7 @@ func NewSdlImgui(tv television.Television) (*SdlImgui, error) {
 // MUST ONLY be called from the #mainthread
 func (img *SdlImgui) Destroy(output io.Writer) {
 	img.wm.destroy()
+	img.gamepads.Destroy()
 	img.audio.EndMixing()
 	img.glsl.destroy()
 	img.plt.destroy()
@@ -60,6 +60,11 @@ func (img *SdlImgui) Service() {
 			case *sdl.QuitEvent:
 				img.events <- gui.EventQuit{}
 
+			case *sdl.ControllerDeviceEvent, *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent:
+				if gev, ok := img.gamepads.Convert(ev); ok {
+					img.events <- gev
+				}
+
 			case *sdl.TextInputEvent:
 				if !img.wm.scr.isCaptured {
 					img.io.AddInputCharacters(string(ev.Text[:]))
@@ -173,7 +178,8 @@ func (img *SdlImgui) Service() {
 		// mouse motion
 		if img.wm.scr.isCaptured {
 			mx, my, _ := sdl.GetMouseState()
-			if mx != img.mx || my != img.my {
+			dx, dy, _ := sdl.GetRelativeMouseState()
+			if mx != img.mx || my != img.my || dx != 0 || dy != 0 {
 				w, h := img.plt.window.GetSize()
 
 				// reduce mouse x and y coordintes to the range 0.0 to 1.0
@@ -184,7 +190,14 @@ func (img *SdlImgui) Service() {
 				x := float32(mx) / float32(w)
 				y := float32(my) / float32(h)
 
-				img.events <- gui.EventMouseMotion{X: x, Y: y}
+				// relative motion is reported even when the pointer is at the
+				// edge of the window
+				img.events <- gui.EventMouseMotion{
+					X:  x,
+					Y:  y,
+					DX: float32(dx) / float32(w),
+					DY: float32(dy) / float32(h),
+				}
 				img.mx = mx
 				img.my = my
 			}
@@ -24,6 +24,7 @@ import (
 	"gopher2600/debugger"
 	"gopher2600/disassembly"
 	"gopher2600/hardware/memory/memorymap"
+	"strconv"
 
 	"github.com/inkyblackness/imgui-go/v2"
 )
@@ -44,6 +45,13 @@ type winDisasm struct {
 	bankPrevFrame int
 	pcPrevFrame   uint16
 
+	// the xref pane lists the references to the xrefAddress. the list is
+	// read through the lazyvalues system
+	showXrefs     bool
+	xrefAddress   uint16
+	xrefInput     string
+	xrefPaneLines int
+
 	// packed colors for drawlist
 	colCurrentEntryBg imgui.PackedColor
 	colBreakAddress   imgui.PackedColor
@@ -52,8 +60,9 @@ type winDisasm struct {
 
 func newWinDisasm(img *SdlImgui) (managedWindow, error) {
 	win := &winDisasm{
-		img:      img,
-		followPC: true,
+		img:           img,
+		followPC:      true,
+		xrefPaneLines: 8,
 	}
 
 	return win, nil
@@ -83,6 +92,8 @@ func (win *winDisasm) draw() {
 	imgui.BeginV(winDisasmTitle, &win.open, 0)
 
 	imgui.Text(win.img.lazy.Cart.String)
+	imgui.SameLine()
+	imgui.Checkbox("Xrefs", &win.showXrefs)
 	imgui.Spacing()
 	imgui.Spacing()
 
@@ -129,6 +140,10 @@ func (win *winDisasm) draw() {
 			imgui.EndTabBar()
 		}
 
+		if win.showXrefs {
+			win.drawXrefs()
+		}
+
 		// if the current bank has only been selected this frame then we need
 		// an extra frame to draw the tab page with drawBank() and for the page
 		// to scroll to the correct position. the second part of the condition
@@ -144,7 +159,12 @@ func (win *winDisasm) draw() {
 }
 
 func (win *winDisasm) drawBank(pcAddr uint16, b int, selected bool) {
-	imgui.BeginChild(fmt.Sprintf("bank %d", b))
+	// leave room for the xref pane if necessary
+	h := float32(0.0)
+	if win.showXrefs {
+		h = -(imgui.FrameHeightWithSpacing() + imgui.TextLineHeightWithSpacing()*float32(win.xrefPaneLines))
+	}
+	imgui.BeginChildV(fmt.Sprintf("bank %d", b), imgui.Vec2{X: 0, Y: h}, false, 0)
 
 	itr, _ := win.img.dsm.NewIteration(disassembly.EntryTypeDecode, b)
 
@@ -216,6 +236,10 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, selected bool) {
 
 		// make entry a bit brighter
 		adj = imgui.Vec4{0.1, 0.1, 0.1, 0.0}
+	} else if e.Type < disassembly.EntryTypeLive && win.img.dsm.Counts[e.Bank][disassembly.EntryTypeLive] > 0 {
+		// once there is runtime coverage for the bank, entries that have
+		// never been executed are dimmed
+		adj = imgui.Vec4{0.0, 0.0, 0.0, -0.5}
 	}
 
 	// add some space for the gutter. has to be something tangible so that the
@@ -249,6 +273,12 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, selected bool) {
 	s = win.img.dsm.GetField(disassembly.FldDefnNotes, e)
 	imgui.Text(s)
 
+	// user comment uses the same colour as the notes field
+	if e.Comment != "" {
+		imgui.SameLine()
+		imgui.Text(fmt.Sprintf("; %s", e.Comment))
+	}
+
 	imgui.PopStyleColorV(5)
 
 	imgui.EndGroup()
@@ -262,6 +292,25 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, selected bool) {
 		win.followPC = true
 	}
 
+	// runtime coverage of the entry
+	if imgui.IsItemHoveredV(imgui.HoveredFlagsAllowWhenDisabled) {
+		ce := win.img.lazy.Coverage(win.img.dsm, e)
+		imgui.BeginTooltip()
+		if e.Location != "" {
+			imgui.Text(e.Location)
+		}
+		imgui.Text(fmt.Sprintf("coverage: %s", ce.Flags))
+		if r := ce.ReadBy(); r != "" {
+			imgui.Text(fmt.Sprintf("read by %s", r))
+		}
+		imgui.EndTooltip()
+	}
+
+	// single click shows the references to the entry's address
+	if win.showXrefs && imgui.IsItemHoveredV(imgui.HoveredFlagsAllowWhenDisabled) && imgui.IsMouseClicked(0) {
+		win.updateXrefs(e.Result.Address)
+	}
+
 	// double click toggles a PC breakpoint on the entries address
 	if imgui.IsItemHoveredV(imgui.HoveredFlagsAllowWhenDisabled) && imgui.IsMouseDoubleClicked(0) {
 		win.img.lazy.Dbg.PushRawEvent(func() { win.img.lazy.Dbg.TogglePCBreak(e) })
@@ -304,3 +353,37 @@ func (win *winDisasm) drawGutter(fill gutterType, col imgui.PackedColor) {
 func (win *winDisasm) drawPopupMenu(e *disassembly.Entry) {
 	// !!TODO: popup menu on right mouse click over disasm entry
 }
+
+// updateXrefs changes the address shown in the xref pane
+func (win *winDisasm) updateXrefs(address uint16) {
+	win.xrefAddress = address
+	win.xrefInput = fmt.Sprintf("%04x", address)
+}
+
+func (win *winDisasm) drawXrefs() {
+	imgui.Separator()
+
+	imgui.AlignTextToFramePadding()
+	imgui.Text("References to")
+	imgui.SameLine()
+	imgui.PushItemWidth(imguiGetFrameDim("FFFF").X)
+	if imguiHexInput("##xrefaddress", true, 4, &win.xrefInput) {
+		if v, err := strconv.ParseUint(win.xrefInput, 16, 16); err == nil {
+			win.updateXrefs(uint16(v))
+		}
+	}
+	imgui.PopItemWidth()
+
+	// the references are requested every frame because the runtime
+	// references change while the emulation is running
+	xrefs := win.img.lazy.Xrefs(win.img.dsm, win.xrefAddress)
+
+	imgui.BeginChildV("xrefs", imgui.Vec2{X: 0, Y: imgui.TextLineHeightWithSpacing() * float32(win.xrefPaneLines)}, false, 0)
+	if len(xrefs) == 0 {
+		imgui.Text("no references")
+	}
+	for _, x := range xrefs {
+		imgui.Text(x.String())
+	}
+	imgui.EndChild()
+}
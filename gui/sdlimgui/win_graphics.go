@@ -0,0 +1,205 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package sdlimgui
+
+import (
+	"fmt"
+	"gopher2600/disassembly"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/inkyblackness/imgui-go/v2"
+)
+
+const winGraphicsTitle = "Graphics"
+
+type winGraphics struct {
+	windowManagement
+	img *SdlImgui
+
+	// the bank being browsed and the index of the selected region in the
+	// disassembly's Graphics list
+	bank     int
+	selected int
+
+	// the size of each pixel of the selected region
+	scale int32
+
+	// the result of the most recent refresh or export. the operations are
+	// performed in the emulation goroutine so the result is stored atomically
+	atomicStatus atomic.Value // string
+
+	// packed colors for drawlist
+	colBackground imgui.PackedColor
+	colPixel      imgui.PackedColor
+}
+
+func newWinGraphics(img *SdlImgui) (managedWindow, error) {
+	win := &winGraphics{
+		img:      img,
+		selected: -1,
+		scale:    6,
+	}
+
+	return win, nil
+}
+
+func (win *winGraphics) init() {
+	win.colBackground = imgui.PackedColorFromVec4(imgui.Vec4{0.0, 0.0, 0.0, 1.0})
+	win.colPixel = imgui.PackedColorFromVec4(imgui.Vec4{1.0, 1.0, 1.0, 1.0})
+}
+
+func (win *winGraphics) destroy() {
+}
+
+func (win *winGraphics) id() string {
+	return winGraphicsTitle
+}
+
+func (win *winGraphics) draw() {
+	if !win.open {
+		return
+	}
+
+	imgui.SetNextWindowPosV(imgui.Vec2{905, 720}, imgui.ConditionFirstUseEver, imgui.Vec2{0, 0})
+	imgui.SetNextWindowSizeV(imgui.Vec2{353, 300}, imgui.ConditionFirstUseEver)
+	imgui.BeginV(winGraphicsTitle, &win.open, 0)
+
+	dsm := win.img.dsm
+	if dsm == nil {
+		imgui.End()
+		return
+	}
+
+	// bank selection. the selected region is forgotten if the bank changes
+	if len(dsm.Entries) > 1 {
+		imgui.PushItemWidth(imguiGetFrameDim("bank 00").X)
+		if imgui.BeginComboV("##graphicsbank", fmt.Sprintf("bank %d", win.bank), imgui.ComboFlagNoArrowButton) {
+			for b := range dsm.Entries {
+				if imgui.Selectable(fmt.Sprintf("bank %d", b)) {
+					win.bank = b
+					win.selected = -1
+				}
+			}
+			imgui.EndCombo()
+		}
+		imgui.PopItemWidth()
+		imgui.SameLine()
+	}
+	if win.bank >= len(dsm.Entries) {
+		win.bank = 0
+		win.selected = -1
+	}
+
+	// searching for graphics requires access to the cartridge so must happen
+	// in the emulation goroutine
+	if imgui.Button("Refresh") {
+		win.img.lazy.Dbg.PushRawEvent(func() {
+			if err := dsm.UpdateGraphics(); err != nil {
+				win.atomicStatus.Store(err.Error())
+			}
+		})
+		win.selected = -1
+	}
+
+	imgui.SameLine()
+	if imgui.Button("Export") {
+		win.img.lazy.Dbg.PushRawEvent(func() {
+			filename := win.img.lazy.VCS.Mem.Cart.Filename
+			basename := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+			files, err := dsm.SaveGraphics(basename, win.img.lazy.VCS.TV.GetSpec().Colors)
+			if err != nil {
+				win.atomicStatus.Store(err.Error())
+			} else {
+				win.atomicStatus.Store(fmt.Sprintf("exported %d sheets", len(files)))
+			}
+		})
+	}
+
+	imgui.SameLine()
+	imgui.PushItemWidth(imguiGetFrameDim("scale 00").X * 2)
+	imgui.SliderIntV("##graphicsscale", &win.scale, 1, 12, "scale %d")
+	imgui.PopItemWidth()
+
+	if status, _ := win.atomicStatus.Load().(string); status != "" {
+		imgui.Text(status)
+	}
+
+	imgui.Spacing()
+
+	graphics := win.img.lazy.Graphics(dsm)
+
+	// list of regions in the selected bank
+	imgui.BeginChildV("##graphicslist", imgui.Vec2{imguiGetFrameDim("GRP0 $ffff (000 rows)").X, 0}, true, 0)
+	for i, gr := range graphics {
+		if gr.Bank != win.bank {
+			continue
+		}
+		label := fmt.Sprintf("%s $%04x (%d rows)##%d", gr.Register, gr.Address, len(gr.Data), i)
+		if imgui.SelectableV(label, i == win.selected, 0, imgui.Vec2{}) {
+			win.selected = i
+		}
+		if imgui.IsItemHovered() {
+			imgui.SetTooltip(gr.String())
+		}
+	}
+	imgui.EndChild()
+
+	imgui.SameLine()
+
+	imgui.BeginChildV("##graphicsview", imgui.Vec2{0, 0}, true, imgui.WindowFlagsHorizontalScrollbar)
+	if win.selected >= 0 && win.selected < len(graphics) {
+		win.drawRegion(graphics[win.selected])
+	}
+	imgui.EndChild()
+
+	imgui.End()
+}
+
+// drawRegion draws the graphics region as an 8 pixel wide bitmap, with one
+// row for each byte of data
+func (win *winGraphics) drawRegion(gr disassembly.GraphicsRegion) {
+	_, pal := win.img.imguiTVPalette()
+
+	s := float32(win.scale)
+	p := imgui.CursorScreenPos()
+	dl := imgui.WindowDrawList()
+
+	for row := range gr.Data {
+		col := win.colPixel
+		if gr.Colors != nil {
+			col = pal[gr.Colors[row]]
+		}
+
+		for x := 0; x < 8; x++ {
+			c := win.colBackground
+			if gr.Pixel(row, x) {
+				c = col
+			}
+			p1 := imgui.Vec2{p.X + float32(x)*s, p.Y + float32(row)*s}
+			p2 := imgui.Vec2{p1.X + s, p1.Y + s}
+			dl.AddRectFilled(p1, p2, c)
+		}
+	}
+
+	// reserve the space used by the drawing so that scrolling works
+	imgui.Dummy(imgui.Vec2{8 * s, float32(len(gr.Data)) * s})
+}
@@ -0,0 +1,195 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package sdlimgui
+
+import (
+	"fmt"
+	"gopher2600/ramsearch"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/inkyblackness/imgui-go/v2"
+)
+
+const winRAMSearchTitle = "RAM Search"
+
+type winRAMSearch struct {
+	windowManagement
+	img *SdlImgui
+
+	// value for the equal comparison
+	equalInput string
+
+	// the error from the most recent search operation. the operation is
+	// performed in the emulation goroutine so the error is stored atomically
+	atomicErr atomic.Value // string
+}
+
+func newWinRAMSearch(img *SdlImgui) (managedWindow, error) {
+	win := &winRAMSearch{
+		img:        img,
+		equalInput: "00",
+	}
+
+	return win, nil
+}
+
+func (win *winRAMSearch) init() {
+}
+
+func (win *winRAMSearch) destroy() {
+}
+
+func (win *winRAMSearch) id() string {
+	return winRAMSearchTitle
+}
+
+func (win *winRAMSearch) draw() {
+	if !win.open {
+		return
+	}
+
+	imgui.SetNextWindowPosV(imgui.Vec2{890, 320}, imgui.ConditionFirstUseEver, imgui.Vec2{0, 0})
+	imgui.SetNextWindowSizeV(imgui.Vec2{300, 350}, imgui.ConditionFirstUseEver)
+	imgui.BeginV(winRAMSearchTitle, &win.open, 0)
+
+	if imgui.Button("Start") {
+		win.update(func(srch *ramsearch.Search) error {
+			return srch.Start()
+		})
+	}
+	imgui.SameLine()
+	if imgui.Button("Clear") {
+		win.update(func(srch *ramsearch.Search) error {
+			srch.Clear()
+			return nil
+		})
+	}
+
+	imgui.Spacing()
+
+	candidates := win.img.lazy.RAMSearch.Candidates
+	current := win.img.lazy.RAMSearch.Current
+	frozen := win.img.lazy.RAMSearch.Frozen
+
+	// narrowing the search is not possible until the search has started
+	if candidates != nil {
+		for _, cmp := range []ramsearch.Comparison{ramsearch.Changed, ramsearch.Unchanged, ramsearch.Increased, ramsearch.Decreased} {
+			cmp := cmp
+			if imgui.Button(cmp.String()) {
+				win.narrow(cmp, 0)
+			}
+			imgui.SameLine()
+		}
+		imgui.Spacing()
+
+		if imgui.Button(ramsearch.Equal.String()) {
+			if v, err := strconv.ParseUint(win.equalInput, 16, 8); err == nil {
+				win.narrow(ramsearch.Equal, uint8(v))
+			}
+		}
+		imgui.SameLine()
+		imgui.PushItemWidth(imguiGetFrameDim("FF").X)
+		imguiHexInput("##equal", true, 2, &win.equalInput)
+		imgui.PopItemWidth()
+	}
+
+	if err, _ := win.atomicErr.Load().(string); err != "" {
+		imgui.Text(err)
+	} else if candidates != nil {
+		imgui.Text(fmt.Sprintf("%d candidates", len(candidates)))
+	}
+
+	imgui.Separator()
+
+	imgui.BeginChildV("##candidates", imgui.Vec2{X: 0, Y: -imgui.TextLineHeightWithSpacing() * float32(len(frozen)+2)}, false, 0)
+	for i, c := range candidates {
+		// the lists of candidates and current values are stored separately
+		// and may be momentarily out of step
+		if i >= len(current) {
+			break // for loop
+		}
+		v := current[i]
+
+		imgui.AlignTextToFramePadding()
+		imgui.Text(fmt.Sprintf("%s now %#02x", c.String(), v))
+		imgui.SameLine()
+		if imgui.Button(fmt.Sprintf("Freeze##%04x", c.Address)) {
+			a := c.Address
+			win.update(func(srch *ramsearch.Search) error {
+				return srch.Freeze(a, v)
+			})
+		}
+	}
+	imgui.EndChild()
+
+	imgui.Separator()
+
+	if len(frozen) == 0 {
+		imgui.Text("no frozen addresses")
+	}
+	for _, f := range frozen {
+		f := f
+		imgui.AlignTextToFramePadding()
+		imgui.Text(fmt.Sprintf("%#04x =", f.Address))
+		imgui.SameLine()
+
+		// the frozen value can be changed
+		content := fmt.Sprintf("%02x", f.Value)
+		imgui.PushItemWidth(imguiGetFrameDim("FF").X)
+		if imguiHexInput(fmt.Sprintf("##frozen%04x", f.Address), false, 2, &content) {
+			if v, err := strconv.ParseUint(content, 16, 8); err == nil {
+				win.update(func(srch *ramsearch.Search) error {
+					return srch.Freeze(f.Address, uint8(v))
+				})
+			}
+		}
+		imgui.PopItemWidth()
+
+		imgui.SameLine()
+		if imgui.Button(fmt.Sprintf("Unfreeze##%04x", f.Address)) {
+			win.update(func(srch *ramsearch.Search) error {
+				srch.Unfreeze(f.Address)
+				return nil
+			})
+		}
+	}
+
+	imgui.End()
+}
+
+func (win *winRAMSearch) narrow(cmp ramsearch.Comparison, value uint8) {
+	win.update(func(srch *ramsearch.Search) error {
+		_, err := srch.Narrow(cmp, value)
+		return err
+	})
+}
+
+// update performs the search operation in the emulation goroutine. the
+// results are read through the lazyvalues system
+func (win *winRAMSearch) update(f func(srch *ramsearch.Search) error) {
+	win.img.lazy.Dbg.PushRawEvent(func() {
+		if err := f(win.img.lazy.Dbg.GetRAMSearch()); err != nil {
+			win.atomicErr.Store(err.Error())
+		} else {
+			win.atomicErr.Store("")
+		}
+	})
+}
@@ -111,6 +111,9 @@ func newWindowManager(img *SdlImgui) (*windowManager, error) {
 	if err := addWindow(newWinRAM); err != nil {
 		return nil, err
 	}
+	if err := addWindow(newWinRAMSearch); err != nil {
+		return nil, err
+	}
 	if err := addWindow(newWinTIA); err != nil {
 		return nil, err
 	}
@@ -120,6 +123,9 @@ func newWindowManager(img *SdlImgui) (*windowManager, error) {
 	if err := addWindow(newWinDisasm); err != nil {
 		return nil, err
 	}
+	if err := addWindow(newWinGraphics); err != nil {
+		return nil, err
+	}
 	if err := addWindow(newWinAudio); err != nil {
 		return nil, err
 	}
@@ -73,6 +73,16 @@ func (scr *SdlPlay) serviceFeatureRequests(request featureRequest) {
 	case gui.ReqSetScale:
 		err = scr.setWindow(request.args[0].(float32))
 
+	case gui.ReqSetAudioStereo:
+		scr.aud.SetStereo(request.args[0].(bool))
+
+	case gui.ReqSetAudioLowPass:
+		scr.aud.SetLowPass(request.args[0].(bool))
+
+	case gui.ReqSetMessage:
+		scr.message = request.args[0].(string)
+		scr.setTitle()
+
 	default:
 		err = errors.New(errors.UnsupportedGUIRequest, request.request)
 	}
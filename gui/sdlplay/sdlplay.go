@@ -20,9 +20,11 @@
 package sdlplay
 
 import (
+	"fmt"
 	"gopher2600/errors"
 	"gopher2600/gui"
 	"gopher2600/gui/sdlaudio"
+	"gopher2600/gui/sdlgamepad"
 	"gopher2600/television"
 	"io"
 
@@ -51,6 +53,9 @@ type SdlPlay struct {
 	// all audio is handled by the sound type
 	aud *sdlaudio.Audio
 
+	// game controllers attached to the host
+	gamepads *sdlgamepad.Gamepads
+
 	// sdl stuff
 	window   *sdl.Window
 	renderer *sdl.Renderer
@@ -78,11 +83,27 @@ type SdlPlay struct {
 
 	// whether mouse is captured
 	isCaptured bool
+
+	// message shown in the window title
+	message string
 }
 
 const windowTitle = "Gopher2600"
 const windowTitleCaptured = "Gopher2600 [captured]"
 
+// setTitle sets the window title according to the mouse capture state and
+// the current message
+func (scr *SdlPlay) setTitle() {
+	title := windowTitle
+	if scr.isCaptured {
+		title = windowTitleCaptured
+	}
+	if scr.message != "" {
+		title = fmt.Sprintf("%s - %s", title, scr.message)
+	}
+	scr.window.SetTitle(title)
+}
+
 // NewSdlPlay is the preferred method of initialisation for SdlPlay.
 func NewSdlPlay(tv television.Television, scale float32) (*SdlPlay, error) {
 	scr := &SdlPlay{
@@ -103,6 +124,8 @@ func NewSdlPlay(tv television.Television, scale float32) (*SdlPlay, error) {
 
 	setupService()
 
+	scr.gamepads = sdlgamepad.NewGamepads()
+
 	// SDL window - window size is set in Resize() function
 	scr.window, err = sdl.CreateWindow(windowTitle,
 		int32(sdl.WINDOWPOS_UNDEFINED), int32(sdl.WINDOWPOS_UNDEFINED),
@@ -156,6 +179,8 @@ func NewSdlPlay(tv television.Television, scale float32) (*SdlPlay, error) {
 //
 // MUST ONLY be called from the #mainthread
 func (scr *SdlPlay) Destroy(output io.Writer) {
+	scr.gamepads.Destroy()
+
 	err := scr.texture.Destroy()
 	if err != nil {
 		output.Write([]byte(err.Error()))
@@ -67,6 +67,11 @@ func (scr *SdlPlay) Service() {
 			case *sdl.QuitEvent:
 				scr.events <- gui.EventQuit{}
 
+			case *sdl.ControllerDeviceEvent, *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent:
+				if gev, ok := scr.gamepads.Convert(ev); ok {
+					scr.events <- gev
+				}
+
 			case *sdl.KeyboardEvent:
 				mod := gui.KeyModNone
 
@@ -119,7 +124,14 @@ func (scr *SdlPlay) Service() {
 						if err == nil {
 							scr.window.SetGrab(true)
 							sdl.ShowCursor(sdl.DISABLE)
-							scr.window.SetTitle(windowTitleCaptured)
+							scr.setTitle()
+
+							// relative mouse mode means that movement is
+							// still reported when the pointer reaches the
+							// edge of the window. discard any movement from
+							// before the capture
+							sdl.SetRelativeMouseMode(true)
+							_, _, _ = sdl.GetRelativeMouseState()
 						}
 					}
 
@@ -134,7 +146,8 @@ func (scr *SdlPlay) Service() {
 						if err == nil {
 							scr.window.SetGrab(false)
 							sdl.ShowCursor(sdl.ENABLE)
-							scr.window.SetTitle(windowTitle)
+							scr.setTitle()
+							sdl.SetRelativeMouseMode(false)
 						}
 					}
 				}
@@ -150,7 +163,8 @@ func (scr *SdlPlay) Service() {
 		// mouse motion
 		if scr.isCaptured {
 			mx, my, _ := sdl.GetMouseState()
-			if mx != scr.mx || my != scr.my {
+			dx, dy, _ := sdl.GetRelativeMouseState()
+			if mx != scr.mx || my != scr.my || dx != 0 || dy != 0 {
 				w, h := scr.window.GetSize()
 
 				// reduce mouse x and y coordintes to the range 0.0 to 1.0
@@ -161,7 +175,14 @@ func (scr *SdlPlay) Service() {
 				x := float32(mx) / float32(w)
 				y := float32(my) / float32(h)
 
-				scr.events <- gui.EventMouseMotion{X: x, Y: y}
+				// relative motion is reported even when the pointer is at the
+				// edge of the window
+				scr.events <- gui.EventMouseMotion{
+					X:  x,
+					Y:  y,
+					DX: float32(dx) / float32(w),
+					DY: float32(dy) / float32(h),
+				}
 				scr.mx = mx
 				scr.my = my
 			}
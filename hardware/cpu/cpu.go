@@ -1549,3 +1549,54 @@ func (mc *CPU) ExecuteInstruction(cycleCallback func() error) error {
 
 	return nil
 }
+
+// the state of the CPU as returned by SaveState()
+type cpuState struct {
+	pc         registers.ProgramCounter
+	a          registers.Register
+	x          registers.Register
+	y          registers.Register
+	sp         registers.Register
+	status     registers.StatusRegister
+	rdyFlg     bool
+	lastResult execution.Result
+}
+
+// SaveState notes and returns the current state of the CPU. The state of the
+// CPU can not be saved in the middle of an instruction.
+func (mc *CPU) SaveState() (interface{}, error) {
+	if mc.isExecuting {
+		return nil, errors.New(errors.InvalidOperationMidInstruction, "save state")
+	}
+
+	return cpuState{
+		pc:         *mc.PC,
+		a:          *mc.A,
+		x:          *mc.X,
+		y:          *mc.Y,
+		sp:         *mc.SP,
+		status:     *mc.Status,
+		rdyFlg:     mc.RdyFlg,
+		lastResult: mc.LastResult,
+	}, nil
+}
+
+// RestoreState returns the CPU to a previously known state. Like Reset(), the
+// state can not be restored in the middle of an instruction.
+func (mc *CPU) RestoreState(state interface{}) error {
+	if mc.isExecuting {
+		return errors.New(errors.InvalidOperationMidInstruction, "restore state")
+	}
+
+	s := state.(cpuState)
+	*mc.PC = s.pc
+	*mc.A = s.a
+	*mc.X = s.x
+	*mc.Y = s.y
+	*mc.SP = s.sp
+	*mc.Status = s.status
+	mc.RdyFlg = s.rdyFlg
+	mc.LastResult = s.lastResult
+
+	return nil
+}
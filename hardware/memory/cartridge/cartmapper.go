@@ -47,6 +47,10 @@ type cartMapper interface {
 	patch(offset uint16, data uint8) error
 
 	getRAMinfo() []RAMinfo
+
+	// the addresses that change the memory map when accessed. the map is
+	// keyed by the address of the Hotspot
+	hotspots() map[uint16]Hotspot
 }
 
 // optionalSuperchip are implemented by cartMappers that have an optional
@@ -55,6 +59,14 @@ type optionalSuperchip interface {
 	addSuperchip() bool
 }
 
+// optionalPokeOffset are implemented by cartMappers that support poke(). it
+// returns the offset into the cartridge data of a poked address, as it would
+// be given to patch(). the boolean is false if the address is not in the
+// cartridge data (ie. it is cartridge RAM)
+type optionalPokeOffset interface {
+	pokeOffset(addr uint16) (int, bool)
+}
+
 // RAMinfo details the read/write addresses for any cartridge ram
 type RAMinfo struct {
 	Label       string
@@ -37,6 +37,20 @@ type Cartridge struct {
 	Filename string
 	Hash     string
 
+	// the format requested when the cartridge was attached. "AUTO" indicates
+	// that the format was decided by fingerprinting
+	Format string
+
+	// the list of patch files that have been applied to the cartridge since it
+	// was attached. it is the responsibility of the patching code to add to
+	// this list
+	Patches []string
+
+	// every change made to the cartridge data with Patch() or Poke() since
+	// the cartridge was attached, in the order they were made. changes made
+	// by patches in the setup database are removed by the setup package
+	Changes []Change
+
 	// the specific cartridge data, mapped appropriately to the memory
 	// interfaces
 	mapper cartMapper
@@ -54,22 +68,53 @@ func (cart Cartridge) String() string {
 	return fmt.Sprintf("%s\n%s", cart.Filename, cart.mapper)
 }
 
+// ShortName returns the filename of the cartridge with the path and extension
+// removed
+func (cart Cartridge) ShortName() string {
+	return cartridgeloader.Loader{Filename: cart.Filename}.ShortName()
+}
+
 // Peek is an implementation of memory.DebuggerBus. Address must be normalised.
 func (cart *Cartridge) Peek(addr uint16) (uint8, error) {
 	return cart.Read(addr)
 }
 
+// Change is a single change to the cartridge data. The offset is measured from
+// the start of the cartridge data, in the same way as for Patch()
+type Change struct {
+	Offset int
+	Data   uint8
+}
+
 // Poke is an implementation of memory.DebuggerBus. This poke pokes the current
-// cartridge bank. See Patch for a different method. Address must be
-// normalised.
+// cartridge bank, or the cartridge RAM if the address is in the RAM read
+// range. See Patch for a different method. Address must be normalised.
 func (cart *Cartridge) Poke(addr uint16, data uint8) error {
-	return cart.mapper.poke(addr^memorymap.OriginCart, data)
+	err := cart.mapper.poke(addr^memorymap.OriginCart, data)
+	if err != nil {
+		return err
+	}
+
+	// note the change if the mapper can say where in the cartridge data the
+	// poke landed
+	if o, ok := cart.mapper.(optionalPokeOffset); ok {
+		if offset, ok := o.pokeOffset(addr ^ memorymap.OriginCart); ok {
+			cart.Changes = append(cart.Changes, Change{Offset: offset, Data: data})
+		}
+	}
+
+	return nil
 }
 
 // Patch writes to cartridge memory. Offset is measured from the start of
 // cartridge memory. It differs from Poke in that respect
 func (cart *Cartridge) Patch(offset uint16, data uint8) error {
-	return cart.mapper.patch(offset, data)
+	err := cart.mapper.patch(offset, data)
+	if err != nil {
+		return err
+	}
+	cart.Changes = append(cart.Changes, Change{Offset: int(offset), Data: data})
+	return nil
 }
 
 // Read is an implementation of memory.CPUBus. Address must be normalised.
@@ -87,6 +132,9 @@ func (cart *Cartridge) Write(addr uint16, data uint8) error {
 func (cart *Cartridge) Eject() {
 	cart.Filename = ejectedName
 	cart.Hash = ejectedHash
+	cart.Format = ""
+	cart.Patches = nil
+	cart.Changes = nil
 	cart.mapper = newEjected()
 }
 
@@ -105,6 +153,8 @@ func (cart *Cartridge) Attach(cartload cartridgeloader.Loader) error {
 
 	// note name of cartridge
 	cart.Filename = cartload.Filename
+	cart.Patches = nil
+	cart.Changes = nil
 	cart.mapper = newEjected()
 
 	// generate hash
@@ -122,9 +172,12 @@ func (cart *Cartridge) Attach(cartload cartridgeloader.Loader) error {
 	cartload.Format = strings.ToUpper(cartload.Format)
 
 	if cartload.Format == "" || cartload.Format == "AUTO" {
+		cart.Format = "AUTO"
 		return cart.fingerprint(data)
 	}
 
+	cart.Format = cartload.Format
+
 	addSuperchip := false
 
 	switch cartload.Format {
@@ -22,6 +22,7 @@ package cartridge
 import (
 	"fmt"
 	"gopher2600/errors"
+	"gopher2600/hardware/memory/memorymap"
 )
 
 // from bankswitch_sizes.txt:
@@ -183,10 +184,24 @@ func (cart *atari) listen(addr uint16, data uint8) {
 }
 
 func (cart *atari) poke(addr uint16, data uint8) error {
+	if cart.superchip != nil {
+		if addr > 127 && addr < 256 {
+			cart.superchip[addr-128] = data
+			return nil
+		}
+	}
 	cart.banks[cart.bank][addr] = data
 	return nil
 }
 
+func (cart *atari) pokeOffset(addr uint16) (int, bool) {
+	// pokes to the superchip do not change the cartridge data
+	if cart.superchip != nil && addr > 127 && addr < 256 {
+		return 0, false
+	}
+	return cart.bank*cart.bankSize + int(addr)%cart.bankSize, true
+}
+
 func (cart *atari) patch(addr uint16, data uint8) error {
 	bank := int(addr) / cart.bankSize
 	addr = addr % uint16(cart.bankSize)
@@ -198,6 +213,12 @@ func (cart atari) getRAMinfo() []RAMinfo {
 	return cart.ramInfo
 }
 
+// 2k and 4k cartridges have no hotspots. the bank-switching formats override
+// this function
+func (cart atari) hotspots() map[uint16]Hotspot {
+	return nil
+}
+
 // atari4k is the original and most straightforward format
 //  o Pitfall
 //  o River Raid
@@ -329,6 +350,12 @@ func (cart atari8k) numBanks() int {
 	return 2
 }
 
+var atari8kHotspots = bankHotspots(0x0ff8, 2, memorymap.OriginCart)
+
+func (cart atari8k) hotspots() map[uint16]Hotspot {
+	return atari8kHotspots
+}
+
 func (cart *atari8k) read(addr uint16) (uint8, error) {
 	if data, ok := cart.atari.read(addr); ok {
 		return data, nil
@@ -395,6 +422,12 @@ func (cart atari16k) numBanks() int {
 	return 4
 }
 
+var atari16kHotspots = bankHotspots(0x0ff6, 4, memorymap.OriginCart)
+
+func (cart atari16k) hotspots() map[uint16]Hotspot {
+	return atari16kHotspots
+}
+
 func (cart *atari16k) read(addr uint16) (uint8, error) {
 	if data, ok := cart.atari.read(addr); ok {
 		return data, nil
@@ -469,6 +502,12 @@ func (cart atari32k) numBanks() int {
 	return 8
 }
 
+var atari32kHotspots = bankHotspots(0x0ff4, 8, memorymap.OriginCart)
+
+func (cart atari32k) hotspots() map[uint16]Hotspot {
+	return atari32kHotspots
+}
+
 func (cart *atari32k) read(addr uint16) (uint8, error) {
 	if data, ok := cart.atari.read(addr); ok {
 		return data, nil
@@ -22,6 +22,7 @@ package cartridge
 import (
 	"fmt"
 	"gopher2600/errors"
+	"gopher2600/hardware/memory/memorymap"
 )
 
 type cbs struct {
@@ -62,14 +63,14 @@ func newCBS(data []byte) (cartMapper, error) {
 	cart.superchip = make([]uint8, 256)
 
 	// prepare ram details
-	cart.ramInfo = make([]RAMinfo, 5)
+	cart.ramInfo = make([]RAMinfo, 1)
 	cart.ramInfo[0] = RAMinfo{
 		Label:       "CBS RAM+",
 		Active:      true,
-		ReadOrigin:  0x1080,
-		ReadMemtop:  0x10ff,
+		ReadOrigin:  0x1100,
+		ReadMemtop:  0x11ff,
 		WriteOrigin: 0x1000,
-		WriteMemtop: 0x107f,
+		WriteMemtop: 0x10ff,
 	}
 
 	cart.initialise()
@@ -158,7 +159,12 @@ func (cart *cbs) numBanks() int {
 func (cart *cbs) listen(addr uint16, data uint8) {
 }
 
+// only the cartridge RAM can be poked, using the read addresses
 func (cart *cbs) poke(addr uint16, data uint8) error {
+	if addr >= 0x0100 && addr <= 0x01ff {
+		cart.superchip[addr-0x100] = data
+		return nil
+	}
 	return errors.New(errors.UnpokeableAddress, addr)
 }
 
@@ -173,3 +179,9 @@ func (cart cbs) getRAMinfo() []RAMinfo {
 
 	return cart.ramInfo
 }
+
+var cbsHotspots = bankHotspots(0x0ff8, 3, memorymap.OriginCart)
+
+func (cart cbs) hotspots() map[uint16]Hotspot {
+	return cbsHotspots
+}
@@ -89,3 +89,7 @@ func (cart *ejected) patch(addr uint16, data uint8) error {
 func (cart ejected) getRAMinfo() []RAMinfo {
 	return nil
 }
+
+func (cart ejected) hotspots() map[uint16]Hotspot {
+	return nil
+}
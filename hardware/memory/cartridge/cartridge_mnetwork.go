@@ -130,10 +130,10 @@ func newMnetwork(data []byte) (cartMapper, error) {
 	cart.ramInfo[0] = RAMinfo{
 		Label: "1k",
 		// whether the segment is active depends on the value of bank
-		ReadOrigin:  0x1000,
-		ReadMemtop:  0x13ff,
-		WriteOrigin: 0x1400,
-		WriteMemtop: 0x177f,
+		ReadOrigin:  0x1400,
+		ReadMemtop:  0x17ff,
+		WriteOrigin: 0x1000,
+		WriteMemtop: 0x13ff,
 	}
 	cart.ramInfo[1] = RAMinfo{
 		// the name of the segment depends on the value of ram256byteIdx
@@ -323,7 +323,16 @@ func (cart *mnetwork) restoreState(state interface{}) error {
 func (cart *mnetwork) listen(addr uint16, data uint8) {
 }
 
+// only the cartridge RAM can be poked, using the read addresses
 func (cart *mnetwork) poke(addr uint16, data uint8) error {
+	if cart.bank == 7 && addr >= 0x0400 && addr <= 0x07ff {
+		cart.ram1k[addr&0x03ff] = data
+		return nil
+	}
+	if addr >= 0x0900 && addr <= 0x09ff {
+		cart.ram256byte[cart.ram256byteIdx][addr&0x00ff] = data
+		return nil
+	}
 	return errors.New(errors.UnpokeableAddress, addr)
 }
 
@@ -336,3 +345,25 @@ func (cart mnetwork) getRAMinfo() []RAMinfo {
 	cart.ramInfo[1].Label = fmt.Sprintf("256byte [%d]", cart.ram256byteIdx)
 	return cart.ramInfo
 }
+
+// hotspots for the first segment and for the 256 byte RAM segment. see
+// bankSwitchOnAccess()
+var mnetworkHotspots = func() map[uint16]Hotspot {
+	hs := bankHotspots(0x0fe0, 8, 0x1000)
+
+	// bank 7 is the 1k of RAM
+	h := hs[0x1fe7]
+	h.RAM = true
+	hs[0x1fe7] = h
+
+	for a, h := range bankHotspots(0x0ff8, 4, 0x1800) {
+		h.RAM = true
+		hs[a] = h
+	}
+
+	return hs
+}()
+
+func (cart mnetwork) hotspots() map[uint16]Hotspot {
+	return mnetworkHotspots
+}
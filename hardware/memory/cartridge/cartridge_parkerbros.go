@@ -248,3 +248,18 @@ func (cart *parkerBros) patch(addr uint16, data uint8) error {
 func (cart parkerBros) getRAMinfo() []RAMinfo {
 	return nil
 }
+
+// hotspots for the first three segments. see bankSwitchOnAccess()
+var parkerBrosHotspots = func() map[uint16]Hotspot {
+	hs := make(map[uint16]Hotspot)
+	for s, segment := range []uint16{0x1000, 0x1400, 0x1800} {
+		for a, h := range bankHotspots(0x0fe0+uint16(s*8), 8, segment) {
+			hs[a] = h
+		}
+	}
+	return hs
+}()
+
+func (cart parkerBros) hotspots() map[uint16]Hotspot {
+	return parkerBrosHotspots
+}
@@ -189,3 +189,17 @@ func (cart *tigervision) patch(addr uint16, data uint8) error {
 func (cart tigervision) getRAMinfo() []RAMinfo {
 	return nil
 }
+
+// any write to an address in TIA space below 0x40 selects the bank for the
+// first segment. see listen()
+var tigervisionHotspots = func() map[uint16]Hotspot {
+	hs := make(map[uint16]Hotspot)
+	for a := uint16(0x00); a < 0x40; a++ {
+		hs[a] = Hotspot{Address: a, Bank: HotspotData, Segment: 0x1000, WriteOnly: true, Listen: true}
+	}
+	return hs
+}()
+
+func (cart tigervision) hotspots() map[uint16]Hotspot {
+	return tigervisionHotspots
+}
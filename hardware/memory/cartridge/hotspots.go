@@ -0,0 +1,96 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cartridge
+
+import (
+	"gopher2600/hardware/memory/memorymap"
+	"sort"
+)
+
+// HotspotData is the value of Hotspot.Bank when the bank selected by the
+// hotspot is taken from the data written to it
+const HotspotData = -1
+
+// Hotspot is an address that changes the cartridge's memory map when it is
+// accessed. The disassembler uses hotspots to model bank switching.
+type Hotspot struct {
+	// the address of the hotspot. hotspots in cartridge space use the primary
+	// cartridge mirror (origin 0x1000)
+	Address uint16
+
+	// the bank selected by accessing the hotspot. the bank is selected for
+	// the segment of cartridge memory that contains the Segment address. for
+	// cartridge formats that switch the entire address space, Segment is the
+	// cartridge origin
+	Bank    int
+	Segment uint16
+
+	// the hotspot selects cartridge RAM rather than ROM
+	RAM bool
+
+	// the hotspot only reacts to writes
+	WriteOnly bool
+
+	// the hotspot is outside of cartridge space. see Listen()
+	Listen bool
+}
+
+// bankHotspots creates the hotspots for a cartridge format that selects one
+// bank for each address in a contiguous range
+func bankHotspots(address uint16, numBanks int, segment uint16) map[uint16]Hotspot {
+	hs := make(map[uint16]Hotspot)
+	for b := 0; b < numBanks; b++ {
+		a := memorymap.OriginCart | (address + uint16(b))
+		hs[a] = Hotspot{Address: a, Bank: b, Segment: segment}
+	}
+	return hs
+}
+
+// Hotspot returns the hotspot at the address, if there is one. Cartridge
+// addresses are normalised before comparison.
+func (cart *Cartridge) Hotspot(addr uint16, write bool) (Hotspot, bool) {
+	hs := cart.mapper.hotspots()
+
+	if addr&memorymap.OriginCart == memorymap.OriginCart {
+		h, ok := hs[memorymap.OriginCart|(addr&memorymap.AddressMaskCart)]
+		if !ok || h.Listen || (h.WriteOnly && !write) {
+			return Hotspot{}, false
+		}
+		return h, true
+	}
+
+	h, ok := hs[addr]
+	if !ok || !h.Listen || (h.WriteOnly && !write) {
+		return Hotspot{}, false
+	}
+	return h, true
+}
+
+// Hotspots returns every hotspot of the cartridge, sorted by address
+func (cart *Cartridge) Hotspots() []Hotspot {
+	l := make([]Hotspot, 0, len(cart.mapper.hotspots()))
+	for _, h := range cart.mapper.hotspots() {
+		l = append(l, h)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Address < l[j].Address
+	})
+	return l
+}
@@ -0,0 +1,154 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cartridge_test
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/hardware/memory/cartridge"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// the start of each segment of cartridge memory. no cartridge format has
+// segments smaller than 1k
+var segments = []uint16{0x1000, 0x1400, 0x1800, 0x1c00}
+
+// the bank currently selected for each segment
+func banks(cart *cartridge.Cartridge) [4]int {
+	var b [4]int
+	for i, s := range segments {
+		b[i] = cart.GetBank(s)
+	}
+	return b
+}
+
+// the hotspot table of every bank switching format must agree with the bank
+// switching of the mapper. accessing a hotspot must select the bank given in
+// the table and accessing any other cartridge address must not change the
+// selected banks
+func TestHotspots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_cartridge")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		format string
+		size   int
+	}{
+		{"F8", 8192},
+		{"F6", 16384},
+		{"F4", 32768},
+		{"FA", 12288},
+		{"E0", 8192},
+		{"E7", 16384},
+		{"3F", 8192},
+	}
+
+	for _, tst := range tests {
+		rom := test.WriteROM(t, dir, tst.format, test.NewROM(tst.size))
+
+		cart := cartridge.NewCartridge()
+		if err := cart.Attach(cartridgeloader.Loader{Filename: rom, Format: tst.format}); err != nil {
+			t.Fatalf("%s: cannot attach cartridge: %v", tst.format, err)
+		}
+
+		hotspots := cart.Hotspots()
+		if len(hotspots) == 0 {
+			t.Errorf("%s: no hotspots", tst.format)
+			continue
+		}
+
+		for _, h := range hotspots {
+			state := cart.SaveState()
+
+			// the tigervision format switches banks when listening for
+			// writes to the TIA. the bank is taken from the data written
+			if h.Listen {
+				for b := 0; b < cart.NumBanks(); b++ {
+					cart.Listen(h.Address, uint8(b))
+					if got := cart.GetBank(h.Segment); got != b {
+						t.Errorf("%s: hotspot %#04x: expected bank %d for data %d but got %d", tst.format, h.Address, b, b, got)
+					}
+				}
+			} else {
+				if h.WriteOnly {
+					err = cart.Write(h.Address, 0)
+				} else {
+					_, err = cart.Read(h.Address)
+				}
+				if err != nil {
+					t.Fatalf("%s: cannot access hotspot %#04x: %v", tst.format, h.Address, err)
+				}
+
+				if got := cart.GetBank(h.Segment); got != h.Bank {
+					t.Errorf("%s: hotspot %#04x: expected bank %d in segment %#04x but got %d", tst.format, h.Address, h.Bank, h.Segment, got)
+				}
+			}
+
+			if _, ok := cart.Hotspot(h.Address, true); !ok {
+				t.Errorf("%s: hotspot %#04x not found by Hotspot()", tst.format, h.Address)
+			}
+
+			if err := cart.RestoreState(state); err != nil {
+				t.Fatalf("%s: cannot restore state: %v", tst.format, err)
+			}
+		}
+
+		// reading or writing any other cartridge address does not change the
+		// selected banks. the bank is changed before the test so that a
+		// switch back to the bank selected at startup is also noticed
+		for _, h := range hotspots {
+			if !h.Listen && h.Bank != 0 {
+				if h.WriteOnly {
+					_ = cart.Write(h.Address, 0)
+				} else {
+					_, _ = cart.Read(h.Address)
+				}
+				break
+			}
+		}
+
+		for a := uint16(0x1000); a <= 0x1fff; a++ {
+			if _, ok := cart.Hotspot(a, true); ok {
+				continue
+			}
+
+			before := banks(cart)
+			if _, err := cart.Read(a); err != nil {
+				t.Fatalf("%s: cannot read %#04x: %v", tst.format, a, err)
+			}
+			if after := banks(cart); after != before {
+				t.Errorf("%s: reading %#04x changed banks from %v to %v but it is not a hotspot", tst.format, a, before, after)
+			}
+
+			// writing to cartridge ROM is not always allowed. the error is
+			// not important, only that the banks do not change
+			before = banks(cart)
+			_ = cart.Write(a, 0)
+			if after := banks(cart); after != before {
+				t.Errorf("%s: writing %#04x changed banks from %v to %v but it is not a hotspot", tst.format, a, before, after)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cartridge_test
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/hardware/memory/cartridge"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// the RAMinfo for a cartridge must agree with the mapper. a value written to
+// the write address must be readable from the corresponding read address
+func TestRAMinfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_cartridge")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		format string
+		size   int
+	}{
+		{"F8+SC", 8192},
+		{"FA", 12288},
+		{"E7", 16384},
+	}
+
+	for _, tst := range tests {
+		rom := filepath.Join(dir, tst.format)
+		if err := ioutil.WriteFile(rom, make([]byte, tst.size), 0600); err != nil {
+			t.Fatalf("cannot create ROM file: %v", err)
+		}
+
+		cart := cartridge.NewCartridge()
+		if err := cart.Attach(cartridgeloader.Loader{Filename: rom, Format: tst.format}); err != nil {
+			t.Fatalf("%s: cannot attach cartridge: %v", tst.format, err)
+		}
+
+		// the 1k RAM of the M-Network cartridge is only active in the last
+		// bank of the first segment
+		if tst.format == "E7" {
+			if err := cart.SetBank(0x1000, 7); err != nil {
+				t.Fatalf("%s: cannot set bank: %v", tst.format, err)
+			}
+		}
+
+		ram := cart.GetRAMinfo()
+		if len(ram) == 0 {
+			t.Fatalf("%s: no cartridge RAM", tst.format)
+		}
+
+		for _, r := range ram {
+			if !r.Active {
+				t.Errorf("%s: %s RAM is not active", tst.format, r.Label)
+				continue
+			}
+			if r.ReadMemtop-r.ReadOrigin != r.WriteMemtop-r.WriteOrigin {
+				t.Errorf("%s: %s read and write ranges are different sizes", tst.format, r.Label)
+				continue
+			}
+
+			for i := uint16(0); i <= r.WriteMemtop-r.WriteOrigin; i++ {
+				if err := cart.Write(r.WriteOrigin+i, uint8(i)+1); err != nil {
+					t.Fatalf("%s: cannot write %#04x: %v", tst.format, r.WriteOrigin+i, err)
+				}
+				v, err := cart.Read(r.ReadOrigin + i)
+				if err != nil {
+					t.Fatalf("%s: cannot read %#04x: %v", tst.format, r.ReadOrigin+i, err)
+				}
+				if v != uint8(i)+1 {
+					t.Fatalf("%s: %s value written to %#04x not read from %#04x", tst.format, r.Label, r.WriteOrigin+i, r.ReadOrigin+i)
+				}
+			}
+		}
+	}
+}
@@ -115,6 +115,31 @@ func (mem *VCSMemory) GetArea(area memorymap.Area) (bus.DebuggerBus, error) {
 	return nil, errors.New(errors.MemoryError, "area not mapped correctly")
 }
 
+// Peek is an implementation of DebuggerBus. Address will be normalised and
+// processed by the correct memory area. Unlike Read(), the last access fields
+// are not updated.
+func (mem *VCSMemory) Peek(address uint16) (uint8, error) {
+	ma, ar := memorymap.MapAddress(address, true)
+	area, err := mem.GetArea(ar)
+	if err != nil {
+		return 0, err
+	}
+	return area.Peek(ma)
+}
+
+// Poke is an implementation of DebuggerBus. Address will be normalised and
+// processed by the correct memory area. Note that addresses are mapped as
+// though they were being read. This is so that Poke() and Peek() refer to the
+// same memory.
+func (mem *VCSMemory) Poke(address uint16, data uint8) error {
+	ma, ar := memorymap.MapAddress(address, true)
+	area, err := mem.GetArea(ar)
+	if err != nil {
+		return err
+	}
+	return area.Poke(ma, data)
+}
+
 // read maps an address to the normalised for all memory areas.
 func (mem *VCSMemory) read(address uint16, zeroPage bool) (uint8, error) {
 	// optimisation: called a lot. pointer to VCSMemory to prevent duffcopy
@@ -189,3 +214,67 @@ func (mem *VCSMemory) Write(address uint16, data uint8) error {
 
 	return area.(bus.CPUBus).Write(ma, data)
 }
+
+// the state of a chip memory area as returned by VCSMemory.SaveState()
+type chipState struct {
+	chip   ChipMemory
+	memory []uint8
+}
+
+func (area *ChipMemory) saveState() chipState {
+	return chipState{chip: *area, memory: append([]uint8{}, area.memory...)}
+}
+
+func (area *ChipMemory) restoreState(s chipState) {
+	memory := area.memory
+	*area = s.chip
+	area.memory = memory
+	copy(area.memory, s.memory)
+}
+
+// the state of the VCS memory as returned by SaveState()
+type memoryState struct {
+	riot chipState
+	tia  chipState
+	ram  []uint8
+	cart interface{}
+
+	lastAccessAddress uint16
+	lastAccessValue   uint8
+	lastAccessWrite   bool
+	lastAccessID      int
+	accessCount       int
+}
+
+// SaveState notes and returns the current state of every memory area,
+// including the cartridge (see cartridge.SaveState())
+func (mem *VCSMemory) SaveState() interface{} {
+	return memoryState{
+		riot:              mem.RIOT.saveState(),
+		tia:               mem.TIA.saveState(),
+		ram:               append([]uint8{}, mem.RAM.memory...),
+		cart:              mem.Cart.SaveState(),
+		lastAccessAddress: mem.LastAccessAddress,
+		lastAccessValue:   mem.LastAccessValue,
+		lastAccessWrite:   mem.LastAccessWrite,
+		lastAccessID:      mem.LastAccessID,
+		accessCount:       mem.accessCount,
+	}
+}
+
+// RestoreState returns every memory area to a previously known state
+func (mem *VCSMemory) RestoreState(state interface{}) error {
+	s := state.(memoryState)
+
+	mem.RIOT.restoreState(s.riot)
+	mem.TIA.restoreState(s.tia)
+	copy(mem.RAM.memory, s.ram)
+
+	mem.LastAccessAddress = s.lastAccessAddress
+	mem.LastAccessValue = s.lastAccessValue
+	mem.LastAccessWrite = s.lastAccessWrite
+	mem.LastAccessID = s.lastAccessID
+	mem.accessCount = s.accessCount
+
+	return mem.Cart.RestoreState(s.cart)
+}
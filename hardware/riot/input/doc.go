@@ -25,7 +25,10 @@
 // three input devices - the panel and the two hand controller ports.
 //
 // The HandController type handles the input from all types of hand controllers
-// (although, currently, joystick and paddle only)
+// (although, currently, joystick, paddle and keypad only). Paddles come in pairs
+// and both paddles of the pair are emulated for each port, each with its own
+// capacitor. The PaddleValue type is used to address the second paddle of a
+// pair.
 //
 // The Panel type handles the input from the VCS's front panel switches.
 //
@@ -19,6 +19,13 @@
 
 package input
 
+import (
+	"fmt"
+	"gopher2600/errors"
+	"strconv"
+	"strings"
+)
+
 // Event represents the possible actions that can be performed by the user
 // when interacting with the console
 type Event string
@@ -49,9 +56,41 @@ const (
 	PanelTogglePlayer0Pro Event = "PanelTogglePlayer0Pro" // nil
 	PanelTogglePlayer1Pro Event = "PanelTogglePlayer1Pro" // nil
 
-	// paddles
-	PaddleFire Event = "PaddleFire" // bool
-	PaddleSet  Event = "PaddleSet"  // float64
+	// paddles. events for the second paddle of the pair should use the
+	// PaddleValue type
+	PaddleFire Event = "PaddleFire" // bool or PaddleValue
+	PaddleSet  Event = "PaddleSet"  // float32 or PaddleValue
+
+	// driving controller. DrivingStep rotates the controller by a number of
+	// steps. DrivingRotate sets the speed of continuous rotation, in steps
+	// per frame. for both events, positive values are clockwise
+	DrivingStep   Event = "DrivingStep"   // int or float32
+	DrivingRotate Event = "DrivingRotate" // float32
+
+	// Trak-Ball and mice. PointerMoveX and PointerMoveY give the movement of
+	// the host's pointer as a fraction of the display. movement is accumulated
+	// and converted to quadrature signals according to the resolution, which
+	// is the number of signal changes over the width (or height) of the
+	// display
+	PointerMoveX      Event = "PointerMoveX"      // float32
+	PointerMoveY      Event = "PointerMoveY"      // float32
+	PointerResolution Event = "PointerResolution" // float32
+
+	// extra buttons of the Genesis pad and Booster Grip. directions and the
+	// main fire button use the joystick events
+	GenesisButtonC     Event = "GenesisButtonC"     // bool
+	BoosterGripTrigger Event = "BoosterGripTrigger" // bool
+	BoosterGripBooster Event = "BoosterGripBooster" // bool
+
+	// Kid Vid cassette. KidVidTape loads the tape from the WAV files with the
+	// base filename and KidVidPlay presses or releases the play button
+	KidVidTape       Event = "KidVidTape"       // string
+	KidVidPlay       Event = "KidVidPlay"       // bool
+	KidVidTogglePlay Event = "KidVidTogglePlay" // nil
+
+	// change the type of controller attached to the port. the value is the
+	// name of the controller type, as accepted by ParseControllerType()
+	SetControllerType Event = "SetControllerType" // string
 
 	// keypad (only need down event)
 	KeypadDown Event = "KeypadDown" // rune
@@ -64,4 +103,54 @@ const (
 // should be restricted to bool, float32, or int. string is also acceptable but
 // for simplicity of playback parsers, "true" or "false" should not be used and
 // numbers should be represented by float32 or int.
+//
+// The exception is the PaddleValue type, which is used to address a paddle
+// other than the first paddle on a port.
 type EventValue interface{}
+
+// PaddleValue is the EventValue for PaddleSet and PaddleFire events that
+// specifies which of the pair of paddles the event is intended for. The Value
+// field should be of the type expected by the event (float32 for PaddleSet and
+// bool for PaddleFire).
+//
+// PaddleSet and PaddleFire events with a value of any other type are for the
+// first paddle (paddle 0).
+type PaddleValue struct {
+	Paddle int
+	Value  EventValue
+}
+
+func (v PaddleValue) String() string {
+	return fmt.Sprintf("%d:%v", v.Paddle, v.Value)
+}
+
+// ParsePaddleValue converts a string, as created by PaddleValue.String(), to a
+// PaddleValue.
+func ParsePaddleValue(s string) (PaddleValue, error) {
+	p := strings.SplitN(s, ":", 2)
+	if len(p) != 2 {
+		return PaddleValue{}, errors.New(errors.BadInputEventType, "paddle", "PaddleValue")
+	}
+
+	idx, err := strconv.Atoi(p[0])
+	if err != nil {
+		return PaddleValue{}, errors.New(errors.BadInputEventType, "paddle", "PaddleValue")
+	}
+
+	v := PaddleValue{Paddle: idx}
+
+	switch p[1] {
+	case "true":
+		v.Value = true
+	case "false":
+		v.Value = false
+	default:
+		f, err := strconv.ParseFloat(p[1], 32)
+		if err != nil {
+			return PaddleValue{}, errors.New(errors.BadInputEventType, "paddle", "PaddleValue")
+		}
+		v.Value = float32(f)
+	}
+
+	return v, nil
+}
@@ -22,6 +22,7 @@ package input
 import (
 	"gopher2600/errors"
 	"gopher2600/hardware/memory/addresses"
+	"strings"
 )
 
 // ControllerType keeps track of which controller type is being used at any
@@ -42,8 +43,99 @@ const (
 	JoystickType ControllerType = iota
 	PaddleType
 	KeypadType
+
+	// the driving controller cannot be detected from user input because the
+	// events used by it are not unique to it. it must be selected with
+	// ForceType(). once selected, the controller type will not change
+	// automatically
+	DrivingType
+
+	// the SaveKey and AtariVox peripherals are not controllers as such but
+	// they are attached to the controller port. like the driving controller
+	// they must be selected with ForceType()
+	SaveKeyType
+	AtariVoxType
+
+	// pointing devices. the Trak-Ball, Amiga mouse and Atari ST mouse all
+	// output quadrature signals to SWCHA in response to movement, each with a
+	// different pattern. they must be selected with ForceType()
+	TrakBallType
+	AmigaMouseType
+	STMouseType
+
+	// joysticks with extra buttons. the extra buttons are read through the
+	// paddle registers. they must be selected with ForceType()
+	GenesisType
+	BoosterGripType
+
+	// the Kid Vid cassette interface. the cartridge controls the tape and
+	// reads the data track through SWCHA. it must be selected with
+	// ForceType()
+	KidVidType
 )
 
+func (t ControllerType) String() string {
+	switch t {
+	case JoystickType:
+		return "JOYSTICK"
+	case PaddleType:
+		return "PADDLE"
+	case KeypadType:
+		return "KEYPAD"
+	case DrivingType:
+		return "DRIVING"
+	case SaveKeyType:
+		return "SAVEKEY"
+	case AtariVoxType:
+		return "ATARIVOX"
+	case TrakBallType:
+		return "TRAKBALL"
+	case AmigaMouseType:
+		return "AMIGAMOUSE"
+	case STMouseType:
+		return "STMOUSE"
+	case GenesisType:
+		return "GENESIS"
+	case BoosterGripType:
+		return "BOOSTERGRIP"
+	case KidVidType:
+		return "KIDVID"
+	}
+	return "unknown"
+}
+
+// ParseControllerType converts a string to a ControllerType. The string is
+// not case sensitive.
+func ParseControllerType(s string) (ControllerType, error) {
+	switch strings.ToUpper(s) {
+	case "JOYSTICK":
+		return JoystickType, nil
+	case "PADDLE":
+		return PaddleType, nil
+	case "KEYPAD":
+		return KeypadType, nil
+	case "DRIVING":
+		return DrivingType, nil
+	case "SAVEKEY":
+		return SaveKeyType, nil
+	case "ATARIVOX":
+		return AtariVoxType, nil
+	case "TRAKBALL":
+		return TrakBallType, nil
+	case "AMIGAMOUSE":
+		return AmigaMouseType, nil
+	case "STMOUSE":
+		return STMouseType, nil
+	case "GENESIS":
+		return GenesisType, nil
+	case "BOOSTERGRIP":
+		return BoosterGripType, nil
+	case "KIDVID":
+		return KidVidType, nil
+	}
+	return JoystickType, errors.New(errors.UnknownControllerType, s)
+}
+
 // HandController represents the "joystick" port on the VCS. The different
 // devices (joysticks, paddles, etc.) send events to the Handle() function.
 //
@@ -56,10 +148,35 @@ type HandController struct {
 	// which controller type is currently being used
 	which ControllerType
 
-	// controller types
-	stick  stick
-	paddle paddle
-	keypad keypad
+	// controller types. there are two paddles for every port
+	stick   stick
+	paddles [2]paddle
+	keypad  keypad
+	driving driving
+
+	// the SaveKey or AtariVox attached to the port. nil if the controller
+	// type is not SaveKeyType or AtariVoxType
+	savekey *SaveKey
+
+	// the Kid Vid attached to the port. nil if the controller type is not
+	// KidVidType
+	kidvid *KidVid
+
+	// whether peripherals attached to the port load and save their contents
+	// from and to disk. see Input.PersistPeripherals()
+	persist bool
+
+	// the state of the Trak-Ball or mouse
+	pointer pointer
+
+	// the state of the extra buttons of the Genesis pad and Booster Grip. the
+	// first button is read through the first paddle register and the second
+	// button through the second paddle register
+	extraButtons [2]bool
+
+	// the most recent value written to SWCHA by the CPU. normalised in the
+	// same way as the ddr field
+	swcha uint8
 
 	// data direction register. for simplicity, the bits should be normalised
 	// such that only the upper nibble is used. in reality, player 0
@@ -97,12 +214,16 @@ type stick struct {
 	button uint8
 }
 
-// the paddle type implements the "paddle" hand controller
+// the paddle type implements the "paddle" hand controller. paddles come in
+// pairs and each paddle in the pair has a separate puck register and fire
+// button
 type paddle struct {
 	puckReg addresses.ChipRegister
 
-	//
-	buttonMask uint8
+	// the bit in SWCHA for the fire button. the bit is in the upper nibble
+	// and will be adjusted according to normaliseOnWrite() in the
+	// HandController
+	buttonBit uint8
 
 	// values indicating paddle state
 	charge     uint8
@@ -136,6 +257,28 @@ type keypad struct {
 // the value of keypad.key when nothing is being pressed
 const noKey = ' '
 
+// the driving type implements the driving controller. the driving controller
+// is a rotary encoder that outputs a 2-bit gray code to the same SWCHA bits as
+// the joystick's up and down directions. the fire button is the same as the
+// joystick's fire button.
+type driving struct {
+	// the index into the drivingGrayCode table
+	position int
+
+	// continuous rotation, measured in steps per frame. positive values are
+	// clockwise. the accumulator collects fractions of a step every cycle
+	rotation float32
+	accum    float32
+}
+
+// the sequence of gray code values as the driving controller is rotated
+// clockwise. bit 0 is the "up" bit and bit 1 is the "down" bit of the joystick
+var drivingGrayCode = [4]uint8{0x03, 0x01, 0x00, 0x02}
+
+// the approximate number of CPU cycles in a frame. used to convert continuous
+// rotation values to steps
+const cyclesPerFrame = 19912
+
 // NewHandController0 is the preferred method of creating a new instance of
 // HandController for representing hand controller zero
 func NewHandController0(mem *inputMemory, control *VBlankBits) *HandController {
@@ -148,11 +291,19 @@ func NewHandController0(mem *inputMemory, control *VBlankBits) *HandController {
 			axis:      0xf0,
 			button:    stickButtonOff,
 		},
-		paddle: paddle{
-			puckReg:     addresses.INPT0,
-			buttonMask:  0x7f,
-			resistance:  0.0,
-			sensitivity: bestGuessSensitivity,
+		paddles: [2]paddle{
+			{
+				puckReg:     addresses.INPT0,
+				buttonBit:   0x80,
+				resistance:  0.0,
+				sensitivity: bestGuessSensitivity,
+			},
+			{
+				puckReg:     addresses.INPT1,
+				buttonBit:   0x40,
+				resistance:  0.0,
+				sensitivity: bestGuessSensitivity,
+			},
 		},
 		keypad: keypad{
 			column: [3]addresses.ChipRegister{addresses.INPT0, addresses.INPT1, addresses.INPT4},
@@ -188,11 +339,19 @@ func NewHandController1(mem *inputMemory, control *VBlankBits) *HandController {
 			axis:      0xf0,
 			button:    stickButtonOff,
 		},
-		paddle: paddle{
-			puckReg:     addresses.INPT1,
-			buttonMask:  0xbf,
-			resistance:  0.0,
-			sensitivity: bestGuessSensitivity,
+		paddles: [2]paddle{
+			{
+				puckReg:     addresses.INPT2,
+				buttonBit:   0x80,
+				resistance:  0.0,
+				sensitivity: bestGuessSensitivity,
+			},
+			{
+				puckReg:     addresses.INPT3,
+				buttonBit:   0x40,
+				resistance:  0.0,
+				sensitivity: bestGuessSensitivity,
+			},
 		},
 		keypad: keypad{
 			column: [3]addresses.ChipRegister{addresses.INPT2, addresses.INPT3, addresses.INPT5},
@@ -224,11 +383,19 @@ func (hc *HandController) String() string {
 // SwitchType causes the HandController to swich controller type. If the type
 // is switched or if the type is already of the requested type then true is
 // returned.
+//
+// The type will never be switched from a type that can only be selected with
+// ForceType() (the DrivingType, for example). Use ForceType() to change from
+// those types.
 func (hc *HandController) SwitchType(prospective ControllerType) bool {
 	if hc.which == prospective {
 		return true
 	}
 
+	if hc.which.forced() {
+		return false
+	}
+
 	switch prospective {
 	case JoystickType:
 		if hc.which != KeypadType {
@@ -243,11 +410,74 @@ func (hc *HandController) SwitchType(prospective ControllerType) bool {
 	case KeypadType:
 		hc.which = KeypadType
 		return true
+	default:
+		if prospective.forced() {
+			hc.ForceType(prospective)
+			return true
+		}
 	}
 
 	return false
 }
 
+// forced returns true if the controller type can only be selected with
+// ForceType()
+func (t ControllerType) forced() bool {
+	return t != JoystickType && t != PaddleType && t != KeypadType
+}
+
+// stickButton returns true if the controller type uses the same fire button
+// as the joystick
+func (t ControllerType) stickButton() bool {
+	switch t {
+	case JoystickType, DrivingType, TrakBallType, AmigaMouseType, STMouseType, GenesisType, BoosterGripType:
+		return true
+	}
+	return false
+}
+
+// stickAxis returns true if the controller type uses the same directions as
+// the joystick
+func (t ControllerType) stickAxis() bool {
+	return t == JoystickType || t == GenesisType || t == BoosterGripType
+}
+
+// ForceType sets the controller type regardless of the current controller
+// type. Note that the type may still be changed automatically by SwitchType()
+// in response to user input, except in the case of the types that can only be
+// selected with ForceType() (the DrivingType, for example).
+func (hc *HandController) ForceType(t ControllerType) {
+	hc.which = t
+	hc.savekey = nil
+	hc.kidvid = nil
+
+	switch t {
+	case DrivingType:
+		hc.driving.rotation = 0
+		hc.driving.accum = 0
+		hc.writeDriving()
+	case SaveKeyType:
+		hc.savekey = newSaveKey(false, hc.persist)
+		hc.updateSaveKey()
+	case AtariVoxType:
+		hc.savekey = newSaveKey(true, hc.persist)
+		hc.updateSaveKey()
+	case TrakBallType, AmigaMouseType, STMouseType:
+		hc.resetPointer()
+	case GenesisType, BoosterGripType:
+		hc.extraButtons = [2]bool{}
+		hc.writeExtraButtons()
+	case KidVidType:
+		hc.kidvid = newKidVid()
+		hc.updateKidVid(true)
+	}
+}
+
+// ControllerType returns the current controller type.
+func (hc *HandController) ControllerType() ControllerType {
+	return hc.which
+}
+
 // Handle implements Port interface
 func (hc *HandController) Handle(event Event, value EventValue) error {
 	switch event {
@@ -262,7 +492,7 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
 
-		if !hc.SwitchType(JoystickType) {
+		if !hc.which.stickAxis() && !hc.SwitchType(JoystickType) {
 			return nil
 		}
 
@@ -279,7 +509,7 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
 
-		if !hc.SwitchType(JoystickType) {
+		if !hc.which.stickAxis() && !hc.SwitchType(JoystickType) {
 			return nil
 		}
 
@@ -296,7 +526,7 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
 
-		if !hc.SwitchType(JoystickType) {
+		if !hc.which.stickAxis() && !hc.SwitchType(JoystickType) {
 			return nil
 		}
 
@@ -313,7 +543,7 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
 
-		if !hc.SwitchType(JoystickType) {
+		if !hc.which.stickAxis() && !hc.SwitchType(JoystickType) {
 			return nil
 		}
 
@@ -330,7 +560,8 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
 
-		if !hc.SwitchType(JoystickType) {
+		// some other controllers use the same fire button as the joystick
+		if !hc.which.stickButton() && !hc.SwitchType(JoystickType) {
 			return nil
 		}
 
@@ -349,7 +580,12 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 		}
 
 	case PaddleFire:
-		b, ok := value.(bool)
+		idx, v := paddleIndex(value)
+		if idx < 0 || idx >= len(hc.paddles) {
+			return errors.New(errors.BadInputEventType, event, "paddle index of 0 or 1")
+		}
+
+		b, ok := v.(bool)
 		if !ok {
 			return errors.New(errors.BadInputEventType, event, "bool")
 		}
@@ -358,17 +594,22 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return nil
 		}
 
-		var v uint8
-
+		// the fire button bit is cleared when the button is pressed. all other
+		// bits in SWCHA are preserved
+		bit := hc.paddles[idx].buttonBit
 		if b {
-			v = 0x00
+			hc.writeSWCHA(0x00, ^hc.normaliseOnWrite(bit))
 		} else {
-			v = 0xff
+			hc.writeSWCHA(bit, ^hc.normaliseOnWrite(bit))
 		}
-		hc.writeSWCHA(v, hc.paddle.buttonMask)
 
 	case PaddleSet:
-		f, ok := value.(float32)
+		idx, v := paddleIndex(value)
+		if idx < 0 || idx >= len(hc.paddles) {
+			return errors.New(errors.BadInputEventType, event, "paddle index of 0 or 1")
+		}
+
+		f, ok := v.(float32)
 		if !ok {
 			return errors.New(errors.BadInputEventType, event, "float32")
 		}
@@ -377,7 +618,135 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 			return nil
 		}
 
-		hc.paddle.resistance = 1.0 - f
+		hc.paddles[idx].resistance = 1.0 - f
+
+	case DrivingStep:
+		var n int
+		switch v := value.(type) {
+		case int:
+			n = v
+		case float32:
+			n = int(v)
+		default:
+			return errors.New(errors.BadInputEventType, event, "int or float32")
+		}
+
+		if hc.which != DrivingType {
+			return nil
+		}
+
+		hc.stepDriving(n)
+
+	case DrivingRotate:
+		f, ok := value.(float32)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "float32")
+		}
+
+		if hc.which != DrivingType {
+			return nil
+		}
+
+		hc.driving.rotation = f
+		hc.driving.accum = 0
+
+	case GenesisButtonC, BoosterGripTrigger, BoosterGripBooster:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		switch {
+		case event == GenesisButtonC && hc.which == GenesisType:
+			hc.extraButtons[1] = b
+		case event == BoosterGripTrigger && hc.which == BoosterGripType:
+			hc.extraButtons[1] = b
+		case event == BoosterGripBooster && hc.which == BoosterGripType:
+			hc.extraButtons[0] = b
+		default:
+			return nil
+		}
+
+		hc.writeExtraButtons()
+
+	case PointerMoveX, PointerMoveY:
+		f, ok := value.(float32)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "float32")
+		}
+
+		if !hc.which.IsPointer() {
+			return nil
+		}
+
+		hc.movePointer(event == PointerMoveX, f)
+
+	case PointerResolution:
+		f, ok := value.(float32)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "float32")
+		}
+
+		if f <= 0 {
+			return errors.New(errors.BadInputEventType, event, "float32 greater than zero")
+		}
+
+		hc.pointer.resolution = f
+
+	case SetControllerType:
+		v, ok := value.(string)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "string")
+		}
+
+		t, err := ParseControllerType(v)
+		if err != nil {
+			return err
+		}
+
+		hc.ForceType(t)
+
+	case KidVidTape:
+		v, ok := value.(string)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "string")
+		}
+
+		if hc.which != KidVidType {
+			return nil
+		}
+
+		if err := hc.kidvid.load(v); err != nil {
+			return err
+		}
+
+		// the track selected by the cartridge applies to the new tape
+		hc.updateKidVid(true)
+
+	case KidVidPlay:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		if hc.which != KidVidType {
+			return nil
+		}
+
+		hc.kidvid.play(b)
+		hc.updateKidVid(false)
+
+	case KidVidTogglePlay:
+		if value != nil {
+			return errors.New(errors.BadInputEventType, event, "nil")
+		}
+
+		if hc.which != KidVidType {
+			return nil
+		}
+
+		hc.kidvid.play(!hc.kidvid.playing)
+		hc.updateKidVid(false)
 
 	case KeypadDown:
 		v, ok := value.(rune)
@@ -423,6 +792,8 @@ func (hc *HandController) Handle(event Event, value EventValue) error {
 // passed to the function. this simplifies the implementation.
 func (hc *HandController) setDDR(data uint8) {
 	hc.ddr = hc.normaliseOnRead(data)
+	hc.updateSaveKey()
+	hc.updateKidVid(true)
 
 	// if the ddr value is being such so that SWCHA is input rather than output
 	// the the expected controller is most probably a keypad. not sure what
@@ -438,6 +809,8 @@ func (hc *HandController) setDDR(data uint8) {
 // readKeypad() is called whenever SWCHA is tickled by the CPU. the state of
 // the ddr is of importance here.
 func (hc *HandController) readKeypad(data uint8) {
+	hc.swcha = hc.normaliseOnRead(data)
+
 	if hc.which != KeypadType {
 		return
 	}
@@ -534,7 +907,7 @@ func (hc *HandController) readKeypad(data uint8) {
 // VBLANK bit 6 has been set. joystick button will latch, meaning that
 // releasing the fire button has no immediate effect
 func (hc *HandController) unlatch() {
-	if hc.which != JoystickType {
+	if !hc.which.stickButton() {
 		return
 	}
 
@@ -553,12 +926,40 @@ func (hc *HandController) ground() {
 	// the the high bit of INPT1 and I'm now wondering if the charge value ever
 	// reaches the last bit (?) if it doesn't we can change the recharge
 	// function and not worry about clobbering the high bit
+	//
+	// the extra buttons of the Genesis pad and Booster Grip are connected to
+	// the same inputs so they must be updated too
+	if hc.which == GenesisType || hc.which == BoosterGripType {
+		hc.writeExtraButtons()
+		return
+	}
+
 	if hc.which != PaddleType {
 		return
 	}
 
-	hc.paddle.charge = 0
-	hc.mem.riot.InputDeviceWrite(hc.paddle.puckReg, hc.paddle.charge, 0x00)
+	for i := range hc.paddles {
+		hc.paddles[i].charge = 0
+		hc.mem.tia.InputDeviceWrite(hc.paddles[i].puckReg, hc.paddles[i].charge, 0x00)
+	}
+}
+
+// write the state of the extra buttons of the Genesis pad or Booster Grip to
+// the paddle registers. a pressed button connects the paddle input directly to
+// the supply so the register reads high, unless the paddle inputs are
+// grounded
+func (hc *HandController) writeExtraButtons() {
+	if hc.which != GenesisType && hc.which != BoosterGripType {
+		return
+	}
+
+	for i, b := range hc.extraButtons {
+		v := uint8(0x00)
+		if b && !hc.control.groundPaddles {
+			v = 0x80
+		}
+		hc.mem.tia.InputDeviceWrite(hc.paddles[i].puckReg, v, 0x00)
+	}
 }
 
 // recharge() is called every video step via Input.Step()
@@ -580,16 +981,67 @@ func (hc *HandController) recharge() {
 	// VBLANK. When this control bit is cleared the potentiometers begin to
 	// recharge the capacitors and the microprocessor measures the time required
 	// to detect a logic 1 at each input port."
-	if hc.paddle.charge < 255 {
-		hc.paddle.ticks += hc.paddle.sensitivity
-		if hc.paddle.ticks >= hc.paddle.resistance {
-			hc.paddle.ticks = 0
-			hc.paddle.charge++
-			hc.mem.tia.InputDeviceWrite(hc.paddle.puckReg, hc.paddle.charge, 0x00)
+	//
+	// each paddle in the pair has its own capacitor, which charges according
+	// to the resistance of that paddle
+	for i := range hc.paddles {
+		pdl := &hc.paddles[i]
+		if pdl.charge < 255 {
+			pdl.ticks += pdl.sensitivity
+			if pdl.ticks >= pdl.resistance {
+				pdl.ticks = 0
+				pdl.charge++
+				hc.mem.tia.InputDeviceWrite(pdl.puckReg, pdl.charge, 0x00)
+			}
 		}
 	}
 }
 
+// rotate() is called every cycle via Input.Step() and advances the driving
+// controller according to the continuous rotation value
+func (hc *HandController) rotate() {
+	if hc.which != DrivingType || hc.driving.rotation == 0 {
+		return
+	}
+
+	hc.driving.accum += hc.driving.rotation / cyclesPerFrame
+
+	if hc.driving.accum >= 1.0 {
+		hc.driving.accum -= 1.0
+		hc.stepDriving(1)
+	} else if hc.driving.accum <= -1.0 {
+		hc.driving.accum += 1.0
+		hc.stepDriving(-1)
+	}
+}
+
+// move the driving controller by the number of steps. positive values are
+// clockwise
+func (hc *HandController) stepDriving(n int) {
+	hc.driving.position = (hc.driving.position + n) % len(drivingGrayCode)
+	if hc.driving.position < 0 {
+		hc.driving.position += len(drivingGrayCode)
+	}
+	hc.writeDriving()
+}
+
+// write the current gray code of the driving controller to SWCHA. the gray
+// code occupies the "up" and "down" bits of the joystick
+func (hc *HandController) writeDriving() {
+	data := drivingGrayCode[hc.driving.position] << 4
+	hc.writeSWCHA(data, ^hc.normaliseOnWrite(0x30))
+}
+
+// paddleIndex returns the paddle index and the underlying value of the
+// EventValue used with PaddleSet and PaddleFire events. values that are not of
+// the PaddleValue type are for the first paddle.
+func paddleIndex(value EventValue) (int, EventValue) {
+	if v, ok := value.(PaddleValue); ok {
+		return v.Paddle, v.Value
+	}
+	return 0, value
+}
+
 // writing to SWCHA requires some filtering according to the data direction
 // register (DDR). joysticks always write their axis data to SWCHA and paddles
 // always write fire button data to SWCHA, according to a mask for which hand
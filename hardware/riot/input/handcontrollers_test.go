@@ -0,0 +1,230 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"gopher2600/television"
+	"testing"
+)
+
+// addresses of the registers used by the hand controllers
+const (
+	addrSWCHA  = 0x0280
+	addrSWACNT = 0x0281
+	addrINPT0  = 0x0008
+	addrINPT1  = 0x0009
+	addrINPT2  = 0x000a
+	addrINPT3  = 0x000b
+	addrINPT4  = 0x000c
+)
+
+func newVCS(t *testing.T) *hardware.VCS {
+	t.Helper()
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	return vcs
+}
+
+// peek returns the value of the register without side effects
+func peek(t *testing.T, vcs *hardware.VCS, address uint16) uint8 {
+	t.Helper()
+
+	v, err := vcs.Mem.Peek(address)
+	if err != nil {
+		t.Fatalf("cannot peek address %#04x: %v", address, err)
+	}
+
+	return v
+}
+
+// write a value to the register as the CPU would and step the RIOT so that
+// the write is serviced
+func write(t *testing.T, vcs *hardware.VCS, address uint16, data uint8) {
+	t.Helper()
+
+	err := vcs.Mem.Write(address, data)
+	if err != nil {
+		t.Fatalf("cannot write address %#04x: %v", address, err)
+	}
+
+	vcs.RIOT.Step()
+}
+
+func handle(t *testing.T, port input.Port, ev input.Event, v input.EventValue) {
+	t.Helper()
+
+	err := port.Handle(ev, v)
+	if err != nil {
+		t.Fatalf("cannot handle %s event: %v", ev, err)
+	}
+}
+
+func TestDrivingGrayCode(t *testing.T) {
+	vcs := newVCS(t)
+
+	// the gray code sequence for clockwise rotation, in the up and down bits
+	// of the left port
+	clockwise := []uint8{0x30, 0x10, 0x00, 0x20}
+
+	vcs.RIOT.Input.HandController0.ForceType(input.DrivingType)
+
+	for i := 0; i < len(clockwise)*2; i++ {
+		v := peek(t, vcs, addrSWCHA) & 0x30
+		if v != clockwise[i%len(clockwise)] {
+			t.Errorf("clockwise step %d: unexpected SWCHA bits %#02x", i, v)
+		}
+		handle(t, vcs.HandController0, input.DrivingStep, 1)
+	}
+
+	// counter-clockwise rotation goes through the sequence in reverse. only
+	// one bit should change at each step
+	prev := peek(t, vcs, addrSWCHA) & 0x30
+	for i := 0; i < len(clockwise)*2; i++ {
+		handle(t, vcs.HandController0, input.DrivingStep, -1)
+		v := peek(t, vcs, addrSWCHA) & 0x30
+		if d := v ^ prev; d != 0x10 && d != 0x20 {
+			t.Errorf("counter-clockwise step %d: more than one bit changed (%#02x to %#02x)", i, prev, v)
+		}
+		prev = v
+	}
+	if prev != clockwise[0] {
+		t.Errorf("driving controller did not return to its starting position")
+	}
+
+	// the right port uses the lower bits of SWCHA
+	vcs.RIOT.Input.HandController1.ForceType(input.DrivingType)
+	handle(t, vcs.HandController1, input.DrivingStep, float32(1))
+	if v := peek(t, vcs, addrSWCHA) & 0x03; v != clockwise[1]>>4 {
+		t.Errorf("right port: unexpected SWCHA bits %#02x", v)
+	}
+}
+
+func TestDrivingRotate(t *testing.T) {
+	vcs := newVCS(t)
+
+	vcs.RIOT.Input.HandController0.ForceType(input.DrivingType)
+
+	// one step per frame
+	handle(t, vcs.HandController0, input.DrivingRotate, float32(-1.0))
+
+	// 19912 is the number of CPU cycles per frame assumed by the driving
+	// controller. the RIOT is stepped once per CPU cycle
+	for i := 0; i < 19912; i++ {
+		vcs.RIOT.Input.Step()
+	}
+
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != 0x20 {
+		t.Errorf("unexpected SWCHA bits after one frame of rotation %#02x", v)
+	}
+
+	// stopping rotation leaves the controller where it is
+	handle(t, vcs.HandController0, input.DrivingRotate, float32(0.0))
+	for i := 0; i < 19912; i++ {
+		vcs.RIOT.Input.Step()
+	}
+
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != 0x20 {
+		t.Errorf("driving controller moved after rotation has stopped %#02x", v)
+	}
+
+	// the driving controller only responds to driving events once selected
+	vcs.RIOT.Input.HandController0.ForceType(input.JoystickType)
+	handle(t, vcs.HandController0, input.DrivingStep, 1)
+	if vcs.RIOT.Input.HandController0.ControllerType() != input.JoystickType {
+		t.Errorf("driving event changed the controller type")
+	}
+}
+
+func TestBoosterGrip(t *testing.T) {
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.BoosterGripType)
+
+	// the booster and trigger buttons are connected to the paddle inputs of
+	// the port. the inputs read high when the button is pressed
+	if peek(t, vcs, addrINPT0)&0x80 != 0x00 || peek(t, vcs, addrINPT1)&0x80 != 0x00 {
+		t.Errorf("booster grip buttons should not be pressed")
+	}
+
+	handle(t, vcs.HandController0, input.BoosterGripBooster, true)
+	if peek(t, vcs, addrINPT0)&0x80 != 0x80 {
+		t.Errorf("booster button not seen in INPT0")
+	}
+	if peek(t, vcs, addrINPT1)&0x80 != 0x00 {
+		t.Errorf("booster button seen in INPT1")
+	}
+
+	handle(t, vcs.HandController0, input.BoosterGripTrigger, true)
+	if peek(t, vcs, addrINPT1)&0x80 != 0x80 {
+		t.Errorf("trigger button not seen in INPT1")
+	}
+
+	// grounding the paddle inputs with VBLANK overrides the buttons
+	vcs.RIOT.Input.VBlankBits.SetGroundPaddles(true)
+	if peek(t, vcs, addrINPT0)&0x80 != 0x00 || peek(t, vcs, addrINPT1)&0x80 != 0x00 {
+		t.Errorf("booster grip buttons should read low when paddle inputs are grounded")
+	}
+	vcs.RIOT.Input.VBlankBits.SetGroundPaddles(false)
+	if peek(t, vcs, addrINPT0)&0x80 != 0x80 || peek(t, vcs, addrINPT1)&0x80 != 0x80 {
+		t.Errorf("booster grip buttons should read high when paddle inputs are released")
+	}
+
+	handle(t, vcs.HandController0, input.BoosterGripBooster, false)
+	handle(t, vcs.HandController0, input.BoosterGripTrigger, false)
+	if peek(t, vcs, addrINPT0)&0x80 != 0x00 || peek(t, vcs, addrINPT1)&0x80 != 0x00 {
+		t.Errorf("booster grip buttons should have been released")
+	}
+
+	// the fire button and directions are the same as the joystick
+	handle(t, vcs.HandController0, input.Fire, true)
+	if peek(t, vcs, addrINPT4)&0x80 != 0x00 {
+		t.Errorf("fire button not seen in INPT4")
+	}
+	handle(t, vcs.HandController0, input.Up, true)
+	if peek(t, vcs, addrSWCHA)&0x10 != 0x00 {
+		t.Errorf("up direction not seen in SWCHA")
+	}
+
+	// the right port uses INPT2 and INPT3
+	vcs.RIOT.Input.HandController1.ForceType(input.BoosterGripType)
+	handle(t, vcs.HandController1, input.BoosterGripBooster, true)
+	handle(t, vcs.HandController1, input.BoosterGripTrigger, true)
+	if peek(t, vcs, addrINPT2)&0x80 != 0x80 || peek(t, vcs, addrINPT3)&0x80 != 0x80 {
+		t.Errorf("right port booster grip buttons not seen in INPT2 and INPT3")
+	}
+
+	// forcing the type releases the buttons. the extra button of the Genesis
+	// pad is not part of the booster grip
+	vcs.RIOT.Input.HandController1.ForceType(input.BoosterGripType)
+	handle(t, vcs.HandController1, input.GenesisButtonC, true)
+	if peek(t, vcs, addrINPT3)&0x80 != 0x00 {
+		t.Errorf("Genesis button event changed booster grip trigger")
+	}
+}
@@ -108,6 +108,16 @@ func NewInput(riotMem bus.ChipBus, tiaMem bus.ChipBus) (*Input, error) {
 	return inp, nil
 }
 
+// PersistPeripherals sets whether the peripherals attached to the hand
+// controllers (the SaveKey and AtariVox) load and save their contents from and
+// to disk. It should only be set when the emulation is interactive and is not
+// being recorded. Otherwise, the peripherals start empty so that emulation is
+// reproducible. Only affects peripherals attached after the call.
+func (inp *Input) PersistPeripherals(persist bool) {
+	inp.HandController0.persist = persist
+	inp.HandController1.persist = persist
+}
+
 // ReadMemory checks to see if ChipData applies to the Input type and
 // updates the internal controller/panel states accordingly. Returns true if
 // the ChipData was *not* serviced.
@@ -120,6 +130,12 @@ func (inp *Input) ReadMemory(data bus.ChipData) bool {
 		// write data back to memory
 		inp.mem.riot.InputDeviceWrite(addresses.SWCHA, data.Value, 0x00)
 
+		// peripherals that drive SWCHA lines in response to the CPU
+		inp.HandController0.updateSaveKey()
+		inp.HandController1.updateSaveKey()
+		inp.HandController0.updateKidVid(true)
+		inp.HandController1.updateKidVid(true)
+
 	case "SWACNT":
 		inp.HandController0.setDDR(data.Value)
 		inp.HandController1.setDDR(data.Value)
@@ -152,4 +168,30 @@ func (inp *Input) Step() {
 	// step.
 	inp.HandController0.recharge()
 	inp.HandController1.recharge()
+
+	// likewise, the continuous rotation of the driving controller
+	inp.HandController0.rotate()
+	inp.HandController1.rotate()
+
+	// and the movement of the Trak-Ball or mouse
+	inp.HandController0.track()
+	inp.HandController1.track()
+
+	// and the serial line of the AtariVox
+	if inp.HandController0.savekey != nil {
+		inp.HandController0.savekey.step()
+	}
+	if inp.HandController1.savekey != nil {
+		inp.HandController1.savekey.step()
+	}
+
+	// and the Kid Vid tape
+	if inp.HandController0.kidvid != nil {
+		inp.HandController0.kidvid.step()
+		inp.HandController0.updateKidVid(false)
+	}
+	if inp.HandController1.kidvid != nil {
+		inp.HandController1.kidvid.step()
+		inp.HandController1.updateKidVid(false)
+	}
 }
@@ -0,0 +1,408 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+import (
+	"encoding/binary"
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/memory/addresses"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// the Kid Vid cassette player is connected to the VCS through the controller
+// port. the pins are given here normalised to the upper nibble (see the
+// SaveKey for a description of normalisation)
+//
+// the cartridge starts and stops the tape motor with pin 1 and selects the
+// track to play with pins 2 and 3. the data track of the cassette drives pin 4
+const (
+	kidVidMotor     = uint8(0x10)
+	kidVidTrackPins = uint8(0x60)
+	kidVidData      = uint8(0x80)
+)
+
+// the track selected by the cartridge is the value of the track pins, shifted
+// by this amount. a value of zero selects no track
+const kidVidTrackShift = 5
+
+// the number of tracks on a tape
+const kidVidNumTracks = 3
+
+// the number of CPU cycles per second. the tape is advanced every CPU cycle
+const kidVidCyclesPerSecond = 1193182.0
+
+// the length, in seconds, of the marker generated on the data track at the
+// end of a track of a mono tape
+const kidVidMarkerLength = 0.1
+
+// kidVidTrackFile returns the filename of the track of the tape with the base
+// filename. tracks are numbered from one
+func kidVidTrackFile(base string, track int) string {
+	return fmt.Sprintf("%s_%d.wav", base, track)
+}
+
+// KidVid represents the Kid Vid cassette interface, as used by Berenstain
+// Bears and Smurfs Save the Day.
+//
+// A tape is a set of WAV files, one for each track, named after the base
+// filename of the tape: "<base>_1.wav", "<base>_2.wav" and "<base>_3.wav". The
+// cartridge selects the track and starts and stops the tape through the
+// controller port.
+//
+// The left channel of a WAV file is the voice track, which is played through
+// the audio mixer, and the right channel is the data track, which the
+// cartridge reads through the controller port. For a mono WAV file the data
+// track is generated: a marker is placed at the end of the track.
+type KidVid struct {
+	// the base filename of the tape. empty if no tape has been loaded
+	filename string
+
+	// the tracks of the tape. a track that could not be found has no samples
+	tracks [kidVidNumTracks]kidVidTrack
+
+	// the track currently selected, counting from one. zero if no track has
+	// been selected
+	track int
+
+	// the value of the track pins as last written by the cartridge
+	trackPins int
+
+	// the current position in the selected track, in samples
+	pos float64
+
+	// whether the play button of the cassette player is pressed. the tape
+	// only moves if the cartridge has also started the motor
+	playing bool
+
+	// whether the cartridge has started the tape motor
+	motor bool
+
+	// the state of the data pin as last written to SWCHA
+	pin bool
+}
+
+// kidVidTrack is a single track of a Kid Vid tape
+type kidVidTrack struct {
+	// the samples of the voice and data tracks in the range -1.0 to 1.0. the
+	// data slice is nil for mono tracks
+	voice []float32
+	data  []float32
+
+	// the sample rate of the track
+	rate float64
+}
+
+// newKidVid is the preferred method of initialisation for the KidVid type.
+func newKidVid() *KidVid {
+	return &KidVid{pin: true}
+}
+
+func (kv *KidVid) String() string {
+	if kv.filename == "" {
+		return "no tape"
+	}
+
+	if kv.track == 0 {
+		return fmt.Sprintf("%s: no track selected", filepath.Base(kv.filename))
+	}
+
+	state := "stopped"
+	if kv.running() {
+		state = "playing"
+	} else if kv.playing {
+		state = "motor off"
+	}
+
+	t := kv.tracks[kv.track-1]
+	if len(t.voice) == 0 {
+		return fmt.Sprintf("%s: track %d is missing", filepath.Base(kv.filename), kv.track)
+	}
+
+	return fmt.Sprintf("%s: track %d: %.1fs of %.1fs (%s)", filepath.Base(kv.filename),
+		kv.track, kv.pos/t.rate, float64(len(t.voice))/t.rate, state)
+}
+
+// Filename returns the base filename of the tape.
+func (kv *KidVid) Filename() string {
+	return kv.filename
+}
+
+// Track returns the track selected by the cartridge, counting from one.
+// Returns zero if no track has been selected.
+func (kv *KidVid) Track() int {
+	return kv.track
+}
+
+// Playing returns true if the play button of the cassette player is pressed.
+// The tape only moves if the cartridge has also started the motor.
+func (kv *KidVid) Playing() bool {
+	return kv.playing
+}
+
+// load a tape from the WAV files for each track. tracks that do not exist are
+// left empty but at least one track must exist. the play button is pressed so
+// that the tape is under the control of the cartridge
+func (kv *KidVid) load(base string) error {
+	var tracks [kidVidNumTracks]kidVidTrack
+	found := false
+
+	for i := range tracks {
+		filename := kidVidTrackFile(base, i+1)
+
+		d, err := ioutil.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // for loop
+			}
+			return errors.New(errors.KidVidError, err)
+		}
+
+		voice, data, rate, err := decodeWAV(d)
+		if err != nil {
+			return errors.New(errors.KidVidError, fmt.Sprintf("%s: %v", filename, err))
+		}
+
+		tracks[i] = kidVidTrack{voice: voice, data: data, rate: float64(rate)}
+		found = true
+	}
+
+	if !found {
+		return errors.New(errors.KidVidError, fmt.Sprintf("no tracks for tape %s", base))
+	}
+
+	kv.filename = base
+	kv.tracks = tracks
+	kv.track = 0
+	kv.trackPins = 0
+	kv.pos = 0
+	kv.playing = true
+
+	return nil
+}
+
+// the cartridge has written to SWCHA or SWACNT. the state of the motor and
+// track pins is noted. only pins set to output in the DDR are driven by the
+// cartridge. selecting a track rewinds it to the beginning
+func (kv *KidVid) control(swcha uint8, ddr uint8) {
+	pins := swcha & ddr
+
+	kv.motor = pins&kidVidMotor == kidVidMotor
+
+	track := int(pins&kidVidTrackPins) >> kidVidTrackShift
+	if track != kv.trackPins && track != 0 {
+		kv.track = track
+		kv.pos = 0
+	}
+	kv.trackPins = track
+}
+
+// press or release the play button. playing a track that has reached the end
+// rewinds it
+func (kv *KidVid) play(play bool) {
+	if kv.filename == "" {
+		return
+	}
+
+	if play && kv.track != 0 && int(kv.pos) >= len(kv.tracks[kv.track-1].voice) {
+		kv.pos = 0
+	}
+
+	kv.playing = play
+}
+
+// running returns true if the tape is moving. the tape stops at the end of
+// the selected track
+func (kv *KidVid) running() bool {
+	if !kv.playing || !kv.motor || kv.track == 0 {
+		return false
+	}
+	return int(kv.pos) < len(kv.tracks[kv.track-1].voice)
+}
+
+// advance the tape by one CPU cycle
+func (kv *KidVid) step() {
+	if !kv.running() {
+		return
+	}
+
+	t := kv.tracks[kv.track-1]
+	kv.pos += t.rate / kidVidCyclesPerSecond
+	if int(kv.pos) >= len(t.voice) {
+		kv.pos = float64(len(t.voice))
+	}
+}
+
+// the state of the data track at the current position of the tape. the
+// interface squares the signal so the pin is high whenever the signal is
+// positive. for mono tracks the pin is held low for the length of the marker
+// at the end of the track. the pin is pulled high when the tape is not moving
+func (kv *KidVid) dataPin() bool {
+	if !kv.running() {
+		return true
+	}
+
+	t := kv.tracks[kv.track-1]
+	if t.data == nil {
+		return kv.pos < float64(len(t.voice))-kidVidMarkerLength*t.rate
+	}
+
+	return t.data[int(kv.pos)] >= 0
+}
+
+// the sample of the voice track at the current position of the tape. zero
+// when the tape is not moving
+func (kv *KidVid) sample() float32 {
+	if !kv.running() {
+		return 0
+	}
+	return kv.tracks[kv.track-1].voice[int(kv.pos)]
+}
+
+// decodeWAV decodes a PCM WAV file. the first channel is returned as the
+// voice track and the second channel, if present, as the data track.
+func decodeWAV(d []byte) ([]float32, []float32, int, error) {
+	if len(d) < 12 || string(d[0:4]) != "RIFF" || string(d[8:12]) != "WAVE" {
+		return nil, nil, 0, fmt.Errorf("not a WAV file")
+	}
+
+	var channels, bits, rate int
+	var samples []byte
+
+	// walk through chunks
+	d = d[12:]
+	for len(d) >= 8 {
+		id := string(d[0:4])
+		size := int(binary.LittleEndian.Uint32(d[4:8]))
+		d = d[8:]
+		if size > len(d) {
+			size = len(d)
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, nil, 0, fmt.Errorf("malformed fmt chunk")
+			}
+			if binary.LittleEndian.Uint16(d[0:2]) != 1 {
+				return nil, nil, 0, fmt.Errorf("only PCM WAV files are supported")
+			}
+			channels = int(binary.LittleEndian.Uint16(d[2:4]))
+			rate = int(binary.LittleEndian.Uint32(d[4:8]))
+			bits = int(binary.LittleEndian.Uint16(d[14:16]))
+		case "data":
+			samples = d[:size]
+		}
+
+		// chunks are padded to an even number of bytes
+		if size%2 == 1 && size < len(d) {
+			size++
+		}
+		d = d[size:]
+	}
+
+	if channels == 0 || rate == 0 {
+		return nil, nil, 0, fmt.Errorf("no fmt chunk")
+	}
+	if bits != 8 && bits != 16 {
+		return nil, nil, 0, fmt.Errorf("only 8 and 16 bit samples are supported")
+	}
+	if len(samples) == 0 {
+		return nil, nil, 0, fmt.Errorf("no samples")
+	}
+
+	frameSize := channels * bits / 8
+	n := len(samples) / frameSize
+
+	voice := make([]float32, n)
+	var data []float32
+	if channels > 1 {
+		data = make([]float32, n)
+	}
+
+	sample := func(b []byte) float32 {
+		if bits == 8 {
+			// 8 bit samples are unsigned
+			return (float32(b[0]) - 128.0) / 128.0
+		}
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+	}
+
+	for i := 0; i < n; i++ {
+		f := samples[i*frameSize:]
+		voice[i] = sample(f)
+		if data != nil {
+			data[i] = sample(f[bits/8:])
+		}
+	}
+
+	return voice, data, rate, nil
+}
+
+// KidVid returns the Kid Vid attached to the hand controller port. Returns
+// nil if no Kid Vid is attached.
+func (hc *HandController) KidVid() *KidVid {
+	return hc.kidvid
+}
+
+// write the state of the data pin to SWCHA. the pin is written only if it
+// has changed, unless the force flag is set. CPU writes to SWCHA or SWACNT will
+// have clobbered the pin and changed the pins driven by the cartridge so the
+// flag should be set in that case.
+func (hc *HandController) updateKidVid(force bool) {
+	if hc.kidvid == nil {
+		return
+	}
+
+	if force {
+		hc.kidvid.control(hc.swcha, hc.ddr)
+	}
+
+	pin := hc.kidvid.dataPin()
+	if pin == hc.kidvid.pin && !force {
+		return
+	}
+	hc.kidvid.pin = pin
+
+	var data uint8
+	if pin {
+		data = kidVidData
+	}
+
+	// only the pins set to input in the DDR can be written to by the Kid Vid
+	mask := kidVidData &^ hc.ddr
+	hc.mem.riot.InputDeviceWrite(addresses.SWCHA, hc.normaliseOnWrite(data&mask), ^hc.normaliseOnWrite(mask))
+}
+
+// ExternalAudio returns the current sample of the voice track of any Kid Vid
+// attached to either hand controller port. It satisfies the
+// audio.ExternalSource interface.
+func (inp *Input) ExternalAudio() float32 {
+	var v float32
+	if inp.HandController0.kidvid != nil {
+		v += inp.HandController0.kidvid.sample()
+	}
+	if inp.HandController1.kidvid != nil {
+		v += inp.HandController1.kidvid.sample()
+	}
+	return v
+}
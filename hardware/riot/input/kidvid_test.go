@@ -0,0 +1,310 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// the sample rate of the test tapes. the tape advances by one sample every
+// kidVidCycles CPU cycles
+const (
+	kidVidRate   = 1000
+	kidVidCycles = 1194
+)
+
+// wav creates a WAV file with the format and sample data. the format should be
+// 1 for PCM data
+func wav(format int, channels int, bits int, samples []byte) []byte {
+	d := []byte("RIFF\x00\x00\x00\x00WAVE")
+
+	fmtChunk := make([]byte, 24)
+	copy(fmtChunk, "fmt ")
+	binary.LittleEndian.PutUint32(fmtChunk[4:], 16)
+	binary.LittleEndian.PutUint16(fmtChunk[8:], uint16(format))
+	binary.LittleEndian.PutUint16(fmtChunk[10:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[12:], kidVidRate)
+	binary.LittleEndian.PutUint32(fmtChunk[16:], uint32(kidVidRate*channels*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[20:], uint16(channels*bits/8))
+	binary.LittleEndian.PutUint16(fmtChunk[22:], uint16(bits))
+	d = append(d, fmtChunk...)
+
+	dataChunk := make([]byte, 8)
+	copy(dataChunk, "data")
+	binary.LittleEndian.PutUint32(dataChunk[4:], uint32(len(samples)))
+	d = append(d, dataChunk...)
+	d = append(d, samples...)
+
+	binary.LittleEndian.PutUint32(d[4:], uint32(len(d)-8))
+
+	return d
+}
+
+// sample16 converts the values to 16 bit little-endian samples
+func sample16(v ...int16) []byte {
+	d := make([]byte, len(v)*2)
+	for i := range v {
+		binary.LittleEndian.PutUint16(d[i*2:], uint16(v[i]))
+	}
+	return d
+}
+
+// the base filename of the test tapes
+const kidVidTape = "tape"
+
+// loadTape writes the data for each track to a file and loads the tape into
+// the Kid Vid attached to the left port. tracks with no data are not written
+func loadTape(t *testing.T, vcs *hardware.VCS, tracks ...[]byte) error {
+	t.Helper()
+
+	for i, d := range tracks {
+		filename := fmt.Sprintf("%s_%d.wav", kidVidTape, i+1)
+		_ = os.Remove(filename)
+		if d == nil {
+			continue // for loop
+		}
+		if err := ioutil.WriteFile(filename, d, 0600); err != nil {
+			t.Fatalf("cannot write tape: %v", err)
+		}
+	}
+
+	return vcs.HandController0.Handle(input.KidVidTape, kidVidTape)
+}
+
+// the pins of the left port used by the Kid Vid
+const (
+	kidVidMotor = 0x10
+	kidVidTrack = 0x20
+	kidVidData  = 0x80
+)
+
+// selectTrack writes to the port as the cartridge would, to select the track
+// and to start or stop the motor
+func selectTrack(t *testing.T, vcs *hardware.VCS, track int, motor bool) {
+	t.Helper()
+
+	v := uint8(track * kidVidTrack)
+	if motor {
+		v |= kidVidMotor
+	}
+	write(t, vcs, addrSWACNT, kidVidMotor|kidVidTrack*3)
+	write(t, vcs, addrSWCHA, v)
+}
+
+// advance the tape by the number of samples
+func stepTape(vcs *hardware.VCS, samples int) {
+	for i := 0; i < samples*kidVidCycles; i++ {
+		vcs.RIOT.Input.Step()
+	}
+}
+
+func TestKidVidDecode(t *testing.T) {
+	defer chdir(t)()
+
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.KidVidType)
+
+	bad := map[string][]byte{
+		"not a WAV file":   []byte("RIFF\x00\x00\x00\x00AVI "),
+		"not PCM":          wav(3, 1, 16, sample16(0, 0)),
+		"24 bit samples":   wav(1, 1, 24, make([]byte, 6)),
+		"no samples":       wav(1, 1, 16, nil),
+		"no fmt chunk":     []byte("RIFF\x04\x00\x00\x00WAVE"),
+		"truncated header": []byte("RIFF"),
+	}
+
+	for name, d := range bad {
+		if err := loadTape(t, vcs, d); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if err := loadTape(t, vcs); err == nil {
+		t.Errorf("expected error for tape with no tracks")
+	}
+
+	// 8 bit samples are unsigned
+	if err := loadTape(t, vcs, wav(1, 1, 8, []byte{0xc0, 0x40, 0x80})); err != nil {
+		t.Fatalf("cannot load 8 bit tape: %v", err)
+	}
+	selectTrack(t, vcs, 1, true)
+	for i, v := range []float32{0.5, -0.5, 0.0} {
+		if a := vcs.RIOT.Input.ExternalAudio(); a != v {
+			t.Errorf("8 bit sample %d: expected %f but got %f", i, v, a)
+		}
+		stepTape(vcs, 1)
+	}
+}
+
+func TestKidVid(t *testing.T) {
+	defer chdir(t)()
+
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.KidVidType)
+
+	// the left channel is the voice track and the right channel is the data
+	// track
+	tape := wav(1, 2, 16, sample16(
+		16384, 1000,
+		-16384, -1000,
+		8192, 1000,
+	))
+	if err := loadTape(t, vcs, tape); err != nil {
+		t.Fatalf("cannot load tape: %v", err)
+	}
+
+	// the data pin is pulled high and there is no sound until the cartridge
+	// has selected a track and started the motor
+	if peek(t, vcs, addrSWCHA)&kidVidData != kidVidData {
+		t.Errorf("data pin should be high when the tape is stopped")
+	}
+	selectTrack(t, vcs, 1, false)
+	stepTape(vcs, 1)
+	if vcs.RIOT.Input.ExternalAudio() != 0 {
+		t.Errorf("voice track should be silent when the motor is off")
+	}
+
+	selectTrack(t, vcs, 1, true)
+
+	// the voice track is not quantised and swings either side of zero
+	expect := []struct {
+		voice float32
+		pin   uint8
+	}{
+		{voice: 0.5, pin: kidVidData},
+		{voice: -0.5, pin: 0x00},
+		{voice: 0.25, pin: kidVidData},
+	}
+
+	for i, e := range expect {
+		if v := vcs.RIOT.Input.ExternalAudio(); v != e.voice {
+			t.Errorf("sample %d: expected voice %f but got %f", i, e.voice, v)
+		}
+		if v := peek(t, vcs, addrSWCHA) & kidVidData; v != e.pin {
+			t.Errorf("sample %d: expected data pin %#02x but got %#02x", i, e.pin, v)
+		}
+		stepTape(vcs, 1)
+	}
+
+	// the tape stops at the end of the track
+	if vcs.RIOT.Input.ExternalAudio() != 0 {
+		t.Errorf("voice track should be silent at the end of the track")
+	}
+	if peek(t, vcs, addrSWCHA)&kidVidData != kidVidData {
+		t.Errorf("data pin should be high at the end of the track")
+	}
+
+	// pressing play again rewinds the track
+	handle(t, vcs.HandController0, input.KidVidTogglePlay, nil)
+	if vcs.RIOT.Input.HandController0.KidVid().Playing() {
+		t.Errorf("play button should have been released")
+	}
+	handle(t, vcs.HandController0, input.KidVidTogglePlay, nil)
+	if v := vcs.RIOT.Input.ExternalAudio(); v != 0.5 {
+		t.Errorf("tape did not rewind (voice %f)", v)
+	}
+}
+
+func TestKidVidTrackSelection(t *testing.T) {
+	defer chdir(t)()
+
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.KidVidType)
+
+	// two mono tracks. the marker at the end of each track is generated
+	track := func(n int, v int16) []byte {
+		d := make([]int16, n)
+		for i := range d {
+			d[i] = v
+		}
+		return wav(1, 1, 16, sample16(d...))
+	}
+	if err := loadTape(t, vcs, track(300, 8192), track(500, -8192)); err != nil {
+		t.Fatalf("cannot load tape: %v", err)
+	}
+
+	selectTrack(t, vcs, 2, true)
+	kv := vcs.RIOT.Input.HandController0.KidVid()
+	if kv.Track() != 2 {
+		t.Fatalf("expected track 2 to be selected (got %d)", kv.Track())
+	}
+	if v := vcs.RIOT.Input.ExternalAudio(); v != -0.25 {
+		t.Errorf("expected voice of track 2 but got %f", v)
+	}
+
+	// the marker lasts for the final 100 samples (0.1s) of the track after
+	// which the tape stops and the pin is pulled high
+	type transition struct {
+		sample int
+		pin    uint8
+	}
+	expect := []transition{{sample: 400, pin: 0x00}, {sample: 500, pin: kidVidData}}
+
+	var got []transition
+	pin := peek(t, vcs, addrSWCHA) & kidVidData
+	if pin != kidVidData {
+		t.Errorf("data pin should be high at the start of the track")
+	}
+	for i := 1; i <= 600; i++ {
+		stepTape(vcs, 1)
+		if p := peek(t, vcs, addrSWCHA) & kidVidData; p != pin {
+			got = append(got, transition{sample: i, pin: p})
+			pin = p
+		}
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(expect) {
+		t.Errorf("unexpected data pin transitions: %v (expected %v)", got, expect)
+	}
+
+	// stopping the motor stops the tape
+	selectTrack(t, vcs, 1, true)
+	stepTape(vcs, 10)
+	selectTrack(t, vcs, 1, false)
+	stepTape(vcs, 10)
+	if v := vcs.RIOT.Input.ExternalAudio(); v != 0 {
+		t.Errorf("voice track should be silent when the motor is off")
+	}
+	if !strings.Contains(kv.String(), "track 1: 0.0s of 0.3s (motor off)") {
+		t.Errorf("unexpected Kid Vid state: %s", kv)
+	}
+
+	// selecting the same track again rewinds it
+	selectTrack(t, vcs, 1, true)
+	stepTape(vcs, 200)
+	if !strings.Contains(kv.String(), "track 1: 0.2s of 0.3s (playing)") {
+		t.Errorf("unexpected Kid Vid state: %s", kv)
+	}
+	selectTrack(t, vcs, 0, true)
+	selectTrack(t, vcs, 1, true)
+	if v := vcs.RIOT.Input.ExternalAudio(); v != 0.25 {
+		t.Errorf("expected voice of track 1 but got %f", v)
+	}
+	if !strings.Contains(kv.String(), "track 1: 0.0s of 0.3s (playing)") {
+		t.Errorf("track 1 has not been rewound: %s", kv)
+	}
+}
@@ -87,6 +87,13 @@ func (pan *Panel) String() string {
 	return s.String()
 }
 
+// Switches returns the state of the panel's switches. The player difficulty
+// switches return true if they are set to "pro" and the color switch returns
+// true if it is set to "color".
+func (pan *Panel) Switches() (p0pro bool, p1pro bool, color bool) {
+	return pan.p0pro, pan.p1pro, pan.color
+}
+
 func (pan *Panel) write() {
 	// commit changes to RIOT memory
 	v := uint8(0)
@@ -27,6 +27,9 @@ package input
 type Playback interface {
 	// note the type restrictions on EventValue in the type definition's
 	// commentary
+	//
+	// CheckInput() is called repeatedly until it returns NoEvent, so an
+	// implementation must eventually return NoEvent for the current moment
 	CheckInput(id ID) (Event, EventValue, error)
 }
 
@@ -0,0 +1,166 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+// the pointer type implements the Trak-Ball and the Amiga and Atari ST mice.
+// movement of the host's pointer is converted into a number of pending steps
+// for each axis. the pending steps are then output to SWCHA one at a time, so
+// that the VCS has a chance to see every step.
+type pointer struct {
+	// the number of steps over the width (or height) of the display
+	resolution float32
+
+	// fractions of a step not yet added to the pending steps
+	accumX, accumY float32
+
+	// the number of steps still to be output for each axis. positive values
+	// are right (for the horizontal axis) and down (for the vertical axis)
+	pendingX, pendingY int
+
+	// the position in the quadrature sequence for each axis
+	countX, countY int
+
+	// the direction of the most recent step of each axis. used by the
+	// Trak-Ball, which outputs direction as a separate signal
+	left, down bool
+
+	// the number of cycles since the last step
+	cycles int
+}
+
+// the default number of steps over the width of the display
+const defaultPointerResolution = 320
+
+// the number of CPU cycles between each step output to SWCHA. too short an
+// interval and the VCS will miss steps. this value is four scanlines
+const pointerCyclesPerStep = 304
+
+// the quadrature patterns of the pointer types. the patterns are for the
+// upper nibble of SWCHA. bit 4 is the "up" direction of the joystick, bit 5
+// is "down", bit 6 is "left" and bit 7 is "right"
+//
+// the Trak-Ball (in Trak-Ball mode) does not use a quadrature pattern. bits 4
+// and 7 toggle on every step of the horizontal and vertical axes; bits 5 and 6
+// indicate the direction of movement (left and up respectively)
+var amigaMouseH = [4]uint8{0x00, 0x20, 0xa0, 0x80}
+var amigaMouseV = [4]uint8{0x00, 0x40, 0x50, 0x10}
+var stMouseH = [4]uint8{0x00, 0x10, 0x30, 0x20}
+var stMouseV = [4]uint8{0x00, 0x40, 0xc0, 0x80}
+
+// IsPointer returns true if the controller type is a Trak-Ball or mouse
+func (t ControllerType) IsPointer() bool {
+	return t == TrakBallType || t == AmigaMouseType || t == STMouseType
+}
+
+// reset pointer state (but not the resolution) and write the initial state
+// of the pointer to SWCHA
+func (hc *HandController) resetPointer() {
+	res := hc.pointer.resolution
+	if res == 0 {
+		res = defaultPointerResolution
+	}
+	hc.pointer = pointer{resolution: res}
+	hc.writePointer()
+}
+
+// add the movement of the host's pointer on the horizontal (or vertical) axis
+// to the pending steps. fractions of a step are accumulated
+func (hc *HandController) movePointer(horiz bool, delta float32) {
+	p := &hc.pointer
+
+	if horiz {
+		p.accumX += delta * p.resolution
+		n := int(p.accumX)
+		p.accumX -= float32(n)
+		p.pendingX += n
+	} else {
+		p.accumY += delta * p.resolution
+		n := int(p.accumY)
+		p.accumY -= float32(n)
+		p.pendingY += n
+	}
+}
+
+// track() is called every cycle via Input.Step() and outputs pending
+// pointer steps to SWCHA
+func (hc *HandController) track() {
+	if !hc.which.IsPointer() {
+		return
+	}
+
+	p := &hc.pointer
+
+	if p.pendingX == 0 && p.pendingY == 0 {
+		return
+	}
+
+	p.cycles++
+	if p.cycles < pointerCyclesPerStep {
+		return
+	}
+	p.cycles = 0
+
+	if p.pendingX > 0 {
+		p.pendingX--
+		p.countX = (p.countX + 1) & 0x03
+		p.left = false
+	} else if p.pendingX < 0 {
+		p.pendingX++
+		p.countX = (p.countX - 1) & 0x03
+		p.left = true
+	}
+
+	if p.pendingY > 0 {
+		p.pendingY--
+		p.countY = (p.countY + 1) & 0x03
+		p.down = true
+	} else if p.pendingY < 0 {
+		p.pendingY++
+		p.countY = (p.countY - 1) & 0x03
+		p.down = false
+	}
+
+	hc.writePointer()
+}
+
+// write the current state of the pointer to SWCHA
+func (hc *HandController) writePointer() {
+	p := &hc.pointer
+
+	var data uint8
+
+	switch hc.which {
+	case TrakBallType:
+		data = uint8(p.countX&0x01) << 4
+		if p.left {
+			data |= 0x20
+		}
+		data |= uint8(p.countY&0x01) << 7
+		if !p.down {
+			data |= 0x40
+		}
+	case AmigaMouseType:
+		data = amigaMouseH[p.countX] | amigaMouseV[p.countY]
+	case STMouseType:
+		data = stMouseH[p.countX] | stMouseV[p.countY]
+	}
+
+	hc.writeSWCHA(data, hc.writeMask)
+}
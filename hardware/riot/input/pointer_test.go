@@ -0,0 +1,135 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"testing"
+)
+
+// the number of CPU cycles between each pointer step
+const pointerCyclesPerStep = 304
+
+// step the input system forward by the number of pointer steps
+func stepPointer(vcs *hardware.VCS, steps int) {
+	for i := 0; i < steps*pointerCyclesPerStep; i++ {
+		vcs.RIOT.Input.Step()
+	}
+}
+
+func TestPointerType(t *testing.T) {
+	for _, c := range []input.ControllerType{input.TrakBallType, input.AmigaMouseType, input.STMouseType} {
+		if !c.IsPointer() {
+			t.Errorf("%s should be a pointer type", c)
+		}
+	}
+	for _, c := range []input.ControllerType{input.JoystickType, input.PaddleType, input.DrivingType} {
+		if c.IsPointer() {
+			t.Errorf("%s should not be a pointer type", c)
+		}
+	}
+}
+
+func TestSTMouse(t *testing.T) {
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.STMouseType)
+
+	// a resolution and movement that can be represented exactly
+	handle(t, vcs.HandController0, input.PointerResolution, float32(256))
+
+	// the quadrature sequence of the horizontal axis
+	right := []uint8{0x00, 0x10, 0x30, 0x20, 0x00}
+
+	// three steps to the right, in a single movement
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(3.0/256.0))
+	for i := 0; i < 4; i++ {
+		if v := peek(t, vcs, addrSWCHA) & 0x30; v != right[i] {
+			t.Errorf("step %d: unexpected SWCHA bits %#02x", i, v)
+		}
+		stepPointer(vcs, 1)
+	}
+
+	// there are no more steps pending
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != right[3] {
+		t.Errorf("pointer moved more than expected %#02x", v)
+	}
+
+	// movements of less than a step are accumulated
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(1.0/512.0))
+	stepPointer(vcs, 1)
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != right[3] {
+		t.Errorf("pointer moved for less than a step %#02x", v)
+	}
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(1.0/512.0))
+	stepPointer(vcs, 1)
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != right[4] {
+		t.Errorf("accumulated movement did not produce a step %#02x", v)
+	}
+
+	// moving left goes through the sequence in reverse
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(-2.0/256.0))
+	stepPointer(vcs, 2)
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != right[2] {
+		t.Errorf("unexpected SWCHA bits after moving left %#02x", v)
+	}
+
+	// the vertical axis uses the other two bits
+	handle(t, vcs.HandController0, input.PointerMoveY, float32(1.0/256.0))
+	stepPointer(vcs, 1)
+	if v := peek(t, vcs, addrSWCHA) & 0xc0; v != 0x40 {
+		t.Errorf("unexpected SWCHA bits after moving down %#02x", v)
+	}
+}
+
+func TestTrakBall(t *testing.T) {
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.TrakBallType)
+	handle(t, vcs.HandController0, input.PointerResolution, float32(256))
+
+	// bit 4 toggles on every horizontal step. bit 5 is set when moving left
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(1.0/256.0))
+	stepPointer(vcs, 1)
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != 0x10 {
+		t.Errorf("unexpected SWCHA bits after moving right %#02x", v)
+	}
+
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(-1.0/256.0))
+	stepPointer(vcs, 1)
+	if v := peek(t, vcs, addrSWCHA) & 0x30; v != 0x20 {
+		t.Errorf("unexpected SWCHA bits after moving left %#02x", v)
+	}
+}
+
+func TestPointerIgnored(t *testing.T) {
+	vcs := newVCS(t)
+	vcs.RIOT.Input.HandController0.ForceType(input.JoystickType)
+
+	before := peek(t, vcs, addrSWCHA)
+	handle(t, vcs.HandController0, input.PointerMoveX, float32(0.5))
+	stepPointer(vcs, 2)
+
+	if vcs.RIOT.Input.HandController0.ControllerType() != input.JoystickType {
+		t.Errorf("pointer event changed the controller type")
+	}
+	if v := peek(t, vcs, addrSWCHA); v != before {
+		t.Errorf("pointer event changed SWCHA for a joystick (%#02x to %#02x)", before, v)
+	}
+}
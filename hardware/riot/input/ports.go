@@ -66,14 +66,20 @@ func (p *port) AttachEventRecorder(scribe EventRecorder) {
 	p.recorder = scribe
 }
 
-// CheckInput polls the attached playback for an Event
+// CheckInput polls the attached playback for an Event. The playback is polled
+// until it has no more events for the current moment because more than one
+// event can happen at the same time.
 func (p *port) CheckInput() error {
-	if p.playback != nil {
+	for p.playback != nil {
 		ev, v, err := p.playback.CheckInput(p.id)
 		if err != nil {
 			return err
 		}
 
+		if ev == NoEvent {
+			return nil
+		}
+
 		err = p.handle(ev, v)
 		if err != nil {
 			if !errors.Is(err, errors.InputDeviceUnplugged) {
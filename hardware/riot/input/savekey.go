@@ -0,0 +1,512 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+import (
+	"fmt"
+	"gopher2600/hardware/memory/addresses"
+	"gopher2600/paths"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// the SaveKey and AtariVox peripherals communicate with the VCS through the
+// four SWCHA bits of the port they are attached to. the bits are given here
+// normalised to the upper nibble (the hand controller's normaliseOnWrite()
+// and normaliseOnRead() functions adjust them for the correct port)
+//
+// the EEPROM is accessed through a "bit-banged" I2C bus on the SDA and SCL
+// pins. the AtariVox additionally has a serial line to the SpeakJet speech
+// chip and a line to signal that the SpeakJet is ready to receive data. the
+// SaveKey leaves these two pins unconnected.
+const (
+	saveKeySpeechData  = uint8(0x10)
+	saveKeySpeechReady = uint8(0x20)
+	saveKeySDA         = uint8(0x40)
+	saveKeySCL         = uint8(0x80)
+)
+
+// the name of the file (in the resource path) in which the contents of the
+// EEPROM are kept between sessions
+const saveKeyFile = "savekey_eeprom"
+
+// EEPROMSize is the number of bytes in the 24LC256 EEPROM.
+const EEPROMSize = 32768
+
+// write operations to the 24LC256 wrap around in pages of 64 bytes
+const eepromPageSize = 64
+
+// the I2C address of the EEPROM. the lowest bit of the control byte indicates
+// the direction of the transfer and is not part of the address
+const eepromAddress = uint8(0xa0)
+
+// the serial line to the SpeakJet runs at 19200 baud. the value is the number
+// of CPU cycles per bit
+const speechCyclesPerBit = 1193182.0 / 19200.0
+
+// the maximum number of speech codes kept in the speech log
+const maxSpeechLog = 256
+
+// the state of the I2C bus from the point of view of the EEPROM
+type i2cState int
+
+const (
+	i2cIdle i2cState = iota
+	i2cControl
+	i2cAddressHi
+	i2cAddressLo
+	i2cWrite
+	i2cReadStart
+	i2cRead
+)
+
+// SaveKey represents the SaveKey peripheral or, if the speech flag is set,
+// the AtariVox peripheral. Both peripherals contain a 24LC256 EEPROM. If the
+// peripheral is persistent, the contents of the EEPROM are loaded from disk
+// and saved to disk whenever the VCS finishes writing to it. Otherwise the
+// EEPROM starts empty and is never saved.
+type SaveKey struct {
+	// whether the peripheral is an AtariVox
+	speech bool
+
+	// the contents of the EEPROM
+	data [EEPROMSize]uint8
+
+	// the current address pointer of the EEPROM
+	address uint16
+
+	// the current state of the I2C bus
+	state i2cState
+
+	// the number of clock pulses in the current byte (including the
+	// acknowledge pulse) and the byte being sent or received
+	bits  int
+	shift uint8
+
+	// whether the VCS acknowledged the last byte read from the EEPROM
+	ack bool
+
+	// the state of the SDA line as driven by the EEPROM. the line is "open
+	// collector" so the EEPROM can only pull the line low
+	sda bool
+
+	// the most recent state of the SCL and SDA lines as driven by the VCS
+	lastSCL bool
+	lastSDA bool
+
+	// whether the EEPROM has been written to since the last save
+	dirty bool
+
+	// speech serial decoding
+	serial speechSerial
+
+	// the filename of the EEPROM on disk. empty if the peripheral is not
+	// persistent
+	filename string
+}
+
+// the speechSerial type decodes the serial stream sent to the SpeakJet. the
+// stream is one start bit (low), eight data bits (least significant bit
+// first) and one stop bit (high)
+type speechSerial struct {
+	line      bool
+	receiving bool
+	cycles    float32
+	bit       int
+	shift     uint8
+
+	log []uint8
+}
+
+// newSaveKey is the preferred method of initialisation for the SaveKey type.
+// If persist is true, the contents of the EEPROM are loaded from disk if
+// possible.
+func newSaveKey(speech bool, persist bool) *SaveKey {
+	sk := &SaveKey{
+		speech: speech,
+		sda:    true,
+		serial: speechSerial{line: true},
+	}
+
+	// an erased EEPROM is filled with 0xff
+	for i := range sk.data {
+		sk.data[i] = 0xff
+	}
+
+	// a peripheral that is not persistent starts with an empty EEPROM so that
+	// emulation is reproducible
+	if !persist {
+		return sk
+	}
+
+	// the contents of the EEPROM are not essential so failure to load (or to
+	// find the resource path) is not an error
+	pth, err := paths.ResourcePath("", saveKeyFile)
+	if err == nil {
+		sk.filename = pth
+		if d, err := ioutil.ReadFile(pth); err == nil {
+			copy(sk.data[:], d)
+		}
+	}
+
+	return sk
+}
+
+func (sk *SaveKey) String() string {
+	if sk.speech {
+		return "AtariVox"
+	}
+	return "SaveKey"
+}
+
+// Data returns a copy of the contents of the EEPROM.
+func (sk *SaveKey) Data() []uint8 {
+	d := make([]uint8, len(sk.data))
+	copy(d, sk.data[:])
+	return d
+}
+
+// Clear the contents of the EEPROM and, if the peripheral is persistent, save
+// the cleared EEPROM to disk.
+func (sk *SaveKey) Clear() error {
+	for i := range sk.data {
+		sk.data[i] = 0xff
+	}
+	sk.dirty = true
+	if sk.filename == "" {
+		return nil
+	}
+	return sk.Save()
+}
+
+// Save the contents of the EEPROM to disk.
+func (sk *SaveKey) Save() error {
+	if sk.filename == "" {
+		return fmt.Errorf("no file for %s EEPROM", sk)
+	}
+
+	f, err := os.Create(sk.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(sk.data[:])
+	if err != nil {
+		return err
+	}
+
+	sk.dirty = false
+
+	return nil
+}
+
+// Filename returns the name of the file the EEPROM is saved to. Returns the
+// empty string if the peripheral is not persistent.
+func (sk *SaveKey) Filename() string {
+	return sk.filename
+}
+
+// Speech returns the speech codes sent to the AtariVox, decoded into
+// SpeakJet allophones and instructions. Returns the empty string if the
+// peripheral is not an AtariVox.
+func (sk *SaveKey) Speech() string {
+	if !sk.speech {
+		return ""
+	}
+
+	s := strings.Builder{}
+	for i, c := range sk.serial.log {
+		if i > 0 {
+			s.WriteString(" ")
+		}
+		s.WriteString(speakJetCode(c))
+	}
+
+	return s.String()
+}
+
+// update the state of the SaveKey with the state of the SWCHA pins, as driven
+// by the VCS. the lines are "pulled up" so pins that are not set to output in
+// the DDR are high. returns the value of the pins as driven by the SaveKey
+func (sk *SaveKey) update(swcha uint8, ddr uint8) uint8 {
+	line := func(bit uint8) bool {
+		return ddr&bit == 0x00 || swcha&bit == bit
+	}
+
+	sk.serial.line = line(saveKeySpeechData)
+	sk.clock(line(saveKeySCL), line(saveKeySDA))
+
+	// the speech ready line is always high (SpeakJet is ready to receive)
+	data := saveKeySpeechReady
+	if sk.sda && line(saveKeySDA) {
+		data |= saveKeySDA
+	}
+
+	return data
+}
+
+// clock in the new state of the I2C bus
+func (sk *SaveKey) clock(scl bool, sda bool) {
+	defer func() {
+		sk.lastSCL = scl
+		sk.lastSDA = sda
+	}()
+
+	// a change in the SDA line while SCL is high is either a start or stop
+	// condition
+	if scl && sk.lastSCL {
+		if sk.lastSDA && !sda {
+			sk.start()
+		} else if !sk.lastSDA && sda {
+			sk.stop()
+		}
+		return
+	}
+
+	if scl && !sk.lastSCL {
+		sk.rising(sda)
+	} else if !scl && sk.lastSCL {
+		sk.falling()
+	}
+}
+
+func (sk *SaveKey) start() {
+	sk.state = i2cControl
+	sk.bits = 0
+	sk.shift = 0
+	sk.sda = true
+}
+
+func (sk *SaveKey) stop() {
+	sk.state = i2cIdle
+	sk.sda = true
+
+	// save contents of EEPROM on completion of a write operation. the
+	// contents of the EEPROM are not essential so errors are ignored
+	if sk.dirty && sk.filename != "" {
+		_ = sk.Save()
+	}
+}
+
+// data is sampled on the rising edge of the clock
+func (sk *SaveKey) rising(sda bool) {
+	switch sk.state {
+	case i2cIdle:
+		return
+
+	case i2cRead:
+		// the ninth pulse is the acknowledge from the VCS
+		if sk.bits == 8 {
+			sk.ack = !sda
+		}
+
+	default:
+		if sk.bits < 8 {
+			sk.shift <<= 1
+			if sda {
+				sk.shift |= 0x01
+			}
+		}
+	}
+
+	sk.bits++
+}
+
+// data is changed on the falling edge of the clock
+func (sk *SaveKey) falling() {
+	switch sk.state {
+	case i2cIdle:
+		return
+
+	case i2cRead:
+		switch {
+		case sk.bits < 8:
+			sk.sda = sk.shift&(0x80>>uint(sk.bits)) != 0x00
+		case sk.bits == 8:
+			// release line for acknowledgement from VCS
+			sk.sda = true
+		default:
+			if !sk.ack {
+				sk.state = i2cIdle
+				return
+			}
+			sk.address = (sk.address + 1) % EEPROMSize
+			sk.startRead()
+		}
+
+	default:
+		switch {
+		case sk.bits == 8:
+			// pull the SDA line low to acknowledge the byte
+			sk.sda = !sk.receive(sk.shift)
+		case sk.bits > 8:
+			sk.sda = true
+			sk.bits = 0
+			sk.shift = 0
+			if sk.state == i2cReadStart {
+				sk.state = i2cRead
+				sk.startRead()
+			}
+		}
+	}
+}
+
+// load the byte at the current address and put the first bit on the bus
+func (sk *SaveKey) startRead() {
+	sk.bits = 0
+	sk.shift = sk.data[sk.address]
+	sk.sda = sk.shift&0x80 == 0x80
+}
+
+// a complete byte has been received. returns true if the byte is to be
+// acknowledged
+func (sk *SaveKey) receive(b uint8) bool {
+	switch sk.state {
+	case i2cControl:
+		if b&0xfe != eepromAddress {
+			sk.state = i2cIdle
+			return false
+		}
+		if b&0x01 == 0x01 {
+			// reading starts after the acknowledge pulse
+			sk.state = i2cReadStart
+		} else {
+			sk.state = i2cAddressHi
+		}
+
+	case i2cAddressHi:
+		sk.address = uint16(b&0x7f) << 8
+		sk.state = i2cAddressLo
+
+	case i2cAddressLo:
+		sk.address |= uint16(b)
+		sk.state = i2cWrite
+
+	case i2cWrite:
+		sk.data[sk.address] = b
+		sk.dirty = true
+
+		// the address wraps around at the end of the page
+		page := sk.address &^ (eepromPageSize - 1)
+		sk.address = page | ((sk.address + 1) & (eepromPageSize - 1))
+	}
+
+	return true
+}
+
+// step the speech serial decoder forward one cycle
+func (sk *SaveKey) step() {
+	if !sk.speech {
+		return
+	}
+
+	s := &sk.serial
+
+	if !s.receiving {
+		// start bit
+		if !s.line {
+			s.receiving = true
+			s.cycles = 0
+			s.bit = 0
+			s.shift = 0
+		}
+		return
+	}
+
+	s.cycles++
+
+	// sample the line in the middle of every bit
+	if s.cycles < (float32(s.bit)+0.5)*speechCyclesPerBit {
+		return
+	}
+
+	switch {
+	case s.bit == 0:
+		// false start bit
+		if s.line {
+			s.receiving = false
+			return
+		}
+	case s.bit <= 8:
+		if s.line {
+			s.shift |= 0x01 << uint(s.bit-1)
+		}
+	default:
+		// only accept the byte if the stop bit is correct
+		if s.line {
+			if len(s.log) >= maxSpeechLog {
+				s.log = s.log[1:]
+			}
+			s.log = append(s.log, s.shift)
+		}
+		s.receiving = false
+		return
+	}
+
+	s.bit++
+}
+
+// names of the SpeakJet allophones, starting with code 128
+var speakJetAllophones = []string{
+	"IY", "IH", "EY", "EH", "AY", "AX", "UX", "OH", "AW", "OW", "UH", "UW",
+	"MM", "NE", "NO", "NGE", "NGO", "LE", "LO", "WW", "RR", "IYRR", "EYRR",
+	"AXRR", "AWRR", "OWRR", "EYIY", "OHIY", "OWIY", "OHIH", "IYEH", "EHLL",
+	"IYUW", "AXUW", "IHWW", "AYWW", "OWWW", "JH", "VV", "ZZ", "ZH", "DH",
+	"BE", "BO", "EB", "OB", "DE", "DO", "ED", "OD", "GE", "GO", "EG", "OG",
+	"CH", "HE", "HO", "WH", "FF", "SE", "SO", "SH", "TH", "TT", "TU", "TS",
+	"KE", "KO", "EK", "OK", "PE", "PO",
+}
+
+// speakJetCode returns a readable representation of a SpeakJet code
+func speakJetCode(c uint8) string {
+	switch {
+	case c <= 6:
+		return fmt.Sprintf("P%d", c)
+	case c < 128:
+		return fmt.Sprintf("[%d]", c)
+	case int(c) < 128+len(speakJetAllophones):
+		return speakJetAllophones[c-128]
+	case c == 255:
+		return "."
+	}
+	return fmt.Sprintf("SFX%d", c)
+}
+
+// SaveKey returns the SaveKey (or AtariVox) attached to the hand controller
+// port. Returns nil if no SaveKey is attached.
+func (hc *HandController) SaveKey() *SaveKey {
+	return hc.savekey
+}
+
+// the SWCHA or SWACNT register has been written to by the CPU. pass the state
+// of the port to the SaveKey and write the response of the SaveKey to SWCHA.
+func (hc *HandController) updateSaveKey() {
+	if hc.savekey == nil {
+		return
+	}
+
+	data := hc.savekey.update(hc.swcha, hc.ddr)
+
+	// only the pins set to input in the DDR can be written to by the SaveKey
+	mask := (saveKeySDA | saveKeySpeechReady) &^ hc.ddr
+	hc.mem.riot.InputDeviceWrite(addresses.SWCHA, hc.normaliseOnWrite(data&mask), ^hc.normaliseOnWrite(mask))
+}
@@ -0,0 +1,288 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// i2c is a bit-banged I2C bus master, in the manner of a VCS program talking to
+// a SaveKey plugged into the left port
+type i2c struct {
+	t   *testing.T
+	vcs *hardware.VCS
+}
+
+// the SCL and SDA lines of the left port
+const (
+	lineSCL = 0x80
+	lineSDA = 0x40
+)
+
+// set the state of the SCL and SDA lines
+func (b i2c) set(scl bool, sda bool) {
+	var v uint8
+	if scl {
+		v |= lineSCL
+	}
+	if sda {
+		v |= lineSDA
+	}
+	write(b.t, b.vcs, addrSWCHA, v)
+}
+
+// drive the SDA line or release it so that it can be driven by the SaveKey
+func (b i2c) drive(sda bool) {
+	if sda {
+		write(b.t, b.vcs, addrSWACNT, lineSCL|lineSDA)
+	} else {
+		write(b.t, b.vcs, addrSWACNT, lineSCL)
+	}
+}
+
+func (b i2c) start() {
+	b.drive(true)
+	b.set(false, true)
+	b.set(true, true)
+	b.set(true, false)
+	b.set(false, false)
+}
+
+func (b i2c) stop() {
+	b.drive(true)
+	b.set(false, false)
+	b.set(true, false)
+	b.set(true, true)
+}
+
+// send a byte to the SaveKey. returns true if the SaveKey acknowledged it
+func (b i2c) send(v uint8) bool {
+	b.drive(true)
+	for i := 7; i >= 0; i-- {
+		bit := v&(0x01<<uint(i)) != 0x00
+		b.set(false, bit)
+		b.set(true, bit)
+		b.set(false, bit)
+	}
+
+	// acknowledge pulse
+	b.drive(false)
+	ack := peek(b.t, b.vcs, addrSWCHA)&lineSDA == 0x00
+	b.set(true, true)
+	b.set(false, true)
+
+	return ack
+}
+
+// receive a byte from the SaveKey, acknowledging it if ack is true
+func (b i2c) receive(ack bool) uint8 {
+	var v uint8
+
+	b.drive(false)
+	for i := 0; i < 8; i++ {
+		v <<= 1
+		if peek(b.t, b.vcs, addrSWCHA)&lineSDA == lineSDA {
+			v |= 0x01
+		}
+		b.set(true, true)
+		b.set(false, true)
+	}
+
+	// acknowledge pulse
+	b.drive(true)
+	b.set(false, !ack)
+	b.set(true, !ack)
+	b.set(false, !ack)
+
+	return v
+}
+
+// chdir changes the working directory to a new temporary directory so that
+// the EEPROM file is not written to the source tree. the returned function
+// restores the working directory and removes the temporary directory
+func chdir(t *testing.T) func() {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "savekey_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+
+	return func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestSaveKey(t *testing.T) {
+	defer chdir(t)()
+
+	vcs := newVCS(t)
+	vcs.RIOT.Input.PersistPeripherals(true)
+	hc := vcs.RIOT.Input.HandController0
+	hc.ForceType(input.SaveKeyType)
+
+	sk := hc.SaveKey()
+	if sk == nil {
+		t.Fatalf("no SaveKey attached to hand controller")
+	}
+
+	bus := i2c{t: t, vcs: vcs}
+
+	// the control byte must address the EEPROM
+	bus.start()
+	if bus.send(0xb0) {
+		t.Errorf("SaveKey acknowledged the wrong control byte")
+	}
+	bus.stop()
+
+	// write two bytes starting at address 0x0102
+	bus.start()
+	if !bus.send(0xa0) {
+		t.Fatalf("SaveKey did not acknowledge control byte")
+	}
+	if !bus.send(0x01) || !bus.send(0x02) {
+		t.Fatalf("SaveKey did not acknowledge address")
+	}
+	if !bus.send(0x12) || !bus.send(0x34) {
+		t.Fatalf("SaveKey did not acknowledge data")
+	}
+	bus.stop()
+
+	d := sk.Data()
+	if d[0x0102] != 0x12 || d[0x0103] != 0x34 {
+		t.Errorf("unexpected EEPROM contents after write %#02x %#02x", d[0x0102], d[0x0103])
+	}
+
+	// writes wrap around at the end of the page
+	bus.start()
+	bus.send(0xa0)
+	bus.send(0x00)
+	bus.send(0x3f)
+	bus.send(0x56)
+	bus.send(0x78)
+	bus.stop()
+
+	d = sk.Data()
+	if d[0x003f] != 0x56 || d[0x0000] != 0x78 || d[0x0040] != 0xff {
+		t.Errorf("EEPROM write did not wrap around at the end of the page")
+	}
+
+	// random read: set the address with a dummy write and then read
+	bus.start()
+	bus.send(0xa0)
+	bus.send(0x01)
+	bus.send(0x02)
+	bus.start()
+	if !bus.send(0xa1) {
+		t.Fatalf("SaveKey did not acknowledge read control byte")
+	}
+	if v := bus.receive(true); v != 0x12 {
+		t.Errorf("unexpected first byte read %#02x", v)
+	}
+	if v := bus.receive(false); v != 0x34 {
+		t.Errorf("unexpected second byte read %#02x", v)
+	}
+	bus.stop()
+
+	// the EEPROM is saved to disk at the end of a write. a new SaveKey should
+	// have the same contents
+	data, err := ioutil.ReadFile(sk.Filename())
+	if err != nil {
+		t.Fatalf("EEPROM has not been saved: %v", err)
+	}
+	if len(data) != input.EEPROMSize || data[0x0102] != 0x12 || data[0x0103] != 0x34 {
+		t.Errorf("unexpected EEPROM file contents")
+	}
+
+	hc.ForceType(input.SaveKeyType)
+	d = hc.SaveKey().Data()
+	if d[0x0102] != 0x12 || d[0x0103] != 0x34 {
+		t.Errorf("EEPROM contents have not persisted")
+	}
+}
+
+func TestSaveKeyNotPersistent(t *testing.T) {
+	defer chdir(t)()
+
+	// an EEPROM file left by an earlier interactive session
+	vcs := newVCS(t)
+	vcs.RIOT.Input.PersistPeripherals(true)
+	hc := vcs.RIOT.Input.HandController0
+	hc.ForceType(input.SaveKeyType)
+	filename := hc.SaveKey().Filename()
+
+	eeprom := make([]byte, input.EEPROMSize)
+	eeprom[0x0102] = 0x12
+	if err := ioutil.WriteFile(filename, eeprom, 0644); err != nil {
+		t.Fatalf("cannot write EEPROM file: %v", err)
+	}
+
+	// peripherals are not persistent by default
+	vcs = newVCS(t)
+	hc = vcs.RIOT.Input.HandController0
+	hc.ForceType(input.SaveKeyType)
+	sk := hc.SaveKey()
+
+	if sk.Filename() != "" {
+		t.Errorf("unexpected EEPROM filename for peripheral that is not persistent")
+	}
+	if d := sk.Data(); d[0x0102] != 0xff {
+		t.Errorf("EEPROM contents loaded for peripheral that is not persistent")
+	}
+
+	bus := i2c{t: t, vcs: vcs}
+	bus.start()
+	bus.send(0xa0)
+	bus.send(0x01)
+	bus.send(0x02)
+	bus.send(0x34)
+	bus.stop()
+
+	if d := sk.Data(); d[0x0102] != 0x34 {
+		t.Errorf("unexpected EEPROM contents after write %#02x", d[0x0102])
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("cannot read EEPROM file: %v", err)
+	}
+	if data[0x0102] != 0x12 {
+		t.Errorf("EEPROM file written by peripheral that is not persistent")
+	}
+
+	if err := sk.Clear(); err != nil {
+		t.Errorf("unexpected error clearing EEPROM: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+// the state of a hand controller as returned by SaveState(). the peripherals
+// are referenced by pointer in the HandController type and so are saved
+// separately
+type handControllerState struct {
+	hc      HandController
+	savekey *SaveKey
+	kidvid  *KidVid
+}
+
+func (hc *HandController) saveState() handControllerState {
+	s := handControllerState{hc: *hc}
+
+	if hc.savekey != nil {
+		sk := *hc.savekey
+		sk.serial.log = append([]uint8{}, hc.savekey.serial.log...)
+		s.savekey = &sk
+	}
+
+	// the voice and data slices of the KidVid type do not change once the tape
+	// has been loaded so they can be shared with the saved state
+	if hc.kidvid != nil {
+		kv := *hc.kidvid
+		s.kidvid = &kv
+	}
+
+	return s
+}
+
+func (hc *HandController) restoreState(s handControllerState) {
+	// the playback and recorder attached to the port are not part of the
+	// state of the hand controller
+	p := hc.port
+	*hc = s.hc
+	hc.port = p
+
+	// the savekey and kidvid pointers have been restored to the peripherals
+	// that were attached when the state was saved
+	if s.savekey != nil {
+		*hc.savekey = *s.savekey
+		hc.savekey.serial.log = append([]uint8{}, s.savekey.serial.log...)
+	}
+	if s.kidvid != nil {
+		*hc.kidvid = *s.kidvid
+	}
+}
+
+// the state of the input sub-system as returned by SaveState()
+type inputState struct {
+	vblankBits      VBlankBits
+	panel           Panel
+	handController0 handControllerState
+	handController1 handControllerState
+}
+
+// SaveState notes and returns the current state of the panel and the hand
+// controllers, including any attached peripherals. The state can only be
+// restored to the same Input instance.
+func (inp *Input) SaveState() interface{} {
+	return inputState{
+		vblankBits:      inp.VBlankBits,
+		panel:           *inp.Panel,
+		handController0: inp.HandController0.saveState(),
+		handController1: inp.HandController1.saveState(),
+	}
+}
+
+// RestoreState returns the input sub-system to a previously known state
+func (inp *Input) RestoreState(state interface{}) {
+	s := state.(inputState)
+
+	inp.VBlankBits = s.vblankBits
+
+	p := inp.Panel.port
+	*inp.Panel = s.panel
+	inp.Panel.port = p
+
+	inp.HandController0.restoreState(s.handController0)
+	inp.HandController1.restoreState(s.handController1)
+}
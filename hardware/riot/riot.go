@@ -76,3 +76,25 @@ func (riot *RIOT) Step() {
 	riot.Timer.Step()
 	riot.Input.Step()
 }
+
+// the state of the RIOT as returned by SaveState()
+type riotState struct {
+	timer timer.Timer
+	input interface{}
+}
+
+// SaveState notes and returns the current state of the RIOT. The state can
+// only be restored to the same RIOT.
+func (riot *RIOT) SaveState() interface{} {
+	return riotState{
+		timer: *riot.Timer,
+		input: riot.Input.SaveState(),
+	}
+}
+
+// RestoreState returns the RIOT to a previously known state
+func (riot *RIOT) RestoreState(state interface{}) {
+	s := state.(riotState)
+	*riot.Timer = s.timer
+	riot.Input.RestoreState(s.input)
+}
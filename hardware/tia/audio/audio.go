@@ -28,8 +28,8 @@ import (
 // the 30Khz reference frequency desribed in the Stella Programmer's Guide.
 const SampleFreq = 31403
 
-// the Atari 2600 has two independent sound generators. these will be mixed
-// into one value by the Mix() function
+// the Atari 2600 has two independent sound generators. the volume of each is
+// returned by the Channels() function
 const numChannels = 2
 
 // Audio is the implementation of the TIA audio sub-system, using Ron Fries'
@@ -44,7 +44,7 @@ type Audio struct {
 	// frequency we need.  Ron Fries' talks about this in  his original
 	// documentation for TIASound.c
 	//
-	// see the Mix() function to see how it is used
+	// see the Channels() function to see how it is used
 	clock114 int
 
 	poly4bit [15]uint8
@@ -58,6 +58,18 @@ type Audio struct {
 	// completely independent and can be operated simultaneously [...]"
 	channel0 channel
 	channel1 channel
+
+	// sound from outside the TIA. nil if there is no external source
+	external ExternalSource
+}
+
+// ExternalSource is implemented by peripherals that produce sound of their
+// own, such as the Kid Vid cassette player. The sound is not mixed with the
+// TIA channels but is passed to the television alongside them.
+type ExternalSource interface {
+	// the current sample of the external sound. in the range -1.0 to 1.0
+	// and centred on zero
+	ExternalAudio() float32
 }
 
 func (au *Audio) String() string {
@@ -69,8 +81,10 @@ func (au *Audio) String() string {
 	return s.String()
 }
 
-// NewAudio is the preferred method of initialisation for the Video structure
-func NewAudio() *Audio {
+// NewAudio is the preferred method of initialisation for the Video structure.
+// The random number generator is used to create the 9bit polynomial. If it is
+// nil then the default source of the math/rand package is used.
+func NewAudio(rnd *rand.Rand) *Audio {
 	au := &Audio{}
 	au.channel0.au = au
 	au.channel1.au = au
@@ -89,8 +103,12 @@ func NewAudio() *Audio {
 	//
 	// "Rather than have a table with 511 entries, I use a random number
 	// generator."
+	randInt := rand.Int
+	if rnd != nil {
+		randInt = rnd.Int
+	}
 	for i := 0; i < len(au.poly9bit); i++ {
-		au.poly9bit[i] = uint16(rand.Int() & 0x01)
+		au.poly9bit[i] = uint16(randInt() & 0x01)
 	}
 
 	// from TIASound.c:
@@ -105,38 +123,71 @@ func NewAudio() *Audio {
 	return au
 }
 
-// Mix the two VCS audio channels, returning a boolean indicating whether the
-// sound has been updated and a single value representing the mixed volume
-func (au *Audio) Mix() (bool, uint8) {
+// Channels returns a boolean indicating whether the sound has been updated and
+// the current volume of each of the two VCS audio channels.
+//
+// The two channels are output on separate pins of the real TIA chip and are
+// combined by the console's output circuitry. Mixing is therefore the
+// responsibility of the television and the AudioMixers attached to it. This
+// allows, for example, the channels to be panned left and right for stereo
+// output.
+func (au *Audio) Channels() (bool, uint8, uint8) {
 	// the reference frequency for all sound produced by the TIA is 30Khz. this
 	// is the 3.58Mhz clock, which the TIA operates at, divided by 114 (see
-	// declaration). Mix() is called every video cycle and we return
+	// declaration). Channels() is called every video cycle and we return
 	// immediately except on the 114th tick, whereupon we process the current
-	// audio registers and mix the two signals
+	// audio registers
 	au.clock114++
 	if au.clock114 < 114 {
-		return false, 0
+		return false, 0, 0
 	}
 
 	// reset clock114
 	au.clock114 = 0
 
-	// process each channel before mixing
+	// process each channel
 	au.channel0.tick()
 	au.channel1.tick()
 
-	// mix channels: deciding the combined output volume for the two channels
-	// is not as straight-forward and is it first seems. what we have here is
-	// the naive implementation, simply adding the two volume values together
-	// (we're not even taking an average).
-	//
-	// because the 2600 sound generator is an analogue circuit however, there
-	// are some subtleties that we have not accounted for. people have worked
-	// on this already. the document, "TIA Sounding off in the Digital Domain"
-	// gives a good description of what's required.
-	//
-	// https://atariage.com/forums/topic/249865-tia-sounding-off-in-the-digital-domain/
-	//
-	// !TODO: simulate analogue sound generation
-	return true, au.channel0.actualVol + au.channel1.actualVol
+	return true, au.channel0.actualVol, au.channel1.actualVol
+}
+
+// External returns the current sample of the external source, in the range
+// -1.0 to 1.0. Returns zero if there is no external source.
+func (au *Audio) External() float32 {
+	if au.external == nil {
+		return 0
+	}
+	return au.external.ExternalAudio()
+}
+
+// AttachExternalSource adds sound from outside the TIA. A value of nil removes
+// the external source.
+func (au *Audio) AttachExternalSource(external ExternalSource) {
+	au.external = external
+}
+
+// the state of the audio sub-system as returned by SaveState(). the
+// polynomial tables do not change once the Audio type has been created
+type audioState struct {
+	clock114 int
+	channel0 channel
+	channel1 channel
+}
+
+// SaveState notes and returns the current state of the audio sub-system
+func (au *Audio) SaveState() interface{} {
+	return audioState{
+		clock114: au.clock114,
+		channel0: au.channel0,
+		channel1: au.channel1,
+	}
+}
+
+// RestoreState returns the audio sub-system to a previously known state
+func (au *Audio) RestoreState(state interface{}) {
+	s := state.(audioState)
+	au.clock114 = s.clock114
+	au.channel0 = s.channel0
+	au.channel1 = s.channel1
 }
@@ -22,7 +22,7 @@
 // TIASound.c (easily searchable). The bit patterns are taken from there and
 // the channels are mixed in the same way.
 //
-// Unlike the Fries' implementation, the Mix() function is called every video
+// Unlike the Fries' implementation, the Channels() function is called every video
 // cycle, returning a new sample every 114th video clock. The TIA_Process()
 // function in Frie's implementation meanwhile is called to fill a buffer. The
 // samepl buffer in this emulation must sit outside of the TIA emulation and
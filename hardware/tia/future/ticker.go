@@ -116,3 +116,32 @@ func (tck *Ticker) drop(ev *Event) {
 	// wrong. it is okay to panic.
 	panic("cannot drop an event that is not in the list of active events")
 }
+
+// the state of a ticker as returned by SaveState()
+type tickerState struct {
+	// the order of the elements in the pool and the event of each element
+	order  []*list.Element
+	events []Event
+}
+
+// SaveState notes and returns the current state of the ticker, including
+// every pending event. The state can only be restored to the same ticker.
+func (tck *Ticker) SaveState() interface{} {
+	state := tickerState{}
+	for e := tck.pool.Front(); e != nil; e = e.Next() {
+		state.order = append(state.order, e)
+		state.events = append(state.events, *e.Value.(*Event))
+	}
+	return state
+}
+
+// RestoreState returns the ticker to a previously known state
+func (tck *Ticker) RestoreState(state interface{}) {
+	// moving every element to the back of the pool, in the order they were
+	// saved, reproduces the order of the pool. the active sentinal is one of
+	// the elements in the pool so it is also correctly placed
+	for i, e := range state.(tickerState).order {
+		tck.pool.MoveToBack(e)
+		*e.Value.(*Event) = state.(tickerState).events[i]
+	}
+}
@@ -207,7 +207,25 @@ func (tia *TIA) Step(readMemory bool) (bool, error) {
 	}
 
 	// copy audio to television signal
-	tia.sig.AudioUpdate, tia.sig.AudioData = tia.Audio.Mix()
+	tia.sig.AudioUpdate, tia.sig.AudioChannel0, tia.sig.AudioChannel1 = tia.Audio.Channels()
+	if tia.sig.AudioUpdate {
+		tia.sig.AudioExternal = tia.Audio.External()
+	}
+
+	// mix channels: deciding the combined output volume for the two channels
+	// is not as straight-forward and is it first seems. what we have here is
+	// the naive implementation, simply adding the two volume values together
+	// (we're not even taking an average).
+	//
+	// because the 2600 sound generator is an analogue circuit however, there
+	// are some subtleties that we have not accounted for. people have worked
+	// on this already. the document, "TIA Sounding off in the Digital Domain"
+	// gives a good description of what's required.
+	//
+	// https://atariage.com/forums/topic/249865-tia-sounding-off-in-the-digital-domain/
+	//
+	// !TODO: simulate analogue sound generation
+	tia.sig.AudioData = tia.sig.AudioChannel0 + tia.sig.AudioChannel1
 
 	// send signal to television
 	if err := tia.tv.Signal(tia.sig); err != nil {
@@ -29,6 +29,7 @@ import (
 	"gopher2600/hardware/tia/polycounter"
 	"gopher2600/hardware/tia/video"
 	"gopher2600/television"
+	"math/rand"
 	"strings"
 )
 
@@ -118,8 +119,9 @@ func (tia TIA) String() string {
 	return s.String()
 }
 
-// NewTIA creates a TIA, to be used in a VCS emulation
-func NewTIA(tv television.Television, mem bus.ChipBus, vblankBits *input.VBlankBits) (*TIA, error) {
+// NewTIA creates a TIA, to be used in a VCS emulation. The random number
+// generator is passed to the audio sub-system and can be nil.
+func NewTIA(tv television.Television, mem bus.ChipBus, vblankBits *input.VBlankBits, rnd *rand.Rand) (*TIA, error) {
 	tia := TIA{
 		tv:         tv,
 		mem:        mem,
@@ -143,7 +145,7 @@ func NewTIA(tv television.Television, mem bus.ChipBus, vblankBits *input.VBlankB
 		return nil, err
 	}
 
-	tia.Audio = audio.NewAudio()
+	tia.Audio = audio.NewAudio(rnd)
 	if err != nil {
 		return nil, err
 	}
@@ -334,3 +336,38 @@ func (tia *TIA) _futureResetColorBurst() {
 func (tia *TIA) _futureResetHBlank() {
 	tia.hblank = false
 }
+
+// the state of the TIA as returned by SaveState()
+type tiaState struct {
+	tia   TIA
+	hsync polycounter.Polycounter
+	delay interface{}
+	video interface{}
+	audio interface{}
+}
+
+// SaveState notes and returns the current state of the TIA, including the
+// video and audio sub-systems. The state can only be restored to the same
+// TIA.
+func (tia *TIA) SaveState() interface{} {
+	return tiaState{
+		tia:   *tia,
+		hsync: *tia.hsync,
+		delay: tia.Delay.SaveState(),
+		video: tia.Video.SaveState(),
+		audio: tia.Audio.SaveState(),
+	}
+}
+
+// RestoreState returns the TIA to a previously known state
+func (tia *TIA) RestoreState(state interface{}) {
+	s := state.(tiaState)
+
+	// the pointers in the saved TIA are the same as the pointers in the
+	// current TIA. the values they point to are restored below
+	*tia = s.tia
+	*tia.hsync = s.hsync
+	tia.Delay.RestoreState(s.delay)
+	tia.Video.RestoreState(s.video)
+	tia.Audio.RestoreState(s.audio)
+}
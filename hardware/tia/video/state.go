@@ -0,0 +1,93 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package video
+
+import "gopher2600/hardware/tia/polycounter"
+
+// the state of the video sub-system as returned by SaveState(). the sprite
+// types contain only pointers to other parts of the video sub-system so
+// copying them by value is sufficient, with the exception of the position
+// counters and the future tickers, which are saved separately
+type videoState struct {
+	collisions collisions
+	playfield  playfield
+
+	player0  playerSprite
+	player1  playerSprite
+	missile0 missileSprite
+	missile1 missileSprite
+	ball     ballSprite
+
+	// in the order player 0, player 1, missile 0, missile 1 and ball
+	positions [5]polycounter.Polycounter
+	delays    [5]interface{}
+}
+
+// SaveState notes and returns the current state of the video sub-system
+func (vd *Video) SaveState() interface{} {
+	return videoState{
+		collisions: *vd.collisions,
+		playfield:  *vd.Playfield,
+		player0:    *vd.Player0,
+		player1:    *vd.Player1,
+		missile0:   *vd.Missile0,
+		missile1:   *vd.Missile1,
+		ball:       *vd.Ball,
+		positions: [5]polycounter.Polycounter{
+			*vd.Player0.position,
+			*vd.Player1.position,
+			*vd.Missile0.position,
+			*vd.Missile1.position,
+			*vd.Ball.position,
+		},
+		delays: [5]interface{}{
+			vd.Player0.Delay.SaveState(),
+			vd.Player1.Delay.SaveState(),
+			vd.Missile0.Delay.SaveState(),
+			vd.Missile1.Delay.SaveState(),
+			vd.Ball.Delay.SaveState(),
+		},
+	}
+}
+
+// RestoreState returns the video sub-system to a previously known state
+func (vd *Video) RestoreState(state interface{}) {
+	s := state.(videoState)
+
+	*vd.collisions = s.collisions
+	*vd.Playfield = s.playfield
+	*vd.Player0 = s.player0
+	*vd.Player1 = s.player1
+	*vd.Missile0 = s.missile0
+	*vd.Missile1 = s.missile1
+	*vd.Ball = s.ball
+
+	*vd.Player0.position = s.positions[0]
+	*vd.Player1.position = s.positions[1]
+	*vd.Missile0.position = s.positions[2]
+	*vd.Missile1.position = s.positions[3]
+	*vd.Ball.position = s.positions[4]
+
+	vd.Player0.Delay.RestoreState(s.delays[0])
+	vd.Player1.Delay.RestoreState(s.delays[1])
+	vd.Missile0.Delay.RestoreState(s.delays[2])
+	vd.Missile1.Delay.RestoreState(s.delays[3])
+	vd.Ball.Delay.RestoreState(s.delays[4])
+}
@@ -28,6 +28,7 @@ import (
 	"gopher2600/hardware/riot/input"
 	"gopher2600/hardware/tia"
 	"gopher2600/television"
+	"math/rand"
 )
 
 // VCS struct is the main container for the emulated components of the VCS
@@ -48,7 +49,13 @@ type VCS struct {
 
 // NewVCS creates a new VCS and everything associated with the hardware. It is
 // used for all aspects of emulation: debugging sessions, and regular play
-func NewVCS(tv television.Television) (*VCS, error) {
+//
+// The random number generator is used to initialise those parts of the
+// hardware that are unpredictable on power up. Emulations that need to be
+// repeatable (playback of recordings, regression tests, etc.) should supply a
+// generator with a known seed. If it is nil then the default source of the
+// math/rand package is used.
+func NewVCS(tv television.Television, rnd *rand.Rand) (*VCS, error) {
 	var err error
 
 	vcs := &VCS{TV: tv}
@@ -68,11 +75,14 @@ func NewVCS(tv television.Television) (*VCS, error) {
 		return nil, err
 	}
 
-	vcs.TIA, err = tia.NewTIA(vcs.TV, vcs.Mem.TIA, &vcs.RIOT.Input.VBlankBits)
+	vcs.TIA, err = tia.NewTIA(vcs.TV, vcs.Mem.TIA, &vcs.RIOT.Input.VBlankBits, rnd)
 	if err != nil {
 		return nil, err
 	}
 
+	// peripherals attached to the controller ports may produce sound
+	vcs.TIA.Audio.AttachExternalSource(vcs.RIOT.Input)
+
 	vcs.Panel = vcs.RIOT.Input.Panel
 	vcs.HandController0 = vcs.RIOT.Input.HandController0
 	vcs.HandController1 = vcs.RIOT.Input.HandController1
@@ -133,3 +143,56 @@ func (vcs *VCS) checkDeviceInput() error {
 
 	return vcs.Panel.CheckInput()
 }
+
+// the state of the VCS as returned by SaveState()
+type vcsState struct {
+	cpu  interface{}
+	mem  interface{}
+	tia  interface{}
+	riot interface{}
+	tv   interface{}
+}
+
+// SaveState notes and returns the complete state of the VCS, including the
+// television. The state can only be restored to the same VCS instance.
+//
+// The state can not be saved in the middle of a CPU instruction. In practice,
+// this means it must be called between calls to Step() or from the
+// continueCheck() function of Run() or RunForFrameCount().
+func (vcs *VCS) SaveState() (interface{}, error) {
+	cpu, err := vcs.CPU.SaveState()
+	if err != nil {
+		return nil, err
+	}
+
+	return vcsState{
+		cpu:  cpu,
+		mem:  vcs.Mem.SaveState(),
+		tia:  vcs.TIA.SaveState(),
+		riot: vcs.RIOT.SaveState(),
+		tv:   vcs.TV.SaveState(),
+	}, nil
+}
+
+// RestoreState returns the VCS to a state previously returned by
+// SaveState(). The same restrictions on when the state can be saved apply to
+// when it can be restored.
+func (vcs *VCS) RestoreState(state interface{}) error {
+	s := state.(vcsState)
+
+	err := vcs.CPU.RestoreState(s.cpu)
+	if err != nil {
+		return err
+	}
+
+	err = vcs.Mem.RestoreState(s.mem)
+	if err != nil {
+		return err
+	}
+
+	vcs.TIA.RestoreState(s.tia)
+	vcs.RIOT.RestoreState(s.riot)
+	vcs.TV.RestoreState(s.tv)
+
+	return nil
+}
@@ -0,0 +1,272 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"gopher2600/hardware/memory/cartridge"
+	"hash/crc32"
+	"math"
+)
+
+// BPS patch files begin with the header, followed by the source size, target
+// size and metadata size (all variable length numbers) and then the metadata.
+// The patch actions follow and the file ends with three CRC32 checksums: of
+// the source data, the target data and the patch file itself.
+//
+// Each action is a variable length number. The lower two bits are the action
+// type and the remaining bits are the length of the action minus one.
+const bpsHeader = "BPS1"
+
+// the length of the CRC32 checksums at the end of the file
+const bpsFooterLen = 12
+
+// bps actions
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// the largest variable length number accepted. numbers in a BPS file for a
+// cartridge are never anywhere near as large as this and the limit means that
+// decoded numbers fit in an int on every platform
+const bpsMaxNumber = math.MaxInt32
+
+// decode variable length number
+func bpsDecode(buffer []byte, i *int) (int, error) {
+	var data uint64
+	var shift uint64 = 1
+	for {
+		if *i >= len(buffer) {
+			return 0, fmt.Errorf("BPS file is truncated")
+		}
+		x := uint64(buffer[*i])
+		*i++
+		data += (x & 0x7f) * shift
+		if data > bpsMaxNumber {
+			return 0, fmt.Errorf("BPS file is corrupted")
+		}
+		if x&0x80 == 0x80 {
+			break // for loop
+		}
+		shift <<= 7
+		data += shift
+		if data > bpsMaxNumber {
+			return 0, fmt.Errorf("BPS file is corrupted")
+		}
+	}
+	return int(data), nil
+}
+
+// encode variable length number
+func bpsEncode(b *bytes.Buffer, data int) {
+	for {
+		x := byte(data & 0x7f)
+		data >>= 7
+		if data == 0 {
+			b.WriteByte(0x80 | x)
+			break // for loop
+		}
+		b.WriteByte(x)
+		data--
+	}
+}
+
+// applyBPS applies the BPS patch in buffer to cartridge memory. the patch is
+// created against the cartridge file, which is reloaded to check the source
+// checksum
+func applyBPS(mem *cartridge.Cartridge, buffer []byte) (bool, error) {
+	if len(buffer) < len(bpsHeader)+bpsFooterLen {
+		return false, fmt.Errorf("BPS file is truncated")
+	}
+
+	footer := buffer[len(buffer)-bpsFooterLen:]
+	if crc32.ChecksumIEEE(buffer[:len(buffer)-4]) != binary.LittleEndian.Uint32(footer[8:]) {
+		return false, fmt.Errorf("BPS file is corrupted")
+	}
+
+	source, err := cartridgeData(mem)
+	if err != nil {
+		return false, err
+	}
+	if crc32.ChecksumIEEE(source) != binary.LittleEndian.Uint32(footer[0:]) {
+		return false, fmt.Errorf("BPS file is not for this cartridge")
+	}
+
+	i := len(bpsHeader)
+	sourceSize, err := bpsDecode(buffer, &i)
+	if err != nil {
+		return false, err
+	}
+	targetSize, err := bpsDecode(buffer, &i)
+	if err != nil {
+		return false, err
+	}
+	metadataSize, err := bpsDecode(buffer, &i)
+	if err != nil {
+		return false, err
+	}
+	if metadataSize > len(buffer)-bpsFooterLen-i {
+		return false, fmt.Errorf("BPS file is truncated")
+	}
+	i += metadataSize
+
+	if sourceSize != len(source) || targetSize != len(source) {
+		return false, fmt.Errorf("BPS patches cannot change the size of the cartridge")
+	}
+
+	target := make([]byte, targetSize)
+	outputOffset := 0
+	sourceRelativeOffset := 0
+	targetRelativeOffset := 0
+
+	// relative offsets are stored as a sign bit and a magnitude
+	relative := func() (int, error) {
+		v, err := bpsDecode(buffer, &i)
+		if err != nil {
+			return 0, err
+		}
+		if v&1 == 1 {
+			return -(v >> 1), nil
+		}
+		return v >> 1, nil
+	}
+
+	for i < len(buffer)-bpsFooterLen {
+		data, err := bpsDecode(buffer, &i)
+		if err != nil {
+			return false, err
+		}
+		length := (data >> 2) + 1
+
+		if length < 1 || outputOffset+length > len(target) {
+			return false, fmt.Errorf("BPS action is out of range")
+		}
+
+		switch data & 0x03 {
+		case bpsSourceRead:
+			copy(target[outputOffset:], source[outputOffset:outputOffset+length])
+			outputOffset += length
+
+		case bpsTargetRead:
+			if i+length > len(buffer)-bpsFooterLen {
+				return false, fmt.Errorf("BPS file is truncated")
+			}
+			copy(target[outputOffset:], buffer[i:i+length])
+			i += length
+			outputOffset += length
+
+		case bpsSourceCopy:
+			o, err := relative()
+			if err != nil {
+				return false, err
+			}
+			sourceRelativeOffset += o
+			if sourceRelativeOffset < 0 || sourceRelativeOffset+length > len(source) {
+				return false, fmt.Errorf("BPS action is out of range")
+			}
+			copy(target[outputOffset:], source[sourceRelativeOffset:sourceRelativeOffset+length])
+			sourceRelativeOffset += length
+			outputOffset += length
+
+		case bpsTargetCopy:
+			o, err := relative()
+			if err != nil {
+				return false, err
+			}
+			targetRelativeOffset += o
+			if targetRelativeOffset < 0 || targetRelativeOffset >= outputOffset {
+				return false, fmt.Errorf("BPS action is out of range")
+			}
+
+			// target copies can overlap the output so copy a byte at a time
+			for j := 0; j < length; j++ {
+				target[outputOffset] = target[targetRelativeOffset]
+				outputOffset++
+				targetRelativeOffset++
+			}
+		}
+	}
+
+	if crc32.ChecksumIEEE(target) != binary.LittleEndian.Uint32(footer[4:]) {
+		return false, fmt.Errorf("BPS patch produced unexpected data")
+	}
+
+	// patch the bytes that differ from the cartridge file
+	patched := false
+	for o := range target {
+		if target[o] == source[o] {
+			continue // for loop
+		}
+		if err := mem.Patch(uint16(o), target[o]); err != nil {
+			return patched, err
+		}
+		patched = true
+	}
+
+	return patched, nil
+}
+
+// encodeBPS creates a BPS patch that transforms the source data into the
+// target data. the source and target must be the same length
+func encodeBPS(source []byte, target []byte) ([]byte, error) {
+	if len(source) != len(target) {
+		return nil, fmt.Errorf("BPS patches cannot change the size of the cartridge")
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(bpsHeader)
+	bpsEncode(b, len(source))
+	bpsEncode(b, len(target))
+	bpsEncode(b, 0)
+
+	i := 0
+	for i < len(target) {
+		// unchanged data is read from the source and changed data from the
+		// patch
+		j := i
+		same := source[i] == target[i]
+		for j < len(target) && (source[j] == target[j]) == same {
+			j++
+		}
+
+		if same {
+			bpsEncode(b, ((j-i-1)<<2)|bpsSourceRead)
+		} else {
+			bpsEncode(b, ((j-i-1)<<2)|bpsTargetRead)
+			b.Write(target[i:j])
+		}
+		i = j
+	}
+
+	crc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(crc, crc32.ChecksumIEEE(source))
+	b.Write(crc)
+	binary.LittleEndian.PutUint32(crc, crc32.ChecksumIEEE(target))
+	b.Write(crc)
+	binary.LittleEndian.PutUint32(crc, crc32.ChecksumIEEE(b.Bytes()))
+	b.Write(crc)
+
+	return b.Bytes(), nil
+}
@@ -23,8 +23,8 @@
 // (see cartridge package) deal with that individually.
 //
 // This package simply loads the patch instructions, interprets them and calls
-// the cartridge.Patch() function. Currently only one patch format is
-// supported. This is an ad-hoc format taken from the "In case you can't wait"
+// the cartridge.Patch() function. The IPS and BPS patch formats are supported,
+// along with an ad-hoc text format taken from the "In case you can't wait"
 // section of the following web page:
 //
 //	"Fixing E.T. The Extra-Terrestrial for the Atari 2600"
@@ -51,4 +51,8 @@
 // to how memory is mapped inside the VCS. Imagine that the patches are being
 // applied to the cartridge file image. The cartridge mapper handles the VCS
 // memory side of things.
+//
+// The Save() function writes the changes made to a cartridge, with either
+// Patch() or Poke(), to an IPS or BPS file. BPS patches include a checksum of
+// the original cartridge file and will only be applied to that cartridge.
 package patch
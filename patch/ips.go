@@ -0,0 +1,136 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"gopher2600/hardware/memory/cartridge"
+)
+
+// IPS patch files begin with the header and end with the footer. in between
+// are records of the form:
+//
+//	offset (3 bytes, big endian)
+//	size (2 bytes, big endian)
+//	data (size bytes)
+//
+// a record with a size of zero is a run-length encoded record:
+//
+//	offset (3 bytes, big endian)
+//	0x0000
+//	run length (2 bytes, big endian)
+//	value (1 byte)
+const ipsHeader = "PATCH"
+const ipsFooter = "EOF"
+
+// the maximum number of bytes in a single IPS record
+const ipsMaxRecord = 0xffff
+
+// applyIPS applies the IPS patch in buffer to cartridge memory
+func applyIPS(mem *cartridge.Cartridge, buffer []byte) (bool, error) {
+	patched := false
+
+	patch := func(offset int, v uint8) error {
+		if offset > 0xffff {
+			return fmt.Errorf("IPS offset out of range (%#x)", offset)
+		}
+		if err := mem.Patch(uint16(offset), v); err != nil {
+			return err
+		}
+		patched = true
+		return nil
+	}
+
+	i := len(ipsHeader)
+	for {
+		if i+len(ipsFooter) <= len(buffer) && string(buffer[i:i+len(ipsFooter)]) == ipsFooter {
+			return patched, nil
+		}
+
+		if i+5 > len(buffer) {
+			return patched, fmt.Errorf("IPS file is truncated")
+		}
+
+		offset := int(buffer[i])<<16 | int(buffer[i+1])<<8 | int(buffer[i+2])
+		size := int(buffer[i+3])<<8 | int(buffer[i+4])
+		i += 5
+
+		if size == 0 {
+			if i+3 > len(buffer) {
+				return patched, fmt.Errorf("IPS file is truncated")
+			}
+			run := int(buffer[i])<<8 | int(buffer[i+1])
+			v := buffer[i+2]
+			i += 3
+
+			for j := 0; j < run; j++ {
+				if err := patch(offset+j, v); err != nil {
+					return patched, err
+				}
+			}
+			continue // for loop
+		}
+
+		if i+size > len(buffer) {
+			return patched, fmt.Errorf("IPS file is truncated")
+		}
+
+		for j := 0; j < size; j++ {
+			if err := patch(offset+j, buffer[i+j]); err != nil {
+				return patched, err
+			}
+		}
+		i += size
+	}
+}
+
+// encodeIPS creates an IPS patch that transforms the source data into the
+// target data. the source and target must be the same length
+func encodeIPS(source []byte, target []byte) ([]byte, error) {
+	if len(source) != len(target) {
+		return nil, fmt.Errorf("IPS patches cannot change the size of the cartridge")
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(ipsHeader)
+
+	i := 0
+	for i < len(target) {
+		if source[i] == target[i] {
+			i++
+			continue // for loop
+		}
+
+		// find end of changed data
+		j := i
+		for j < len(target) && j-i < ipsMaxRecord && source[j] != target[j] {
+			j++
+		}
+
+		b.Write([]byte{byte(i >> 16), byte(i >> 8), byte(i), byte((j - i) >> 8), byte(j - i)})
+		b.Write(target[i:j])
+		i = j
+	}
+
+	b.WriteString(ipsFooter)
+
+	return b.Bytes(), nil
+}
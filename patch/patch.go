@@ -20,6 +20,7 @@
 package patch
 
 import (
+	"bytes"
 	"fmt"
 	"gopher2600/errors"
 	"gopher2600/hardware/memory/cartridge"
@@ -38,14 +39,22 @@ const commentLeader = '-'
 const pokeLineSeparator = ":"
 
 // CartridgeMemory applies the contents of a patch file to cartridge memory.
-// Currently, patch file must be in the patches sub-directory of the
-// resource path (see paths package).
+// The patch file can be any path. If no file exists at that path then the
+// file is looked for in the patches sub-directory of the resource path (see
+// paths package).
+//
+// IPS and BPS patch files are recognised by their header. Any other file is
+// treated as a patch in the text format described in the package
+// documentation.
 func CartridgeMemory(mem *cartridge.Cartridge, patchFile string) (bool, error) {
 	var err error
 
-	p, err := paths.ResourcePath(patchPath, patchFile)
-	if err != nil {
-		return false, errors.New(errors.PatchError, err)
+	p := patchFile
+	if _, err := os.Stat(p); err != nil {
+		p, err = paths.ResourcePath(patchPath, patchFile)
+		if err != nil {
+			return false, errors.New(errors.PatchError, err)
+		}
 	}
 
 	f, err := os.Open(p)
@@ -64,9 +73,38 @@ func CartridgeMemory(mem *cartridge.Cartridge, patchFile string) (bool, error) {
 	}
 
 	buffer, err := ioutil.ReadAll(f)
+	if err != nil {
+		return false, errors.New(errors.PatchError, err)
+	}
 
 	// once a patch has been made then we'll flip patched to true and return it
 	// to the calling function
+	var patched bool
+
+	switch {
+	case bytes.HasPrefix(buffer, []byte(ipsHeader)):
+		patched, err = applyIPS(mem, buffer)
+	case bytes.HasPrefix(buffer, []byte(bpsHeader)):
+		patched, err = applyBPS(mem, buffer)
+	default:
+		patched, err = applyText(mem, buffer)
+	}
+
+	if err != nil {
+		return patched, errors.New(errors.PatchError, err)
+	}
+
+	// note that the patch file has been applied to the cartridge
+	if patched {
+		mem.Patches = append(mem.Patches, patchFile)
+	}
+
+	return patched, nil
+}
+
+// applyText applies a patch in the text format described in the package
+// documentation
+func applyText(mem *cartridge.Cartridge, buffer []byte) (bool, error) {
 	patched := false
 
 	// walk through lines
@@ -121,7 +159,7 @@ func CartridgeMemory(mem *cartridge.Cartridge, patchFile string) (bool, error) {
 			// patch memory
 			err = mem.Patch(uint16(address), uint8(v))
 			if err != nil {
-				return patched, errors.New(errors.PatchError, err)
+				return patched, err
 			}
 			patched = true
 
@@ -0,0 +1,246 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package patch_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"gopher2600/cartridgeloader"
+	"gopher2600/hardware/memory/cartridge"
+	"gopher2600/patch"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// attach the 8k ROM to a new cartridge
+func attach(t *testing.T, rom string) *cartridge.Cartridge {
+	t.Helper()
+	cart := cartridge.NewCartridge()
+	if err := cart.Attach(cartridgeloader.Loader{Filename: rom, Format: "F8"}); err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	return cart
+}
+
+// check value in the cartridge at the bank and address
+func expect(t *testing.T, cart *cartridge.Cartridge, bank int, address uint16, value uint8) {
+	t.Helper()
+	if err := cart.SetBank(address, bank); err != nil {
+		t.Fatalf("cannot set bank: %v", err)
+	}
+	v, err := cart.Peek(address)
+	if err != nil {
+		t.Fatalf("cannot peek cartridge: %v", err)
+	}
+	if v != value {
+		t.Errorf("unexpected value at %#04x in bank %d: %#02x (expected %#02x)", address, bank, v, value)
+	}
+}
+
+func TestSaveAndApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_patch")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := make([]byte, 8192)
+	for i := range data {
+		data[i] = uint8(i)
+	}
+	rom := filepath.Join(dir, "patch.bin")
+	if err := ioutil.WriteFile(rom, data, 0600); err != nil {
+		t.Fatalf("cannot create ROM file: %v", err)
+	}
+
+	cart := attach(t, rom)
+
+	// nothing to save yet
+	if err := patch.Save(cart, filepath.Join(dir, "empty.ips")); err == nil {
+		t.Errorf("expected error saving patch with no changes")
+	}
+
+	// a text patch applied from an arbitrary path
+	txt := filepath.Join(dir, "patch.txt")
+	if err := ioutil.WriteFile(txt, []byte("- test patch\n0010: aa bb\n"), 0600); err != nil {
+		t.Fatalf("cannot create patch file: %v", err)
+	}
+	if ok, err := patch.CartridgeMemory(cart, txt); !ok || err != nil {
+		t.Fatalf("cannot apply text patch: %v", err)
+	}
+
+	// a poke in the second bank
+	if err := cart.SetBank(0x1020, 1); err != nil {
+		t.Fatalf("cannot set bank: %v", err)
+	}
+	if err := cart.Poke(0x1020, 0xcc); err != nil {
+		t.Fatalf("cannot poke cartridge: %v", err)
+	}
+
+	if len(cart.Changes) != 3 {
+		t.Fatalf("expected 3 changes to cartridge (got %d)", len(cart.Changes))
+	}
+
+	for _, f := range []string{"patch.ips", "patch.bps"} {
+		pf := filepath.Join(dir, f)
+		if err := patch.Save(cart, pf); err != nil {
+			t.Fatalf("cannot save %s: %v", f, err)
+		}
+
+		c := attach(t, rom)
+		if ok, err := patch.CartridgeMemory(c, pf); !ok || err != nil {
+			t.Fatalf("cannot apply %s: %v", f, err)
+		}
+
+		expect(t, c, 0, 0x1010, 0xaa)
+		expect(t, c, 0, 0x1011, 0xbb)
+		expect(t, c, 0, 0x1012, 0x12)
+		expect(t, c, 1, 0x1020, 0xcc)
+		expect(t, c, 0, 0x1020, 0x20)
+
+		if len(c.Patches) != 1 || c.Patches[0] != pf {
+			t.Errorf("unexpected list of patches applied: %v", c.Patches)
+		}
+	}
+
+	// BPS patches are only applied to the cartridge they were made for
+	data[0] = 0xff
+	other := filepath.Join(dir, "other.bin")
+	if err := ioutil.WriteFile(other, data, 0600); err != nil {
+		t.Fatalf("cannot create ROM file: %v", err)
+	}
+	if _, err := patch.CartridgeMemory(attach(t, other), filepath.Join(dir, "patch.bps")); err == nil {
+		t.Errorf("expected error applying BPS patch to wrong cartridge")
+	}
+}
+
+// BPS files with numbers that are out of range are rejected without panicking
+func TestBPSCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_patch")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := make([]byte, 8192)
+	rom := filepath.Join(dir, "patch.bin")
+	if err := ioutil.WriteFile(rom, data, 0600); err != nil {
+		t.Fatalf("cannot create ROM file: %v", err)
+	}
+
+	// variable length number as used in BPS files
+	number := func(b *bytes.Buffer, v int) {
+		for {
+			x := byte(v & 0x7f)
+			v >>= 7
+			if v == 0 {
+				b.WriteByte(0x80 | x)
+				return
+			}
+			b.WriteByte(x)
+			v--
+		}
+	}
+
+	// a number that is too large to be decoded into an int. decoded without
+	// care, it is a SourceRead action with a negative length
+	overflow := append([]byte{0x7c}, bytes.Repeat([]byte{0x7f}, 8)...)
+	overflow = append(overflow, 0xff)
+
+	for name, body := range map[string]func(b *bytes.Buffer){
+		"metadata": func(b *bytes.Buffer) {
+			number(b, len(data))
+			number(b, len(data))
+			number(b, 1000000)
+		},
+		"size": func(b *bytes.Buffer) {
+			b.Write(overflow)
+		},
+		"action": func(b *bytes.Buffer) {
+			number(b, len(data))
+			number(b, len(data))
+			number(b, 0)
+			b.Write(overflow)
+		},
+	} {
+		b := &bytes.Buffer{}
+		b.WriteString("BPS1")
+		body(b)
+
+		footer := make([]byte, 4)
+		binary.LittleEndian.PutUint32(footer, crc32.ChecksumIEEE(data))
+		b.Write(footer)
+		b.Write(footer)
+		binary.LittleEndian.PutUint32(footer, crc32.ChecksumIEEE(b.Bytes()))
+		b.Write(footer)
+
+		pf := filepath.Join(dir, name+".bps")
+		if err := ioutil.WriteFile(pf, b.Bytes(), 0600); err != nil {
+			t.Fatalf("cannot create patch file: %v", err)
+		}
+
+		if _, err := patch.CartridgeMemory(attach(t, rom), pf); err == nil {
+			t.Errorf("%s: expected error applying corrupted BPS patch", name)
+		}
+	}
+}
+
+// pokes to the superchip are not changes to the cartridge data
+func TestSuperchipPoke(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_patch")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the first 256 bytes of each bank must be empty for the superchip to be
+	// added
+	rom := filepath.Join(dir, "superchip.bin")
+	if err := ioutil.WriteFile(rom, make([]byte, 8192), 0600); err != nil {
+		t.Fatalf("cannot create ROM file: %v", err)
+	}
+
+	cart := cartridge.NewCartridge()
+	if err := cart.Attach(cartridgeloader.Loader{Filename: rom, Format: "F8+SC"}); err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	if err := cart.Poke(0x1090, 0x55); err != nil {
+		t.Fatalf("cannot poke cartridge: %v", err)
+	}
+	expect(t, cart, 0, 0x1090, 0x55)
+	if len(cart.Changes) != 0 {
+		t.Errorf("expected no changes to cartridge (got %d)", len(cart.Changes))
+	}
+
+	if err := patch.Save(cart, filepath.Join(dir, "superchip.ips")); err == nil {
+		t.Errorf("expected error saving patch with no changes")
+	}
+
+	if err := cart.Poke(0x1200, 0x66); err != nil {
+		t.Fatalf("cannot poke cartridge: %v", err)
+	}
+	if len(cart.Changes) != 1 || cart.Changes[0].Offset != 0x200 {
+		t.Errorf("unexpected changes to cartridge: %v", cart.Changes)
+	}
+}
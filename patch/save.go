@@ -0,0 +1,79 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package patch
+
+import (
+	"gopher2600/cartridgeloader"
+	"gopher2600/errors"
+	"gopher2600/hardware/memory/cartridge"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// cartridgeData returns the contents of the cartridge file, as it was before
+// any patching
+func cartridgeData(mem *cartridge.Cartridge) ([]byte, error) {
+	if mem.IsEjected() {
+		return nil, errors.New(errors.CartridgeEjected)
+	}
+	return cartridgeloader.Loader{Filename: mem.Filename}.Load()
+}
+
+// Save writes every change made to cartridge memory since the cartridge was
+// attached (see cartridge.Changes) to a patch file. The patch format is
+// decided by the file extension: BPS for files ending in ".bps", IPS
+// otherwise. The saved file can be applied with CartridgeMemory().
+func Save(mem *cartridge.Cartridge, patchFile string) error {
+	if len(mem.Changes) == 0 {
+		return errors.New(errors.PatchError, "no changes have been made to the cartridge")
+	}
+
+	source, err := cartridgeData(mem)
+	if err != nil {
+		return errors.New(errors.PatchError, err)
+	}
+
+	target := make([]byte, len(source))
+	copy(target, source)
+	for _, c := range mem.Changes {
+		if c.Offset >= len(target) {
+			return errors.New(errors.PatchError, "change is outside of cartridge data")
+		}
+		target[c.Offset] = c.Data
+	}
+
+	var data []byte
+	if strings.ToLower(filepath.Ext(patchFile)) == ".bps" {
+		data, err = encodeBPS(source, target)
+	} else {
+		data, err = encodeIPS(source, target)
+	}
+	if err != nil {
+		return errors.New(errors.PatchError, err)
+	}
+
+	err = ioutil.WriteFile(patchFile, data, 0644)
+	if err != nil {
+		return errors.New(errors.PatchError, err)
+	}
+
+	return nil
+}
@@ -37,13 +37,13 @@ func Check(output io.Writer, profile bool, tv television.Television, runTime str
 	var err error
 
 	// create vcs using the tv created above
-	vcs, err := hardware.NewVCS(tv)
+	vcs, err := hardware.NewVCS(tv, nil)
 	if err != nil {
 		return errors.New(errors.PerformanceError, err)
 	}
 
 	// attach cartridge to te vcs
-	err = setup.AttachCartridge(vcs, cartload)
+	err = setup.AttachCartridge(vcs, cartload, nil)
 	if err != nil {
 		return errors.New(errors.PerformanceError, err)
 	}
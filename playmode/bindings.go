@@ -0,0 +1,490 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"bufio"
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/gui"
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"gopher2600/paths"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the name of the bindings file in the resource path
+const bindingsFile = "bindings"
+
+// gamepad inputs are named with a prefix of "pad" followed by the number of
+// the gamepad, a dot, and then the name of the button or axis. for example,
+// "pad0.a" or "pad1.leftx". any other input name is a keyboard key
+const gamepadPrefix = "pad"
+
+// how far an axis must be moved from the centre before it triggers an event
+// that is not an analogue event
+const axisDeadzone = 0.5
+
+// the default bindings. written to the bindings file if the file does not
+// exist
+const defaultBindings = `# input bindings for Gopher2600
+#
+# each line is of the form:
+#
+#   <input>, <port>, <event>
+#
+# input is either the name of a keyboard key or the name of a gamepad button
+# or axis. gamepad inputs are prefixed with "pad", the number of the gamepad
+# and a dot. for example, pad0.a or pad1.leftx
+#
+# port is 0 or 1 for the hand controller ports or "panel" for the front panel
+#
+# event is one of the input events. the KeypadDown, PaddleFire and PaddleSet
+# events take an argument, separated from the event by a colon. for KeypadDown
+# it is the key on the keypad and for the paddle events it is the paddle
+# number (0 or 1). PaddleSet can only be bound to gamepad axes.
+#
+# one input can be bound to more than one event. events for controller types
+# other than the one attached to the port are ignored
+
+# player 0 joystick
+Left, 0, Left
+Right, 0, Right
+Up, 0, Up
+Down, 0, Down
+Space, 0, Fire
+
+# player 1 joystick
+J, 1, Left
+L, 1, Right
+I, 1, Up
+K, 1, Down
+U, 1, Fire
+
+# player 0 Genesis pad and Booster Grip
+Return, 0, GenesisButtonC
+Return, 0, BoosterGripTrigger
+Right Shift, 0, BoosterGripBooster
+
+# Kid Vid tape
+F6, 0, KidVidTogglePlay
+F6, 1, KidVidTogglePlay
+
+# panel
+F1, panel, PanelSelect
+F2, panel, PanelReset
+F3, panel, PanelToggleColor
+F4, panel, PanelTogglePlayer0Pro
+F5, panel, PanelTogglePlayer1Pro
+
+# player 0 keypad
+1, 0, KeypadDown:1
+2, 0, KeypadDown:2
+3, 0, KeypadDown:3
+Q, 0, KeypadDown:4
+W, 0, KeypadDown:5
+E, 0, KeypadDown:6
+A, 0, KeypadDown:7
+S, 0, KeypadDown:8
+D, 0, KeypadDown:9
+Z, 0, KeypadDown:*
+X, 0, KeypadDown:0
+C, 0, KeypadDown:#
+
+# player 1 keypad
+4, 1, KeypadDown:1
+5, 1, KeypadDown:2
+6, 1, KeypadDown:3
+R, 1, KeypadDown:4
+T, 1, KeypadDown:5
+Y, 1, KeypadDown:6
+F, 1, KeypadDown:7
+G, 1, KeypadDown:8
+H, 1, KeypadDown:9
+V, 1, KeypadDown:*
+B, 1, KeypadDown:0
+N, 1, KeypadDown:#
+
+# gamepads. the first gamepad is player 0 and the second gamepad is player 1
+pad0.dpleft, 0, Left
+pad0.dpright, 0, Right
+pad0.dpup, 0, Up
+pad0.dpdown, 0, Down
+pad0.leftx, 0, Left
+pad0.leftx, 0, Right
+pad0.lefty, 0, Up
+pad0.lefty, 0, Down
+pad0.a, 0, Fire
+pad0.b, 0, GenesisButtonC
+pad0.b, 0, BoosterGripTrigger
+pad0.x, 0, BoosterGripBooster
+pad0.rightx, 0, PaddleSet:0
+pad0.rightshoulder, 0, PaddleFire:0
+pad0.back, panel, PanelSelect
+pad0.start, panel, PanelReset
+
+pad1.dpleft, 1, Left
+pad1.dpright, 1, Right
+pad1.dpup, 1, Up
+pad1.dpdown, 1, Down
+pad1.leftx, 1, Left
+pad1.leftx, 1, Right
+pad1.lefty, 1, Up
+pad1.lefty, 1, Down
+pad1.a, 1, Fire
+pad1.b, 1, GenesisButtonC
+pad1.b, 1, BoosterGripTrigger
+pad1.x, 1, BoosterGripBooster
+pad1.rightx, 1, PaddleSet:0
+pad1.rightshoulder, 1, PaddleFire:0
+`
+
+// the kind of event determines how the state of the input is converted to an
+// EventValue
+type bindingKind int
+
+const (
+	// bool value. true when the input is pressed, false when it is released
+	kindBool bindingKind = iota
+
+	// nil value. sent only when the input is pressed
+	kindToggle
+
+	// KeypadDown with the key when pressed, KeypadUp when released
+	kindKeypad
+
+	// bool value for the paddle given in the argument
+	kindPaddleFire
+
+	// float32 value for the paddle given in the argument. axes only
+	kindPaddleSet
+)
+
+// the events that can be bound to an input
+var bindableEvents = map[input.Event]bindingKind{
+	input.Fire:                  kindBool,
+	input.Up:                    kindBool,
+	input.Down:                  kindBool,
+	input.Left:                  kindBool,
+	input.Right:                 kindBool,
+	input.GenesisButtonC:        kindBool,
+	input.BoosterGripTrigger:    kindBool,
+	input.BoosterGripBooster:    kindBool,
+	input.PanelSelect:           kindBool,
+	input.PanelReset:            kindBool,
+	input.PanelToggleColor:      kindToggle,
+	input.PanelTogglePlayer0Pro: kindToggle,
+	input.PanelTogglePlayer1Pro: kindToggle,
+	input.KidVidTogglePlay:      kindToggle,
+	input.KeypadDown:            kindKeypad,
+	input.PaddleFire:            kindPaddleFire,
+	input.PaddleSet:             kindPaddleSet,
+}
+
+// binding is a single event bound to an input
+type binding struct {
+	port  input.ID
+	event input.Event
+	kind  bindingKind
+
+	// the key for KeypadDown events
+	key rune
+
+	// the paddle for PaddleFire and PaddleSet events
+	paddle int
+
+	// whether an axis is currently past the deadzone. only used when an axis
+	// is bound to an event that is not kindPaddleSet
+	active bool
+}
+
+// Bindings maps keyboard keys and gamepad buttons and axes to input events.
+// The bindings are read from a file in the resource path.
+type Bindings struct {
+	bindings map[string][]*binding
+}
+
+// NewBindings is the preferred method of initialisation for the Bindings
+// type. Bindings are loaded from the bindings file in the resource path. If
+// the file does not exist then the default bindings are used and written to
+// the bindings file.
+func NewBindings() (*Bindings, error) {
+	pth, err := paths.ResourcePath("", bindingsFile)
+	if err != nil {
+		return nil, errors.New(errors.BindingsError, err)
+	}
+
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, errors.New(errors.BindingsError, err)
+		}
+
+		// writing the default bindings is a convenience for the user. failure
+		// is not an error
+		data = []byte(defaultBindings)
+		_ = ioutil.WriteFile(pth, data, 0644)
+	}
+
+	return ParseBindings(string(data))
+}
+
+// ParseBindings creates a new Bindings instance from the definitions in the
+// string. The format is the same as the bindings file.
+func ParseBindings(s string) (*Bindings, error) {
+	b := &Bindings{
+		bindings: make(map[string][]*binding),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		name, bnd, err := parseBinding(line)
+		if err != nil {
+			return nil, errors.New(errors.BindingsError, fmt.Sprintf("line %d: %v", lineNum, err))
+		}
+
+		b.bindings[name] = append(b.bindings[name], bnd)
+	}
+
+	return b, nil
+}
+
+// parse a single line of the bindings file. returns the name of the input and
+// the binding
+func parseBinding(line string) (string, *binding, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) != 3 {
+		return "", nil, fmt.Errorf("expected three fields")
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	name := fields[0]
+	if name == "" {
+		return "", nil, fmt.Errorf("no input name")
+	}
+
+	bnd := &binding{}
+
+	// event and optional argument
+	ev := strings.SplitN(fields[2], ":", 2)
+	bnd.event = input.Event(ev[0])
+
+	var ok bool
+	bnd.kind, ok = bindableEvents[bnd.event]
+	if !ok {
+		return "", nil, fmt.Errorf("cannot bind event (%s)", ev[0])
+	}
+
+	switch bnd.kind {
+	case kindKeypad:
+		if len(ev) != 2 || len(ev[1]) != 1 || !strings.Contains("0123456789*#", ev[1]) {
+			return "", nil, fmt.Errorf("%s requires a keypad key", bnd.event)
+		}
+		bnd.key = rune(ev[1][0])
+
+	case kindPaddleFire, kindPaddleSet:
+		if len(ev) == 2 {
+			n, err := strconv.Atoi(ev[1])
+			if err != nil || n < 0 || n > 1 {
+				return "", nil, fmt.Errorf("%s requires a paddle number of 0 or 1", bnd.event)
+			}
+			bnd.paddle = n
+		}
+		if bnd.kind == kindPaddleSet && !strings.HasPrefix(name, gamepadPrefix) {
+			return "", nil, fmt.Errorf("%s can only be bound to a gamepad axis", bnd.event)
+		}
+
+	default:
+		if len(ev) != 1 {
+			return "", nil, fmt.Errorf("%s does not take an argument", bnd.event)
+		}
+	}
+
+	// port. panel events can only be sent to the panel and all other events
+	// can only be sent to the hand controllers
+	panelEvent := strings.HasPrefix(string(bnd.event), "Panel")
+	switch strings.ToLower(fields[1]) {
+	case "0":
+		bnd.port = input.HandControllerZeroID
+	case "1":
+		bnd.port = input.HandControllerOneID
+	case "panel":
+		bnd.port = input.PanelID
+	default:
+		return "", nil, fmt.Errorf("unrecognised port (%s)", fields[1])
+	}
+	if panelEvent != (bnd.port == input.PanelID) {
+		return "", nil, fmt.Errorf("%s cannot be sent to port %s", bnd.event, fields[1])
+	}
+
+	return name, bnd, nil
+}
+
+// return the port and hand controller for the port ID. the hand controller
+// will be nil for the panel
+func bindingPort(vcs *hardware.VCS, id input.ID) (input.Port, *input.HandController) {
+	switch id {
+	case input.HandControllerZeroID:
+		return vcs.HandController0, vcs.RIOT.Input.HandController0
+	case input.HandControllerOneID:
+		return vcs.HandController1, vcs.RIOT.Input.HandController1
+	}
+	return vcs.Panel, nil
+}
+
+// press or release the input bound to the event
+func (bnd *binding) press(vcs *hardware.VCS, down bool) error {
+	port, hc := bindingPort(vcs, bnd.port)
+
+	switch bnd.kind {
+	case kindToggle:
+		if down {
+			return port.Handle(bnd.event, nil)
+		}
+
+	case kindKeypad:
+		if down {
+			return port.Handle(input.KeypadDown, bnd.key)
+		}
+		return port.Handle(input.KeypadUp, nil)
+
+	case kindPaddleFire:
+		return port.Handle(input.PaddleFire, input.PaddleValue{Paddle: bnd.paddle, Value: down})
+
+	case kindBool:
+		// left and right rotate the driving controller instead of moving the
+		// joystick
+		if hc != nil && hc.ControllerType() == input.DrivingType && (bnd.event == input.Left || bnd.event == input.Right) {
+			rotation := float32(0)
+			if down {
+				rotation = drivingRotation
+				if bnd.event == input.Left {
+					rotation = -rotation
+				}
+			}
+			return port.Handle(input.DrivingRotate, rotation)
+		}
+
+		return port.Handle(bnd.event, down)
+	}
+
+	return nil
+}
+
+// move the axis bound to the event. the value is in the range -1.0 to 1.0
+func (bnd *binding) axis(vcs *hardware.VCS, value float32) error {
+	if bnd.kind == kindPaddleSet {
+		port, _ := bindingPort(vcs, bnd.port)
+		return port.Handle(input.PaddleSet, input.PaddleValue{Paddle: bnd.paddle, Value: (value + 1.0) / 2.0})
+	}
+
+	// the negative half of the axis is used for left and up. the positive
+	// half for everything else
+	var active bool
+	if bnd.event == input.Left || bnd.event == input.Up {
+		active = value < -axisDeadzone
+	} else {
+		active = value > axisDeadzone
+	}
+
+	if active == bnd.active {
+		return nil
+	}
+	bnd.active = active
+
+	return bnd.press(vcs, active)
+}
+
+// returns true if the key is a modifier key. modifier keys can be bound like
+// any other key
+func isModifierKey(key string) bool {
+	return strings.HasSuffix(key, " Shift") || strings.HasSuffix(key, " Ctrl") || strings.HasSuffix(key, " Alt")
+}
+
+// KeyboardEventHandler handles keypresses sent from a GUI. Returns true if
+// key has been handled, false otherwise.
+//
+// For reasons of consistency, this handler is used by the debugger too.
+func (b *Bindings) KeyboardEventHandler(ev gui.EventKeyboard, vcs *hardware.VCS) (bool, error) {
+	// key presses with a modifier are not for the emulation
+	if ev.Down && ev.Mod != gui.KeyModNone && !isModifierKey(ev.Key) {
+		return false, nil
+	}
+
+	bindings, ok := b.bindings[ev.Key]
+	if !ok {
+		return false, nil
+	}
+
+	for _, bnd := range bindings {
+		if err := bnd.press(vcs, ev.Down); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// GamepadButtonEventHandler handles gamepad buttons sent from a GUI. Returns
+// true if the button has been handled, false otherwise.
+func (b *Bindings) GamepadButtonEventHandler(ev gui.EventGamepadButton, vcs *hardware.VCS) (bool, error) {
+	bindings, ok := b.bindings[fmt.Sprintf("%s%d.%s", gamepadPrefix, ev.Gamepad, ev.Button)]
+	if !ok {
+		return false, nil
+	}
+
+	for _, bnd := range bindings {
+		if err := bnd.press(vcs, ev.Down); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// GamepadAxisEventHandler handles gamepad axis movement sent from a GUI.
+// Returns true if the axis has been handled, false otherwise.
+func (b *Bindings) GamepadAxisEventHandler(ev gui.EventGamepadAxis, vcs *hardware.VCS) (bool, error) {
+	bindings, ok := b.bindings[fmt.Sprintf("%s%d.%s", gamepadPrefix, ev.Gamepad, ev.Axis)]
+	if !ok {
+		return false, nil
+	}
+
+	for _, bnd := range bindings {
+		if err := bnd.axis(vcs, ev.Value); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,245 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode_test
+
+import (
+	"gopher2600/gui"
+	"gopher2600/hardware"
+	"gopher2600/playmode"
+	"gopher2600/television"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBindings(t *testing.T) {
+	_, err := playmode.ParseBindings(`
+# comment
+Left, 0, Left
+Right Shift, 1, BoosterGripBooster
+F1, panel, PanelSelect
+1, 0, KeypadDown:#
+pad0.rightx, 1, PaddleSet:1
+pad0.rightshoulder, 1, PaddleFire
+`)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	bad := []string{
+		// not enough fields
+		"Left, Left",
+		// unknown event
+		"Left, 0, Sideways",
+		// unknown port
+		"Left, 2, Left",
+		// panel event to hand controller
+		"F1, 0, PanelSelect",
+		// hand controller event to panel
+		"Left, panel, Left",
+		// keypad event without a key
+		"1, 0, KeypadDown",
+		// paddle number out of range
+		"pad0.rightx, 0, PaddleSet:2",
+		// paddle set bound to a key
+		"P, 0, PaddleSet",
+		// argument to an event that doesn't take one
+		"Left, 0, Left:1",
+	}
+
+	for _, b := range bad {
+		_, err := playmode.ParseBindings(b)
+		if err == nil {
+			t.Errorf("expected error for %q", b)
+		}
+	}
+}
+
+// addresses of the registers changed by the default bindings
+const (
+	addrSWCHA = 0x0280
+	addrSWCHB = 0x0282
+	addrINPT4 = 0x000c
+	addrINPT5 = 0x000d
+)
+
+func newVCS(t *testing.T) *hardware.VCS {
+	t.Helper()
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	return vcs
+}
+
+func peek(t *testing.T, vcs *hardware.VCS, address uint16) uint8 {
+	t.Helper()
+
+	v, err := vcs.Mem.Peek(address)
+	if err != nil {
+		t.Fatalf("cannot peek address %#04x: %v", address, err)
+	}
+
+	return v
+}
+
+// change to a temporary directory so that the bindings file is written to a
+// resource path that is removed at the end of the test
+func chdir(t *testing.T) func() {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "bindings_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+
+	return func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestDefaultBindings(t *testing.T) {
+	defer chdir(t)()
+
+	bnd, err := playmode.NewBindings()
+	if err != nil {
+		t.Fatalf("cannot create bindings: %v", err)
+	}
+
+	vcs := newVCS(t)
+
+	key := func(k string, down bool) {
+		t.Helper()
+		handled, err := bnd.KeyboardEventHandler(gui.EventKeyboard{Key: k, Down: down}, vcs)
+		if err != nil {
+			t.Fatalf("unexpected error for key %s: %v", k, err)
+		}
+		if !handled {
+			t.Fatalf("key %s not handled", k)
+		}
+	}
+
+	// player 0 joystick. SWCHA bits are low when the stick is pushed
+	key("Left", true)
+	if peek(t, vcs, addrSWCHA)&0x40 != 0x00 {
+		t.Errorf("player 0 left not seen in SWCHA")
+	}
+	key("Left", false)
+	if peek(t, vcs, addrSWCHA)&0x40 != 0x40 {
+		t.Errorf("player 0 left not released in SWCHA")
+	}
+
+	key("Space", true)
+	if peek(t, vcs, addrINPT4)&0x80 != 0x00 {
+		t.Errorf("player 0 fire not seen in INPT4")
+	}
+	key("Space", false)
+
+	// player 1 joystick uses the lower nibble of SWCHA and INPT5
+	key("I", true)
+	if peek(t, vcs, addrSWCHA)&0x01 != 0x00 {
+		t.Errorf("player 1 up not seen in SWCHA")
+	}
+	if peek(t, vcs, addrSWCHA)&0xf0 != 0xf0 {
+		t.Errorf("player 1 up changed player 0 bits in SWCHA")
+	}
+	key("I", false)
+
+	key("L", true)
+	if peek(t, vcs, addrSWCHA)&0x08 != 0x00 {
+		t.Errorf("player 1 right not seen in SWCHA")
+	}
+	key("L", false)
+
+	key("U", true)
+	if peek(t, vcs, addrINPT5)&0x80 != 0x00 {
+		t.Errorf("player 1 fire not seen in INPT5")
+	}
+	if peek(t, vcs, addrINPT4)&0x80 != 0x80 {
+		t.Errorf("player 1 fire seen in INPT4")
+	}
+	key("U", false)
+
+	if peek(t, vcs, addrSWCHA) != 0xff {
+		t.Errorf("joysticks not released in SWCHA (%#02x)", peek(t, vcs, addrSWCHA))
+	}
+
+	// panel select is bit 1 of SWCHB
+	key("F1", true)
+	if peek(t, vcs, addrSWCHB)&0x02 != 0x00 {
+		t.Errorf("panel select not seen in SWCHB")
+	}
+	key("F1", false)
+
+	// keys pressed with a modifier are not for the emulation
+	handled, err := bnd.KeyboardEventHandler(gui.EventKeyboard{Key: "Left", Down: true, Mod: gui.KeyModCtrl}, vcs)
+	if err != nil || handled {
+		t.Errorf("key with modifier should not be handled")
+	}
+
+	// the second gamepad's left stick is the player 1 joystick. the stick
+	// must be moved past the deadzone
+	axis := func(v float32) {
+		t.Helper()
+		_, err := bnd.GamepadAxisEventHandler(gui.EventGamepadAxis{Gamepad: 1, Axis: "lefty", Value: v}, vcs)
+		if err != nil {
+			t.Fatalf("unexpected error for axis: %v", err)
+		}
+	}
+
+	axis(-0.25)
+	if peek(t, vcs, addrSWCHA)&0x01 != 0x01 {
+		t.Errorf("player 1 gamepad up seen in SWCHA inside the deadzone")
+	}
+	axis(-1.0)
+	if peek(t, vcs, addrSWCHA)&0x01 != 0x00 {
+		t.Errorf("player 1 gamepad up not seen in SWCHA")
+	}
+	axis(0.0)
+	if peek(t, vcs, addrSWCHA)&0x01 != 0x01 {
+		t.Errorf("player 1 gamepad up not released in SWCHA")
+	}
+
+	handled, err = bnd.GamepadButtonEventHandler(gui.EventGamepadButton{Gamepad: 1, Button: "a", Down: true}, vcs)
+	if err != nil || !handled {
+		t.Fatalf("player 1 gamepad fire not handled")
+	}
+	if peek(t, vcs, addrINPT5)&0x80 != 0x00 {
+		t.Errorf("player 1 gamepad fire not seen in INPT5")
+	}
+}
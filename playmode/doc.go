@@ -20,4 +20,8 @@
 // Package playmode is a simple way of running the emulation. It handles setup
 // of the hardware, preparation of playback scripts (for recording or
 // playback), attaching of a GUI and routing of input events.
+//
+// Keyboard and gamepad input is routed according to the Bindings type. The
+// bindings are read from a file in the resource path and are also used by the
+// debugger.
 package playmode
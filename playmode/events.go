@@ -20,17 +20,50 @@
 package playmode
 
 import (
+	"fmt"
+	"gopher2600/errors"
 	"gopher2600/gui"
 	"gopher2600/hardware"
 	"gopher2600/hardware/riot/input"
+	"gopher2600/screenshot"
 )
 
 // MouseMotionEventHandler handles mouse events sent from a GUI. Returns true if key
 // has been handled, false otherwise.
 func MouseMotionEventHandler(ev gui.EventMouseMotion, vcs *hardware.VCS) (bool, error) {
+	// the Trak-Ball and mice follow the mouse on both axes
+	if port := pointerPort(vcs); port != nil {
+		if err := port.Handle(input.PointerMoveX, ev.DX); err != nil {
+			return true, err
+		}
+		return true, port.Handle(input.PointerMoveY, ev.DY)
+	}
+
+	// horizontal distance from the centre of the screen sets the speed of
+	// rotation of the driving controller
+	if vcs.RIOT.Input.HandController0.ControllerType() == input.DrivingType {
+		return true, vcs.HandController0.Handle(input.DrivingRotate, (ev.X-0.5)*2*drivingRotation)
+	}
 	return true, vcs.HandController0.Handle(input.PaddleSet, ev.X)
 }
 
+// pointerPort returns the first port with a Trak-Ball or mouse attached.
+// returns nil if there is no such port.
+func pointerPort(vcs *hardware.VCS) input.Port {
+	if vcs.RIOT.Input.HandController0.ControllerType().IsPointer() {
+		return vcs.HandController0
+	}
+	if vcs.RIOT.Input.HandController1.ControllerType().IsPointer() {
+		return vcs.HandController1
+	}
+	return nil
+}
+
+// the rate of rotation (in gray code steps per frame) of the driving
+// controller when operated with the keyboard or gamepad. the mouse can rotate
+// the controller at up to the same rate.
+const drivingRotation = float32(0.5)
+
 // MouseButtonEventHandler handles mouse events sent from a GUI. Returns true if key
 // has been handled, false otherwise.
 func MouseButtonEventHandler(ev gui.EventMouseButton, vcs *hardware.VCS, scr gui.GUI) (bool, error) {
@@ -39,7 +72,11 @@ func MouseButtonEventHandler(ev gui.EventMouseButton, vcs *hardware.VCS, scr gui
 
 	switch ev.Button {
 	case gui.MouseButtonLeft:
-		if ev.Down {
+		if port := pointerPort(vcs); port != nil {
+			err = port.Handle(input.Fire, ev.Down)
+		} else if vcs.RIOT.Input.HandController0.ControllerType() == input.DrivingType {
+			err = vcs.HandController0.Handle(input.Fire, ev.Down)
+		} else if ev.Down {
 			err = vcs.HandController0.Handle(input.PaddleFire, true)
 		} else {
 			err = vcs.HandController0.Handle(input.PaddleFire, false)
@@ -51,168 +88,34 @@ func MouseButtonEventHandler(ev gui.EventMouseButton, vcs *hardware.VCS, scr gui
 	return handled, err
 }
 
-// KeyboardEventHandler handles keypresses sent from a GUI. Returns true if
-// key has been handled, false otherwise.
-//
-// For reasons of consistency, this handler is used by the debugger too.
-func KeyboardEventHandler(ev gui.EventKeyboard, vcs *hardware.VCS) (bool, error) {
-	var handled bool
-	var err error
-
-	if ev.Down && ev.Mod == gui.KeyModNone {
-		switch ev.Key {
-		// panel
-		case "F1":
-			err = vcs.Panel.Handle(input.PanelSelect, true)
-			handled = true
-		case "F2":
-			err = vcs.Panel.Handle(input.PanelReset, true)
-			handled = true
-		case "F3":
-			err = vcs.Panel.Handle(input.PanelToggleColor, nil)
-			handled = true
-		case "F4":
-			err = vcs.Panel.Handle(input.PanelTogglePlayer0Pro, nil)
-			handled = true
-		case "F5":
-			err = vcs.Panel.Handle(input.PanelTogglePlayer1Pro, nil)
-			handled = true
-
-		// joystick
-		case "Left":
-			err = vcs.HandController0.Handle(input.Left, true)
-			handled = true
-		case "Right":
-			err = vcs.HandController0.Handle(input.Right, true)
-			handled = true
-		case "Up":
-			err = vcs.HandController0.Handle(input.Up, true)
-			handled = true
-		case "Down":
-			err = vcs.HandController0.Handle(input.Down, true)
-			handled = true
-		case "Space":
-			err = vcs.HandController0.Handle(input.Fire, true)
-			handled = true
-
-		// keypad (left player)
-		case "1", "2", "3":
-			err = vcs.HandController0.Handle(input.KeypadDown, rune(ev.Key[0]))
-			handled = true
-		case "Q":
-			err = vcs.HandController0.Handle(input.KeypadDown, '4')
-			handled = true
-		case "W":
-			err = vcs.HandController0.Handle(input.KeypadDown, '5')
-			handled = true
-		case "E":
-			err = vcs.HandController0.Handle(input.KeypadDown, '6')
-			handled = true
-		case "A":
-			err = vcs.HandController0.Handle(input.KeypadDown, '7')
-			handled = true
-		case "S":
-			err = vcs.HandController0.Handle(input.KeypadDown, '8')
-			handled = true
-		case "D":
-			err = vcs.HandController0.Handle(input.KeypadDown, '9')
-			handled = true
-		case "Z":
-			err = vcs.HandController0.Handle(input.KeypadDown, '*')
-			handled = true
-		case "X":
-			err = vcs.HandController0.Handle(input.KeypadDown, '0')
-			handled = true
-		case "C":
-			err = vcs.HandController0.Handle(input.KeypadDown, '#')
-			handled = true
-
-		// keypad (right player)
-		case "4":
-			err = vcs.HandController1.Handle(input.KeypadDown, '1')
-			handled = true
-		case "5":
-			err = vcs.HandController1.Handle(input.KeypadDown, '2')
-			handled = true
-		case "6":
-			err = vcs.HandController1.Handle(input.KeypadDown, '3')
-			handled = true
-		case "R":
-			err = vcs.HandController1.Handle(input.KeypadDown, '4')
-			handled = true
-		case "T":
-			err = vcs.HandController1.Handle(input.KeypadDown, '5')
-			handled = true
-		case "Y":
-			err = vcs.HandController1.Handle(input.KeypadDown, '6')
-			handled = true
-		case "F":
-			err = vcs.HandController1.Handle(input.KeypadDown, '7')
-			handled = true
-		case "G":
-			err = vcs.HandController1.Handle(input.KeypadDown, '8')
-			handled = true
-		case "H":
-			err = vcs.HandController1.Handle(input.KeypadDown, '9')
-			handled = true
-		case "V":
-			err = vcs.HandController1.Handle(input.KeypadDown, '*')
-			handled = true
-		case "B":
-			err = vcs.HandController1.Handle(input.KeypadDown, '0')
-			handled = true
-		case "N":
-			err = vcs.HandController1.Handle(input.KeypadDown, '#')
-			handled = true
-		}
-	} else {
-		switch ev.Key {
-		// panel
-		case "F1":
-			err = vcs.Panel.Handle(input.PanelSelect, false)
-			handled = true
-		case "F2":
-			err = vcs.Panel.Handle(input.PanelReset, false)
-			handled = true
-
-		// josytick
-		case "Left":
-			err = vcs.HandController0.Handle(input.Left, false)
-			handled = true
-		case "Right":
-			err = vcs.HandController0.Handle(input.Right, false)
-			handled = true
-		case "Up":
-			err = vcs.HandController0.Handle(input.Up, false)
-			handled = true
-		case "Down":
-			err = vcs.HandController0.Handle(input.Down, false)
-			handled = true
-		case "Space":
-			err = vcs.HandController0.Handle(input.Fire, false)
-			handled = true
-
-		// keypad (left player)
-		case "1", "2", "3", "Q", "W", "E", "A", "S", "D", "Z", "X", "C":
-			err = vcs.HandController0.Handle(input.KeypadUp, nil)
-			handled = true
-
-		// keypad (right player)
-		case "4", "5", "6", "R", "T", "Y", "F", "G", "H", "V", "B", "N":
-			err = vcs.HandController1.Handle(input.KeypadUp, nil)
-			handled = true
-		}
-	}
-
-	return handled, err
-}
-
 func (pl *playmode) guiEventHandler(ev gui.Event) (bool, error) {
 	switch ev := ev.(type) {
 	case gui.EventQuit:
 		return false, nil
 	case gui.EventKeyboard:
-		_, err := KeyboardEventHandler(ev, pl.vcs)
+		// screenshot hotkey is only available in playmode. the debugger has
+		// the SCREENSHOT command
+		if ev.Down && ev.Mod == gui.KeyModNone && ev.Key == "F12" {
+			fn := screenshot.UniqueFilename(pl.vcs.Mem.Cart.ShortName())
+			err := pl.screenshot.Save(fn, screenshot.ModeAspect)
+			if err != nil {
+				return false, err
+			}
+
+			// not all GUIs can show messages. that's not a problem
+			err = pl.scr.SetFeature(gui.ReqSetMessage, fmt.Sprintf("screenshot saved to %s", fn))
+			if err != nil && !errors.Is(err, errors.UnsupportedGUIRequest) {
+				return false, err
+			}
+			return true, nil
+		}
+		_, err := pl.bindings.KeyboardEventHandler(ev, pl.vcs)
+		return err == nil, err
+	case gui.EventGamepadButton:
+		_, err := pl.bindings.GamepadButtonEventHandler(ev, pl.vcs)
+		return err == nil, err
+	case gui.EventGamepadAxis:
+		_, err := pl.bindings.GamepadAxisEventHandler(ev, pl.vcs)
 		return err == nil, err
 	case gui.EventMouseButton:
 		_, err := MouseButtonEventHandler(ev, pl.vcs, pl.scr)
@@ -27,8 +27,10 @@ import (
 	"gopher2600/hardware"
 	"gopher2600/patch"
 	"gopher2600/recorder"
+	"gopher2600/screenshot"
 	"gopher2600/setup"
 	"gopher2600/television"
+	"math/rand"
 	"os"
 	"os/signal"
 	"time"
@@ -39,10 +41,19 @@ type playmode struct {
 	scr     gui.GUI
 	intChan chan os.Signal
 	guiChan chan gui.Event
+
+	// keeps a copy of the most recent frame for the screenshot hotkey
+	screenshot *screenshot.Screenshot
+
+	// maps keyboard and gamepad input to VCS input
+	bindings *Bindings
 }
 
 // Play is a quick of setting up a playable instance of the emulator.
-func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording bool, cartload cartridgeloader.Loader, patchFile string) error {
+//
+// If the cartridge is a playback file then the skipFrame argument is the
+// frame the playback should be advanced to before the display is shown.
+func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording bool, cartload cartridgeloader.Loader, patchFile string, skipFrame int) error {
 	var transcript string
 
 	// if supplied cartridge name is actually a playback file then set
@@ -58,7 +69,27 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 		cartload = cartridgeloader.Loader{}
 	}
 
-	vcs, err := hardware.NewVCS(tv)
+	// the playback must be prepared before the VCS is created because the
+	// random number generator must be seeded with the value from the
+	// recording
+	var plb *recorder.Playback
+
+	// a new recording needs a known random seed so that it can be stored in
+	// the recording
+	seed := time.Now().UnixNano()
+
+	if transcript != "" {
+		var err error
+
+		plb, err = recorder.NewPlayback(transcript)
+		if err != nil {
+			return err
+		}
+
+		seed = plb.Seed
+	}
+
+	vcs, err := hardware.NewVCS(tv, rand.New(rand.NewSource(seed)))
 	if err != nil {
 		return errors.New(errors.PlayError, err)
 	}
@@ -76,7 +107,7 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 				n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second()))
 
 		// prepare new recording
-		rec, err := recorder.NewRecorder(transcript, vcs)
+		rec, err := recorder.NewRecorder(transcript, vcs, seed)
 		if err != nil {
 			return errors.New(errors.PlayError, err)
 		}
@@ -88,31 +119,22 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 
 		// attach cartridge after recorder and transcribers have been
 		// setup because we want to catch any setup events in the recording
-		err = setup.AttachCartridge(vcs, cartload)
+		err = setup.AttachCartridge(vcs, cartload, setup.Disassemble(vcs))
 		if err != nil {
 			return errors.New(errors.PlayError, err)
 		}
 
-	} else if transcript != "" {
-		// not a new recording but a transcript has been supplied. this is a
-		// playback request
-
-		plb, err := recorder.NewPlayback(transcript)
-		if err != nil {
-			return err
-		}
-
-		if cartload.Filename != "" && cartload.Filename != plb.CartLoad.Filename {
-			return errors.New(errors.PlayError, "cartridge doesn't match name in the playback recording")
+		// apply patch if requested. the patch will be noted in the recording
+		if patchFile != "" {
+			_, err := patch.CartridgeMemory(vcs.Mem.Cart, patchFile)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
 		}
 
-		// not using setup.AttachCartridge. if the playback was recorded with setup
-		// changes the events will have been copied into the playback script and
-		// will be applied that way
-		err = vcs.AttachCartridge(plb.CartLoad)
-		if err != nil {
-			return errors.New(errors.PlayError, err)
-		}
+	} else if plb != nil {
+		// not a new recording but a transcript has been supplied. this is a
+		// playback request
 
 		// the following will fail if the recording was made with different tv
 		// parameters. currently, the only parameter is the tv spec (ie. AUTO,
@@ -123,11 +145,23 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 			return errors.New(errors.PlayError, err)
 		}
 
+		if skipFrame > 0 {
+			err = plb.Skip(skipFrame)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+		}
+
 	} else {
 		// no new recording requested and no transcript given. this is a 'normal'
 		// launch of the emalator for regular play
 
-		err = setup.AttachCartridge(vcs, cartload)
+		// the contents of the SaveKey and AtariVox are kept between sessions.
+		// not for recordings or playbacks because the contents are not
+		// part of the recording
+		vcs.RIOT.Input.PersistPeripherals(true)
+
+		err = setup.AttachCartridge(vcs, cartload, setup.Disassemble(vcs))
 		if err != nil {
 			return errors.New(errors.PlayError, err)
 		}
@@ -149,6 +183,13 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 		guiChan: make(chan gui.Event, 2),
 	}
 
+	pl.screenshot = screenshot.NewScreenshot(tv)
+
+	pl.bindings, err = NewBindings()
+	if err != nil {
+		return errors.New(errors.PlayError, err)
+	}
+
 	// connect gui
 	err = scr.SetFeature(gui.ReqSetEventChan, pl.guiChan)
 	if err != nil {
@@ -0,0 +1,39 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package ramsearch helps find the location of game variables (the number of
+// lives, the score, the position of the player, etc.) in the VCS's 128 bytes
+// of RAM and in any active cartridge RAM.
+//
+// A search is started with Start(), which takes a snapshot of every RAM
+// address. Every call to Narrow() compares the current value of each candidate
+// address with the value in the previous snapshot, discarding those that do
+// not match the Comparison, and then takes a new snapshot. For example, to
+// find the number of lives: start the search, lose a life, narrow with
+// Decreased, play for a while without losing a life, narrow with Unchanged,
+// and so on until only a few candidates remain.
+//
+// Addresses can be frozen with Freeze(). A frozen address has its value
+// forced at the start of every frame. The Search type is a
+// television.PixelRenderer for this reason and NewSearch() adds the new
+// instance to the television's list of renderers.
+//
+// All addresses are read addresses and all memory access is through the
+// memory.VCSMemory Peek() and Poke() functions.
+package ramsearch
@@ -0,0 +1,304 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package ramsearch
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware/memory"
+	"gopher2600/hardware/memory/memorymap"
+	"gopher2600/television"
+	"sort"
+	"strings"
+)
+
+// Comparison specifies how the current value of an address is compared to the
+// value in the previous snapshot
+type Comparison int
+
+// List of valid comparisons
+const (
+	Equal Comparison = iota
+	Changed
+	Unchanged
+	Increased
+	Decreased
+)
+
+func (c Comparison) String() string {
+	switch c {
+	case Equal:
+		return "EQUAL"
+	case Changed:
+		return "CHANGED"
+	case Unchanged:
+		return "UNCHANGED"
+	case Increased:
+		return "INCREASED"
+	case Decreased:
+		return "DECREASED"
+	}
+	return "unknown"
+}
+
+// ParseComparison converts a string to a Comparison value. The string is not
+// case sensitive.
+func ParseComparison(s string) (Comparison, error) {
+	switch strings.ToUpper(s) {
+	case "EQUAL":
+		return Equal, nil
+	case "CHANGED":
+		return Changed, nil
+	case "UNCHANGED":
+		return Unchanged, nil
+	case "INCREASED":
+		return Increased, nil
+	case "DECREASED":
+		return Decreased, nil
+	}
+	return Equal, errors.New(errors.RAMSearchError, fmt.Sprintf("unrecognised comparison (%s)", s))
+}
+
+// Candidate is an address that has matched every comparison since the search
+// started. The Previous field is the value of the address in the snapshot
+// before the most recent one.
+type Candidate struct {
+	Address  uint16
+	Previous uint8
+	Value    uint8
+}
+
+func (c Candidate) String() string {
+	return fmt.Sprintf("%#04x: %#02x (was %#02x)", c.Address, c.Value, c.Previous)
+}
+
+// Frozen is an address that has its value forced every frame
+type Frozen struct {
+	Address uint16
+	Value   uint8
+}
+
+func (f Frozen) String() string {
+	return fmt.Sprintf("%#04x = %#02x", f.Address, f.Value)
+}
+
+// Search is an implementation of the television.PixelRenderer interface.
+type Search struct {
+	mem *memory.VCSMemory
+
+	// candidates are kept in address order
+	candidates []Candidate
+
+	// frozen addresses and the value to force them to
+	frozen map[uint16]uint8
+}
+
+// NewSearch is the preferred method of initialisation for the Search type.
+// The new instance is added to the television's list of renderers.
+func NewSearch(tv television.Television, mem *memory.VCSMemory) *Search {
+	srch := &Search{
+		mem:    mem,
+		frozen: make(map[uint16]uint8),
+	}
+
+	tv.AddPixelRenderer(srch)
+
+	return srch
+}
+
+// Addresses returns every address that is included in a new search: VCS RAM
+// followed by the read addresses of any active cartridge RAM.
+func (srch *Search) Addresses() []uint16 {
+	addresses := make([]uint16, 0, memorymap.MemtopRAM-memorymap.OriginRAM+1)
+	for a := memorymap.OriginRAM; a <= memorymap.MemtopRAM; a++ {
+		addresses = append(addresses, a)
+	}
+
+	for _, r := range srch.mem.Cart.GetRAMinfo() {
+		if !r.Active {
+			continue
+		}
+		for a := r.ReadOrigin; a <= r.ReadMemtop; a++ {
+			addresses = append(addresses, a)
+		}
+	}
+
+	return addresses
+}
+
+// Start a new search. Every address returned by Addresses() is a candidate.
+// Frozen addresses are not affected.
+func (srch *Search) Start() error {
+	addresses := srch.Addresses()
+	srch.candidates = make([]Candidate, 0, len(addresses))
+
+	for _, a := range addresses {
+		v, err := srch.mem.Peek(a)
+		if err != nil {
+			return errors.New(errors.RAMSearchError, err)
+		}
+		srch.candidates = append(srch.candidates, Candidate{Address: a, Previous: v, Value: v})
+	}
+
+	return nil
+}
+
+// Narrow the search by discarding every candidate that does not match the
+// comparison. The value argument is only used by the Equal comparison.
+// Returns the number of remaining candidates.
+func (srch *Search) Narrow(cmp Comparison, value uint8) (int, error) {
+	if srch.candidates == nil {
+		return 0, errors.New(errors.RAMSearchError, "search has not been started")
+	}
+
+	// the narrowed list is built separately so that the candidates are left
+	// untouched if there is an error
+	n := make([]Candidate, 0, len(srch.candidates))
+
+	for _, c := range srch.candidates {
+		v, err := srch.mem.Peek(c.Address)
+		if err != nil {
+			return 0, errors.New(errors.RAMSearchError, err)
+		}
+
+		var match bool
+
+		switch cmp {
+		case Equal:
+			match = v == value
+		case Changed:
+			match = v != c.Value
+		case Unchanged:
+			match = v == c.Value
+		case Increased:
+			match = v > c.Value
+		case Decreased:
+			match = v < c.Value
+		}
+
+		if match {
+			n = append(n, Candidate{Address: c.Address, Previous: c.Value, Value: v})
+		}
+	}
+
+	srch.candidates = n
+
+	return len(srch.candidates), nil
+}
+
+// Candidates returns a copy of the remaining candidates. The result is nil if
+// the search has not been started.
+func (srch *Search) Candidates() []Candidate {
+	if srch.candidates == nil {
+		return nil
+	}
+	c := make([]Candidate, len(srch.candidates))
+	copy(c, srch.candidates)
+	return c
+}
+
+// Clear the search. Frozen addresses are not affected.
+func (srch *Search) Clear() {
+	srch.candidates = nil
+}
+
+// Freeze an address at the specified value. The value is poked immediately
+// and then again at the start of every frame. Freezing an address that is
+// already frozen changes the value. Only addresses returned by Addresses()
+// can be frozen.
+func (srch *Search) Freeze(address uint16, value uint8) error {
+	ok := false
+	for _, a := range srch.Addresses() {
+		if a == address {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return errors.New(errors.RAMSearchError, fmt.Sprintf("%#04x is not a RAM address", address))
+	}
+
+	if err := srch.mem.Poke(address, value); err != nil {
+		return errors.New(errors.RAMSearchError, err)
+	}
+	srch.frozen[address] = value
+	return nil
+}
+
+// Unfreeze an address. Returns false if the address was not frozen.
+func (srch *Search) Unfreeze(address uint16) bool {
+	if _, ok := srch.frozen[address]; !ok {
+		return false
+	}
+	delete(srch.frozen, address)
+	return true
+}
+
+// UnfreezeAll removes every frozen address.
+func (srch *Search) UnfreezeAll() {
+	srch.frozen = make(map[uint16]uint8)
+}
+
+// Frozen returns the list of frozen addresses, in address order.
+func (srch *Search) Frozen() []Frozen {
+	f := make([]Frozen, 0, len(srch.frozen))
+	for a, v := range srch.frozen {
+		f = append(f, Frozen{Address: a, Value: v})
+	}
+	sort.Slice(f, func(i, j int) bool {
+		return f[i].Address < f[j].Address
+	})
+	return f
+}
+
+// Resize implements television.PixelRenderer interface
+func (srch *Search) Resize(topScanline, visibleScanlines int) error {
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (srch *Search) NewFrame(frameNum int) error {
+	// errors are ignored. cartridge RAM can be switched out, in which case
+	// the poke will fail until it is switched back in
+	for a, v := range srch.frozen {
+		_ = srch.mem.Poke(a, v)
+	}
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (srch *Search) NewScanline(scanline int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (srch *Search) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (srch *Search) SetAltPixel(x, y int, red, green, blue byte, vblank bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (srch *Search) EndRendering() error {
+	return nil
+}
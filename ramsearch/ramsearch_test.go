@@ -0,0 +1,126 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package ramsearch_test
+
+import (
+	"gopher2600/hardware/memory"
+	"gopher2600/ramsearch"
+	"gopher2600/television"
+	"testing"
+)
+
+func newSearch(t *testing.T) (*ramsearch.Search, *memory.VCSMemory) {
+	t.Helper()
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	mem, err := memory.NewVCSMemory()
+	if err != nil {
+		t.Fatalf("cannot create memory: %v", err)
+	}
+
+	return ramsearch.NewSearch(tv, mem), mem
+}
+
+func poke(t *testing.T, mem *memory.VCSMemory, address uint16, value uint8) {
+	t.Helper()
+	if err := mem.Poke(address, value); err != nil {
+		t.Fatalf("cannot poke %#04x: %v", address, err)
+	}
+}
+
+func TestNarrow(t *testing.T) {
+	srch, mem := newSearch(t)
+
+	if _, err := srch.Narrow(ramsearch.Changed, 0); err == nil {
+		t.Errorf("expected error for search that has not been started")
+	}
+
+	poke(t, mem, 0x80, 3)
+	poke(t, mem, 0x81, 3)
+	poke(t, mem, 0x82, 3)
+	if err := srch.Start(); err != nil {
+		t.Fatalf("cannot start search: %v", err)
+	}
+	if len(srch.Candidates()) != 128 {
+		t.Fatalf("expected 128 candidates, got %d", len(srch.Candidates()))
+	}
+
+	tests := []struct {
+		cmp   ramsearch.Comparison
+		value uint8
+		pokes map[uint16]uint8
+		n     int
+	}{
+		{ramsearch.Equal, 3, nil, 3},
+		{ramsearch.Changed, 0, map[uint16]uint8{0x80: 2, 0x81: 4}, 2},
+		{ramsearch.Decreased, 0, map[uint16]uint8{0x80: 1, 0x81: 3}, 2},
+		{ramsearch.Unchanged, 0, map[uint16]uint8{0x81: 2}, 1},
+		{ramsearch.Increased, 0, map[uint16]uint8{0x80: 1}, 0},
+	}
+
+	for _, tst := range tests {
+		for a, v := range tst.pokes {
+			poke(t, mem, a, v)
+		}
+		n, err := srch.Narrow(tst.cmp, tst.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tst.cmp, err)
+		}
+		if n != tst.n {
+			t.Fatalf("%s: expected %d candidates, got %d (%v)", tst.cmp, tst.n, n, srch.Candidates())
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	srch, mem := newSearch(t)
+
+	if err := srch.Freeze(0x1000, 1); err == nil {
+		t.Errorf("expected error freezing a cartridge ROM address")
+	}
+
+	if err := srch.Freeze(0x90, 0x42); err != nil {
+		t.Fatalf("cannot freeze address: %v", err)
+	}
+
+	poke(t, mem, 0x90, 0)
+	if err := srch.NewFrame(1); err != nil {
+		t.Fatalf("new frame: %v", err)
+	}
+	if v, _ := mem.Peek(0x90); v != 0x42 {
+		t.Errorf("frozen address has value %#02x (expected 0x42)", v)
+	}
+
+	if !srch.Unfreeze(0x90) || srch.Unfreeze(0x90) {
+		t.Errorf("unexpected result from Unfreeze()")
+	}
+
+	poke(t, mem, 0x90, 0)
+	if err := srch.NewFrame(2); err != nil {
+		t.Fatalf("new frame: %v", err)
+	}
+	if v, _ := mem.Peek(0x90); v != 0x00 {
+		t.Errorf("unfrozen address has value %#02x (expected 0x00)", v)
+	}
+}
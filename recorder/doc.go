@@ -24,4 +24,26 @@
 //
 // Currently, a recorder and playback is attached to all ports of the VCS,
 // including the panel.
+//
+// The Recorder writes version 2 transcripts. As well as user input, version 2
+// transcripts record the cartridge format, the state of the panel, the
+// patches applied to the cartridge and the random seed used to initialise the
+// VCS. Identical consecutive events are run-length encoded and snapshots of
+// the CPU registers and RAM are written periodically.
+//
+// The snapshots are used to verify the emulation during playback. In
+// addition, the complete state of the emulation (the CPU, RAM, TIA, RIOT,
+// cartridge and television) is saved by the Playback type whenever a snapshot
+// frame is reached during a Playback.Skip(), and also at the start of the
+// playback. Skipping to any frame, including frames before the current frame,
+// restores the nearest saved state before that frame and runs the emulation
+// from there. The complete state is held in memory and is not written to the
+// transcript, which makes no difference to the result because the emulation
+// is deterministic.
+//
+// Version 1 transcripts can still be played back. In version 1 transcripts,
+// paddle events sent to the second hand controller were for the second paddle
+// of the pair plugged into the left port. These events are converted on
+// playback to PaddleValue events for the first hand controller. Version 2
+// transcripts made before the introduction of PaddleValue are not converted.
 package recorder
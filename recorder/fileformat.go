@@ -20,13 +20,24 @@
 package recorder
 
 import (
+	"bufio"
 	"fmt"
 	"gopher2600/errors"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
+const fieldSep = ", "
+
+// version 1 event lines
+// ---------------------
+//
+// <id>, <event>, <value>, <frame>, <scanline>, <horizpos>, <hash>
+//
+// every event line has a video digest hash, which is checked on playback
+
 const (
 	fieldID int = iota
 	fieldEvent
@@ -38,7 +49,53 @@ const (
 	numFields
 )
 
-const fieldSep = ", "
+// version 2 record lines
+// ----------------------
+//
+// version 2 files contain two types of record, indicated by the first field:
+//
+// E, <repeat>, <id>, <event>, <value>, <frame delta>, <scanline>, <horizpos>
+// S, <frame>, <hash>, <cpu>, <ram>
+//
+// event records (E) do not contain a video digest hash. moreover, the frame
+// field is the number of frames since the previous event, rather than the
+// absolute frame number. event records that are identical in every field are
+// run-length encoded, with the repeat field recording how many times the
+// event record should be repeated.
+//
+// string values in event records, such as the file name of a Kid Vid tape,
+// are quoted in the manner of strconv.Quote() so that they can contain the
+// field separator.
+//
+// snapshot records (S) are written periodically and are used to verify the
+// state of the emulation during playback. the playback also saves the complete
+// state of the emulation when a snapshot frame is reached, so that it can be
+// returned to later (see Playback.Skip()). the cpu field is the value of the
+// PC, A, X, Y, SP and Status registers, in hex; the ram field is the content of
+// the VCS RAM, in hex.
+
+const recordTypeEvent = "E"
+const recordTypeSnapshot = "S"
+
+const (
+	fieldV2RecordType int = iota
+	fieldV2Repeat
+	fieldV2ID
+	fieldV2Event
+	fieldV2EventValue
+	fieldV2FrameDelta
+	fieldV2Scanline
+	fieldV2HorizPos
+	numV2EventFields
+)
+
+const (
+	fieldV2SnapshotFrame int = iota + 1
+	fieldV2SnapshotHash
+	fieldV2SnapshotCPU
+	fieldV2SnapshotRAM
+	numV2SnapshotFields
+)
 
 // playback file header format
 // ---------------------------
@@ -48,6 +105,20 @@ const fieldSep = ", "
 // <cartridge name>
 // <cartridge hash>
 // <tv type on startup>
+//
+// version 2 adds the following lines:
+//
+// <cartridge format>
+// <panel state>
+// <random seed>
+// <patch files>
+//
+// the panel state line is the state of the player 0 difficulty, player 1
+// difficulty and color switches, as a list of three boolean values. the patch
+// files line is a list of the patch files applied to the cartridge. the list
+// may be empty. each entry in the list is quoted, in the manner of
+// strconv.Quote(), so that file names containing the field separator can be
+// recovered.
 
 const (
 	lineMagicString int = iota
@@ -58,20 +129,45 @@ const (
 	numHeaderLines
 )
 
+const (
+	lineCartFormat int = iota + numHeaderLines
+	linePanel
+	lineSeed
+	linePatches
+	numV2HeaderLines
+)
+
 const magicString = "gopher2600playback"
-const versionString = "1.0"
+
+// DefaultSeed is the random seed assumed for version 1 transcripts. version 1
+// recordings were made without seeding the random number generator, which is
+// the same as seeding it with the value 1.
+const DefaultSeed = 1
+
+// the version string written by the recorder
+const versionString = versionString2
+
+// all supported version strings
+const (
+	versionString1 = "1.0"
+	versionString2 = "2.0"
+)
 
 func (rec *Recorder) writeHeader() error {
-	lines := make([]string, numHeaderLines)
+	lines := make([]string, numV2HeaderLines)
 
 	// add header information
 	lines[lineMagicString] = magicString
 	lines[lineVersion] = versionString
 	lines[lineCartName] = rec.vcs.Mem.Cart.Filename
 	lines[lineCartHash] = rec.vcs.Mem.Cart.Hash
-	lines[lineTVSpec] = fmt.Sprintf("%v\n", rec.vcs.TV.SpecIDOnCreation())
+	lines[lineTVSpec] = rec.vcs.TV.SpecIDOnCreation()
+	lines[lineCartFormat] = rec.vcs.Mem.Cart.Format
+	lines[linePanel] = fmt.Sprintf("%v%s%v%s%v", rec.panel[0], fieldSep, rec.panel[1], fieldSep, rec.panel[2])
+	lines[lineSeed] = fmt.Sprintf("%d", rec.seed)
+	lines[linePatches] = joinPatches(rec.vcs.Mem.Cart.Patches)
 
-	line := strings.Join(lines, "\n")
+	line := fmt.Sprintf("%s\n", strings.Join(lines, "\n"))
 
 	n, err := io.WriteString(rec.output, line)
 
@@ -88,17 +184,143 @@ func (rec *Recorder) writeHeader() error {
 	return nil
 }
 
-func (plb *Playback) readHeader(lines []string) error {
-	if lines[lineMagicString] != magicString {
-		return errors.New(errors.PlaybackError, fmt.Sprintf("not a valid playback transcript (%s)", plb.transcript))
+// readHeader returns the number of lines in the header
+func (plb *Playback) readHeader(lines []string) (int, error) {
+	if len(lines) < numHeaderLines || lines[lineMagicString] != magicString {
+		return 0, errors.New(errors.PlaybackError, fmt.Sprintf("not a valid playback transcript (%s)", plb.transcript))
 	}
 
+	plb.version = lines[lineVersion]
+
 	// read header
 	plb.CartLoad.Filename = lines[lineCartName]
 	plb.CartLoad.Hash = lines[lineCartHash]
 	plb.TVSpec = lines[lineTVSpec]
 
-	return nil
+	switch plb.version {
+	case versionString1:
+		plb.Seed = DefaultSeed
+		return numHeaderLines, nil
+
+	case versionString2:
+		if len(lines) < numV2HeaderLines {
+			return 0, errors.New(errors.PlaybackError, fmt.Sprintf("truncated header (%s)", plb.transcript))
+		}
+
+		plb.CartLoad.Format = lines[lineCartFormat]
+
+		panel := strings.Split(lines[linePanel], fieldSep)
+		if len(panel) != len(plb.panel) {
+			return 0, errors.New(errors.PlaybackError, fmt.Sprintf("invalid panel state at line %d", linePanel+1))
+		}
+		for i := range panel {
+			b, err := strconv.ParseBool(panel[i])
+			if err != nil {
+				return 0, errors.New(errors.PlaybackError, fmt.Sprintf("invalid panel state at line %d", linePanel+1))
+			}
+			plb.panel[i] = b
+		}
+		plb.hasPanel = true
+
+		var err error
+		plb.Seed, err = strconv.ParseInt(lines[lineSeed], 10, 64)
+		if err != nil {
+			return 0, errors.New(errors.PlaybackError, fmt.Sprintf("invalid random seed at line %d", lineSeed+1))
+		}
+
+		plb.patches, err = splitPatches(lines[linePatches])
+		if err != nil {
+			return 0, errors.New(errors.PlaybackError, fmt.Sprintf("%v at line %d", err, linePatches+1))
+		}
+
+		return numV2HeaderLines, nil
+	}
+
+	return 0, errors.New(errors.PlaybackError, fmt.Sprintf("unsupported playback version (%s)", plb.version))
+}
+
+// joinPatches quotes each patch file name and joins them with the field
+// separator
+func joinPatches(patches []string) string {
+	q := make([]string, len(patches))
+	for i := range patches {
+		q[i] = strconv.Quote(patches[i])
+	}
+	return strings.Join(q, fieldSep)
+}
+
+// splitPatches is the inverse of joinPatches(). the field separator is only
+// recognised outside of a quoted file name.
+func splitPatches(line string) ([]string, error) {
+	var patches []string
+
+	for len(line) > 0 {
+		if line[0] != '"' {
+			return nil, fmt.Errorf("unquoted patch file name")
+		}
+
+		// find closing quote, skipping over escaped characters
+		i := 1
+		for ; i < len(line) && line[i] != '"'; i++ {
+			if line[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(line) {
+			return nil, fmt.Errorf("unterminated patch file name")
+		}
+
+		p, err := strconv.Unquote(line[:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid patch file name")
+		}
+		patches = append(patches, p)
+
+		line = line[i+1:]
+		if len(line) > 0 {
+			if !strings.HasPrefix(line, fieldSep) {
+				return nil, fmt.Errorf("invalid patch list")
+			}
+			line = line[len(fieldSep):]
+			if len(line) == 0 {
+				return nil, fmt.Errorf("invalid patch list")
+			}
+		}
+	}
+
+	return patches, nil
+}
+
+// splitFields splits a record line into its fields. a field that begins with
+// a double quote is a quoted string, in the manner of strconv.Quote(), and the
+// field separator is not recognised inside it
+func splitFields(line string) []string {
+	var toks []string
+
+	for {
+		// find closing quote of a quoted field, skipping over escaped
+		// characters
+		i := 0
+		if strings.HasPrefix(line, "\"") {
+			i = 1
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			if i > len(line) {
+				i = len(line)
+			}
+		}
+
+		j := strings.Index(line[i:], fieldSep)
+		if j == -1 {
+			return append(toks, line)
+		}
+
+		toks = append(toks, line[:i+j])
+		line = line[i+j+len(fieldSep):]
+	}
 }
 
 // IsPlaybackFile returns true if the specified file appears to be a playback
@@ -114,24 +336,23 @@ func IsPlaybackFile(filename string) bool {
 	}
 	defer func() { f.Close() }()
 
+	r := bufio.NewReader(f)
+
 	// magic string verification
-	b := make([]byte, len(magicString)+1)
-	n, err := f.Read(b)
-	if n != len(magicString)+1 || err != nil {
+	b, err := r.ReadString('\n')
+	if err != nil || b != magicString+"\n" {
 		return false
 	}
-	if string(b) != magicString+"\n" {
-		return false
-
-	}
 
 	// version number verification
-	b = make([]byte, len(versionString)+1)
-	n, err = f.Read(b)
-	if n != len(versionString)+1 || err != nil {
+	b, err = r.ReadString('\n')
+	if err != nil {
 		return false
 	}
-	if string(b) != versionString+"\n" {
+	switch b {
+	case versionString1 + "\n":
+	case versionString2 + "\n":
+	default:
 		return false
 	}
 
@@ -26,6 +26,7 @@ import (
 	"gopher2600/errors"
 	"gopher2600/hardware"
 	"gopher2600/hardware/riot/input"
+	"gopher2600/patch"
 	"gopher2600/television"
 	"io/ioutil"
 	"os"
@@ -39,7 +40,10 @@ type playbackEntry struct {
 	frame    int
 	scanline int
 	horizpos int
-	hash     string
+
+	// the video digest hash. version 2 event records do not have a hash, in
+	// which case the field will be empty and the hash will not be checked
+	hash string
 
 	// the line in the recording file the playback event appears
 	line int
@@ -50,18 +54,66 @@ type playbackSequence struct {
 	eventCt int
 }
 
+// snapshots are compared with the state of the emulation at the start of the
+// frame
+type playbackSnapshot struct {
+	frame  int
+	record string
+
+	// the line in the recording file the snapshot appears
+	line int
+}
+
+// the complete state of the emulation, captured at the first instruction
+// boundary after a snapshot frame has been reached. see Skip()
+type playbackState struct {
+	frame  int
+	vcs    interface{}
+	digest interface{}
+
+	// the position in each playback sequence and in the list of snapshots
+	eventCts   []int
+	snapshotCt int
+}
+
 // Playback is used to reperform the user input recorded in a previously transcribed
 // file. It implements the input.Playback interface.
 type Playback struct {
 	transcript string
+	version    string
 
 	CartLoad cartridgeloader.Loader
 	TVSpec   string
 
+	// the value used to seed the random number generator when the recording
+	// was made. a generator seeded with this value should be given to the VCS
+	// when it is created. version 1 transcripts do not record the seed, in
+	// which case the value will be DefaultSeed
+	Seed int64
+
+	// the state of the panel at the beginning of the recording. not all
+	// versions of the playback format record the state of the panel
+	panel    [3]bool
+	hasPanel bool
+
+	// list of patches that were applied to the cartridge
+	patches []string
+
 	sequences []*playbackSequence
 	vcs       *hardware.VCS
 	digest    *digest.Video
 
+	snapshots  []playbackSnapshot
+	snapshotCt int
+
+	// the state of the emulation at the start of the playback and at every
+	// snapshot frame that has been reached during a Skip(). ordered by frame
+	states []playbackState
+
+	// a snapshot frame has been reached and the state of the emulation should
+	// be saved at the next instruction boundary
+	statePending bool
+
 	// the last frame where an event occurs
 	endFrame int
 }
@@ -117,91 +169,233 @@ func NewPlayback(transcript string) (*Playback, error) {
 	lines := strings.Split(string(buffer), "\n")
 
 	// read header and perform validation checks
-	err = plb.readHeader(lines)
+	numHeaderLines, err := plb.readHeader(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	switch plb.version {
+	case versionString1:
+		err = plb.readV1(lines, numHeaderLines)
+	default:
+		err = plb.readV2(lines, numHeaderLines)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	return plb, nil
+}
+
+// readV1 reads the event lines of a version 1 transcript
+func (plb *Playback) readV1(lines []string, start int) error {
 	// loop through transcript and divide events according to the first field
 	// (the peripheral ID)
-	for i := numHeaderLines; i < len(lines)-1; i++ {
-		toks := strings.Split(lines[i], fieldSep)
+	for i := start; i < len(lines)-1; i++ {
+		toks := splitFields(lines[i])
 
 		// ignore lines that don't have enough fields
 		if len(toks) != numFields {
 			msg := fmt.Sprintf("expected %d fields at line %d", numFields, i+1)
-			return nil, errors.New(errors.PlaybackError, msg)
-		}
-
-		// add a new playbackSequence for the id if it doesn't exist
-		n, err := strconv.Atoi(toks[fieldID])
-		if err != nil {
-			msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldID+1], fieldSep)))
-			return nil, errors.New(errors.PlaybackError, msg)
+			return errors.New(errors.PlaybackError, msg)
 		}
-		id := input.ID(n)
 
-		// create a new entry and convert tokens accordingly
-		// any errors in the transcript causes failure
 		entry := playbackEntry{line: i + 1}
 
-		// no need to convert event field
-		entry.event = input.Event(toks[fieldEvent])
-
-		// parse entry value into the correct type
-		entry.value = parseEntryValue(toks[fieldEventValue])
-
-		// special condition for KeypadDown and KeypadUp events.
-		//
-		// we don't like special conditions but it's difficult to get around
-		// this elegantly. is we store strings for KeypadDown events then,
-		// because the keypad is mostly numbers, converting them back from the
-		// file will require a prefix of some sort to force it to look like a
-		// string, rather than a float. that's probably a more ugly solution.
-		//
-		// any other solution requires altering the handcontroller
-		// implementation which I don't want to do - the problem is caused here
-		// and so should be mitigated here.
-		//
-		// likewise for KeypadUp events. the handcontroller Handle() function
-		// expects a nil argument for these events but we store the empty
-		// string, instead of nil.
-		if entry.event == input.KeypadDown {
-			entry.value = rune(entry.value.(float32))
-		} else if entry.event == input.KeypadUp {
-			entry.value = nil
+		id, err := parseEvent(&entry, toks, i, fieldID)
+		if err != nil {
+			return err
 		}
 
 		entry.frame, err = strconv.Atoi(toks[fieldFrame])
 		if err != nil {
 			msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldFrame+1], fieldSep)))
-			return nil, errors.New(errors.PlaybackError, msg)
+			return errors.New(errors.PlaybackError, msg)
 		}
 
 		// assuming that frames are listed in order in the file. update
 		// endFrame with the most recent frame every time
 		plb.endFrame = entry.frame
 
-		entry.scanline, err = strconv.Atoi(toks[fieldScanline])
-		if err != nil {
-			msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldScanline+1], fieldSep)))
-			return nil, errors.New(errors.PlaybackError, msg)
-		}
-
-		entry.horizpos, err = strconv.Atoi(toks[fieldHorizPos])
+		err = parsePosition(&entry, toks, i, fieldScanline)
 		if err != nil {
-			msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldHorizPos+1], fieldSep)))
-			return nil, errors.New(errors.PlaybackError, msg)
+			return err
 		}
 
 		entry.hash = toks[fieldHash]
 
+		// in version 1 transcripts the second paddle of the pair plugged into
+		// the left port was emulated as a paddle attached to the second hand
+		// controller. convert these events to PaddleValue events for the
+		// second paddle of the first hand controller
+		if id == input.HandControllerOneID && (entry.event == input.PaddleSet || entry.event == input.PaddleFire) {
+			if _, ok := entry.value.(input.PaddleValue); !ok {
+				id = input.HandControllerZeroID
+				entry.value = input.PaddleValue{Paddle: 1, Value: entry.value}
+			}
+		}
+
 		// add new entry to list of events in the correct playback sequence
 		seq := plb.sequences[id]
 		seq.events = append(seq.events, entry)
 	}
 
-	return plb, nil
+	return nil
+}
+
+// readV2 reads the records of a version 2 transcript
+func (plb *Playback) readV2(lines []string, start int) error {
+	frame := 0
+
+	for i := start; i < len(lines)-1; i++ {
+		toks := splitFields(lines[i])
+
+		switch toks[fieldV2RecordType] {
+		case recordTypeSnapshot:
+			if len(toks) != numV2SnapshotFields {
+				msg := fmt.Sprintf("expected %d fields at line %d", numV2SnapshotFields, i+1)
+				return errors.New(errors.PlaybackError, msg)
+			}
+
+			snp := playbackSnapshot{record: lines[i], line: i + 1}
+
+			var err error
+			snp.frame, err = strconv.Atoi(toks[fieldV2SnapshotFrame])
+			if err != nil {
+				msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldV2SnapshotFrame+1], fieldSep)))
+				return errors.New(errors.PlaybackError, msg)
+			}
+
+			plb.snapshots = append(plb.snapshots, snp)
+
+		case recordTypeEvent:
+			if len(toks) != numV2EventFields {
+				msg := fmt.Sprintf("expected %d fields at line %d", numV2EventFields, i+1)
+				return errors.New(errors.PlaybackError, msg)
+			}
+
+			repeat, err := strconv.Atoi(toks[fieldV2Repeat])
+			if err != nil || repeat < 1 {
+				msg := fmt.Sprintf("invalid repeat value line %d, col %d", i+1, len(strings.Join(toks[:fieldV2Repeat+1], fieldSep)))
+				return errors.New(errors.PlaybackError, msg)
+			}
+
+			entry := playbackEntry{line: i + 1}
+
+			id, err := parseEvent(&entry, toks, i, fieldV2ID)
+			if err != nil {
+				return err
+			}
+
+			delta, err := strconv.Atoi(toks[fieldV2FrameDelta])
+			if err != nil {
+				msg := fmt.Sprintf("%s line %d, col %d", err, i+1, len(strings.Join(toks[:fieldV2FrameDelta+1], fieldSep)))
+				return errors.New(errors.PlaybackError, msg)
+			}
+
+			err = parsePosition(&entry, toks, i, fieldV2Scanline)
+			if err != nil {
+				return err
+			}
+
+			// expand run-length encoded events
+			seq := plb.sequences[id]
+			for r := 0; r < repeat; r++ {
+				frame += delta
+				entry.frame = frame
+				seq.events = append(seq.events, entry)
+			}
+
+			plb.endFrame = frame
+
+		default:
+			msg := fmt.Sprintf("unrecognised record type (%s) at line %d", toks[fieldV2RecordType], i+1)
+			return errors.New(errors.PlaybackError, msg)
+		}
+	}
+
+	return nil
+}
+
+// parseEvent parses the id, event and value fields, starting at the specified
+// field, and updates the playbackEntry accordingly
+func parseEvent(entry *playbackEntry, toks []string, line int, field int) (input.ID, error) {
+	n, err := strconv.Atoi(toks[field])
+	if err != nil {
+		msg := fmt.Sprintf("%s line %d, col %d", err, line+1, len(strings.Join(toks[:field+1], fieldSep)))
+		return 0, errors.New(errors.PlaybackError, msg)
+	}
+	if n < 0 || n >= int(input.NumIDs) {
+		msg := fmt.Sprintf("invalid id line %d, col %d", line+1, len(strings.Join(toks[:field+1], fieldSep)))
+		return 0, errors.New(errors.PlaybackError, msg)
+	}
+	id := input.ID(n)
+
+	// no need to convert event field
+	entry.event = input.Event(toks[field+1])
+
+	// parse entry value into the correct type
+	entry.value = parseEntryValue(toks[field+2])
+
+	// special condition for KeypadDown and KeypadUp events.
+	//
+	// we don't like special conditions but it's difficult to get around
+	// this elegantly. is we store strings for KeypadDown events then,
+	// because the keypad is mostly numbers, converting them back from the
+	// file will require a prefix of some sort to force it to look like a
+	// string, rather than a float. that's probably a more ugly solution.
+	//
+	// any other solution requires altering the handcontroller
+	// implementation which I don't want to do - the problem is caused here
+	// and so should be mitigated here.
+	//
+	// likewise for KeypadUp events. the handcontroller Handle() function
+	// expects a nil argument for these events but we store the empty
+	// string, instead of nil.
+	if entry.event == input.KeypadDown {
+		f, ok := entry.value.(float32)
+		if !ok {
+			msg := fmt.Sprintf("invalid keypad value line %d", line+1)
+			return 0, errors.New(errors.PlaybackError, msg)
+		}
+		entry.value = rune(f)
+	} else if entry.event == input.KeypadUp {
+		entry.value = nil
+	}
+
+	// likewise, PaddleSet and PaddleFire events for the second paddle of a
+	// pair are stored as a string that must be converted to a PaddleValue.
+	if entry.event == input.PaddleSet || entry.event == input.PaddleFire {
+		if s, ok := entry.value.(string); ok {
+			entry.value, err = input.ParsePaddleValue(s)
+			if err != nil {
+				msg := fmt.Sprintf("invalid paddle value line %d", line+1)
+				return 0, errors.New(errors.PlaybackError, msg)
+			}
+		}
+	}
+
+	return id, nil
+}
+
+// parsePosition parses the scanline and horizpos fields, starting at the
+// specified field, and updates the playbackEntry accordingly
+func parsePosition(entry *playbackEntry, toks []string, line int, field int) error {
+	var err error
+
+	entry.scanline, err = strconv.Atoi(toks[field])
+	if err != nil {
+		msg := fmt.Sprintf("%s line %d, col %d", err, line+1, len(strings.Join(toks[:field+1], fieldSep)))
+		return errors.New(errors.PlaybackError, msg)
+	}
+
+	entry.horizpos, err = strconv.Atoi(toks[field+1])
+	if err != nil {
+		msg := fmt.Sprintf("%s line %d, col %d", err, line+1, len(strings.Join(toks[:field+2], fieldSep)))
+		return errors.New(errors.PlaybackError, msg)
+	}
+
+	return nil
 }
 
 // parse value entry as best we can. the theory here is that there is no
@@ -211,6 +405,15 @@ func NewPlayback(transcript string) (*Playback, error) {
 func parseEntryValue(value string) input.EventValue {
 	var err error
 
+	// quoted values are always strings, even if the string looks like a
+	// number or a boolean
+	if strings.HasPrefix(value, "\"") {
+		s, err := strconv.Unquote(value)
+		if err == nil {
+			return s
+		}
+	}
+
 	// the order of these conversions is important. ParseBool will interpret
 	// "0" or "1" as false and true. we want to treat these value as ints or
 	// floats (a float of 0.0 will be written as 0) so we MUST try converting
@@ -231,8 +434,14 @@ func parseEntryValue(value string) input.EventValue {
 	return value
 }
 
-// AttachToVCS attaches the playback instance (an implementation of the
-// playback interface) to all the ports of the VCS, including the panel.
+// AttachToVCS attaches the cartridge named in the recording to the VCS,
+// applying any patches and setting the panel as required. The playback
+// instance (an implementation of the playback interface) is then attached to
+// all the ports of the VCS, including the panel.
+//
+// Note that the cartridge is not attached with setup.AttachCartridge(). If
+// the playback was recorded with setup changes the events will have been
+// copied into the playback script and will be applied that way.
 func (plb *Playback) AttachToVCS(vcs *hardware.VCS) error {
 	// check we're working with correct information
 	if vcs == nil || vcs.TV == nil {
@@ -251,19 +460,175 @@ func (plb *Playback) AttachToVCS(vcs *hardware.VCS) error {
 
 	var err error
 
+	err = vcs.AttachCartridge(plb.CartLoad)
+	if err != nil {
+		return errors.New(errors.PlaybackError, err)
+	}
+
+	for _, p := range plb.patches {
+		_, err = patch.CartridgeMemory(vcs.Mem.Cart, p)
+		if err != nil {
+			return errors.New(errors.PlaybackError, err)
+		}
+	}
+
+	if plb.hasPanel {
+		if err := vcs.Panel.Handle(input.PanelSetPlayer0Pro, plb.panel[0]); err != nil {
+			return errors.New(errors.PlaybackError, err)
+		}
+		if err := vcs.Panel.Handle(input.PanelSetPlayer1Pro, plb.panel[1]); err != nil {
+			return errors.New(errors.PlaybackError, err)
+		}
+		if err := vcs.Panel.Handle(input.PanelSetColor, plb.panel[2]); err != nil {
+			return errors.New(errors.PlaybackError, err)
+		}
+	}
+
 	plb.digest, err = digest.NewVideo(plb.vcs.TV)
 	if err != nil {
 		return errors.New(errors.RecordingError, err)
 	}
 
+	// register ourselves as a television.PixelRenderer so that we can check
+	// snapshots at the start of every frame. the digest must be registered
+	// before the playback.
+	vcs.TV.AddPixelRenderer(plb)
+
 	// attach playback to vcs ports
 	vcs.HandController0.AttachPlayback(plb)
 	vcs.HandController1.AttachPlayback(plb)
 	vcs.Panel.AttachPlayback(plb)
 
+	// the state at the start of the playback means that Skip() can always go
+	// back to an earlier frame
+	err = plb.saveState()
+	if err != nil {
+		return errors.New(errors.PlaybackError, err)
+	}
+
+	return nil
+}
+
+// saveState notes the complete state of the emulation for the current frame.
+// it must only be called at an instruction boundary
+func (plb *Playback) saveState() error {
+	frame, err := plb.vcs.TV.GetState(television.ReqFramenum)
+	if err != nil {
+		return err
+	}
+
+	// the state for this frame may have been saved during an earlier Skip()
+	i := 0
+	for i < len(plb.states) && plb.states[i].frame < frame {
+		i++
+	}
+	if i < len(plb.states) && plb.states[i].frame == frame {
+		return nil
+	}
+
+	vcs, err := plb.vcs.SaveState()
+	if err != nil {
+		return err
+	}
+
+	st := playbackState{
+		frame:      frame,
+		vcs:        vcs,
+		digest:     plb.digest.SaveState(),
+		eventCts:   make([]int, len(plb.sequences)),
+		snapshotCt: plb.snapshotCt,
+	}
+	for j := range plb.sequences {
+		st.eventCts[j] = plb.sequences[j].eventCt
+	}
+
+	plb.states = append(plb.states, playbackState{})
+	copy(plb.states[i+1:], plb.states[i:])
+	plb.states[i] = st
+
 	return nil
 }
 
+// restoreState returns the emulation to a previously saved state
+func (plb *Playback) restoreState(st playbackState) error {
+	err := plb.vcs.RestoreState(st.vcs)
+	if err != nil {
+		return err
+	}
+
+	plb.digest.RestoreState(st.digest)
+	for j := range plb.sequences {
+		plb.sequences[j].eventCt = st.eventCts[j]
+	}
+	plb.snapshotCt = st.snapshotCt
+
+	return nil
+}
+
+// Skip moves the emulation to the specified frame, which can be before or
+// after the current frame.
+//
+// The complete state of the emulation is saved at the start of the playback
+// and whenever a snapshot frame is reached during a Skip(). The emulation is
+// returned to the most recent saved state before the requested frame, if that
+// is nearer than the current frame, and then run as quickly as possible until
+// the frame is reached. Snapshots are checked along the way in the normal
+// manner.
+func (plb *Playback) Skip(frame int) error {
+	if plb.vcs == nil {
+		return errors.New(errors.PlaybackError, "playback is not attached to a VCS")
+	}
+
+	if frame < 0 || frame > plb.endFrame {
+		return errors.New(errors.PlaybackError, fmt.Sprintf("cannot skip beyond the end of the playback (frame %d)", plb.endFrame))
+	}
+
+	currFrame, err := plb.vcs.TV.GetState(television.ReqFramenum)
+	if err != nil {
+		return errors.New(errors.PlaybackError, err)
+	}
+
+	// the most recent saved state before the requested frame. there is always
+	// at least one saved state (see AttachToVCS()) and it is for the start of
+	// the playback
+	st := plb.states[0]
+	for _, s := range plb.states[1:] {
+		if s.frame > frame {
+			break
+		}
+		st = s
+	}
+
+	if frame < currFrame || st.frame > currFrame {
+		err = plb.restoreState(st)
+		if err != nil {
+			return errors.New(errors.PlaybackError, err)
+		}
+		currFrame = st.frame
+	}
+
+	// a snapshot frame reached outside of Skip() was not followed by a call
+	// to saveState(). the state would be for the wrong point in the frame so
+	// it is forgotten
+	plb.statePending = false
+
+	// run without waiting for the frame limiter
+	fpsCap := plb.vcs.TV.SetFPSCap(false)
+	defer plb.vcs.TV.SetFPSCap(fpsCap)
+
+	// the continueCheck() function is called at every instruction boundary,
+	// which is where the state of the emulation can be saved
+	return plb.vcs.RunForFrameCount(frame-currFrame, func(_ int) (bool, error) {
+		if plb.statePending {
+			plb.statePending = false
+			if err := plb.saveState(); err != nil {
+				return false, errors.New(errors.PlaybackError, err)
+			}
+		}
+		return true, nil
+	})
+}
+
 // CheckInput implements the input.Playback interface.
 func (plb *Playback) CheckInput(id input.ID) (input.Event, input.EventValue, error) {
 	// there's no events for this id at all
@@ -291,7 +656,7 @@ func (plb *Playback) CheckInput(id input.ID) (input.Event, input.EventValue, err
 	// compare current state with the recording
 	entry := seq.events[seq.eventCt]
 	if frame == entry.frame && scanline == entry.scanline && horizpos == entry.horizpos {
-		if entry.hash != plb.digest.Hash() {
+		if entry.hash != "" && entry.hash != plb.digest.Hash() {
 			return input.NoEvent, nil, errors.New(errors.PlaybackHashError, fmt.Sprintf("line %d", entry.line))
 		}
 		seq.eventCt++
@@ -301,3 +666,51 @@ func (plb *Playback) CheckInput(id input.ID) (input.Event, input.EventValue, err
 	// next event does not match
 	return input.NoEvent, nil, nil
 }
+
+// Resize implements television.PixelRenderer interface
+func (plb *Playback) Resize(_, _ int) error {
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (plb *Playback) NewFrame(frameNum int) error {
+	if plb.snapshotCt >= len(plb.snapshots) {
+		return nil
+	}
+
+	snp := plb.snapshots[plb.snapshotCt]
+	if snp.frame != frameNum {
+		return nil
+	}
+	plb.snapshotCt++
+
+	if snapshot(plb.vcs, frameNum, plb.digest.Hash()) != snp.record {
+		return errors.New(errors.PlaybackHashError, fmt.Sprintf("snapshot mismatch at line %d", snp.line))
+	}
+
+	// the complete state of the emulation can not be saved in the middle of
+	// an instruction, which is where we are now
+	plb.statePending = true
+
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (plb *Playback) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (plb *Playback) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (plb *Playback) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (plb *Playback) EndRendering() error {
+	return nil
+}
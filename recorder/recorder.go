@@ -24,12 +24,18 @@ import (
 	"gopher2600/digest"
 	"gopher2600/errors"
 	"gopher2600/hardware"
+	"gopher2600/hardware/memory/memorymap"
 	"gopher2600/hardware/riot/input"
 	"gopher2600/television"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 )
 
+// the number of frames between snapshot records
+const snapshotInterval = 300
+
 // Recorder transcribes user input to a file. The transcribed file is intended
 // for future playback. The Recorder type implements the
 // riot.input.EventRecorder interface.
@@ -40,13 +46,33 @@ type Recorder struct {
 	// using video digest only to test recording validity
 	digest *digest.Video
 
+	// the random seed used to initialise the VCS and the state of the panel
+	// when the recorder was created
+	seed  int64
+	panel [3]bool
+
+	// the header is not written until the first frame. until that point lines
+	// are stored in the deferred list
 	headerWritten bool
+	deferred      []string
+
+	// the event line that is being run-length encoded and the number of times
+	// it has been seen
+	runLine  string
+	runCount int
+
+	// frame of the most recent event
+	lastFrame int
 }
 
 // NewRecorder is the preferred method of implementation for the FileRecorder
 // type. Note that attaching of the Recorder to all the ports of the VCS
 // (including the panel) is implicit in this function call.
-func NewRecorder(transcript string, vcs *hardware.VCS) (*Recorder, error) {
+//
+// The seed argument is the value used to seed the random number generator
+// before the VCS was created. It is stored in the transcript so that the
+// playback can be initialised in the same way.
+func NewRecorder(transcript string, vcs *hardware.VCS, seed int64) (*Recorder, error) {
 	var err error
 
 	// check we're working with correct information
@@ -54,7 +80,10 @@ func NewRecorder(transcript string, vcs *hardware.VCS) (*Recorder, error) {
 		return nil, errors.New(errors.RecordingError, "hardware is not suitable for recording")
 	}
 
-	rec := &Recorder{vcs: vcs}
+	rec := &Recorder{vcs: vcs, seed: seed}
+
+	// note state of panel before any events are recorded
+	rec.panel[0], rec.panel[1], rec.panel[2] = vcs.RIOT.Input.Panel.Switches()
 
 	// attach recorder to vcs peripherals, including the panel
 	vcs.HandController0.AttachEventRecorder(rec)
@@ -67,6 +96,11 @@ func NewRecorder(transcript string, vcs *hardware.VCS) (*Recorder, error) {
 		return nil, errors.New(errors.RecordingError, err)
 	}
 
+	// register ourselves as a television.PixelRenderer. the digest must be
+	// registered before the recorder so that the digest is up to date when
+	// we take snapshots.
+	vcs.TV.AddPixelRenderer(rec)
+
 	// open file
 	_, err = os.Stat(transcript)
 	if os.IsNotExist(err) {
@@ -78,10 +112,10 @@ func NewRecorder(transcript string, vcs *hardware.VCS) (*Recorder, error) {
 		return nil, errors.New(errors.RecordingError, "file already exists")
 	}
 
-	// delay writing of header until the first call to transcribe. we're
-	// delaying this because we want to prepare the NewRecorder before we
-	// attach the cartridge but writing the header requires the cartridge to
-	// have been attached.
+	// delay writing of header until the first frame. we're delaying this
+	// because we want to prepare the NewRecorder before we attach the
+	// cartridge but writing the header requires the cartridge to have been
+	// attached and for any patches to have been applied.
 	//
 	// the reason we want to create the NewRecorder before attaching the
 	// cartridge is because we want to catch the setup events caused by the
@@ -98,27 +132,29 @@ func (rec *Recorder) End() error {
 		return errors.New(errors.RecordingError, err)
 	}
 
-	err = rec.output.Close()
+	err = rec.flushRun()
 	if err != nil {
 		return errors.New(errors.RecordingError, err)
 	}
 
-	return nil
-}
-
-// RecordEvent implements the input.EventRecorder interface
-func (rec *Recorder) RecordEvent(id input.ID, event input.Event, value input.EventValue) error {
-	var err error
-
-	// write header if it's not been written already
+	// make sure header and any deferred lines have been written
 	if !rec.headerWritten {
-		err = rec.writeHeader()
+		err = rec.writeDeferred()
 		if err != nil {
 			return errors.New(errors.RecordingError, err)
 		}
-		rec.headerWritten = true
 	}
 
+	err = rec.output.Close()
+	if err != nil {
+		return errors.New(errors.RecordingError, err)
+	}
+
+	return nil
+}
+
+// RecordEvent implements the input.EventRecorder interface
+func (rec *Recorder) RecordEvent(id input.ID, event input.Event, value input.EventValue) error {
 	// don't do anything if event is the NoEvent
 	if event == input.NoEvent {
 		return nil
@@ -147,21 +183,67 @@ func (rec *Recorder) RecordEvent(id input.ID, event input.Event, value input.Eve
 		return err
 	}
 
-	// convert value of nil type to the empty string
-	if value == nil {
+	// convert value of nil type to the empty string. string values (eg. the
+	// Kid Vid tape file name) are quoted so that they can contain the field
+	// separator. see splitFields()
+	switch v := value.(type) {
+	case nil:
 		value = ""
+	case string:
+		value = strconv.Quote(v)
 	}
 
-	line := fmt.Sprintf("%v%s%v%s%v%s%v%s%v%s%v%s%v\n",
+	line := fmt.Sprintf("%v%s%v%s%v%s%v%s%v%s%v",
 		id, fieldSep,
 		event, fieldSep,
 		value, fieldSep,
-		frame, fieldSep,
+		frame-rec.lastFrame, fieldSep,
 		scanline, fieldSep,
-		horizpos, fieldSep,
-		rec.digest.Hash(),
+		horizpos,
 	)
 
+	rec.lastFrame = frame
+
+	// extend current run if the event line is the same
+	if line == rec.runLine {
+		rec.runCount++
+		return nil
+	}
+
+	err = rec.flushRun()
+	if err != nil {
+		return errors.New(errors.RecordingError, err)
+	}
+
+	rec.runLine = line
+	rec.runCount = 1
+
+	return nil
+}
+
+// flushRun writes the current run of event lines
+func (rec *Recorder) flushRun() error {
+	if rec.runCount == 0 {
+		return nil
+	}
+
+	line := fmt.Sprintf("%s%s%d%s%s", recordTypeEvent, fieldSep, rec.runCount, fieldSep, rec.runLine)
+	rec.runLine = ""
+	rec.runCount = 0
+
+	return rec.writeLine(line)
+}
+
+// writeLine writes the line to the transcript. if the header has not yet been
+// written then the line is deferred
+func (rec *Recorder) writeLine(line string) error {
+	if !rec.headerWritten {
+		rec.deferred = append(rec.deferred, line)
+		return nil
+	}
+
+	line = fmt.Sprintf("%s\n", line)
+
 	n, err := io.WriteString(rec.output, line)
 	if err != nil {
 		return errors.New(errors.RecordingError, err)
@@ -172,3 +254,90 @@ func (rec *Recorder) RecordEvent(id input.ID, event input.Event, value input.Eve
 
 	return nil
 }
+
+// writeDeferred writes the header and then any lines that have been deferred
+func (rec *Recorder) writeDeferred() error {
+	err := rec.writeHeader()
+	if err != nil {
+		return err
+	}
+	rec.headerWritten = true
+
+	for _, l := range rec.deferred {
+		err = rec.writeLine(l)
+		if err != nil {
+			return err
+		}
+	}
+	rec.deferred = nil
+
+	return nil
+}
+
+// snapshot returns the snapshot record for the current state of the VCS
+func snapshot(vcs *hardware.VCS, frame int, hash string) string {
+	cpu := fmt.Sprintf("%04x%02x%02x%02x%02x%02x",
+		vcs.CPU.PC.Address(),
+		vcs.CPU.A.Value(),
+		vcs.CPU.X.Value(),
+		vcs.CPU.Y.Value(),
+		vcs.CPU.SP.Value(),
+		vcs.CPU.Status.Value(),
+	)
+
+	ram := strings.Builder{}
+	for a := memorymap.OriginRAM; a <= memorymap.MemtopRAM; a++ {
+		v, _ := vcs.Mem.RAM.Peek(a)
+		ram.WriteString(fmt.Sprintf("%02x", v))
+	}
+
+	return fmt.Sprintf("%s%s%d%s%s%s%s%s%s", recordTypeSnapshot, fieldSep, frame, fieldSep, hash, fieldSep, cpu, fieldSep, ram.String())
+}
+
+// Resize implements television.PixelRenderer interface
+func (rec *Recorder) Resize(_, _ int) error {
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (rec *Recorder) NewFrame(frameNum int) error {
+	if !rec.headerWritten {
+		err := rec.writeDeferred()
+		if err != nil {
+			return err
+		}
+	}
+
+	if frameNum%snapshotInterval != 0 {
+		return nil
+	}
+
+	// make sure the snapshot is placed after any events that have happened
+	// before this frame
+	err := rec.flushRun()
+	if err != nil {
+		return err
+	}
+
+	return rec.writeLine(snapshot(rec.vcs, frameNum, rec.digest.Hash()))
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (rec *Recorder) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (rec *Recorder) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (rec *Recorder) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (rec *Recorder) EndRendering() error {
+	return nil
+}
@@ -0,0 +1,661 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package recorder_test
+
+import (
+	"fmt"
+	"gopher2600/cartridgeloader"
+	"gopher2600/digest"
+	"gopher2600/errors"
+	"gopher2600/hardware"
+	"gopher2600/hardware/memory/addresses"
+	"gopher2600/hardware/riot/input"
+	"gopher2600/patch"
+	"gopher2600/recorder"
+	"gopher2600/television"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// create the test.Kernel ROM in the directory. returns the filename of the ROM
+func createROM(t *testing.T, dir string) string {
+	t.Helper()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+
+	return test.WriteROM(t, dir, "kernel.bin", data)
+}
+
+func newVCS(t *testing.T) *hardware.VCS {
+	t.Helper()
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+	tv.SetFPSCap(false)
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	return vcs
+}
+
+const numFrames = 350
+
+func TestRecordAndPlayback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	// record session. the fire button is pressed at the start of every frame
+	vcs := newVCS(t)
+
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	lastFrame := 0
+	numEvents := 0
+	err = vcs.RunForFrameCount(numFrames, func(frame int) (bool, error) {
+		if frame != lastFrame {
+			lastFrame = frame
+			numEvents++
+			return true, vcs.HandController0.Handle(input.Fire, true)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	if !recorder.IsPlaybackFile(transcript) {
+		t.Fatalf("transcript is not recognised as a playback file")
+	}
+
+	// events are identical on every frame. the run-length encoding should
+	// mean the transcript is far shorter than the number of events
+	data, err := ioutil.ReadFile(transcript)
+	if err != nil {
+		t.Fatalf("cannot read transcript: %v", err)
+	}
+	if n := len(strings.Split(string(data), "\n")); n > 20 {
+		t.Errorf("transcript is too long (%d lines for %d events)", n, numEvents)
+	}
+
+	// playback session
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	if plb.Seed != 1 {
+		t.Errorf("unexpected random seed (%d)", plb.Seed)
+	}
+
+	vcs = newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	// skip part of the way into the playback and then run to the end
+	err = plb.Skip(numFrames / 2)
+	if err != nil {
+		t.Fatalf("error during skip: %v", err)
+	}
+
+	err = vcs.Run(func() (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+}
+
+func TestPaddleEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	vcs := newVCS(t)
+
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	// events for both paddles of the second port
+	events := []struct {
+		ev input.Event
+		v  input.EventValue
+	}{
+		{input.PaddleSet, float32(0.25)},
+		{input.PaddleSet, input.PaddleValue{Paddle: 1, Value: float32(0.75)}},
+		{input.PaddleFire, input.PaddleValue{Paddle: 1, Value: true}},
+		{input.PaddleFire, false},
+	}
+
+	err = vcs.RunForFrameCount(len(events)+1, func(frame int) (bool, error) {
+		if frame > 0 && frame <= len(events) {
+			e := events[frame-1]
+			events[frame-1].ev = input.NoEvent
+			return true, vcs.HandController1.Handle(e.ev, e.v)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(transcript)
+	if err != nil {
+		t.Fatalf("cannot read transcript: %v", err)
+	}
+	if !strings.Contains(string(data), "1:0.75") || !strings.Contains(string(data), "1:true") {
+		t.Errorf("paddle values have not been recorded correctly")
+	}
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs = newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	err = vcs.Run(func() (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+}
+
+// an event in a version 1 transcript
+type v1Event struct {
+	frame int
+	id    input.ID
+	ev    input.Event
+	value string
+}
+
+// writeV1Transcript writes a transcript in the version 1 format, as it would
+// have been written by earlier versions of the recorder. events are written at
+// the start of the specified frame. events for the same frame are written with
+// the same position
+func writeV1Transcript(t *testing.T, rom string, transcript string, events []v1Event, end int) {
+	t.Helper()
+
+	vcs := newVCS(t)
+
+	dig, err := digest.NewVideo(vcs.TV)
+	if err != nil {
+		t.Fatalf("cannot create digest: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	lines := []string{
+		"gopher2600playback",
+		"1.0",
+		rom,
+		vcs.Mem.Cart.Hash,
+		"NTSC",
+	}
+
+	event := func(id input.ID, ev input.Event, v string) {
+		fn, _ := vcs.TV.GetState(television.ReqFramenum)
+		sl, _ := vcs.TV.GetState(television.ReqScanline)
+		hp, _ := vcs.TV.GetState(television.ReqHorizPos)
+		lines = append(lines, fmt.Sprintf("%d, %s, %s, %d, %d, %d, %s", id, ev, v, fn, sl, hp, dig.Hash()))
+	}
+
+	lastFrame := 0
+	err = vcs.RunForFrameCount(end, func(frame int) (bool, error) {
+		if frame != lastFrame {
+			lastFrame = frame
+			for _, e := range events {
+				if e.frame == frame {
+					event(e.id, e.ev, e.value)
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	event(input.PanelID, input.PanelPowerOff, "")
+
+	err = ioutil.WriteFile(transcript, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("cannot write transcript: %v", err)
+	}
+}
+
+func TestVersion1Playback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	writeV1Transcript(t, rom, transcript, []v1Event{
+		{frame: 10, id: input.HandControllerZeroID, ev: input.Fire, value: "true"},
+		{frame: 20, id: input.HandControllerZeroID, ev: input.Fire, value: "false"},
+	}, 30)
+
+	if !recorder.IsPlaybackFile(transcript) {
+		t.Fatalf("version 1 transcript is not recognised as a playback file")
+	}
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs := newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	// the hash of every event line is checked so any difference in the
+	// emulation will result in a PlaybackHashError. the fire button event must
+	// also be seen in the INPT4 register
+	fired := false
+	err = vcs.Run(func() (bool, error) {
+		v, err := vcs.Mem.Peek(uint16(addresses.INPT4))
+		if err != nil {
+			return false, err
+		}
+		fired = fired || v&0x80 == 0x00
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+	if !fired {
+		t.Errorf("fire button event has not been played back")
+	}
+}
+
+func TestVersion1Paddles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	// in version 1 transcripts the second paddle of the left port was sent to
+	// the second hand controller. the fire events happen at the same moment
+	writeV1Transcript(t, rom, transcript, []v1Event{
+		{frame: 10, id: input.HandControllerZeroID, ev: input.PaddleFire, value: "true"},
+		{frame: 10, id: input.HandControllerOneID, ev: input.PaddleFire, value: "true"},
+		{frame: 20, id: input.HandControllerZeroID, ev: input.PaddleFire, value: "false"},
+		{frame: 20, id: input.HandControllerOneID, ev: input.PaddleFire, value: "false"},
+	}, 30)
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs := newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	// the paddle buttons of the left port are bits 7 and 6 of SWCHA. both
+	// buttons must be seen to be pressed at the same time
+	fired := false
+	err = vcs.Run(func() (bool, error) {
+		v, err := vcs.Mem.Peek(0x0280)
+		if err != nil {
+			return false, err
+		}
+		fired = fired || v&0xc0 == 0x00
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+	if !fired {
+		t.Errorf("paddle fire events have not been played back on the left port")
+	}
+
+	if vcs.HandController1.(*input.HandController).ControllerType() == input.PaddleType {
+		t.Errorf("second hand controller should not be a paddle")
+	}
+}
+
+func TestPatchesWithSeparator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	// the name of the patch file contains the field separator and a quote
+	// character
+	patchFile := filepath.Join(dir, "patch, \"one\".txt")
+	err = ioutil.WriteFile(patchFile, []byte("0800: ea\n"), 0600)
+	if err != nil {
+		t.Fatalf("cannot create patch file: %v", err)
+	}
+
+	vcs := newVCS(t)
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	_, err = patch.CartridgeMemory(vcs.Mem.Cart, patchFile)
+	if err != nil {
+		t.Fatalf("cannot apply patch: %v", err)
+	}
+
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.RunForFrameCount(2, nil)
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs = newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	if len(vcs.Mem.Cart.Patches) != 1 || vcs.Mem.Cart.Patches[0] != patchFile {
+		t.Errorf("patch list has not been recovered correctly: %q", vcs.Mem.Cart.Patches)
+	}
+}
+
+func TestStringValueWithSeparator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := createROM(t, dir)
+	transcript := filepath.Join(dir, "transcript")
+
+	// a Kid Vid tape with one track of two 8 bit samples. the name of the tape
+	// contains the field separator and a quote character
+	tape := filepath.Join(dir, "tape, side \"A\"")
+	err = ioutil.WriteFile(tape+"_1.wav", []byte("RIFF\x26\x00\x00\x00WAVE"+
+		"fmt \x10\x00\x00\x00\x01\x00\x01\x00\xe8\x03\x00\x00\xe8\x03\x00\x00\x01\x00\x08\x00"+
+		"data\x02\x00\x00\x00\x80\x80"), 0600)
+	if err != nil {
+		t.Fatalf("cannot create tape file: %v", err)
+	}
+
+	vcs := newVCS(t)
+
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	err = vcs.RunForFrameCount(3, func(frame int) (bool, error) {
+		if frame == 1 && vcs.RIOT.Input.HandController0.ControllerType() != input.KidVidType {
+			return true, vcs.HandController0.Handle(input.SetControllerType, "KidVid")
+		}
+		if frame == 2 && vcs.RIOT.Input.HandController0.KidVid().Filename() == "" {
+			return true, vcs.HandController0.Handle(input.KidVidTape, tape)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs = newVCS(t)
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	err = vcs.Run(func() (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+
+	kv := vcs.RIOT.Input.HandController0.KidVid()
+	if kv == nil || kv.Filename() != tape {
+		t.Errorf("tape file name has not been played back correctly")
+	}
+}
+
+// the state of the VCS, as far as the test ROM in TestSkip() is concerned
+func fingerprint(vcs *hardware.VCS) string {
+	f, _ := vcs.TV.GetState(television.ReqFramenum)
+	s, _ := vcs.TV.GetState(television.ReqScanline)
+	h, _ := vcs.TV.GetState(television.ReqHorizPos)
+	return fmt.Sprintf("%d %d %d %s %s %s", f, s, h, vcs.CPU, vcs.Mem.RAM, vcs.TIA)
+}
+
+func TestSkip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the kernel with the state of the fire button copied to RAM every frame.
+	// the JMP at the end of the kernel is moved to make room
+	data := test.NewROM(4096)
+	n := copy(data, test.Kernel[:len(test.Kernel)-3])
+	copy(data[n:], []byte{
+		0xa5, 0x0c, // LDA INPT4
+		0x85, 0x81, // STA $81
+		0x4c, 0x00, 0xf0, // JMP $F000
+	})
+	rom := test.WriteROM(t, dir, "skip.bin", data)
+	transcript := filepath.Join(dir, "transcript")
+
+	// record session. the fire button changes every frame so that the state
+	// at any frame depends on the playback of the events
+	const skipFrames = 620
+
+	vcs := newVCS(t)
+
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	lastFrame := 0
+	err = vcs.RunForFrameCount(skipFrames, func(frame int) (bool, error) {
+		if frame != lastFrame {
+			lastFrame = frame
+			return true, vcs.HandController0.Handle(input.Fire, frame%2 == 0)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	// the frames to skip to. the first skip passes the snapshot frames at 300
+	// and 600. the later skips restore the state saved at those frames, or at
+	// the start of the playback, before running to the requested frame
+	frames := []int{610, 100, 605, 320, 5}
+
+	// the state of the VCS at each frame when reached by skipping forward
+	// only
+	reference := make(map[int]string)
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs = newVCS(t)
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	for _, frame := range []int{5, 100, 320, 605, 610} {
+		err = plb.Skip(frame)
+		if err != nil {
+			t.Fatalf("error during skip to frame %d: %v", frame, err)
+		}
+		reference[frame] = fingerprint(vcs)
+	}
+
+	// a new playback for the skips in the order given above
+	plb, err = recorder.NewPlayback(transcript)
+	if err != nil {
+		t.Fatalf("cannot create playback: %v", err)
+	}
+
+	vcs = newVCS(t)
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		t.Fatalf("cannot attach playback: %v", err)
+	}
+
+	for _, frame := range frames {
+		err = plb.Skip(frame)
+		if err != nil {
+			t.Fatalf("error during skip to frame %d: %v", frame, err)
+		}
+
+		if f := fingerprint(vcs); f != reference[frame] {
+			t.Errorf("unexpected state after skip to frame %d: %s", frame, f)
+		}
+	}
+
+	// the playback continues normally after a skip
+	err = vcs.Run(func() (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, errors.PowerOff) {
+		t.Errorf("playback did not end as expected: %v", err)
+	}
+}
@@ -27,9 +27,11 @@ import (
 	"gopher2600/digest"
 	"gopher2600/errors"
 	"gopher2600/hardware"
+	"gopher2600/screenshot"
 	"gopher2600/setup"
 	"gopher2600/television"
 	"io"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -184,13 +186,18 @@ func (reg *DigestRegression) regress(newRegression bool, output io.Writer, msg s
 		return false, "", errors.New(errors.RegressionDigestError, fmt.Sprintf("undefined digest mode"))
 	}
 
-	// create VCS and attach cartridge
-	vcs, err := hardware.NewVCS(tv)
+	// keep a copy of the most recent frame. if the regression fails we'll
+	// save it so that the failure can be inspected
+	scr := screenshot.NewScreenshot(tv)
+
+	// create VCS and attach cartridge. tests must be determinate so the random
+	// number generator is seeded with a value we know
+	vcs, err := hardware.NewVCS(tv, rand.New(rand.NewSource(1)))
 	if err != nil {
 		return false, "", errors.New(errors.RegressionDigestError, err)
 	}
 
-	err = setup.AttachCartridge(vcs, reg.CartLoad)
+	err = setup.AttachCartridge(vcs, reg.CartLoad, nil)
 	if err != nil {
 		return false, "", errors.New(errors.RegressionDigestError, err)
 	}
@@ -310,7 +317,18 @@ func (reg *DigestRegression) regress(newRegression bool, output io.Writer, msg s
 	}
 
 	if dig.Hash() != reg.digest {
-		return false, "digest mismatch", nil
+		failm := "digest mismatch"
+
+		// save screenshot of the failed regression
+		fn, err := uniqueFilename("failed", reg.CartLoad)
+		if err == nil {
+			fn = fmt.Sprintf("%s.png", fn)
+			if scr.Save(fn, screenshot.ModeFull) == nil {
+				failm = fmt.Sprintf("%s (screenshot saved to %s)", failm, fn)
+			}
+		}
+
+		return false, failm, nil
 	}
 
 	return true, "", nil
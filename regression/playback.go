@@ -28,6 +28,7 @@ import (
 	"gopher2600/recorder"
 	"gopher2600/television"
 	"io"
+	"math/rand"
 	"os"
 	"path"
 	"strings"
@@ -122,7 +123,7 @@ func (reg *PlaybackRegression) regress(newRegression bool, output io.Writer, msg
 		return false, "", errors.New(errors.RegressionPlaybackError, err)
 	}
 
-	vcs, err := hardware.NewVCS(tv)
+	vcs, err := hardware.NewVCS(tv, rand.New(rand.NewSource(plb.Seed)))
 	if err != nil {
 		return false, "", errors.New(errors.RegressionPlaybackError, err)
 	}
@@ -132,14 +133,6 @@ func (reg *PlaybackRegression) regress(newRegression bool, output io.Writer, msg
 		return false, "", errors.New(errors.RegressionPlaybackError, err)
 	}
 
-	// not using setup.AttachCartridge. if the playback was recorded with setup
-	// changes the events will have been copied into the playback script and
-	// will be applied that way
-	err = vcs.AttachCartridge(plb.CartLoad)
-	if err != nil {
-		return false, "", errors.New(errors.RegressionPlaybackError, err)
-	}
-
 	// prepare ticker for progress meter
 	dur, _ := time.ParseDuration("1s")
 	tck := time.NewTicker(dur)
@@ -0,0 +1,66 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package render
+
+import (
+	"gopher2600/television"
+)
+
+// audio is an implementation of the television.AudioMixer interface. It
+// forwards audio data to another mixer only for the frames that are being
+// written, so that the audio and video outputs stay in sync.
+type audio struct {
+	fr    *frames
+	mixer television.AudioMixer
+}
+
+// SetAudio implements the television.AudioMixer interface
+func (aud *audio) SetAudio(audioData uint8) error {
+	if !aud.fr.inRange() {
+		return nil
+	}
+	return aud.mixer.SetAudio(audioData)
+}
+
+// FlushAudio implements the television.AudioMixer interface
+func (aud *audio) FlushAudio() error {
+	return aud.mixer.FlushAudio()
+}
+
+// PauseAudio implements the television.AudioMixer interface
+func (aud *audio) PauseAudio(pause bool) error {
+	return aud.mixer.PauseAudio(pause)
+}
+
+// SetStereoAudio implements the television.StereoMixer interface
+func (aud *audio) SetStereoAudio(channel0 uint8, channel1 uint8, external float32) error {
+	if !aud.fr.inRange() {
+		return nil
+	}
+	if m, ok := aud.mixer.(television.StereoMixer); ok {
+		return m.SetStereoAudio(channel0, channel1, external)
+	}
+	return aud.mixer.SetAudio(channel0 + channel1)
+}
+
+// EndMixing implements the television.AudioMixer interface
+func (aud *audio) EndMixing() error {
+	return aud.mixer.EndMixing()
+}
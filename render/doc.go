@@ -0,0 +1,44 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package render replays a recorder transcript as quickly as possible and
+// writes the television output to disk. It is intended for the creation of
+// gameplay videos and for attaching evidence to bug reports, without the need
+// for screen capture software.
+//
+// Video can be written in one of three formats:
+//
+//	PNG: one image file per frame, numbered by frame
+//	Y4M: a single YUV4MPEG2 stream (4:4:4 colour, no subsampling)
+//	RAW: a single stream of RGB24 frames with no header
+//
+// The Y4M and RAW formats are suitable for piping into video encoders. For
+// example:
+//
+//	ffmpeg -i output.y4m -i output.wav -c:v libx264 -pix_fmt yuv420p out.mp4
+//
+// Audio is written as a WAV file using the wavwriter package. Only the audio
+// generated during the requested range of frames is written.
+//
+// Frames are cropped to the visible area of the screen. The crop is decided
+// when the first frame is written and does not change for the remainder of
+// the render, meaning that every frame has the same dimensions. Horizontal
+// scaling can optionally take into account the AspectBias of the television
+// specification, as the GUI implementations do.
+package render
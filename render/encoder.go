@@ -0,0 +1,211 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"gopher2600/errors"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// encoder is the interface to the different output formats
+type encoder interface {
+	// the frame rate is the same for every call to encode()
+	encode(frameNum int, img *image.RGBA, fps float32) error
+	end() error
+}
+
+// list of supported video formats
+const (
+	FormatPNG = "PNG"
+	FormatY4M = "Y4M"
+	FormatRAW = "RAW"
+)
+
+func newEncoder(format string, filename string) (encoder, error) {
+	switch strings.ToUpper(format) {
+	case FormatPNG:
+		return &pngEncoder{base: strings.TrimSuffix(filename, filepath.Ext(filename))}, nil
+	case FormatY4M:
+		return newStreamEncoder(filename, true)
+	case FormatRAW:
+		return newStreamEncoder(filename, false)
+	}
+	return nil, errors.New(errors.RenderError, fmt.Sprintf("unsupported video format (%s)", format))
+}
+
+// pngEncoder writes each frame to a separate file. the frame number is
+// appended to the base filename
+type pngEncoder struct {
+	base string
+}
+
+func (enc *pngEncoder) encode(frameNum int, img *image.RGBA, _ float32) error {
+	f, err := os.Create(fmt.Sprintf("%s_%06d.png", enc.base, frameNum))
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+	defer f.Close()
+
+	err = png.Encode(f, img)
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	return nil
+}
+
+func (enc *pngEncoder) end() error {
+	return nil
+}
+
+// streamEncoder writes every frame to a single file, either as a YUV4MPEG2
+// stream or as raw RGB24 data
+type streamEncoder struct {
+	f   *os.File
+	w   *bufio.Writer
+	y4m bool
+
+	// the Y4M header is written when the first frame is received because that
+	// is when we know the size of the image and the frame rate
+	headerWritten bool
+
+	// reusable buffer for a single plane/frame of data
+	buf []byte
+}
+
+func newStreamEncoder(filename string, y4m bool) (*streamEncoder, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, errors.New(errors.RenderError, err)
+	}
+
+	return &streamEncoder{
+		f:   f,
+		w:   bufio.NewWriter(f),
+		y4m: y4m,
+	}, nil
+}
+
+func (enc *streamEncoder) encode(_ int, img *image.RGBA, fps float32) error {
+	var err error
+
+	if enc.y4m {
+		err = enc.encodeY4M(img, fps)
+	} else {
+		err = enc.encodeRaw(img)
+	}
+
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	return nil
+}
+
+func (enc *streamEncoder) encodeRaw(img *image.RGBA) error {
+	sz := img.Bounds().Size()
+
+	if enc.buf == nil {
+		enc.buf = make([]byte, sz.X*sz.Y*3)
+	}
+
+	i := 0
+	for p := 0; p < len(img.Pix); p += 4 {
+		enc.buf[i] = img.Pix[p]
+		enc.buf[i+1] = img.Pix[p+1]
+		enc.buf[i+2] = img.Pix[p+2]
+		i += 3
+	}
+
+	_, err := enc.w.Write(enc.buf)
+	return err
+}
+
+func (enc *streamEncoder) encodeY4M(img *image.RGBA, fps float32) error {
+	sz := img.Bounds().Size()
+
+	if !enc.headerWritten {
+		// C444 means there is no chroma subsampling. Ip means progressive
+		// (non-interlaced) frames and A1:1 means square pixels. scaling has
+		// already been applied so the pixels really are square. the frame
+		// rate is given as a ratio
+		_, err := fmt.Fprintf(enc.w, "YUV4MPEG2 W%d H%d F%d:1000 Ip A1:1 C444\n", sz.X, sz.Y, int(math.Round(float64(fps)*1000)))
+		if err != nil {
+			return err
+		}
+		enc.headerWritten = true
+		enc.buf = make([]byte, sz.X*sz.Y*3)
+	}
+
+	// convert to three separate planes of Y, Cb and Cr
+	plane := sz.X * sz.Y
+	i := 0
+	for p := 0; p < len(img.Pix); p += 4 {
+		y, cb, cr := rgbToYCbCr(img.Pix[p], img.Pix[p+1], img.Pix[p+2])
+		enc.buf[i] = y
+		enc.buf[i+plane] = cb
+		enc.buf[i+plane*2] = cr
+		i++
+	}
+
+	_, err := enc.w.Write([]byte("FRAME\n"))
+	if err != nil {
+		return err
+	}
+
+	_, err = enc.w.Write(enc.buf)
+	return err
+}
+
+func (enc *streamEncoder) end() error {
+	err := enc.w.Flush()
+	if err != nil {
+		enc.f.Close()
+		return errors.New(errors.RenderError, err)
+	}
+
+	err = enc.f.Close()
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	return nil
+}
+
+// rgbToYCbCr converts using the limited range BT.601 coefficients, which is
+// what video encoders expect from Y4M input unless told otherwise
+func rgbToYCbCr(r, g, b uint8) (uint8, uint8, uint8) {
+	rf := float64(r)
+	gf := float64(g)
+	bf := float64(b)
+
+	y := 16 + (65.481*rf+128.553*gf+24.966*bf)/255
+	cb := 128 + (-37.797*rf-74.203*gf+112.0*bf)/255
+	cr := 128 + (112.0*rf-93.786*gf-18.214*bf)/255
+
+	return uint8(math.Round(y)), uint8(math.Round(cb)), uint8(math.Round(cr))
+}
@@ -0,0 +1,142 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package render
+
+import (
+	"gopher2600/screenshot"
+	"gopher2600/television"
+	"image"
+	"image/draw"
+)
+
+// frames passes completed frames that are inside the requested range to the
+// encoder. the frames are captured with screenshot.Screenshot.
+type frames struct {
+	tv  television.Television
+	scr *screenshot.Screenshot
+	enc encoder
+
+	// how the captured frame is cropped and scaled
+	mode  screenshot.Mode
+	scale float64
+
+	// the range of frames to write. an end value of less than zero means
+	// there is no end frame
+	start int
+	end   int
+
+	// number of the frame currently being drawn
+	frameNum int
+
+	// the image passed to the encoder. the size is decided when the first
+	// frame is written and never changes
+	scaled *image.RGBA
+
+	// the frame rate of the video. like the size of the image, this is
+	// decided when the first frame is written. a television in AUTO mode
+	// changes specification during the first few frames of the playback so
+	// the rate can not be decided when the encoder is created
+	fps float32
+
+	// number of frames passed to the encoder
+	written int
+}
+
+func newFrames(tv television.Television, enc encoder, opts Options) *frames {
+	fr := &frames{
+		tv:    tv,
+		scr:   screenshot.NewScreenshot(tv),
+		enc:   enc,
+		mode:  screenshot.ModeVisible,
+		scale: opts.Scale,
+		start: opts.Start,
+		end:   opts.End,
+	}
+
+	// a real television shows video black during vblank
+	fr.scr.SetVBlankBlack(true)
+
+	if opts.Aspect {
+		fr.mode = screenshot.ModeAspect
+	}
+
+	return fr
+}
+
+// inRange returns true if the frame currently being drawn is to be written
+func (fr *frames) inRange() bool {
+	return fr.frameNum >= fr.start && (fr.end < 0 || fr.frameNum <= fr.end)
+}
+
+// finished returns true if the frame currently being drawn is beyond the
+// requested range of frames
+func (fr *frames) finished() bool {
+	return fr.end >= 0 && fr.frameNum > fr.end
+}
+
+// update should be called frequently with the current frame number of the
+// television. when the frame number changes, the frame that has just
+// completed is written to the encoder if it is in range.
+func (fr *frames) update(frameNum int) error {
+	if frameNum == fr.frameNum {
+		return nil
+	}
+
+	var err error
+
+	if fr.inRange() {
+		err = fr.write()
+	}
+
+	fr.frameNum = frameNum
+
+	return err
+}
+
+// flush writes the frame currently being drawn, if it is in range. it should
+// be called when the emulation stops part way through a frame, which is the
+// case at the end of every playback
+func (fr *frames) flush() error {
+	if fr.inRange() {
+		return fr.write()
+	}
+	return nil
+}
+
+// write the most recently completed frame to the encoder
+func (fr *frames) write() error {
+	img := fr.scr.Scaled(fr.mode, fr.scale)
+
+	if fr.scaled == nil {
+		fr.scaled = img
+		fr.fps = fr.tv.GetSpec().FramesPerSecond
+	} else {
+		// the visible area of the screen may have changed since the first
+		// frame. clear any part of the image not covered by the new frame
+		if img.Bounds() != fr.scaled.Bounds() {
+			draw.Draw(fr.scaled, fr.scaled.Bounds(), image.Black, image.Point{}, draw.Src)
+		}
+		draw.Draw(fr.scaled, fr.scaled.Bounds(), img, image.Point{}, draw.Src)
+	}
+
+	fr.written++
+
+	return fr.enc.encode(fr.frameNum, fr.scaled, fr.fps)
+}
@@ -0,0 +1,207 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package render
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/hardware"
+	"gopher2600/recorder"
+	"gopher2600/resampler"
+	"gopher2600/television"
+	"gopher2600/wavwriter"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Options for the render
+type Options struct {
+	// video format. one of the Format* values
+	Format string
+
+	// filename for the video. for the PNG format this is used as the base
+	// of the filename for each frame
+	Video string
+
+	// filename for the audio. no audio will be written if this is empty
+	Audio string
+
+	// sample rate of the audio file. if the rate is zero then the audio will
+	// be written at the TIA's native sample rate, without resampling. the
+	// Stereo and LowPass options only apply to resampled audio
+	AudioRate int
+	Stereo    bool
+	LowPass   bool
+
+	// first and last frames to write. an End value of less than zero means
+	// that the playback will be rendered until it ends
+	Start int
+	End   int
+
+	// scaling value applied to the visible screen. when Aspect is true the
+	// horizontal scaling also takes into account the width of VCS pixels and
+	// the AspectBias of the television specification
+	Scale  float64
+	Aspect bool
+}
+
+// Run the playback in the transcript file and render it using the supplied
+// options. Progress is written to output.
+func Run(output io.Writer, transcript string, opts Options) error {
+	if opts.Scale <= 0 {
+		return errors.New(errors.RenderError, "scale must be greater than zero")
+	}
+
+	if opts.End >= 0 && opts.End < opts.Start {
+		return errors.New(errors.RenderError, "end frame is before start frame")
+	}
+
+	plb, err := recorder.NewPlayback(transcript)
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	tv, err := television.NewTelevision(plb.TVSpec)
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	// run as quickly as possible
+	tv.SetFPSCap(false)
+
+	enc, err := newEncoder(opts.Format, opts.Video)
+	if err != nil {
+		return err
+	}
+
+	fr := newFrames(tv, enc, opts)
+
+	if opts.Audio != "" {
+		var ww *wavwriter.WavWriter
+		if opts.AudioRate == 0 {
+			ww, err = wavwriter.New(opts.Audio)
+		} else {
+			ww, err = wavwriter.NewResampled(opts.Audio, resampler.Options{
+				Rate:    opts.AudioRate,
+				Stereo:  opts.Stereo,
+				LowPass: opts.LowPass,
+			})
+		}
+		if err != nil {
+			_ = enc.end()
+			return errors.New(errors.RenderError, err)
+		}
+		tv.AddAudioMixer(&audio{fr: fr, mixer: ww})
+	}
+
+	vcs, err := hardware.NewVCS(tv, rand.New(rand.NewSource(plb.Seed)))
+	if err != nil {
+		_ = enc.end()
+		_ = tv.End()
+		return errors.New(errors.RenderError, err)
+	}
+
+	err = plb.AttachToVCS(vcs)
+	if err != nil {
+		_ = enc.end()
+		_ = tv.End()
+		return errors.New(errors.RenderError, err)
+	}
+
+	// prepare ticker for progress meter
+	dur, _ := time.ParseDuration("1s")
+	tck := time.NewTicker(dur)
+	defer tck.Stop()
+
+	err = vcs.Run(func() (bool, error) {
+		fn, err := tv.GetState(television.ReqFramenum)
+		if err != nil {
+			return false, err
+		}
+
+		err = fr.update(fn)
+		if err != nil {
+			return false, err
+		}
+
+		if fr.finished() {
+			return false, nil
+		}
+
+		hasEnded, err := plb.EndFrame()
+		if err != nil {
+			return false, err
+		}
+		if hasEnded {
+			// the frame in progress has only just started. the last frame of
+			// the playback was written by the call to update() above
+			return false, nil
+		}
+
+		// display progress meter every 1 second
+		select {
+		case <-tck.C:
+			output.Write([]byte(fmt.Sprintf("\rrendering: %s", plb)))
+		default:
+		}
+
+		return true, nil
+	})
+
+	// the PowerOff error is expected at the end of the playback
+	if err != nil {
+		if !errors.Is(err, errors.PowerOff) {
+			_ = enc.end()
+			_ = tv.End()
+			return errors.New(errors.RenderError, err)
+		}
+
+		// the playback has ended part way through the frame
+		err = fr.flush()
+		if err != nil {
+			_ = enc.end()
+			_ = tv.End()
+			return errors.New(errors.RenderError, err)
+		}
+	}
+
+	// finalise the video file. television End() will finalise the audio file
+	err = enc.end()
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	err = tv.End()
+	if err != nil {
+		return errors.New(errors.RenderError, err)
+	}
+
+	if fr.written == 0 {
+		output.Write([]byte("\rrendering: no frames in range\n"))
+		return nil
+	}
+
+	sz := fr.scaled.Bounds().Size()
+	output.Write([]byte(fmt.Sprintf("\rrendering: %d frames (%dx%d) at %.2f fps\n",
+		fr.written, sz.X, sz.Y, fr.fps)))
+
+	return nil
+}
@@ -0,0 +1,264 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"gopher2600/cartridgeloader"
+	"gopher2600/hardware"
+	"gopher2600/recorder"
+	"gopher2600/render"
+	"gopher2600/television"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// create a ROM and a short recording of it. returns the transcript filename
+func createTranscript(t *testing.T, dir string) string {
+	t.Helper()
+	return createTranscriptSpec(t, dir, "NTSC", test.Kernel)
+}
+
+// create a ROM with the kernel and a short recording of it with the
+// specified television. returns the transcript filename
+func createTranscriptSpec(t *testing.T, dir string, spec string, kernel []byte) string {
+	t.Helper()
+
+	data := test.NewROM(4096)
+	copy(data, kernel)
+	rom := test.WriteROM(t, dir, "kernel.bin", data)
+
+	tv, err := television.NewTelevision(spec)
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+	tv.SetFPSCap(false)
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	transcript := filepath.Join(dir, "transcript")
+	rec, err := recorder.NewRecorder(transcript, vcs, 1)
+	if err != nil {
+		t.Fatalf("cannot create recorder: %v", err)
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: rom})
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	err = vcs.RunForFrameCount(30, nil)
+	if err != nil {
+		t.Fatalf("error during recording: %v", err)
+	}
+
+	// end the recording part way through the last frame
+	for sl := 0; sl < 100; {
+		err = vcs.Step(nil)
+		if err != nil {
+			t.Fatalf("error during recording: %v", err)
+		}
+		sl, err = tv.GetState(television.ReqScanline)
+		if err != nil {
+			t.Fatalf("error during recording: %v", err)
+		}
+	}
+
+	err = rec.End()
+	if err != nil {
+		t.Fatalf("cannot end recording: %v", err)
+	}
+
+	return transcript
+}
+
+func TestRenderY4M(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transcript := createTranscript(t, dir)
+
+	opts := render.Options{
+		Format: render.FormatY4M,
+		Video:  filepath.Join(dir, "out.y4m"),
+		Audio:  filepath.Join(dir, "out.wav"),
+		Start:  5,
+		End:    9,
+		Scale:  2,
+		Aspect: false,
+	}
+
+	output := &bytes.Buffer{}
+	err = render.Run(output, transcript, opts)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(opts.Video)
+	if err != nil {
+		t.Fatalf("cannot read video: %v", err)
+	}
+
+	header := fmt.Sprintf("YUV4MPEG2 W%d H", television.HorizClksVisible*2)
+	if !bytes.HasPrefix(data, []byte(header)) {
+		t.Errorf("unexpected Y4M header: %q", data[:bytes.IndexByte(data, '\n')])
+	}
+
+	if n := bytes.Count(data, []byte("FRAME\n")); n != 5 {
+		t.Errorf("unexpected number of frames in video (%d)", n)
+	}
+
+	if _, err := os.Stat(opts.Audio); err != nil {
+		t.Errorf("audio file not written: %v", err)
+	}
+}
+
+// kernel producing a frame of more than 300 scanlines
+var kernel50Hz = []byte{
+	0xa9, 0x02, // LDA #$02
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0xa9, 0x00, // LDA #$00
+	0x85, 0x00, // STA VSYNC
+	0xa2, 0xff, // LDX #$FF
+	0x85, 0x02, // STA WSYNC
+	0xca,       // DEX
+	0xd0, 0xfb, // BNE -5
+	0xa2, 0x32, // LDX #$32
+	0x85, 0x02, // STA WSYNC
+	0xca,       // DEX
+	0xd0, 0xfb, // BNE -5
+	0x4c, 0x00, 0xf0, // JMP $F000
+}
+
+// the frame rate of a recording made with the AUTO specification is decided
+// after the television has changed to PAL
+func TestRenderAuto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transcript := createTranscriptSpec(t, dir, "AUTO", kernel50Hz)
+
+	opts := render.Options{
+		Format: render.FormatY4M,
+		Video:  filepath.Join(dir, "out.y4m"),
+		Start:  5,
+		End:    9,
+		Scale:  1,
+	}
+
+	output := &bytes.Buffer{}
+	err = render.Run(output, transcript, opts)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(opts.Video)
+	if err != nil {
+		t.Fatalf("cannot read video: %v", err)
+	}
+
+	header := string(data[:bytes.IndexByte(data, '\n')])
+	if !strings.Contains(header, " F50000:1000 ") {
+		t.Errorf("unexpected frame rate in Y4M header: %q", header)
+	}
+	if !strings.Contains(output.String(), "at 50.00 fps") {
+		t.Errorf("unexpected frame rate in summary: %q", output.String())
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transcript := createTranscript(t, dir)
+
+	opts := render.Options{
+		Format: render.FormatPNG,
+		Video:  filepath.Join(dir, "frames"),
+		Start:  10,
+		End:    12,
+		Scale:  1,
+		Aspect: true,
+	}
+
+	err = render.Run(&bytes.Buffer{}, transcript, opts)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for fn := opts.Start; fn <= opts.End; fn++ {
+		if _, err := os.Stat(fmt.Sprintf("%s_%06d.png", opts.Video, fn)); err != nil {
+			t.Errorf("frame %d not written: %v", fn, err)
+		}
+	}
+}
+
+func TestRenderLastFrame(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transcript := createTranscript(t, dir)
+
+	opts := render.Options{
+		Format: render.FormatPNG,
+		Video:  filepath.Join(dir, "frames"),
+		Start:  28,
+		End:    -1,
+		Scale:  1,
+	}
+
+	err = render.Run(&bytes.Buffer{}, transcript, opts)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	// the recording ends part way through frame 30
+	for fn := opts.Start; fn <= 30; fn++ {
+		if _, err := os.Stat(fmt.Sprintf("%s_%06d.png", opts.Video, fn)); err != nil {
+			t.Errorf("frame %d not written: %v", fn, err)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s_%06d.png", opts.Video, 31)); err == nil {
+		t.Errorf("frame 31 written but is not part of the playback")
+	}
+}
@@ -0,0 +1,41 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package resampler converts the audio produced by the TIA to a sample rate
+// suitable for modern audio hardware and file formats, usually 44.1kHz or
+// 48kHz.
+//
+// The TIA produces a new audio sample at 31403Hz (see the SampleFreq value in
+// the hardware/tia/audio package). Resampling is performed by band-limited
+// interpolation using a windowed-sinc filter, which avoids the aliasing that
+// comes with naive methods like sample-and-hold or linear interpolation.
+//
+// Before resampling, the two TIA channels are either mixed into a single mono
+// signal or, in stereo mode, channel 0 is panned to the left and channel 1 is
+// panned to the right.
+//
+// After resampling, the signal passes through a DC blocking filter. This is
+// similar in effect to the coupling capacitor in the console's output stage
+// and means that a constant TIA output results in silence. This is important
+// because many ROMs do not leave the volume registers at zero when there is
+// nothing to play. An optional low-pass filter can also be applied, which
+// softens the harsh edges of the TIA's square waves in a similar manner to
+// the console's output stage. The cutoff frequency of the low-pass filter is
+// an approximation and is not based on measurement of real hardware.
+package resampler
@@ -0,0 +1,262 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package resampler
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	tiaAudio "gopher2600/hardware/tia/audio"
+	"math"
+)
+
+// Commonly used output sample rates
+const (
+	Rate44100 = 44100
+	Rate48000 = 48000
+)
+
+// Options for the resampler
+type Options struct {
+	// the output sample rate
+	Rate int
+
+	// pan channel 0 to the left and channel 1 to the right
+	Stereo bool
+
+	// apply the low-pass filter
+	LowPass bool
+}
+
+// the number of taps in the interpolation filter. half the taps are before the
+// interpolation point and half are after. more taps gives a sharper cutoff at
+// the cost of performance and a longer delay
+const halfTaps = 16
+const numTaps = halfTaps * 2
+
+// the number of phases in the precalculated filter table. the interpolation
+// point is rounded to the nearest phase
+const numPhases = 512
+
+// the cutoff of the interpolation filter as a fraction of the lower nyquist
+// frequency. slightly less than one to allow for the transition band of the
+// filter
+const cutoffRatio = 0.95
+
+// cutoff frequency of the optional low-pass filter
+const lowPassCutoff = 8000.0
+
+// cutoff frequency of the DC blocking filter
+const dcBlockCutoff = 20.0
+
+// the level of the external sound relative to a full scale TIA channel. the
+// external sound swings either side of zero so the peak to peak level is the
+// same as a TIA channel
+const externalLevel = 0.5
+
+// amplitude of a full scale signal. some headroom is left to allow for
+// overshoot in the interpolation filter
+const amplitude = 0.75 * math.MaxInt16
+
+// Resampler converts TIA audio to the requested sample rate
+type Resampler struct {
+	opts Options
+
+	// number of input samples for every output sample
+	step float64
+
+	// position of the next output sample between the two samples at the
+	// centre of the history
+	phase float64
+
+	// precalculated filter coefficients for each phase
+	table [numPhases + 1][numTaps]float64
+
+	// history of input samples for the left and right channels. the history
+	// is stored twice so that the most recent numTaps samples are always
+	// contiguous
+	history [2][numTaps * 2]float64
+	idx     int
+
+	// state of the low-pass and dc blocking filters for each channel
+	lowPassCoeff float64
+	lowPass      [2]float64
+	dcCoeff      float64
+	dcIn         [2]float64
+	dcOut        [2]float64
+}
+
+// NewResampler is the preferred method of initialisation for the Resampler
+// type
+func NewResampler(opts Options) (*Resampler, error) {
+	if opts.Rate <= 0 {
+		return nil, errors.New(errors.ResamplerError, fmt.Sprintf("invalid sample rate (%d)", opts.Rate))
+	}
+
+	rs := &Resampler{
+		opts: opts,
+		step: float64(tiaAudio.SampleFreq) / float64(opts.Rate),
+	}
+
+	// the cutoff of the interpolation filter relative to the input sample
+	// rate. when downsampling the cutoff must be lowered to the nyquist
+	// frequency of the output sample rate
+	fc := cutoffRatio
+	if opts.Rate < tiaAudio.SampleFreq {
+		fc *= float64(opts.Rate) / float64(tiaAudio.SampleFreq)
+	}
+
+	for p := 0; p <= numPhases; p++ {
+		phase := float64(p) / numPhases
+
+		sum := 0.0
+		for j := 0; j < numTaps; j++ {
+			// distance from the interpolation point to the sample in
+			// the history
+			d := float64(halfTaps-1) + phase - float64(j)
+			rs.table[p][j] = fc * sinc(fc*d) * blackman(d/halfTaps)
+			sum += rs.table[p][j]
+		}
+
+		// normalise so that a constant signal is unchanged
+		for j := 0; j < numTaps; j++ {
+			rs.table[p][j] /= sum
+		}
+	}
+
+	rs.lowPassCoeff = 1.0 - math.Exp(-2.0*math.Pi*lowPassCutoff/float64(opts.Rate))
+	rs.dcCoeff = math.Exp(-2.0 * math.Pi * dcBlockCutoff / float64(opts.Rate))
+
+	return rs, nil
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1.0
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// blackman window for values of x between -1 and 1
+func blackman(x float64) float64 {
+	if x <= -1.0 || x >= 1.0 {
+		return 0.0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2.0*math.Pi*x)
+}
+
+// Rate returns the output sample rate
+func (rs *Resampler) Rate() int {
+	return rs.opts.Rate
+}
+
+// IsStereo returns true if the resampler is in stereo mode
+func (rs *Resampler) IsStereo() bool {
+	return rs.opts.Stereo
+}
+
+// SetStereo turns stereo mode on or off
+func (rs *Resampler) SetStereo(stereo bool) {
+	rs.opts.Stereo = stereo
+}
+
+// SetLowPass turns the low-pass filter on or off
+func (rs *Resampler) SetLowPass(lowPass bool) {
+	rs.opts.LowPass = lowPass
+}
+
+// Process the volume values of the two TIA channels and the sample of any
+// external sound source. The external sample should be in the range -1.0 to
+// 1.0 and is mixed equally into both channels. Zero or more resampled frames
+// are appended to the buffer, which is then returned.
+//
+// Every frame consists of two values, the left and right channels. In mono
+// mode the two values will be the same.
+func (rs *Resampler) Process(channel0 uint8, channel1 uint8, external float32, buf []int16) []int16 {
+	var left, right float64
+
+	if rs.opts.Stereo {
+		left = float64(channel0) / 15.0
+		right = float64(channel1) / 15.0
+	} else {
+		left = float64(channel0+channel1) / 30.0
+		right = left
+	}
+
+	left += float64(external) * externalLevel
+	right += float64(external) * externalLevel
+
+	// add samples to history
+	rs.history[0][rs.idx] = left
+	rs.history[0][rs.idx+numTaps] = left
+	rs.history[1][rs.idx] = right
+	rs.history[1][rs.idx+numTaps] = right
+	rs.idx++
+	if rs.idx >= numTaps {
+		rs.idx = 0
+	}
+
+	for rs.phase < 1.0 {
+		coeffs := &rs.table[int(rs.phase*numPhases+0.5)]
+
+		for c := 0; c < 2; c++ {
+			// the oldest sample in the history is at idx
+			hist := rs.history[c][rs.idx : rs.idx+numTaps]
+
+			v := 0.0
+			for j := 0; j < numTaps; j++ {
+				v += hist[j] * coeffs[j]
+			}
+
+			buf = append(buf, rs.filter(c, v))
+		}
+
+		rs.phase += rs.step
+	}
+
+	rs.phase -= 1.0
+
+	return buf
+}
+
+// apply filters to the resampled value for the channel and convert to int16
+func (rs *Resampler) filter(c int, v float64) int16 {
+	// the low-pass filter is a simple one-pole filter. the filter is kept
+	// running even when it is not being used so that there is no click when
+	// it is turned on
+	rs.lowPass[c] += rs.lowPassCoeff * (v - rs.lowPass[c])
+	if rs.opts.LowPass {
+		v = rs.lowPass[c]
+	}
+
+	// dc blocking filter
+	out := v - rs.dcIn[c] + rs.dcCoeff*rs.dcOut[c]
+	rs.dcIn[c] = v
+	rs.dcOut[c] = out
+
+	out *= amplitude
+	if out > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if out < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(out)
+}
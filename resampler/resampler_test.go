@@ -0,0 +1,139 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package resampler_test
+
+import (
+	tiaAudio "gopher2600/hardware/tia/audio"
+	"gopher2600/resampler"
+	"testing"
+)
+
+// one second of a square wave on channel 0 only
+func square(t *testing.T, opts resampler.Options) []int16 {
+	t.Helper()
+
+	rs, err := resampler.NewResampler(opts)
+	if err != nil {
+		t.Fatalf("cannot create resampler: %v", err)
+	}
+
+	buf := make([]int16, 0, opts.Rate*2)
+	for i := 0; i < tiaAudio.SampleFreq; i++ {
+		v := uint8(0)
+		if (i/32)%2 == 0 {
+			v = 15
+		}
+		buf = rs.Process(v, 0, 0, buf)
+	}
+
+	return buf
+}
+
+func TestOutputRate(t *testing.T) {
+	for _, rate := range []int{resampler.Rate44100, resampler.Rate48000} {
+		buf := square(t, resampler.Options{Rate: rate})
+		if n := len(buf) / 2; n < rate-1 || n > rate+1 {
+			t.Errorf("%dHz: unexpected number of frames for one second of audio (%d)", rate, n)
+		}
+	}
+}
+
+func TestStereo(t *testing.T) {
+	buf := square(t, resampler.Options{Rate: resampler.Rate48000, Stereo: true})
+
+	var left, right int
+	for i := 0; i < len(buf); i += 2 {
+		if buf[i] != 0 {
+			left++
+		}
+		if buf[i+1] != 0 {
+			right++
+		}
+	}
+
+	if left == 0 {
+		t.Errorf("expected sound in left channel")
+	}
+	if right != 0 {
+		t.Errorf("expected silence in right channel")
+	}
+
+	// in mono mode both channels are the same
+	buf = square(t, resampler.Options{Rate: resampler.Rate48000})
+	for i := 0; i < len(buf); i += 2 {
+		if buf[i] != buf[i+1] {
+			t.Fatalf("left and right channels differ in mono mode")
+		}
+	}
+}
+
+func TestDCBlock(t *testing.T) {
+	rs, err := resampler.NewResampler(resampler.Options{Rate: resampler.Rate48000, LowPass: true})
+	if err != nil {
+		t.Fatalf("cannot create resampler: %v", err)
+	}
+
+	// a constant non-zero output should settle to silence
+	var buf []int16
+	for i := 0; i < tiaAudio.SampleFreq; i++ {
+		buf = rs.Process(8, 8, 0, buf[:0])
+	}
+
+	for _, v := range buf {
+		if v != 0 {
+			t.Fatalf("constant input did not settle to silence (%d)", v)
+		}
+	}
+}
+
+func TestExternal(t *testing.T) {
+	rs, err := resampler.NewResampler(resampler.Options{Rate: resampler.Rate48000, Stereo: true})
+	if err != nil {
+		t.Fatalf("cannot create resampler: %v", err)
+	}
+
+	// a square wave centred on zero from the external source only. the
+	// external source is mixed equally into both channels
+	var buf []int16
+	var min, max int16
+	for i := 0; i < tiaAudio.SampleFreq; i++ {
+		v := float32(0.5)
+		if (i/32)%2 == 0 {
+			v = -0.5
+		}
+		buf = rs.Process(0, 0, v, buf[:0])
+
+		for j := 0; j < len(buf); j += 2 {
+			if buf[j] != buf[j+1] {
+				t.Fatalf("external sound not mixed equally into both channels")
+			}
+			if buf[j] < min {
+				min = buf[j]
+			}
+			if buf[j] > max {
+				max = buf[j]
+			}
+		}
+	}
+
+	if min == 0 || max == 0 {
+		t.Errorf("expected external sound either side of zero (%d to %d)", min, max)
+	}
+}
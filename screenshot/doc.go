@@ -0,0 +1,33 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package screenshot provides a television.PixelRenderer that keeps a copy of
+// the most recently completed frame and which can save that frame as a PNG
+// file. It does not require a GUI and can be used anywhere a television is
+// available.
+//
+// The frame can be saved in one of three modes:
+//
+//	Full: the entire television signal, including the HBLANK and VBLANK areas
+//	Visible: the visible area of the screen only
+//	Aspect: the visible area, stretched horizontally to correct the aspect ratio
+//
+// In the Aspect mode, the image is scaled using the same pixel width and
+// television.Specification.AspectBias values as the GUI implementations.
+package screenshot
@@ -0,0 +1,266 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package screenshot
+
+import (
+	"fmt"
+	"gopher2600/errors"
+	"gopher2600/television"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mode specifies how the frame is cropped and scaled when saved
+type Mode int
+
+// List of valid screenshot modes
+const (
+	ModeFull Mode = iota
+	ModeVisible
+	ModeAspect
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeFull:
+		return "FULL"
+	case ModeVisible:
+		return "VISIBLE"
+	case ModeAspect:
+		return "ASPECT"
+	}
+	return "unknown"
+}
+
+// ParseMode converts a string to a Mode value. The string is not case
+// sensitive.
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToUpper(s) {
+	case "FULL":
+		return ModeFull, nil
+	case "VISIBLE":
+		return ModeVisible, nil
+	case "ASPECT":
+		return ModeAspect, nil
+	}
+	return ModeFull, errors.New(errors.ScreenshotError, fmt.Sprintf("unrecognised mode (%s)", s))
+}
+
+// the largest frame we capture. scanlines beyond this are ignored
+const maxScanlines = 312
+
+// pixelWidth is the width of a VCS pixel compared to its height. it's the same
+// value as used in the GUI implementations.
+const pixelWidth = 2.0
+
+// Screenshot is an implementation of the television.PixelRenderer interface.
+type Screenshot struct {
+	tv television.Television
+
+	// the frame currently being drawn and the most recently completed frame
+	current *image.RGBA
+	last    *image.RGBA
+
+	// the number of scanlines in the most recently completed frame
+	currentScanlines int
+	lastScanlines    int
+
+	// the visible area of the screen as most recently reported by the
+	// television
+	top       int
+	scanlines int
+
+	// draw pixels in the VBLANK area as video black
+	vblankBlack bool
+}
+
+// NewScreenshot is the preferred method of initialisation for the Screenshot
+// type. The new instance is added to the television's list of renderers.
+func NewScreenshot(tv television.Television) *Screenshot {
+	spec := tv.GetSpec()
+
+	scr := &Screenshot{
+		tv:        tv,
+		current:   image.NewRGBA(image.Rect(0, 0, television.HorizClksScanline, maxScanlines)),
+		last:      image.NewRGBA(image.Rect(0, 0, television.HorizClksScanline, maxScanlines)),
+		top:       spec.ScanlineTop,
+		scanlines: spec.ScanlineBottom - spec.ScanlineTop,
+	}
+
+	tv.AddPixelRenderer(scr)
+
+	return scr
+}
+
+// SetVBlankBlack specifies whether pixels in the VBLANK area should be drawn
+// as video black, as they would be on a real television. By default pixels are
+// drawn as they are received from the television.
+func (scr *Screenshot) SetVBlankBlack(black bool) {
+	scr.vblankBlack = black
+}
+
+// Top returns the top scanline of the visible screen, as most recently
+// reported by the television.
+func (scr *Screenshot) Top() int {
+	return scr.top
+}
+
+// Resize implements television.PixelRenderer interface
+func (scr *Screenshot) Resize(topScanline, visibleScanlines int) error {
+	scr.top = topScanline
+	scr.scanlines = visibleScanlines
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (scr *Screenshot) NewFrame(frameNum int) error {
+	scr.current, scr.last = scr.last, scr.current
+	scr.lastScanlines = scr.currentScanlines
+	scr.currentScanlines = 0
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (scr *Screenshot) NewScanline(scanline int) error {
+	if scanline < maxScanlines {
+		scr.currentScanlines = scanline + 1
+	}
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (scr *Screenshot) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
+	if vblank && scr.vblankBlack {
+		red, green, blue = 0, 0, 0
+	}
+	scr.current.SetRGBA(x, y, color.RGBA{R: red, G: green, B: blue, A: 255})
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (scr *Screenshot) SetAltPixel(x, y int, red, green, blue byte, vblank bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (scr *Screenshot) EndRendering() error {
+	return nil
+}
+
+// Image returns a copy of the most recently completed frame, cropped and
+// scaled according to the mode.
+func (scr *Screenshot) Image(mode Mode) *image.RGBA {
+	return scr.Scaled(mode, 1.0)
+}
+
+// Scaled is the same as Image() except that the image is further scaled in
+// both dimensions by the scale value.
+func (scr *Screenshot) Scaled(mode Mode, scale float64) *image.RGBA {
+	var r image.Rectangle
+
+	switch mode {
+	case ModeFull:
+		scanlines := scr.lastScanlines
+		if scanlines == 0 {
+			scanlines = scr.tv.GetSpec().ScanlinesTotal
+		}
+		r = image.Rect(0, 0, television.HorizClksScanline, scanlines)
+	default:
+		r = image.Rect(television.HorizClksHBlank, scr.top, television.HorizClksScanline, scr.top+scr.scanlines)
+	}
+
+	// horizontal scaling
+	hscale := scale
+	if mode == ModeAspect {
+		hscale *= pixelWidth * float64(scr.tv.GetSpec().AspectBias)
+	}
+
+	return scr.sample(r, hscale, scale)
+}
+
+// Crop returns a copy of the visible portion of the most recently completed
+// frame, starting at the top scanline and extending for the number of
+// scanlines specified. The image is not scaled.
+func (scr *Screenshot) Crop(top int, scanlines int) *image.RGBA {
+	r := image.Rect(television.HorizClksHBlank, top, television.HorizClksScanline, top+scanlines)
+	return scr.sample(r, 1.0, 1.0)
+}
+
+// sample the area of the most recently completed frame using
+// nearest-neighbour sampling
+func (scr *Screenshot) sample(r image.Rectangle, hscale float64, vscale float64) *image.RGBA {
+	w := int(math.Round(float64(r.Dx()) * hscale))
+	h := int(math.Round(float64(r.Dy()) * vscale))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		sy := r.Min.Y + int(float64(y)/vscale)
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, scr.last.RGBAAt(r.Min.X+int(float64(x)/hscale), sy))
+		}
+	}
+
+	return img
+}
+
+// Save the most recently completed frame to the named file in PNG format.
+func (scr *Screenshot) Save(filename string, mode Mode) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New(errors.ScreenshotError, err)
+	}
+	defer f.Close()
+
+	err = png.Encode(f, scr.Image(mode))
+	if err != nil {
+		return errors.New(errors.ScreenshotError, err)
+	}
+
+	return nil
+}
+
+// UniqueFilename returns a filename suitable for a new screenshot, based on
+// the supplied name (usually the cartridge name) and the current time. A
+// counter is added to the filename if a file with that name already exists.
+func UniqueFilename(name string) string {
+	n := time.Now()
+	base := fmt.Sprintf("screenshot_%s_%04d%02d%02d_%02d%02d%02d", name,
+		n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+
+	fn := fmt.Sprintf("%s.png", base)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(fn); os.IsNotExist(err) {
+			return fn
+		}
+		fn = fmt.Sprintf("%s_%d.png", base, i)
+	}
+}
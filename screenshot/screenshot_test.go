@@ -0,0 +1,92 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package screenshot_test
+
+import (
+	"gopher2600/screenshot"
+	"gopher2600/television"
+	"testing"
+)
+
+func TestImageSize(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	scr := screenshot.NewScreenshot(tv)
+	spec := tv.GetSpec()
+	visible := spec.ScanlineBottom - spec.ScanlineTop
+
+	tests := []struct {
+		mode screenshot.Mode
+		w, h int
+	}{
+		{screenshot.ModeFull, television.HorizClksScanline, spec.ScanlinesTotal},
+		{screenshot.ModeVisible, television.HorizClksVisible, visible},
+		{screenshot.ModeAspect, 291, visible},
+	}
+
+	for _, tst := range tests {
+		sz := scr.Image(tst.mode).Bounds().Size()
+		if sz.X != tst.w || sz.Y != tst.h {
+			t.Errorf("%s: unexpected image size %dx%d (expected %dx%d)", tst.mode, sz.X, sz.Y, tst.w, tst.h)
+		}
+	}
+}
+
+func TestScaledAndCrop(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	scr := screenshot.NewScreenshot(tv)
+	spec := tv.GetSpec()
+	visible := spec.ScanlineBottom - spec.ScanlineTop
+
+	sz := scr.Scaled(screenshot.ModeVisible, 2.0).Bounds().Size()
+	if sz.X != television.HorizClksVisible*2 || sz.Y != visible*2 {
+		t.Errorf("unexpected scaled image size %dx%d", sz.X, sz.Y)
+	}
+
+	sz = scr.Scaled(screenshot.ModeAspect, 0.5).Bounds().Size()
+	if sz.X != 146 || sz.Y != visible/2 {
+		t.Errorf("unexpected scaled aspect image size %dx%d", sz.X, sz.Y)
+	}
+
+	sz = scr.Crop(scr.Top(), 100).Bounds().Size()
+	if sz.X != television.HorizClksVisible || sz.Y != 100 {
+		t.Errorf("unexpected cropped image size %dx%d", sz.X, sz.Y)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []screenshot.Mode{screenshot.ModeFull, screenshot.ModeVisible, screenshot.ModeAspect} {
+		p, err := screenshot.ParseMode(m.String())
+		if err != nil || p != m {
+			t.Errorf("%s: mode did not parse correctly", m)
+		}
+	}
+
+	if _, err := screenshot.ParseMode("foo"); err == nil {
+		t.Errorf("expected error for unrecognised mode")
+	}
+}
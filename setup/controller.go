@@ -0,0 +1,163 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package setup
+
+import (
+	"fmt"
+	"gopher2600/cartridgeloader"
+	"gopher2600/database"
+	"gopher2600/disassembly"
+	"gopher2600/errors"
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const controllerID = "controller"
+
+const (
+	controllerFieldCartHash int = iota
+	controllerFieldPort
+	controllerFieldType
+	controllerFieldNotes
+	numControllerFields
+)
+
+// controller is used to set the type of controller attached to a port. this
+// is necessary for controllers that cannot be detected automatically, like the
+// driving controller
+type controller struct {
+	cartHash string
+	port     int
+	which    input.ControllerType
+	notes    string
+}
+
+func deserialiseControllerEntry(fields database.SerialisedEntry) (database.Entry, error) {
+	set := &controller{}
+
+	// basic sanity check
+	if len(fields) > numControllerFields {
+		return nil, errors.New(errors.SetupControllerError, "too many fields in controller entry")
+	}
+	if len(fields) < numControllerFields {
+		return nil, errors.New(errors.SetupControllerError, "too few fields in controller entry")
+	}
+
+	var err error
+
+	set.cartHash = fields[controllerFieldCartHash]
+
+	set.port, err = strconv.Atoi(fields[controllerFieldPort])
+	if err != nil || set.port < 0 || set.port > 1 {
+		return nil, errors.New(errors.SetupControllerError, fmt.Sprintf("invalid port (%s)", fields[controllerFieldPort]))
+	}
+
+	set.which, err = input.ParseControllerType(fields[controllerFieldType])
+	if err != nil {
+		return nil, errors.New(errors.SetupControllerError, err)
+	}
+
+	set.notes = fields[controllerFieldNotes]
+
+	return set, nil
+}
+
+// ID implements the database.Entry interface
+func (set controller) ID() string {
+	return controllerID
+}
+
+// String implements the database.Entry interface
+func (set controller) String() string {
+	return fmt.Sprintf("%s, port %d, %s", set.cartHash, set.port, set.which)
+}
+
+// Serialise implements the database.Entry interface
+func (set *controller) Serialise() (database.SerialisedEntry, error) {
+	return database.SerialisedEntry{
+			set.cartHash,
+			strconv.Itoa(set.port),
+			set.which.String(),
+			set.notes,
+		},
+		nil
+}
+
+// CleanUp implements the database.Entry interface
+func (set controller) CleanUp() error {
+	// no cleanup necessary
+	return nil
+}
+
+// matchCartHash implements setupEntry interface
+func (set controller) matchCartHash(hash string) bool {
+	return set.cartHash == hash
+}
+
+// apply implements setupEntry interface
+func (set controller) apply(vcs *hardware.VCS) error {
+	// using the event system means that the change of controller will be
+	// seen by any attached event recorder
+	switch set.port {
+	case 0:
+		return vcs.HandController0.Handle(input.SetControllerType, set.which.String())
+	case 1:
+		return vcs.HandController1.Handle(input.SetControllerType, set.which.String())
+	}
+	return nil
+}
+
+// detectControllers selects the controller type for each port according to
+// the analysis in the disassembly. a port is set to the joystick if nothing
+// else is detected, so that the controller selected for a previous cartridge
+// does not persist. a nil disassembly means nothing has been detected
+func detectControllers(vcs *hardware.VCS, dsm *disassembly.Disassembly) error {
+	detected := [2]input.ControllerType{input.JoystickType, input.JoystickType}
+	if dsm != nil {
+		detected = dsm.Analysis.Controllers
+	}
+
+	ports := []input.Port{vcs.HandController0, vcs.HandController1}
+	for i, t := range detected {
+		err := ports[i].Handle(input.SetControllerType, t.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadKidVidTape loads the tape for any Kid Vid attached to the VCS. the WAV
+// files of the tape are found alongside the cartridge file and are named after
+// it (see input.KidVid)
+func loadKidVidTape(vcs *hardware.VCS, cartload cartridgeloader.Loader) {
+	base := strings.TrimSuffix(cartload.Filename, filepath.Ext(cartload.Filename))
+
+	for _, hc := range []*input.HandController{vcs.RIOT.Input.HandController0, vcs.RIOT.Input.HandController1} {
+		if hc.KidVid() != nil {
+			// the tape is not essential so failure to load it is not an error
+			_ = hc.Handle(input.KidVidTape, base)
+		}
+	}
+}
@@ -24,6 +24,7 @@
 //	Toggling of panel switches
 //	Apply patches to cartridge
 //	Television specification
+//	Controller type
 //
 // Menu driven selection of patches would be a nice feature to have in the
 // future. But at the moment, the package doesn't even facilitate editing of
@@ -47,5 +48,27 @@
 //
 //	<DB Key>, television, <SHA-1 Hash>, <tv spec>, notes
 //
-// TV spec should be one of PAL or NTSC (or AUTO)
+// TV spec should be one of NTSC, PAL, PAL60 or SECAM (or AUTO). A cartridge
+// made for PAL or SECAM consoles can be given AUTO-PAL or AUTO-SECAM. These
+// hint at the color system of the console while still allowing the frequency
+// to be detected from the number of scanlines (see television.SetSpec()).
+//
+//	Controller
+//
+//	<DB Key>, controller, <SHA-1 Hash>, <port>, <controller type>, notes
+//
+// Port should be 0 or 1 and controller type should be one of JOYSTICK, PADDLE,
+// KEYPAD, DRIVING, SAVEKEY, ATARIVOX, TRAKBALL, AMIGAMOUSE, STMOUSE, GENESIS,
+// BOOSTERGRIP or KIDVID.
+//
+// When a cartridge is attached in play or debug mode, the paddle, keypad and
+// driving controllers are selected according to the analysis made by the
+// disassembly package. Detection is not used for regression tests. A
+// controller entry overrides the detected type and is required for the other
+// controller types. For example, the following entry selects the driving
+// controller for the left player:
+//
+//	<DB Key>, controller, <SHA-1 Hash>, 0, DRIVING, Indy 500
+//
+// The SaveKey and AtariVox are usually attached to port 1.
 package setup
@@ -22,9 +22,11 @@ package setup
 import (
 	"gopher2600/cartridgeloader"
 	"gopher2600/database"
+	"gopher2600/disassembly"
 	"gopher2600/errors"
 	"gopher2600/hardware"
 	"gopher2600/paths"
+	"gopher2600/symbols"
 )
 
 // the location of the setupDB file
@@ -58,18 +60,92 @@ func initDBSession(db *database.Session) error {
 		return err
 	}
 
+	if err := db.RegisterEntryType(controllerID, deserialiseControllerEntry); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// Disassembler returns a disassembly of the cartridge currently attached to
+// the VCS. It is used by AttachCartridge() to select the controllers.
+type Disassembler func() (*disassembly.Disassembly, error)
+
+// Disassemble returns a Disassembler for the cartridge attached to the VCS. It
+// should be used when the disassembly is not otherwise needed. Failure to
+// disassemble the cartridge is not an error, the joystick is selected for
+// both ports.
+func Disassemble(vcs *hardware.VCS) Disassembler {
+	return func() (*disassembly.Disassembly, error) {
+		dsm, err := disassembly.FromMemory(vcs.Mem.Cart, symbols.NewTable())
+		if err != nil {
+			return nil, nil
+		}
+		return dsm, nil
+	}
+}
+
 // AttachCartridge to the VCS and apply setup information from the setupDB.
 // This function should be preferred to the hardware.VCS.AttachCartridge()
 // function in almost all cases.
-func AttachCartridge(vcs *hardware.VCS, cartload cartridgeloader.Loader) error {
+//
+// If a Disassembler is supplied then the controllers are selected according
+// to an analysis of the cartridge. The Disassembler is called after any
+// patches in the setupDB have been applied. Detection should only be used for
+// interactive modes. Changing the controller type changes how the emulation
+// behaves and would, for example, invalidate existing regression digests.
+func AttachCartridge(vcs *hardware.VCS, cartload cartridgeloader.Loader, disassemble Disassembler) error {
 	err := vcs.AttachCartridge(cartload)
 	if err != nil {
 		return err
 	}
 
+	// controller entries are applied after detection so that they override
+	// the detected controller type
+	controllers := make([]setupEntry, 0)
+
+	err = applyEntries(vcs, func(set setupEntry) error {
+		if _, ok := set.(*controller); ok {
+			controllers = append(controllers, set)
+			return nil
+		}
+		return set.apply(vcs)
+	})
+	if err != nil {
+		return err
+	}
+
+	// patches applied from the setup database are part of the cartridge as
+	// far as the user is concerned. they should not be saved along with any
+	// changes the user makes (see patch.Save())
+	vcs.Mem.Cart.Changes = nil
+
+	if disassemble != nil {
+		dsm, err := disassemble()
+		if err != nil {
+			return err
+		}
+		err = detectControllers(vcs, dsm)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, set := range controllers {
+		err = set.apply(vcs)
+		if err != nil {
+			return err
+		}
+	}
+
+	loadKidVidTape(vcs, cartload)
+
+	return nil
+}
+
+// applyEntries calls the apply function for every entry in the setupDB that
+// matches the attached cartridge
+func applyEntries(vcs *hardware.VCS, apply func(set setupEntry) error) error {
 	dbPth, err := paths.ResourcePath("", setupDBFile)
 	if err != nil {
 		return errors.New(errors.SetupError, err)
@@ -93,7 +169,7 @@ func AttachCartridge(vcs *hardware.VCS, cartload cartridgeloader.Loader) error {
 		}
 
 		if set.matchCartHash(vcs.Mem.Cart.Hash) {
-			err := set.apply(vcs)
+			err := apply(set)
 			if err != nil {
 				return false, err
 			}
@@ -0,0 +1,243 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package setup_test
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"gopher2600/cartridgeloader"
+	"gopher2600/disassembly"
+	"gopher2600/hardware"
+	"gopher2600/hardware/riot/input"
+	"gopher2600/setup"
+	"gopher2600/television"
+	"gopher2600/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a Disassembler that reports the controllers without disassembling anything
+func detected(p0, p1 input.ControllerType) setup.Disassembler {
+	return func() (*disassembly.Disassembly, error) {
+		dsm := &disassembly.Disassembly{}
+		dsm.Analysis.Controllers = [2]input.ControllerType{p0, p1}
+		return dsm, nil
+	}
+}
+
+func TestDetectControllers(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+
+	// the setup database is looked for relative to the working directory
+	dir, err := ioutil.TempDir("", "setup_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	cartload := cartridgeloader.Loader{Filename: test.WriteROM(t, dir, "kernel.bin", data)}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	expect := func(p0, p1 input.ControllerType) {
+		t.Helper()
+		if vcs.RIOT.Input.HandController0.ControllerType() != p0 {
+			t.Errorf("unexpected controller in port 0: %v (expected %v)", vcs.RIOT.Input.HandController0.ControllerType(), p0)
+		}
+		if vcs.RIOT.Input.HandController1.ControllerType() != p1 {
+			t.Errorf("unexpected controller in port 1: %v (expected %v)", vcs.RIOT.Input.HandController1.ControllerType(), p1)
+		}
+	}
+
+	err = setup.AttachCartridge(vcs, cartload, detected(input.PaddleType, input.KeypadType))
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	expect(input.PaddleType, input.KeypadType)
+
+	// controllers detected for the previous cartridge do not persist
+	err = setup.AttachCartridge(vcs, cartload, detected(input.JoystickType, input.JoystickType))
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	expect(input.JoystickType, input.JoystickType)
+
+	// without detection the controllers are left as they are
+	err = setup.AttachCartridge(vcs, cartload, detected(input.DrivingType, input.JoystickType))
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	err = setup.AttachCartridge(vcs, cartload, nil)
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	expect(input.DrivingType, input.JoystickType)
+
+	// the cartridge is disassembled if there is no other disassembly and the
+	// kernel doesn't use any special controllers
+	err = setup.AttachCartridge(vcs, cartload, setup.Disassemble(vcs))
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+	expect(input.JoystickType, input.JoystickType)
+}
+
+func TestPatchIsNotChange(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get working directory: %v", err)
+	}
+
+	// the setup database is looked for relative to the working directory
+	dir, err := ioutil.TempDir("", "setup_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf("cannot change working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	cartload := cartridgeloader.Loader{Filename: test.WriteROM(t, dir, "kernel.bin", data)}
+
+	patchFile := filepath.Join(dir, "kernel.patch")
+	err = ioutil.WriteFile(patchFile, []byte("0100: EA EA\n"), 0600)
+	if err != nil {
+		t.Fatalf("cannot create patch file: %v", err)
+	}
+
+	err = os.Mkdir(".gopher2600", 0700)
+	if err != nil {
+		t.Fatalf("cannot create resource directory: %v", err)
+	}
+	entry := fmt.Sprintf("000,patch,%x,%s,\n", sha1.Sum(data), patchFile)
+	err = ioutil.WriteFile(filepath.Join(".gopher2600", "setupDB"), []byte(entry), 0600)
+	if err != nil {
+		t.Fatalf("cannot create setup database: %v", err)
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	err = setup.AttachCartridge(vcs, cartload, nil)
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	if len(vcs.Mem.Cart.Patches) != 1 {
+		t.Fatalf("patch from setup database has not been applied")
+	}
+	if v, _ := vcs.Mem.Cart.Peek(0x1100); v != 0xea {
+		t.Errorf("patch from setup database has not changed the cartridge")
+	}
+
+	// the patch from the setup database should not be recorded as a change
+	if len(vcs.Mem.Cart.Changes) != 0 {
+		t.Errorf("unexpected changes to cartridge: %v", vcs.Mem.Cart.Changes)
+	}
+
+	err = vcs.Mem.Cart.Patch(0x0200, 0xea)
+	if err != nil {
+		t.Fatalf("cannot patch cartridge: %v", err)
+	}
+	if len(vcs.Mem.Cart.Changes) != 1 || vcs.Mem.Cart.Changes[0].Offset != 0x200 {
+		t.Errorf("unexpected changes to cartridge: %v", vcs.Mem.Cart.Changes)
+	}
+}
+
+func TestKidVidTape(t *testing.T) {
+	dir, err := ioutil.TempDir("", "setup_test")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := test.NewROM(4096)
+	copy(data, test.Kernel)
+	cartload := cartridgeloader.Loader{Filename: test.WriteROM(t, dir, "kernel.bin", data)}
+
+	// the first track of the tape, named after the cartridge file. a mono
+	// tape of two 8 bit samples
+	err = ioutil.WriteFile(filepath.Join(dir, "kernel_1.wav"), []byte("RIFF\x26\x00\x00\x00WAVE"+
+		"fmt \x10\x00\x00\x00\x01\x00\x01\x00\xe8\x03\x00\x00\xe8\x03\x00\x00\x01\x00\x08\x00"+
+		"data\x02\x00\x00\x00\x80\x80"), 0600)
+	if err != nil {
+		t.Fatalf("cannot create tape file: %v", err)
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("cannot create television: %v", err)
+	}
+
+	vcs, err := hardware.NewVCS(tv, nil)
+	if err != nil {
+		t.Fatalf("cannot create VCS: %v", err)
+	}
+
+	err = setup.AttachCartridge(vcs, cartload, detected(input.JoystickType, input.KidVidType))
+	if err != nil {
+		t.Fatalf("cannot attach cartridge: %v", err)
+	}
+
+	kv := vcs.RIOT.Input.HandController1.KidVid()
+	if kv == nil {
+		t.Fatalf("no Kid Vid attached to port 1")
+	}
+	if kv.Filename() != filepath.Join(dir, "kernel") {
+		t.Errorf("unexpected tape loaded: %s", kv.Filename())
+	}
+	if !kv.Playing() {
+		t.Errorf("the tape should be ready to play")
+	}
+}
@@ -0,0 +1,166 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"bytes"
+	"fmt"
+	"gopher2600/hardware/memory/memorymap"
+	"strconv"
+	"strings"
+)
+
+func identifyCA65(ext string, data []byte) bool {
+	return ext == ".dbg" || bytes.HasPrefix(data, []byte("version\tmajor="))
+}
+
+// parses the key/value fields of a ca65 debug file record. for example:
+//
+//	id=0,name="CODE",start=0x00F000,size=0x0123,addrsize=absolute,type=ro
+func parseCA65Record(s string) map[string]string {
+	rec := make(map[string]string)
+
+	for len(s) > 0 {
+		var field string
+
+		// quoted values may contain commas so we need to take care when
+		// splitting the fields
+		i := strings.Index(s, "=\"")
+		j := strings.Index(s, ",")
+		if i != -1 && (j == -1 || i < j) {
+			k := strings.Index(s[i+2:], "\"")
+			if k == -1 {
+				k = len(s) - i - 2
+			}
+			field = s[:i+2+k]
+			s = s[i+2+k:]
+			s = strings.TrimPrefix(s, "\"")
+		} else if j != -1 {
+			field = s[:j]
+			s = s[j:]
+		} else {
+			field = s
+			s = ""
+		}
+		s = strings.TrimPrefix(s, ",")
+
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 2 {
+			rec[kv[0]] = strings.Trim(kv[1], "\"")
+		}
+	}
+
+	return rec
+}
+
+// parses a numeric value in a ca65 debug file
+func parseCA65Value(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 0, 32)
+	return int(v), err
+}
+
+// the segment fields used to decide the bank of a label
+type ca65Segment struct {
+	start int
+
+	// the offset of the segment in the output file. a value of -1 indicates
+	// that the segment is not in the output file (RAM segments)
+	ooffs int
+}
+
+// readCA65 reads the debug files generated by the ld65 linker with the
+// --dbgfile option.
+//
+// labels in segments that are written to the output file are locations. the
+// bank of the location is decided by the label's offset in the output file,
+// assuming 4k banks. all other symbols are read/write symbols.
+func readCA65(tbl *Table, data []byte) error {
+	lines := strings.Split(string(data), "\n")
+
+	segs := make(map[string]ca65Segment)
+	syms := make([]map[string]string, 0)
+
+	for _, ln := range lines {
+		p := strings.SplitN(strings.TrimSpace(ln), "\t", 2)
+		if len(p) != 2 {
+			continue // for loop
+		}
+
+		switch p[0] {
+		case "seg":
+			rec := parseCA65Record(p[1])
+			seg := ca65Segment{ooffs: -1}
+			var err error
+			seg.start, err = parseCA65Value(rec["start"])
+			if err != nil {
+				return fmt.Errorf("ca65: segment %s: %v", rec["name"], err)
+			}
+			if _, ok := rec["oname"]; ok {
+				seg.ooffs, err = parseCA65Value(rec["ooffs"])
+				if err != nil {
+					return fmt.Errorf("ca65: segment %s: %v", rec["name"], err)
+				}
+			}
+			segs[rec["id"]] = seg
+
+		case "sym":
+			syms = append(syms, parseCA65Record(p[1]))
+		}
+	}
+
+	locs := make([]location, 0)
+
+	for _, rec := range syms {
+		name := rec["name"]
+
+		// imported symbols have no value
+		v, ok := rec["val"]
+		if !ok || name == "" {
+			continue // for loop
+		}
+		val, err := parseCA65Value(v)
+		if err != nil {
+			return fmt.Errorf("ca65: symbol %s: %v", name, err)
+		}
+		if val < 0 || val > 0xffff {
+			continue // for loop
+		}
+		address := uint16(val)
+
+		if rec["type"] == "lab" {
+			if seg, ok := segs[rec["seg"]]; ok && seg.ooffs != -1 {
+				if _, area := memorymap.MapAddress(address, true); area == memorymap.Cartridge {
+					bank := (seg.ooffs + val - seg.start) >> 12
+					locs = append(locs, location{bank: bank, addr: address, symbol: name})
+					continue // for loop
+				}
+			}
+		}
+
+		tbl.Read.add(address, name, false)
+		tbl.Write.add(address, name, false)
+	}
+
+	if len(locs) > 0 {
+		tbl.addLocations(locs)
+	}
+
+	return nil
+}
@@ -30,4 +30,10 @@
 //
 // ReadSymbolFile() will always give addresses the default or canonised symbol.
 // In this way it is a superset of the NewTable() function.
+//
+// Additional symbols files can be added to an existing Table with the
+// LoadSymbolsFile() function. Symbols files generated by DASM, by the ca65
+// linker, and simple label files are supported. Some of these formats allow
+// location symbols to be qualified with a cartridge bank. Use LocationSymbol()
+// to find the correct symbol for an address in a specific bank.
 package symbols
@@ -22,14 +22,15 @@ package symbols
 import (
 	"fmt"
 	"gopher2600/errors"
-	"io/ioutil"
 	"os"
 	"path"
-	"strconv"
 	"strings"
-	"unicode"
 )
 
+// the list of file extensions that ReadSymbolsFile() will try. every symbols
+// file that exists alongside the cartridge will be read
+var symbolsExtensions = []string{".sym", ".lst", ".dbg", ".lbl"}
+
 // ReadSymbolsFile initialises a symbols table from the symbols file for the
 // specified cartridge
 //
@@ -37,13 +38,11 @@ import (
 // if the symbols file cannot be opened the symbols file will still contain the
 // canonical vcs symbols file
 //
-// Currently, only symbols files generated by DASM are supported
+// Symbols files are looked for alongside the cartridge file, with the same
+// name but a different extension. See LoadSymbolsFile() for the supported
+// formats
 func ReadSymbolsFile(cartridgeFilename string) (*Table, error) {
-	tbl := &Table{
-		Locations: newTable(),
-		Read:      newTable(),
-		Write:     newTable(),
-	}
+	tbl := newTableSet()
 
 	// prefer default symbol for an address over any symbol that has been
 	// specified in the symbols file
@@ -58,65 +57,33 @@ func ReadSymbolsFile(cartridgeFilename string) (*Table, error) {
 		return tbl, nil
 	}
 
-	// try to open symbols file
-	symFilename := cartridgeFilename
-	ext := path.Ext(symFilename)
+	ext := path.Ext(cartridgeFilename)
+	base := cartridgeFilename[:len(cartridgeFilename)-len(ext)]
 
-	// try to figure out the case of the file extension
-	if ext == ".BIN" {
-		symFilename = fmt.Sprintf("%s.SYM", symFilename[:len(symFilename)-len(ext)])
-	} else {
-		symFilename = fmt.Sprintf("%s.sym", symFilename[:len(symFilename)-len(ext)])
-	}
+	found := false
 
-	sf, err := os.Open(symFilename)
-	if err != nil {
-		return tbl, errors.New(errors.SymbolsFileUnavailable, cartridgeFilename)
-	}
-	defer func() {
-		_ = sf.Close()
-	}()
-
-	sym, err := ioutil.ReadAll(sf)
-	if err != nil {
-		return nil, errors.New(errors.SymbolsFileError, err)
-	}
-	lines := strings.Split(string(sym), "\n")
-
-	// find interesting lines in the symbols file and add to the Table
-	// instance.
-	for _, ln := range lines {
-		// ignore uninteresting lines
-		p := strings.Fields(ln)
-		if len(p) < 2 || p[0] == "---" {
-			continue // for loop
+	for _, symExt := range symbolsExtensions {
+		// try to figure out the case of the file extension
+		if ext != strings.ToLower(ext) {
+			symExt = strings.ToUpper(symExt)
 		}
 
-		// get address
-		address, err := strconv.ParseUint(p[1], 16, 16)
-		if err != nil {
+		symFilename := fmt.Sprintf("%s%s", base, symExt)
+
+		if _, err := os.Stat(symFilename); err != nil {
 			continue // for loop
 		}
 
-		// get symbol
-		symbol := p[0]
+		found = true
 
-		// differentiate between location and other symbols. this is a little
-		// heavy handed, but still, it's better than nothing.
-		if unicode.IsDigit(rune(symbol[0])) {
-			// if symbol begins with a number and a period then it is a location symbol
-			i := strings.Index(symbol, ".")
-			if i != -1 {
-				tbl.Locations.add(uint16(address), symbol[i:], false)
-			}
-		} else {
-			// every non-location symbols is both a read and write symbol.
-			// compar to canonical vcs symbols which are specific to a read or
-			// write context
-			tbl.Read.add(uint16(address), symbol, false)
-			tbl.Write.add(uint16(address), symbol, false)
+		if err := tbl.LoadSymbolsFile(symFilename); err != nil {
+			return tbl, err
 		}
 	}
 
+	if !found {
+		return tbl, errors.New(errors.SymbolsFileUnavailable, cartridgeFilename)
+	}
+
 	return tbl, nil
 }
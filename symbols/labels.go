@@ -0,0 +1,78 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func identifyLabels(ext string, data []byte) bool {
+	return true
+}
+
+// readLabels reads the simple label file format described in the
+// LoadSymbolsFile() comment
+func readLabels(tbl *Table, data []byte) error {
+	lines := strings.Split(string(data), "\n")
+
+	for n, ln := range lines {
+		// strip comments
+		if i := strings.IndexAny(ln, ";#"); i != -1 {
+			ln = ln[:i]
+		}
+
+		p := strings.Fields(ln)
+		if len(p) == 0 {
+			continue // for loop
+		}
+		if len(p) != 2 {
+			return fmt.Errorf("labels: line %d: expected address and symbol", n+1)
+		}
+
+		// address may be qualified by a bank number
+		bank := AnyBank
+		address := p[0]
+		if i := strings.Index(address, ":"); i != -1 {
+			b, err := strconv.Atoi(address[:i])
+			if err != nil || b < 0 {
+				return fmt.Errorf("labels: line %d: invalid bank (%s)", n+1, address[:i])
+			}
+			bank = b
+			address = address[i+1:]
+		}
+
+		address = strings.TrimPrefix(address, "$")
+		address = strings.TrimPrefix(strings.ToLower(address), "0x")
+		v, err := strconv.ParseUint(address, 16, 16)
+		if err != nil {
+			return fmt.Errorf("labels: line %d: invalid address (%s)", n+1, p[0])
+		}
+
+		if bank == AnyBank {
+			tbl.addAddress(uint16(v), p[1])
+		} else {
+			tbl.addBanked(bank, uint16(v), p[1])
+		}
+	}
+
+	return nil
+}
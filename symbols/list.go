@@ -35,6 +35,11 @@ func (tbl *Table) ListSymbols(output io.Writer) {
 func (tbl *Table) ListLocations(output io.Writer) {
 	output.Write([]byte(fmt.Sprintf("Locations\n---------\n")))
 	output.Write([]byte(tbl.Locations.String()))
+
+	for _, b := range tbl.banks() {
+		output.Write([]byte(fmt.Sprintf("\nLocations (bank %d)\n------------------\n", b)))
+		output.Write([]byte(tbl.Banked[b].String()))
+	}
 }
 
 // ListReadSymbols outputs every read symbol used in the current ROM
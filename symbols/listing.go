@@ -0,0 +1,193 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"gopher2600/hardware/cpu/instructions"
+	"gopher2600/hardware/memory/memorymap"
+	"strconv"
+	"strings"
+)
+
+func identifyListing(ext string, data []byte) bool {
+	return ext == ".lst"
+}
+
+// the set of mnemonics and assembler directives that can follow a label in a
+// DASM listing. a word that is not in this set is taken to be a label
+var listingKeywords map[string]bool
+
+func init() {
+	listingKeywords = map[string]bool{
+		"ORG": true, "RORG": true, "SEG": true, "SEG.U": true, "PROCESSOR": true,
+		"INCLUDE": true, "INCBIN": true, "ALIGN": true, "ECHO": true, "ERR": true,
+		"DS": true, "DS.B": true, "DS.W": true, "DC": true, "DC.B": true, "DC.W": true,
+		".BYTE": true, ".WORD": true, "BYTE": true, "WORD": true, "HEX": true,
+		"IF": true, "IFCONST": true, "IFNCONST": true, "ELSE": true, "ENDIF": true, "EIF": true,
+		"REPEAT": true, "REPEND": true, "MAC": true, "MACRO": true, "ENDM": true,
+		"SUBROUTINE": true, "=": true, "EQU": true, "SET": true, "END": true,
+	}
+
+	defns, err := instructions.GetDefinitions()
+	if err != nil {
+		return
+	}
+	for _, d := range defns {
+		if d != nil {
+			listingKeywords[strings.ToUpper(d.Mnemonic)] = true
+		}
+	}
+}
+
+// parses an address as it appears in a DASM listing or source. returns false
+// if the address cannot be parsed
+func parseListingValue(s string) (uint16, bool) {
+	s = strings.TrimPrefix(s, "U")
+	base := 16
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "%") {
+		s = s[1:]
+		base = 2
+	}
+	v, err := strconv.ParseUint(s, base, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(v), true
+}
+
+// isListingBytes returns true if the word looks like a byte in the bytecode
+// column of a DASM listing
+func isListingBytes(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 16, 8)
+	return err == nil
+}
+
+// readListing reads the listing files generated by DASM with the -l option.
+// Stella reads the same format.
+//
+// each line of the listing contains the line number, the address, the
+// bytecode and then the source line. labels in the source are added to the
+// table. equates are not in the listing in a useful form and are ignored - the
+// DASM symbols file should be used for those. note that a macro invocation on
+// a line of its own is indistinguishable from a label and will be treated as
+// such.
+//
+// the ORG directive is tracked so that labels can be qualified with the bank
+// in which they appear. this only happens when the listing also uses the RORG
+// directive, which is the usual method of assembling multi-bank cartridges
+// with DASM.
+func readListing(tbl *Table, data []byte) error {
+	lines := strings.Split(string(data), "\n")
+
+	// first pass finds the lowest origin. the bank of a label is the
+	// distance from the lowest origin in units of 4k
+	lowOrigin := -1
+	relocated := false
+	for _, ln := range lines {
+		p := strings.Fields(ln)
+		for i := 2; i < len(p)-1; i++ {
+			switch strings.ToUpper(p[i]) {
+			case "ORG":
+				if v, ok := parseListingValue(p[i+1]); ok {
+					if lowOrigin == -1 || int(v) < lowOrigin {
+						lowOrigin = int(v)
+					}
+				}
+			case "RORG":
+				relocated = true
+			}
+		}
+	}
+
+	locs := make([]location, 0)
+	bank := 0
+
+	for _, ln := range lines {
+		// ignore uninteresting lines
+		p := strings.Fields(ln)
+		if len(p) < 3 || strings.HasPrefix(p[0], "---") {
+			continue // for loop
+		}
+
+		// the first field is the line number
+		if _, err := strconv.Atoi(p[0]); err != nil {
+			continue // for loop
+		}
+
+		// the second field is the address. addresses that are too large to
+		// fit in 16bits are ignored - DASM uses these for lines that have no
+		// meaningful address
+		address, ok := parseListingValue(p[1])
+		if !ok {
+			continue // for loop
+		}
+
+		// skip bytecode and unknown value fields
+		src := p[2:]
+		for len(src) > 0 && (src[0] == "????" || isListingBytes(src[0])) {
+			src = src[1:]
+		}
+		if len(src) == 0 {
+			continue // for loop
+		}
+
+		// track bank changes
+		if relocated && len(src) > 1 && strings.ToUpper(src[0]) == "ORG" {
+			if v, ok := parseListingValue(src[1]); ok {
+				bank = (int(v) - lowOrigin) >> 12
+			}
+			continue // for loop
+		}
+
+		// comments and keywords don't begin with a label
+		symbol := src[0]
+		if strings.HasPrefix(symbol, ";") || listingKeywords[strings.ToUpper(symbol)] {
+			continue // for loop
+		}
+
+		// equates are not locations
+		if len(src) > 1 && listingKeywords[strings.ToUpper(src[1])] {
+			switch strings.ToUpper(src[1]) {
+			case "=", "EQU", "SET":
+				continue // for loop
+			}
+		}
+
+		// labels in a cartridge segment are locations. labels in
+		// uninitialised segments (RAM variables) are read/write symbols
+		if _, area := memorymap.MapAddress(address, true); area == memorymap.Cartridge {
+			locs = append(locs, location{bank: bank, addr: address, symbol: symbol})
+		} else {
+			tbl.Read.add(address, symbol, false)
+			tbl.Write.add(address, symbol, false)
+		}
+	}
+
+	if len(locs) > 0 {
+		tbl.addLocations(locs)
+	}
+
+	return nil
+}
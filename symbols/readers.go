@@ -0,0 +1,93 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"gopher2600/errors"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// reader implementations parse the contents of a symbols file and add the
+// symbols to the Table. new symbols file formats can be supported by adding
+// to the list of readers below.
+type reader struct {
+	// description of file format
+	name string
+
+	// returns true if the reader understands the symbols file. the
+	// extension is the lower case extension of the filename, including the
+	// period
+	identify func(ext string, data []byte) bool
+
+	// adds the symbols found in data to the table
+	read func(tbl *Table, data []byte) error
+}
+
+// list of readers in the order they will be tried. the user label reader
+// identifies every file and so must be the last entry in the list
+var readers = []reader{
+	{name: "ca65 debug file", identify: identifyCA65, read: readCA65},
+	{name: "DASM listing", identify: identifyListing, read: readListing},
+	{name: "DASM symbols file", identify: identifyDASM, read: readDASM},
+	{name: "label file", identify: identifyLabels, read: readLabels},
+}
+
+// LoadSymbolsFile reads the named symbols file and adds the symbols to the
+// table. The format of the file is decided by the file extension and by the
+// contents of the file. Supported formats are:
+//
+//	DASM symbols files (.sym), as also read by Stella
+//	DASM listings (.lst), as also read by Stella
+//	ca65 debug files (.dbg)
+//	label files (any other extension)
+//
+// Label files contain one address and one symbol per line. Addresses can be
+// qualified with a bank number. For example:
+//
+//	; comments begin with a semi-colon
+//	$0080    score
+//	$f000    reset
+//	1:$f000  reset_bank1
+//
+// Symbols already in the table are preferred over symbols in the file.
+// Symbols with a canonical Atari VCS name will continue to use that name.
+func (tbl *Table) LoadSymbolsFile(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.New(errors.SymbolsFileError, err)
+	}
+
+	ext := strings.ToLower(path.Ext(filename))
+
+	for _, r := range readers {
+		if r.identify(ext, data) {
+			if err := r.read(tbl, data); err != nil {
+				return errors.New(errors.SymbolsFileError, err)
+			}
+			break // for loop
+		}
+	}
+
+	tbl.canoniseTable(true)
+
+	return nil
+}
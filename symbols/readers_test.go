@@ -0,0 +1,145 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols_test
+
+import (
+	"gopher2600/symbols"
+	"testing"
+)
+
+// check that the location symbol for the bank/address is as expected
+func expectLocation(t *testing.T, tbl *symbols.Table, bank int, addr uint16, expected string) {
+	t.Helper()
+	if v, _ := tbl.LocationSymbol(bank, addr); v != expected {
+		t.Errorf("location symbol for %#04x in bank %d is %q (expected %q)", addr, bank, v, expected)
+	}
+}
+
+func TestListing(t *testing.T) {
+	tbl := symbols.NewTable()
+	err := tbl.LoadSymbolsFile("testdata/banked.lst")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	expectLocation(t, tbl, 0, 0xf000, "reset")
+	expectLocation(t, tbl, 0, 0xf001, ".loop")
+	expectLocation(t, tbl, 1, 0xf000, "start")
+	expectLocation(t, tbl, 1, 0xf001, "kernel")
+
+	if v := tbl.Read.Symbols[0x80]; v != "score" {
+		t.Errorf("read symbol for 0x80 is %q (expected \"score\")", v)
+	}
+
+	if _, _, _, err := tbl.SearchSymbol("SPEED", symbols.UnspecifiedSymTable); err == nil {
+		t.Errorf("equate should not have been added as a symbol")
+	}
+}
+
+func TestCA65(t *testing.T) {
+	tbl := symbols.NewTable()
+	err := tbl.LoadSymbolsFile("testdata/banked.dbg")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	expectLocation(t, tbl, 0, 0xf000, "Reset")
+	expectLocation(t, tbl, 1, 0xf010, "Kernel")
+	expectLocation(t, tbl, 1, 0xf000, "")
+
+	if v := tbl.Write.Symbols[0x80]; v != "score" {
+		t.Errorf("write symbol for 0x80 is %q (expected \"score\")", v)
+	}
+
+	// canonical symbols are preferred to symbols in the file
+	if v := tbl.Write.Symbols[0x03]; v != "RSYNC" {
+		t.Errorf("write symbol for 0x03 is %q (expected \"RSYNC\")", v)
+	}
+
+	_, _, addr, err := tbl.SearchSymbol("kernel", symbols.UnspecifiedSymTable)
+	if err != nil {
+		t.Errorf("unexpected error (%s)", err)
+	} else if addr != 0xf010 {
+		t.Errorf("kernel symbol found at %#04x (expected 0xf010)", addr)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tbl := symbols.NewTable()
+	err := tbl.LoadSymbolsFile("testdata/labels.lbl")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	expectLocation(t, tbl, 0, 0xf000, "reset")
+	expectLocation(t, tbl, 1, 0xf000, "reset")
+	expectLocation(t, tbl, 1, 0xf080, "bank1_sub")
+	expectLocation(t, tbl, 0, 0xf080, "")
+	expectLocation(t, tbl, 0, 0x1f10, "sub")
+
+	if v := tbl.Read.Symbols[0x81]; v != "lives" {
+		t.Errorf("read symbol for 0x81 is %q (expected \"lives\")", v)
+	}
+
+	err = tbl.LoadSymbolsFile("testdata/flappy.sym")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+}
+
+func TestUserSymbols(t *testing.T) {
+	tbl := symbols.NewTable()
+
+	// user symbols replace existing symbols
+	tbl.AddSymbol(symbols.AnyBank, 0xf000, "reset")
+	tbl.AddSymbol(symbols.AnyBank, 0xf000, "start")
+	expectLocation(t, tbl, 0, 0xf000, "start")
+
+	// mirrors of the address are found
+	expectLocation(t, tbl, 0, 0x1000, "start")
+
+	// bank qualified symbols are preferred
+	tbl.AddSymbol(1, 0xf000, "start_bank1")
+	expectLocation(t, tbl, 0, 0xf000, "start")
+	expectLocation(t, tbl, 1, 0xf000, "start_bank1")
+
+	// non-cartridge addresses are read and write symbols
+	tbl.AddSymbol(symbols.AnyBank, 0x80, "score")
+	if v := tbl.Read.Symbols[0x80]; v != "score" {
+		t.Errorf("read symbol for 0x80 is %q (expected \"score\")", v)
+	}
+	if v := tbl.Write.Symbols[0x80]; v != "score" {
+		t.Errorf("write symbol for 0x80 is %q (expected \"score\")", v)
+	}
+
+	tbl.AddComment(symbols.AnyBank, 0xf012, "draws the score")
+	tbl.AddComment(1, 0xf012, "draws the lives")
+	if c, _ := tbl.Comment(0, 0x1012); c != "draws the score" {
+		t.Errorf("comment for 0x1012 in bank 0 is %q", c)
+	}
+	if c, _ := tbl.Comment(1, 0xf012); c != "draws the lives" {
+		t.Errorf("comment for 0xf012 in bank 1 is %q", c)
+	}
+
+	tbl.AddComment(symbols.AnyBank, 0xf012, "")
+	if _, ok := tbl.Comment(0, 0xf012); ok {
+		t.Errorf("comment for 0xf012 should have been removed")
+	}
+}
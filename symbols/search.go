@@ -53,7 +53,7 @@ const (
 
 // SearchSymbol return the address of the supplied symbol. Search is
 // case-insensitive and is conducted on the subtables in order: locations >
-// read > write.
+// bank qualified locations > read > write.
 func (tbl *Table) SearchSymbol(symbol string, target TableType) (TableType, string, uint16, error) {
 	symbolUpper := strings.ToUpper(symbol)
 
@@ -61,6 +61,12 @@ func (tbl *Table) SearchSymbol(symbol string, target TableType) (TableType, stri
 		if addr, ok := tbl.Locations.search(symbolUpper); ok {
 			return LocationSymTable, symbol, addr, nil
 		}
+
+		for _, b := range tbl.banks() {
+			if addr, ok := tbl.Banked[b].search(symbolUpper); ok {
+				return LocationSymTable, symbol, addr, nil
+			}
+		}
 	}
 
 	if target == UnspecifiedSymTable || target == ReadSymTable {
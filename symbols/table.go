@@ -22,6 +22,7 @@ package symbols
 import (
 	"fmt"
 	"gopher2600/hardware/memory/addresses"
+	"gopher2600/hardware/memory/memorymap"
 	"sort"
 	"strings"
 )
@@ -33,6 +34,15 @@ type Table struct {
 	Read      *symTable
 	Write     *symTable
 
+	// location symbols that are specific to a cartridge bank, indexed by bank
+	// number. bank qualified symbols are preferred over the symbols in the
+	// Locations table. see LocationSymbol()
+	Banked map[int]*symTable
+
+	// comments for cartridge locations, indexed by bank number and then by
+	// address. comments that apply to all banks are indexed by AnyBank
+	comments map[int]map[uint16]string
+
 	// use max width values to help with formatting
 	MaxLocationWidth int
 	MaxSymbolWidth   int
@@ -42,11 +52,126 @@ type Table struct {
 // many instances however, ReadSymbolsFile() might be more appropriate. Naked
 // initalisation of the Table type (ie. &Table{}) will rarely be useful.
 func NewTable() *Table {
-	tbl := &Table{}
+	tbl := newTableSet()
 	tbl.canoniseTable(true)
 	return tbl
 }
 
+// returns an instance of Table with all sub-tables initialised but empty
+func newTableSet() *Table {
+	return &Table{
+		Locations: newTable(),
+		Read:      newTable(),
+		Write:     newTable(),
+		Banked:    make(map[int]*symTable),
+		comments:  make(map[int]map[uint16]string),
+	}
+}
+
+// AnyBank can be used in place of a bank number to indicate that a symbol or
+// comment applies to every bank.
+const AnyBank = -1
+
+// LocationSymbol returns the location symbol for the address in the specified
+// bank. A symbol that has been qualified with the bank is preferred to a
+// symbol that applies to all banks. If there is no symbol for the address
+// then the symbols for the address's cartridge mirrors are considered.
+func (tbl *Table) LocationSymbol(bank int, addr uint16) (string, bool) {
+	for _, m := range mirrors(addr) {
+		if sym, ok := tbl.Banked[bank]; ok {
+			if v, ok := sym.Symbols[m]; ok {
+				return v, true
+			}
+		}
+
+		if tbl.Locations != nil {
+			if v, ok := tbl.Locations.Symbols[m]; ok {
+				return v, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// mirrors returns the list of addresses to look up for an address. the first
+// entry in the list is always the address itself. cartridge addresses are
+// followed by every other mirror of the address.
+func mirrors(addr uint16) []uint16 {
+	if _, area := memorymap.MapAddress(addr, true); area != memorymap.Cartridge {
+		return []uint16{addr}
+	}
+
+	m := make([]uint16, 1, 9)
+	m[0] = addr
+	for i := uint16(0); i < 8; i++ {
+		a := addr&memorymap.AddressMaskCart | memorymap.OriginCart | i<<13
+		if a != addr {
+			m = append(m, a)
+		}
+	}
+	return m
+}
+
+// banks returns the list of banks that have bank qualified symbols, in order
+func (tbl *Table) banks() []int {
+	banks := make([]int, 0, len(tbl.Banked))
+	for b := range tbl.Banked {
+		banks = append(banks, b)
+	}
+	sort.Ints(banks)
+	return banks
+}
+
+// location describes a location symbol as found by a symbols file reader. the
+// bank field is only meaningful if the reader can detect more than one bank
+type location struct {
+	bank   int
+	addr   uint16
+	symbol string
+}
+
+// addLocations adds the list of location symbols to the table. if every
+// symbol is in the same bank then the symbols are added to the Locations
+// table; otherwise they are added to the Banked table.
+func (tbl *Table) addLocations(locs []location) {
+	banked := false
+	for _, l := range locs {
+		if l.bank != locs[0].bank {
+			banked = true
+			break // for loop
+		}
+	}
+
+	for _, l := range locs {
+		if banked {
+			tbl.addBanked(l.bank, l.addr, l.symbol)
+		} else {
+			tbl.Locations.add(l.addr, l.symbol, false)
+		}
+	}
+}
+
+// addBanked adds a bank qualified location symbol
+func (tbl *Table) addBanked(bank int, addr uint16, symbol string) {
+	if _, ok := tbl.Banked[bank]; !ok {
+		tbl.Banked[bank] = newTable()
+	}
+	tbl.Banked[bank].add(addr, symbol, false)
+}
+
+// addAddress adds a symbol for an address, deciding which table to add it to
+// by looking at the address. cartridge addresses are considered to be
+// locations and everything else both a read and write symbol.
+func (tbl *Table) addAddress(addr uint16, symbol string) {
+	if _, area := memorymap.MapAddress(addr, true); area == memorymap.Cartridge {
+		tbl.Locations.add(addr, symbol, false)
+		return
+	}
+	tbl.Read.add(addr, symbol, false)
+	tbl.Write.add(addr, symbol, false)
+}
+
 // put canonical symbols into table. prefer flag should be true if canonical
 // names are to supercede any existing symbol.
 func (tbl *Table) canoniseTable(prefer bool) {
@@ -73,6 +198,12 @@ func (tbl *Table) polishTable() {
 
 	// find max symbol width
 	tbl.MaxLocationWidth = tbl.Locations.maxWidth
+	for _, sym := range tbl.Banked {
+		sort.Sort(sym)
+		if sym.maxWidth > tbl.MaxLocationWidth {
+			tbl.MaxLocationWidth = sym.maxWidth
+		}
+	}
 	if tbl.Read.maxWidth > tbl.Write.maxWidth {
 		tbl.MaxSymbolWidth = tbl.Read.maxWidth
 	} else {
@@ -0,0 +1,84 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"gopher2600/hardware/memory/memorymap"
+)
+
+// AddSymbol adds a user defined symbol to the table. Symbols for cartridge
+// addresses are location symbols and can be qualified with a bank number (or
+// AnyBank). Symbols for all other addresses are added as both a read and a
+// write symbol and the bank is ignored.
+//
+// Unlike symbols read from a symbols file, a user symbol will replace any
+// existing symbol for the address.
+func (tbl *Table) AddSymbol(bank int, addr uint16, symbol string) {
+	defer tbl.polishTable()
+
+	if _, area := memorymap.MapAddress(addr, true); area != memorymap.Cartridge {
+		tbl.Read.add(addr, symbol, true)
+		tbl.Write.add(addr, symbol, true)
+		return
+	}
+
+	if bank == AnyBank {
+		tbl.Locations.add(addr, symbol, true)
+		return
+	}
+
+	if _, ok := tbl.Banked[bank]; !ok {
+		tbl.Banked[bank] = newTable()
+	}
+	tbl.Banked[bank].add(addr, symbol, true)
+}
+
+// AddComment adds a comment for the cartridge location in the specified bank
+// (or AnyBank). An empty comment removes the existing comment.
+func (tbl *Table) AddComment(bank int, addr uint16, comment string) {
+	if tbl.comments == nil {
+		tbl.comments = make(map[int]map[uint16]string)
+	}
+
+	if comment == "" {
+		delete(tbl.comments[bank], addr)
+		return
+	}
+
+	if _, ok := tbl.comments[bank]; !ok {
+		tbl.comments[bank] = make(map[uint16]string)
+	}
+	tbl.comments[bank][addr] = comment
+}
+
+// Comment returns the comment for the cartridge location in the specified
+// bank. As with LocationSymbol(), a comment for the specific bank is preferred
+// and the cartridge mirrors of the address are considered.
+func (tbl *Table) Comment(bank int, addr uint16) (string, bool) {
+	for _, m := range mirrors(addr) {
+		if c, ok := tbl.comments[bank][m]; ok {
+			return c, true
+		}
+		if c, ok := tbl.comments[AnyBank][m]; ok {
+			return c, true
+		}
+	}
+	return "", false
+}
@@ -66,6 +66,13 @@ var pal32bit = []uint32{
 	0x000000, 0x282828, 0x505050, 0x747474, 0x949494, 0xb4b4b4, 0xd0d0d0, 0xececec,
 }
 
+// SECAM consoles can produce only eight colours. the colour is selected by
+// the luminance bits of the color value and the hue bits are ignored. the
+// eight colours are repeated for every hue in the init() function below
+var secam32bit = []uint32{
+	0x000000, 0x2121ff, 0xf03c79, 0xff50ff, 0x7fff00, 0x7fffff, 0xffff3f, 0xffffff,
+}
+
 // this init() function converts the "raw" color values to the RGB components
 func init() {
 	for _, col := range ntsc32bit {
@@ -84,6 +91,16 @@ func init() {
 		PalettePAL = append(PalettePAL, RGB{red, green, blue})
 	}
 
+	for hue := 0; hue < 16; hue++ {
+		for _, col := range secam32bit {
+			red, green, blue := byte((col&0xff0000)>>16), byte((col&0xff00)>>8), byte(col&0xff)
+
+			// repeat color twice in palette
+			PaletteSECAM = append(PaletteSECAM, RGB{red, green, blue})
+			PaletteSECAM = append(PaletteSECAM, RGB{red, green, blue})
+		}
+	}
+
 	for _, col := range alt32bit {
 		red, green, blue := byte((col&0xff0000)>>16), byte((col&0xff00)>>8), byte(col&0xff)
 		PaletteAlt = append(PaletteAlt, RGB{red, green, blue})
@@ -35,6 +35,9 @@ var PaletteNTSC = Palette{}
 // PalettePAL is the collection of PAL colours
 var PalettePAL = Palette{}
 
+// PaletteSECAM is the collection of SECAM colours
+var PaletteSECAM = Palette{}
+
 // PaletteAlt is the collection of ALT colours
 var PaletteAlt = Palette{}
 
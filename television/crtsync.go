@@ -0,0 +1,146 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package television
+
+import "fmt"
+
+// the number of scanlines a frame can differ from the specification and still
+// be locked onto by the television's vertical oscillator
+const syncVerticalTolerance = 10
+
+// the number of scanlines by which the period of the free running vertical
+// oscillator exceeds the specification. a real television is adjusted so that
+// the VSYNC arrives just before the oscillator would flyback by itself. when the
+// oscillator is not locked, the picture therefore rolls even if the frame is
+// the correct length
+const syncVerticalFreeRun = 4
+
+// the proportion of the horizontal phase error that remains after each
+// scanline. the horizontal oscillator of a real television corrects itself
+// over several scanlines, rather than immediately
+const syncHorizontalDecay = 0.75
+
+// crtSync models the way a CRT television synchronises with the signal sent by
+// the VCS. when the model is not enabled the picture is always drawn as though
+// the television has perfectly synchronised with the VCS.
+//
+// vertically, the television has a free running oscillator with a period of
+// the number of scanlines in the specification. the oscillator is locked to
+// the VCS by the VSYNC signal but only if the VSYNC is held for long enough
+// and if the frame is close to the expected number of scanlines. when the
+// oscillator is not locked the picture rolls.
+//
+// horizontally, the television expects every scanline to be the same length.
+// scanlines of a different length (after a write to RSYNC, for example) cause
+// the following scanlines to be skewed until the horizontal oscillator has
+// recovered.
+type crtSync struct {
+	enabled bool
+
+	// whether the vertical oscillator is locked to the VCS
+	locked bool
+
+	// the scanline of the television's vertical sweep at which the current
+	// VCS frame started
+	offset int
+
+	// the current horizontal phase error, in color clocks
+	skew float32
+}
+
+func (crt crtSync) String() string {
+	if !crt.enabled {
+		return "CRT sync off"
+	}
+
+	lock := "unlocked"
+	if crt.locked {
+		lock = "locked"
+	}
+
+	return fmt.Sprintf("CRT sync: %s roll=%d skew=%.1f", lock, crt.offset, crt.skew)
+}
+
+func (crt *crtSync) reset() {
+	crt.locked = true
+	crt.offset = 0
+	crt.skew = 0
+}
+
+// newScanline should be called at the start of every scanline with the length
+// of the previous scanline in color clocks and the number of the new scanline
+func (crt *crtSync) newScanline(length int, scanline int, spec *Specification) {
+	if !crt.enabled {
+		return
+	}
+
+	// VSYNC is late. the vertical oscillator has run freely for the whole of
+	// its period
+	if scanline >= spec.ScanlinesTotal+syncVerticalTolerance {
+		crt.locked = false
+	}
+
+	crt.skew = (crt.skew + float32(length-HorizClksScanline)) * syncHorizontalDecay
+	if crt.skew > -0.5 && crt.skew < 0.5 {
+		crt.skew = 0
+	}
+}
+
+// newFrame should be called at the end of every VCS frame with the number of
+// scanlines in the frame and the number of scanlines for which VSYNC was held
+func (crt *crtSync) newFrame(scanlines int, vsync int, spec *Specification) {
+	if !crt.enabled {
+		return
+	}
+
+	// the oscillator can only be locked by a VSYNC that is long enough and
+	// that arrives when it is expected
+	diff := scanlines - spec.ScanlinesTotal
+	crt.locked = vsync >= spec.ScanlinesVSync &&
+		diff >= -syncVerticalTolerance && diff <= syncVerticalTolerance
+
+	if crt.locked {
+		// the picture settles quickly once the oscillator has locked
+		crt.offset /= 2
+		return
+	}
+
+	// the next frame starts where the vertical sweep has got to
+	crt.offset = (crt.offset + scanlines + spec.ScanlinesTotal - syncVerticalFreeRun) % spec.ScanlinesTotal
+}
+
+// transform the coordinates of a pixel from the VCS to the coordinates on the
+// television screen. returns false if the pixel is not visible.
+func (crt crtSync) transform(x int, y int, spec *Specification) (int, int, bool) {
+	if !crt.enabled {
+		return x, y, true
+	}
+
+	if !crt.locked || crt.offset != 0 {
+		y = (y + crt.offset) % spec.ScanlinesTotal
+	}
+
+	x += int(crt.skew)
+	if x < 0 || x >= HorizClksScanline {
+		return x, y, false
+	}
+
+	return x, y, true
+}
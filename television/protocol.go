@@ -53,6 +53,20 @@ type Television interface {
 	// the VCS is stable
 	IsStable() bool
 
+	// SetCRTSync turns the CRT sync model on or off. When the model is on, the
+	// picture will roll or skew in the same way as a real television would if
+	// the VCS does not produce the correct VSYNC and HSYNC timings
+	SetCRTSync(enable bool)
+
+	// SaveState returns the current state of the television. The state does
+	// not include the attached PixelRenderers and AudioMixers or the frame
+	// rate settings
+	SaveState() interface{}
+
+	// RestoreState returns the television to a state previously returned by
+	// SaveState()
+	RestoreState(state interface{})
+
 	// some televisions may need to conclude and/or dispose of resources
 	// gently. implementations of End() should call EndRendering() and
 	// EndMixing() on each PixelRenderer and AudioMixer that has been added.
@@ -72,8 +86,9 @@ type Television interface {
 	// implementation.
 	SpecIDOnCreation() string
 
-	// Set whether the emulation should wait for FPS limiter
-	SetFPSCap(set bool)
+	// Set whether the emulation should wait for FPS limiter. Returns the
+	// previous setting.
+	SetFPSCap(set bool) bool
 
 	// Request the number frames per second. This overrides the frame rate of
 	// the specification. A negative FPS value restores the specifcications
@@ -170,6 +185,15 @@ type AudioMixer interface {
 	EndMixing() error
 }
 
+// StereoMixer is an optional extension to the AudioMixer interface. If an
+// AudioMixer also implements StereoMixer then the television will call
+// SetStereoAudio() instead of SetAudio(), with the volume of the two TIA
+// audio channels as separate values, and the sample of any external sound
+// source. The external sound is only available through this interface.
+type StereoMixer interface {
+	SetStereoAudio(channel0 uint8, channel1 uint8, external float32) error
+}
+
 // ColorSignal represents the signal that is sent from the VCS to the
 type ColorSignal int
 
@@ -185,6 +209,10 @@ type SignalAttributes struct {
 	Pixel     ColorSignal
 	AudioData uint8
 
+	// the volume of each audio channel. AudioData is the two values mixed
+	AudioChannel0 uint8
+	AudioChannel1 uint8
+
 	// the fields above are real signal attributes in the sense that the
 	// information they represent is really sent to the TV in the real hardware
 	// setup. the fields below are not but help us along in the emulation.
@@ -209,6 +237,11 @@ type SignalAttributes struct {
 	// an alternative color signal for the next pixel
 	AltPixel colors.AltColor
 
+	// sound from a peripheral, such as the voice track of the Kid Vid. in the
+	// range -1.0 to 1.0 and centred on zero. only valid when AudioUpdate is
+	// true
+	AudioExternal float32
+
 	// whether the AudioData is valid. should be true only every 114th clock,
 	// which equates to 30Khz
 	AudioUpdate bool
@@ -21,7 +21,7 @@ package television
 
 import "gopher2600/television/colors"
 
-// Specification is used to define the two television specifications
+// Specification is used to define the television specifications
 type Specification struct {
 	ID     string
 	Colors colors.Palette
@@ -71,6 +71,11 @@ type Specification struct {
 
 	// the number of frames per second required by the specification
 	FramesPerSecond float32
+
+	// the specification to switch to if the number of scanlines in a frame
+	// is too many for this specification (auto flag permitting). nil if there
+	// is no such specification
+	overflow *Specification
 }
 
 // getColor translates a signals to the color type
@@ -92,8 +97,9 @@ func (spec *Specification) getColor(col ColorSignal) colors.RGB {
 // to the left side of the screen, waits for the 68 horizontal blank clock
 // counts, and proceeds to draw the next line below."
 //
-// Horizontal clock counts are the same for both TV specifications. Vertical
-// information should be accessed via SpecNTSC or SpecPAL.
+// Horizontal clock counts are the same for all TV specifications. Vertical
+// information should be accessed via SpecNTSC, SpecPAL, SpecPAL60 or
+// SpecSECAM.
 const (
 	HorizClksHBlank   = 68
 	HorizClksVisible  = 160
@@ -106,6 +112,16 @@ var SpecNTSC *Specification
 // SpecPAL is the specification for PAL television types
 var SpecPAL *Specification
 
+// SpecPAL60 is the specification for PAL television types running at 60Hz.
+// The colors are the same as PAL but the number of scanlines is the same as
+// NTSC
+var SpecPAL60 *Specification
+
+// SpecSECAM is the specification for SECAM television types. The number of
+// scanlines is the same as PAL but the colors are very different (see
+// colors.PaletteSECAM)
+var SpecSECAM *Specification
+
 func init() {
 	SpecNTSC = &Specification{
 		ID:                "NTSC",
@@ -136,4 +152,40 @@ func init() {
 
 	SpecPAL.ScanlineTop = SpecPAL.scanlinesVBlank + SpecPAL.ScanlinesVSync
 	SpecPAL.ScanlineBottom = SpecPAL.ScanlinesTotal - SpecPAL.ScanlinesOverscan
+
+	SpecPAL60 = &Specification{
+		ID:                "PAL60",
+		Colors:            colors.PalettePAL,
+		ScanlinesVSync:    3,
+		scanlinesVBlank:   37,
+		ScanlinesVisible:  192,
+		ScanlinesOverscan: 30,
+		ScanlinesTotal:    262,
+		FramesPerSecond:   60.0,
+		AspectBias:        0.91,
+	}
+
+	SpecPAL60.ScanlineTop = SpecPAL60.scanlinesVBlank + SpecPAL60.ScanlinesVSync
+	SpecPAL60.ScanlineBottom = SpecPAL60.ScanlinesTotal - SpecPAL60.ScanlinesOverscan
+
+	SpecSECAM = &Specification{
+		ID:                "SECAM",
+		Colors:            colors.PaletteSECAM,
+		ScanlinesVSync:    3,
+		scanlinesVBlank:   45,
+		ScanlinesVisible:  228,
+		ScanlinesOverscan: 36,
+		ScanlinesTotal:    312,
+		FramesPerSecond:   50.0,
+		AspectBias:        1.09,
+	}
+
+	SpecSECAM.ScanlineTop = SpecSECAM.scanlinesVBlank + SpecSECAM.ScanlinesVSync
+	SpecSECAM.ScanlineBottom = SpecSECAM.ScanlinesTotal - SpecSECAM.ScanlinesOverscan
+
+	// the 60Hz specifications switch to the 50Hz specification with the same
+	// colors. there is no 60Hz version of SECAM so NTSC switches to PAL unless
+	// the SECAM hint has been given to SetSpec()
+	SpecNTSC.overflow = SpecPAL
+	SpecPAL60.overflow = SpecPAL
 }
@@ -35,9 +35,9 @@ const resizeThreshold = 10
 // before we accept the frame characteristics
 const stabilityThreshold = 15
 
-// the number of scanlines past the NTSC limit before the specification flips
-// to PAL (auto flag permitting)
-const overageNTSC = 13
+// the number of scanlines past the limit of a 60Hz specification before the
+// specification flips to the 50Hz specification (auto flag permitting)
+const overage60Hz = 13
 
 // for the purposes of frame size detection, we should consider the first
 // handful of frames to be unreliable
@@ -62,6 +62,10 @@ type television struct {
 	// spec and move to PAL if the number of scanlines exceeds the NTSC maximum
 	auto bool
 
+	// the specification to move to if the auto flag is set and the number of
+	// scanlines is too many for the current specification. see SetSpec()
+	overflow *Specification
+
 	// state of the television
 	//	- the current horizontal position. the position where the next pixel will be
 	//  drawn. also used to check we're receiving the correct signals at the
@@ -150,6 +154,9 @@ type television struct {
 
 	// the acutal number of scanlines in the last frame
 	actualScanlines int
+
+	// optional model of how a CRT television synchronises with the VCS
+	crt crtSync
 }
 
 // NewTelevision creates a new instance of the television type, satisfying the
@@ -233,6 +240,9 @@ func NewTelevision(spec string) (Television, error) {
 func (tv television) String() string {
 	s := strings.Builder{}
 	s.WriteString(fmt.Sprintf("FR=%04d SL=%03d HP=%03d", tv.frameNum, tv.scanline, tv.horizPos))
+	if tv.crt.enabled {
+		s.WriteString(fmt.Sprintf(" [%s]", tv.crt))
+	}
 	return s.String()
 }
 
@@ -253,6 +263,7 @@ func (tv *television) Reset() error {
 	tv.scanline = 0
 	tv.vsyncCount = 0
 	tv.prevSignal = SignalAttributes{}
+	tv.crt.reset()
 
 	tv.top = tv.spec.ScanlineTop
 	tv.bottom = tv.spec.ScanlineBottom
@@ -268,9 +279,17 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	// see SignalAttributes type definition for notes about the HSyncSimple
 	// attribute
 	if sig.HSyncSimple && !tv.prevSignal.HSyncSimple {
+		length := tv.horizPos + HorizClksHBlank + 1
+
 		tv.horizPos = -HorizClksHBlank
 		tv.scanline++
 
+		// the length of the scanline is meaningless until the first frame
+		// has started
+		if tv.frameNum > 0 {
+			tv.crt.newScanline(length, tv.scanline, tv.spec)
+		}
+
 		// reset key color check for the new scanline
 		tv.key = true
 		tv.keyCol = VideoBlack
@@ -310,21 +329,23 @@ func (tv *television) Signal(sig SignalAttributes) error {
 			// scanline value at the specification maximum but means a loss of
 			// potentially useful information.
 
-			// PAL detection condition:
+			// 50Hz detection condition:
 			//   1. frame must be "unstable"
 			//   2. not be the first frame (because ROMs can still be in the
 			//       setup phae at this point)
-			//   3. not be in PAL mode already
+			//   3. not be in a 50Hz mode already
 			//   4. have the auto flag set
-			//   5. be more than 10 scanlines beyond the NTSC specification
+			//   5. be more than 10 scanlines beyond the 60Hz specification
 			//
-			// Specification detection only works from NTSC to PAL. A PAL frame
-			// can never cause a flip to NTSC
+			// Specification detection only works from 60Hz to 50Hz (NTSC to
+			// PAL, PAL60 to PAL or NTSC to SECAM). A PAL frame can never cause
+			// a flip to NTSC. which 50Hz specification is chosen depends on
+			// the color hint given to SetSpec()
 			if !tv.IsStable() && tv.frameNum > 1 &&
-				tv.spec != SpecPAL && tv.auto &&
-				tv.scanline >= SpecNTSC.ScanlinesTotal+overageNTSC {
+				tv.overflow != nil && tv.auto &&
+				tv.scanline >= tv.spec.ScanlinesTotal+overage60Hz {
 
-				tv.SetSpec("PAL")
+				tv.SetSpec(tv.overflow.ID)
 				tv.resize = true
 			}
 		}
@@ -353,7 +374,7 @@ func (tv *television) Signal(sig SignalAttributes) error {
 		// if vsync has just be turned off then check that it has been held for
 		// the requisite number of scanlines for a new frame to be started
 		if tv.vsyncCount >= tv.spec.ScanlinesVSync {
-			err := tv.newFrame()
+			err := tv.newFrame(tv.vsyncCount)
 			if err != nil {
 				return err
 			}
@@ -367,21 +388,27 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	x := tv.horizPos + HorizClksHBlank
 	y := tv.scanline
 
-	// decode color using the alternative color signal
-	col := colors.GetAltColor(sig.AltPixel)
-	for f := range tv.renderers {
-		err := tv.renderers[f].SetAltPixel(x, y, col.Red, col.Green, col.Blue, sig.VBlank)
-		if err != nil {
-			return err
+	// position of pixel on the screen. pixels will be moved by the CRT sync
+	// model if it is enabled
+	x, y, visible := tv.crt.transform(x, y, tv.spec)
+
+	if visible {
+		// decode color using the alternative color signal
+		col := colors.GetAltColor(sig.AltPixel)
+		for f := range tv.renderers {
+			err := tv.renderers[f].SetAltPixel(x, y, col.Red, col.Green, col.Blue, sig.VBlank)
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	// decode color using the regular color signal
-	col = tv.spec.getColor(sig.Pixel)
-	for f := range tv.renderers {
-		err := tv.renderers[f].SetPixel(x, y, col.Red, col.Green, col.Blue, sig.VBlank)
-		if err != nil {
-			return err
+		// decode color using the regular color signal
+		col = tv.spec.getColor(sig.Pixel)
+		for f := range tv.renderers {
+			err := tv.renderers[f].SetPixel(x, y, col.Red, col.Green, col.Blue, sig.VBlank)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -461,7 +488,12 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	// mix audio
 	if sig.AudioUpdate {
 		for f := range tv.mixers {
-			err := tv.mixers[f].SetAudio(sig.AudioData)
+			var err error
+			if m, ok := tv.mixers[f].(StereoMixer); ok {
+				err = m.SetStereoAudio(sig.AudioChannel0, sig.AudioChannel1, sig.AudioExternal)
+			} else {
+				err = tv.mixers[f].SetAudio(sig.AudioData)
+			}
 			if err != nil {
 				return err
 			}
@@ -474,7 +506,9 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	return nil
 }
 
-func (tv *television) newFrame() error {
+// newFrame is called when a valid VSYNC has been seen. the length of the VSYNC
+// is given in color clocks
+func (tv *television) newFrame(vsyncCount int) error {
 	// screen resizing has been requested
 	if tv.resize {
 		for f := range tv.renderers {
@@ -491,6 +525,12 @@ func (tv *television) newFrame() error {
 		}
 	}
 
+	// the CRT sync model needs to know how many scanlines were in the frame
+	// and how many scanlines VSYNC was held for. the VSYNC length is rounded
+	// because the signal will not usually start exactly at the beginning of a
+	// scanline
+	tv.crt.newFrame(tv.scanline, (vsyncCount+HorizClksScanline/2)/HorizClksScanline, tv.spec)
+
 	// new frame
 	tv.frameNum++
 	tv.scanline = 0
@@ -533,7 +573,16 @@ func (tv *television) GetState(request StateReq) (int, error) {
 	}
 }
 
-// SetSpec implements the Television interface
+// SetSpec implements the Television interface.
+//
+// As well as the specifications, AUTO-PAL and AUTO-SECAM are accepted. These
+// are the same as AUTO but with a hint for the color system of the console.
+// The color burst of a PAL or SECAM console can not be seen in the signal
+// from the TIA so, unlike the frequency, it can not be detected from the
+// number of scanlines. With the PAL hint, a 60Hz frame is shown with the PAL60
+// specification and a 50Hz frame with PAL. With the SECAM hint a 50Hz frame
+// is shown with the SECAM specification. There is no 60Hz SECAM specification
+// so a 60Hz frame is shown with NTSC.
 func (tv *television) SetSpec(spec string) error {
 	switch strings.ToUpper(spec) {
 	case "NTSC":
@@ -542,15 +591,31 @@ func (tv *television) SetSpec(spec string) error {
 	case "PAL":
 		tv.spec = SpecPAL
 		tv.auto = false
+	case "PAL60":
+		tv.spec = SpecPAL60
+		tv.auto = false
+	case "SECAM":
+		tv.spec = SpecSECAM
+		tv.auto = false
 	case "AUTO":
 		tv.auto = true
 
 		// a tv.spec of nil means this is the first call of SetSpec() so
 		// as well as setting the auto flag we need to specify a
-		// specification
+		// specification. otherwise the current specification is kept, so
+		// PAL60 followed by AUTO will flip to PAL if the frame is too long
 		if tv.spec == nil {
 			tv.spec = SpecNTSC
 		}
+		tv.overflow = tv.spec.overflow
+	case "AUTO-PAL":
+		tv.auto = true
+		tv.spec = SpecPAL60
+		tv.overflow = SpecPAL
+	case "AUTO-SECAM":
+		tv.auto = true
+		tv.spec = SpecNTSC
+		tv.overflow = SpecSECAM
 
 	default:
 		return errors.New(errors.Television, fmt.Sprintf("unsupported tv specifcation (%s)", spec))
@@ -562,6 +627,97 @@ func (tv *television) SetSpec(spec string) error {
 	return nil
 }
 
+// SetCRTSync implements the Television interface
+func (tv *television) SetCRTSync(enable bool) {
+	tv.crt.enabled = enable
+	tv.crt.reset()
+}
+
+// the state of the television as returned by SaveState()
+type televisionState struct {
+	spec            *Specification
+	auto            bool
+	overflow        *Specification
+	horizPos        int
+	frameNum        int
+	scanline        int
+	prevSignal      SignalAttributes
+	vsyncCount      int
+	vsyncPos        int
+	top             int
+	bottom          int
+	stabilityCt     int
+	resizeTop       int
+	resizeTopCt     int
+	resizeTopFr     int
+	resizeBot       int
+	resizeBotCt     int
+	resizeBotFr     int
+	key             bool
+	keyCol          ColorSignal
+	actualScanlines int
+	crt             crtSync
+}
+
+// SaveState implements the Television interface
+func (tv *television) SaveState() interface{} {
+	return televisionState{
+		spec:            tv.spec,
+		auto:            tv.auto,
+		overflow:        tv.overflow,
+		horizPos:        tv.horizPos,
+		frameNum:        tv.frameNum,
+		scanline:        tv.scanline,
+		prevSignal:      tv.prevSignal,
+		vsyncCount:      tv.vsyncCount,
+		vsyncPos:        tv.vsyncPos,
+		top:             tv.top,
+		bottom:          tv.bottom,
+		stabilityCt:     tv.stabilityCt,
+		resizeTop:       tv.resizeTop,
+		resizeTopCt:     tv.resizeTopCt,
+		resizeTopFr:     tv.resizeTopFr,
+		resizeBot:       tv.resizeBot,
+		resizeBotCt:     tv.resizeBotCt,
+		resizeBotFr:     tv.resizeBotFr,
+		key:             tv.key,
+		keyCol:          tv.keyCol,
+		actualScanlines: tv.actualScanlines,
+		crt:             tv.crt,
+	}
+}
+
+// RestoreState implements the Television interface
+func (tv *television) RestoreState(state interface{}) {
+	s := state.(televisionState)
+	tv.spec = s.spec
+	tv.auto = s.auto
+	tv.overflow = s.overflow
+	tv.horizPos = s.horizPos
+	tv.frameNum = s.frameNum
+	tv.scanline = s.scanline
+	tv.prevSignal = s.prevSignal
+	tv.vsyncCount = s.vsyncCount
+	tv.vsyncPos = s.vsyncPos
+	tv.top = s.top
+	tv.bottom = s.bottom
+	tv.stabilityCt = s.stabilityCt
+	tv.resizeTop = s.resizeTop
+	tv.resizeTopCt = s.resizeTopCt
+	tv.resizeTopFr = s.resizeTopFr
+	tv.resizeBot = s.resizeBot
+	tv.resizeBotCt = s.resizeBotCt
+	tv.resizeBotFr = s.resizeBotFr
+	tv.key = s.key
+	tv.keyCol = s.keyCol
+	tv.actualScanlines = s.actualScanlines
+	tv.crt = s.crt
+
+	// the renderers may have been resized since the state was saved. make
+	// sure they are resized to the restored screen on the next frame
+	tv.resize = true
+}
+
 // SpecIDOnCreation implements the Television interface
 func (tv *television) SpecIDOnCreation() string {
 	return tv.specIDOnCreation
@@ -599,8 +755,10 @@ func (tv television) End() error {
 // replaces it with its own. The FPS limiter in this television implementation
 // works at the frame level which is not fine grained enough for effective
 // limiting of rates less than 1fps.
-func (tv *television) SetFPSCap(enable bool) {
+func (tv *television) SetFPSCap(enable bool) bool {
+	prev := tv.fpsCap
 	tv.fpsCap = enable
+	return prev
 }
 
 // SetFPS implements the Television interface. A negative value resets the FPS
@@ -38,6 +38,16 @@ func TestNewTelevision(t *testing.T) {
 		t.Errorf("NTSC spec creation failed")
 	}
 
+	tv, err = television.NewTelevision("PAL60")
+	if tv == nil || err != nil {
+		t.Errorf("PAL60 spec creation failed")
+	}
+
+	tv, err = television.NewTelevision("SECAM")
+	if tv == nil || err != nil {
+		t.Errorf("SECAM spec creation failed")
+	}
+
 	tv, err = television.NewTelevision("AUTO")
 	if tv == nil || err != nil {
 		t.Errorf("AUTO spec creation failed")
@@ -48,3 +58,182 @@ func TestNewTelevision(t *testing.T) {
 		t.Errorf("'FOO' spec creation unexpectedly succeeded")
 	}
 }
+
+func TestSECAMPalette(t *testing.T) {
+	spec := television.SpecSECAM
+
+	if len(spec.Colors) != len(television.SpecNTSC.Colors) {
+		t.Fatalf("SECAM palette has %d entries (expecting %d)", len(spec.Colors), len(television.SpecNTSC.Colors))
+	}
+
+	// the color is selected by the luminance bits only
+	for col := range spec.Colors {
+		if spec.Colors[col] != spec.Colors[col&0x0f] {
+			t.Errorf("SECAM color %#02x is not the same as color %#02x", col, col&0x0f)
+		}
+	}
+
+	if spec.Colors[0x00] == spec.Colors[0x0e] {
+		t.Errorf("SECAM colors %#02x and %#02x are unexpectedly the same", 0x00, 0x0e)
+	}
+}
+
+// markerRenderer records the scanline on which a pixel of the marker color is
+// drawn
+type markerRenderer struct {
+	y int
+}
+
+func (r *markerRenderer) Resize(_, _ int) error   { return nil }
+func (r *markerRenderer) NewFrame(_ int) error    { return nil }
+func (r *markerRenderer) NewScanline(_ int) error { return nil }
+func (r *markerRenderer) EndRendering() error     { return nil }
+
+func (r *markerRenderer) SetPixel(x, y int, red, green, blue byte, _ bool) error {
+	if red != 0 || green != 0 || blue != 0 {
+		r.y = y
+	}
+	return nil
+}
+
+func (r *markerRenderer) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error { return nil }
+
+// send a frame of the specified number of scanlines. the marker is drawn on
+// scanline 100 and VSYNC is held for the first vsync scanlines
+func sendFrame(t *testing.T, tv television.Television, scanlines int, vsync int) {
+	t.Helper()
+
+	for sl := 0; sl < scanlines; sl++ {
+		for clk := 0; clk < television.HorizClksScanline; clk++ {
+			sig := television.SignalAttributes{
+				HSyncSimple: clk == 0,
+				VSync:       sl < vsync,
+				Pixel:       television.VideoBlack,
+			}
+			if sl == 100 && clk == 100 {
+				sig.Pixel = 0x0e
+			}
+			if err := tv.Signal(sig); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func TestCRTSync(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf("NTSC spec creation failed")
+	}
+	tv.SetFPSCap(false)
+
+	r := &markerRenderer{}
+	tv.AddPixelRenderer(r)
+
+	// frames that are too long do not roll without the sync model
+	for i := 0; i < 5; i++ {
+		sendFrame(t, tv, 280, 3)
+	}
+	y := r.y
+	sendFrame(t, tv, 280, 3)
+	if r.y != y {
+		t.Errorf("picture moved without the CRT sync model (%d to %d)", y, r.y)
+	}
+
+	// the picture rolls with the sync model
+	tv.SetCRTSync(true)
+	sendFrame(t, tv, 280, 3)
+	y = r.y
+	sendFrame(t, tv, 280, 3)
+	if r.y == y {
+		t.Errorf("picture did not roll with the CRT sync model")
+	}
+
+	// but is steady once the frame is the correct length
+	for i := 0; i < 20; i++ {
+		sendFrame(t, tv, 262, 3)
+	}
+	y = r.y
+	sendFrame(t, tv, 262, 3)
+	if r.y != y {
+		t.Errorf("picture did not settle with the CRT sync model (%d to %d)", y, r.y)
+	}
+
+	// a VSYNC that is too short does not lock the oscillator. the picture
+	// rolls even though the frame is the correct length
+	sendFrame(t, tv, 262, 1)
+	y = r.y
+	sendFrame(t, tv, 262, 1)
+	if r.y == y {
+		t.Errorf("picture did not roll with a short VSYNC")
+	}
+
+	// and settles once the VSYNC is long enough
+	for i := 0; i < 20; i++ {
+		sendFrame(t, tv, 262, 3)
+	}
+	y = r.y
+	sendFrame(t, tv, 262, 3)
+	if r.y != y {
+		t.Errorf("picture did not settle after a short VSYNC (%d to %d)", y, r.y)
+	}
+}
+
+func TestRestoreAuto(t *testing.T) {
+	tv, err := television.NewTelevision("AUTO")
+	if err != nil {
+		t.Fatalf("AUTO spec creation failed")
+	}
+	tv.SetFPSCap(false)
+
+	state := tv.SaveState()
+
+	for i := 0; i < 5; i++ {
+		sendFrame(t, tv, 312, 3)
+	}
+	if tv.GetSpec() != television.SpecPAL {
+		t.Fatalf("spec did not change to PAL (%s)", tv.GetSpec().ID)
+	}
+
+	// the specification can change again after the state is restored
+	tv.RestoreState(state)
+	if tv.GetSpec() != television.SpecNTSC {
+		t.Fatalf("spec was not restored to NTSC (%s)", tv.GetSpec().ID)
+	}
+	for i := 0; i < 5; i++ {
+		sendFrame(t, tv, 312, 3)
+	}
+	if tv.GetSpec() != television.SpecPAL {
+		t.Errorf("spec did not change to PAL after restoring state (%s)", tv.GetSpec().ID)
+	}
+}
+
+func TestAutoHint(t *testing.T) {
+	tests := []struct {
+		spec      string
+		scanlines int
+		expected  *television.Specification
+	}{
+		{"AUTO", 262, television.SpecNTSC},
+		{"AUTO", 312, television.SpecPAL},
+		{"AUTO-PAL", 262, television.SpecPAL60},
+		{"AUTO-PAL", 312, television.SpecPAL},
+		{"AUTO-SECAM", 262, television.SpecNTSC},
+		{"AUTO-SECAM", 312, television.SpecSECAM},
+	}
+
+	for _, tst := range tests {
+		tv, err := television.NewTelevision(tst.spec)
+		if err != nil {
+			t.Fatalf("%s spec creation failed", tst.spec)
+		}
+		tv.SetFPSCap(false)
+
+		for i := 0; i < 5; i++ {
+			sendFrame(t, tv, tst.scanlines, 3)
+		}
+		if tv.GetSpec() != tst.expected {
+			t.Errorf("%s with %d scanlines: expected %s spec (got %s)", tst.spec, tst.scanlines, tst.expected.ID, tv.GetSpec().ID)
+		}
+	}
+}
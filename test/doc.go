@@ -41,6 +41,10 @@
 // types (eg. uint16) can be compared against int for convenience. See Equate()
 // documentation for discussion why.
 //
+// The NewROM() and WriteROM() functions create ROM files for tests that need
+// to run or disassemble a cartridge. The Kernel program is the smallest ROM
+// that produces a stable television frame.
+//
 // The two "assert thread" functions, AssertMainThread() and
 // AssertNonMainThread() will panic if they are not called from, respectively,
 // the main thread or from a non-main thread. The package such that these
@@ -0,0 +1,80 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// Kernel is a minimal program for the start of a 4k ROM. It produces a 262
+// scanline frame and increments RAM location $80 every frame.
+var Kernel = []byte{
+	0xa9, 0x02, // LDA #$02
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0xa9, 0x00, // LDA #$00
+	0x85, 0x00, // STA VSYNC
+	0xa2, 0xff, // LDX #$FF
+	0x85, 0x02, // STA WSYNC
+	0xca,       // DEX
+	0xd0, 0xfb, // BNE -5
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0xe6, 0x80, // INC $80
+	0x4c, 0x00, 0xf0, // JMP $F000
+}
+
+// NewROM returns empty ROM data of the specified size. The reset vector of
+// every 4k bank (or of the whole ROM if it is smaller than 4k) points to the
+// start of the bank at $F000.
+func NewROM(size int) []byte {
+	data := make([]byte, size)
+
+	bankSize := 4096
+	if size < bankSize {
+		bankSize = size
+	}
+
+	for b := 0; b < size; b += bankSize {
+		data[b+bankSize-4] = 0x00
+		data[b+bankSize-3] = 0xf0
+	}
+
+	return data
+}
+
+// WriteROM writes the ROM data to the named file in the directory and returns
+// the full path of the file. The test fails if the file cannot be written.
+func WriteROM(t *testing.T, dir string, name string, data []byte) string {
+	t.Helper()
+
+	rom := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(rom, data, 0600); err != nil {
+		t.Fatalf("cannot create ROM file: %v", err)
+	}
+
+	return rom
+}
@@ -23,6 +23,7 @@ package wavwriter
 import (
 	"gopher2600/errors"
 	tiaAudio "gopher2600/hardware/tia/audio"
+	"gopher2600/resampler"
 	"os"
 
 	"github.com/go-audio/audio"
@@ -33,6 +34,11 @@ import (
 type WavWriter struct {
 	filename string
 	buffer   []int8
+
+	// if the resampler is not nil then audio is resampled and stored in the
+	// resampled buffer instead of the buffer field
+	rs        *resampler.Resampler
+	resampled []int16
 }
 
 // New is the preferred method of initialisation for the Audio2Wav type
@@ -45,6 +51,33 @@ func New(filename string) (*WavWriter, error) {
 	return aw, nil
 }
 
+// NewResampled creates a new WavWriter that resamples the audio before
+// writing. The resulting WAV file will have 16 bit samples and will be stereo
+// if the resampler options specify stereo.
+func NewResampled(filename string, opts resampler.Options) (*WavWriter, error) {
+	rs, err := resampler.NewResampler(opts)
+	if err != nil {
+		return nil, errors.New(errors.WavWriter, err)
+	}
+
+	aw := &WavWriter{
+		filename:  filename,
+		rs:        rs,
+		resampled: make([]int16, 0, 0),
+	}
+
+	return aw, nil
+}
+
+// SetStereoAudio implements the television.StereoMixer interface
+func (aw *WavWriter) SetStereoAudio(channel0 uint8, channel1 uint8, external float32) error {
+	if aw.rs == nil {
+		return aw.SetAudio(channel0 + channel1)
+	}
+	aw.resampled = aw.rs.Process(channel0, channel1, external, aw.resampled)
+	return nil
+}
+
 // SetAudio implements the television.AudioMixer interface
 func (aw *WavWriter) SetAudio(audioData uint8) error {
 	// bring audioData into the correct range
@@ -75,6 +108,10 @@ func (aw *WavWriter) EndMixing() error {
 	}
 	defer f.Close()
 
+	if aw.rs != nil {
+		return aw.writeResampled(f)
+	}
+
 	// see audio commentary in sdlplay package for thinking around sample rates
 
 	enc := wav.NewEncoder(f, tiaAudio.SampleFreq, 8, 1, 1)
@@ -100,3 +137,42 @@ func (aw *WavWriter) EndMixing() error {
 
 	return nil
 }
+
+func (aw *WavWriter) writeResampled(f *os.File) error {
+	numChannels := 1
+	if aw.rs.IsStereo() {
+		numChannels = 2
+	}
+
+	enc := wav.NewEncoder(f, aw.rs.Rate(), 16, numChannels, 1)
+	if enc == nil {
+		return errors.New(errors.WavWriter, "bad parameters for wav encoding")
+	}
+	defer enc.Close()
+
+	// the resampler always produces two values per frame. in mono mode the
+	// values are the same so we only need to write one of them
+	data := make([]int, 0, len(aw.resampled)/2*numChannels)
+	for i := 0; i < len(aw.resampled); i += 2 {
+		data = append(data, int(aw.resampled[i]))
+		if numChannels == 2 {
+			data = append(data, int(aw.resampled[i+1]))
+		}
+	}
+
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{
+			NumChannels: numChannels,
+			SampleRate:  aw.rs.Rate(),
+		},
+		Data:           data,
+		SourceBitDepth: 16,
+	}
+
+	err := enc.Write(buf)
+	if err != nil {
+		return errors.New(errors.WavWriter, err)
+	}
+
+	return nil
+}
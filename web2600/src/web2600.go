@@ -34,7 +34,7 @@ func main() {
 	scr := NewCanvas(worker)
 
 	// create new vcs
-	vcs, err := hardware.NewVCS(scr)
+	vcs, err := hardware.NewVCS(scr, nil)
 	if err != nil {
 		panic(err)
 	}